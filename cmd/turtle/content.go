@@ -0,0 +1,199 @@
+// ABOUTME: The "turtle content" subcommands for people writing skills, challenges and missions
+// ABOUTME: lint reports problems in content files; verify plays each mission's reference solution
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/2389-research/turtle/internal/content"
+	"github.com/2389-research/turtle/internal/sandbox"
+)
+
+const contentUsage = `usage: turtle content <command> [arguments]
+
+commands:
+  lint [--json] [dir]   check a content pack, or the built-in content if no dir is given
+  verify [dir]          check every mission in a content pack (or the built-in ones) can be solved,
+                        and every challenge answer runs
+`
+
+// runContent runs a content subcommand and returns the exit status.
+func runContent(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, contentUsage)
+		return 2
+	}
+	switch args[0] {
+	case "lint":
+		return runLint(args[1:])
+	case "verify":
+		return runVerify(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "turtle content: unknown command %q\n\n%s", args[0], contentUsage)
+		return 2
+	}
+}
+
+// runLint prints every problem in the content; the status is 1 if any is an error.
+func runLint(args []string) int {
+	fs := flag.NewFlagSet("turtle content lint", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "Print diagnostics as a JSON array, for editors")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 1 {
+		fmt.Fprintln(os.Stderr, "turtle content lint: give at most one directory")
+		return 2
+	}
+
+	var diags []content.Diagnostic
+	if dir := fs.Arg(0); dir == "" {
+		diags = content.LintBuiltin()
+	} else {
+		diags = content.LintDir(dir, installedPacks())
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if diags == nil {
+			diags = []content.Diagnostic{}
+		}
+		if err := enc.Encode(diags); err != nil {
+			fmt.Fprintf(os.Stderr, "turtle content lint: %v\n", err)
+			return 2
+		}
+	} else {
+		for _, d := range diags {
+			fmt.Println(d)
+		}
+	}
+	for _, d := range diags {
+		if d.Severity == "error" {
+			return 1
+		}
+	}
+	return 0
+}
+
+// runVerify plays each mission's reference commands in a fresh sandbox, reporting
+// missions that are solved before the learner starts or that the commands don't
+// solve, then checks each challenge's answer in the sandbox. The status is 1 if
+// anything fails.
+func runVerify(args []string) int {
+	fs := flag.NewFlagSet("turtle content verify", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 1 {
+		fmt.Fprintln(os.Stderr, "turtle content verify: give at most one directory")
+		return 2
+	}
+
+	// Without a directory, only the built-in content is checked; with one, only its
+	// packs' content, though it may build on the installed packs.
+	var names []string
+	if dir := fs.Arg(0); dir != "" {
+		dirs, err := content.FindPacks(dir)
+		if err == nil && len(dirs) == 0 {
+			err = fmt.Errorf("no content packs in %s", dir)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "turtle content verify: %v\n", err)
+			return 1
+		}
+		packs := make(map[string]*content.Pack)
+		for _, p := range installedPacks() {
+			packs[p.Name] = p
+		}
+		for _, d := range dirs {
+			p, err := content.ReadPack(d, version)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "turtle content verify: %v\n", err)
+				return 1
+			}
+			packs[p.Name] = p
+			names = append(names, p.Name)
+		}
+		for _, name := range slices.Sorted(maps.Keys(packs)) {
+			content.UsePacks(packs[name])
+		}
+	}
+	if err := content.LoadContent(); err != nil {
+		fmt.Fprintf(os.Stderr, "turtle content verify: %v\n", err)
+		return 1
+	}
+
+	// selected reports whether content from a pack (or built in, for "") is checked.
+	selected := func(pack string) bool {
+		if len(names) == 0 {
+			return pack == ""
+		}
+		return slices.Contains(names, pack)
+	}
+
+	missions := sandbox.GetAllMissions()
+	checked, failed := 0, 0
+	for _, level := range slices.Sorted(maps.Keys(missions)) {
+		for _, m := range missions[level] {
+			pack, _, _ := strings.Cut(m.ID, "/")
+			if !strings.Contains(m.ID, "/") {
+				pack = ""
+			}
+			if !selected(pack) {
+				continue
+			}
+			checked++
+			if err := sandbox.Verify(m); err != nil {
+				failed++
+				fmt.Printf("FAIL %s: %v\n", m.ID, err)
+			}
+		}
+	}
+	fmt.Printf("%d of %d missions can be solved\n", checked-failed, checked)
+
+	challenges, err := content.GetRawChallenges()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "turtle content verify: %v\n", err)
+		return 1
+	}
+	checkedChallenges, failedChallenges := 0, 0
+	for _, skillID := range slices.Sorted(maps.Keys(challenges)) {
+		for i, c := range challenges[skillID] {
+			if !selected(c.Pack) {
+				continue
+			}
+			checkedChallenges++
+			if err := sandbox.VerifyChallenge(c); err != nil {
+				failedChallenges++
+				fmt.Printf("FAIL %s challenge %d: %v\n", skillID, i+1, err)
+			}
+		}
+	}
+	fmt.Printf("%d of %d challenges check out\n", checkedChallenges-failedChallenges, checkedChallenges)
+
+	if failed > 0 || failedChallenges > 0 {
+		return 1
+	}
+	return 0
+}
+
+// installedPacks reads the installed packs that can be read, so a pack being
+// linted can refer to their skills.
+func installedPacks() []*content.Pack {
+	dirs, _ := content.FindPacks(content.DefaultPackRoot())
+	var packs []*content.Pack
+	for _, dir := range dirs {
+		if p, err := content.ReadPack(dir, version); err == nil {
+			packs = append(packs, p)
+		}
+	}
+	return packs
+}
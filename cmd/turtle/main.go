@@ -7,16 +7,35 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"slices"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"github.com/2389-research/turtle/internal/content"
+	"github.com/2389-research/turtle/internal/realshell"
+	"github.com/2389-research/turtle/internal/realtmux"
+	"github.com/2389-research/turtle/internal/sandbox"
+	"github.com/2389-research/turtle/internal/srs"
 	"github.com/2389-research/turtle/internal/tui"
 )
 
 var version = "dev"
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "content" {
+		os.Exit(runContent(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "srs" {
+		os.Exit(runSRS(os.Args[2:]))
+	}
+
 	showVersion := flag.Bool("version", false, "Show version and exit")
+	realTmux := flag.Bool("real-tmux", false, "Offer tmux missions on a private real tmux server (ctrl+t); missions whose setup is outside your home, such as 4.17-copy-mode, stay in the simulator")
+	realShell := flag.Bool("real-shell", false, "Run shell missions in real bash inside a throwaway directory standing in for /; multi-stage missions use the simulator")
+	seed := flag.Uint64("seed", 0, "Seed for randomised missions, to replay the same file names and numbers (0 picks one)")
+	contentDir := flag.String("content-dir", "", "Load a content pack, or a directory of packs, alongside "+content.DefaultPackRoot())
+	now := flag.String("now", "", "Debug: run as if it were this time (2006-01-02, 2006-01-02T15:04 or RFC 3339), to check decay and due reviews; progress isn't saved")
 	flag.Parse()
 
 	if *showVersion {
@@ -24,16 +43,127 @@ func main() {
 		os.Exit(0)
 	}
 
+	if *seed != 0 {
+		sandbox.SetSeed(*seed)
+	}
+
+	var clock srs.Clock = srs.SystemClock{}
+	if *now != "" {
+		t, err := parseNow(*now)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "turtle: --now: %v\n", err)
+			os.Exit(2)
+		}
+		clock = srs.ClockAt(t)
+	}
+
+	if err := loadPacks(*contentDir); err != nil {
+		fmt.Fprintf(os.Stderr, "turtle: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Practice at a pretend time would leave the schedule and review log wrong
+	model := tui.NewMissionTUI(clock, *now == "")
+	if *realTmux {
+		if !realtmux.Available() {
+			fmt.Fprintln(os.Stderr, "turtle: tmux not found, tmux missions will use the simulator")
+		}
+		model.RealTmux = realtmux.Available()
+	}
+	if *realShell {
+		if !realshell.Available() {
+			fmt.Fprintln(os.Stderr, "turtle: bash not found, shell missions will use the simulator")
+		}
+		model.RealShell = realshell.Available()
+	}
+
 	// Create the Bubble Tea program
 	p := tea.NewProgram(
-		tui.NewMissionTUI(),
+		model,
 		tea.WithAltScreen(),       // Use alternate screen buffer
 		tea.WithMouseCellMotion(), // Enable mouse support
 	)
 
 	// Run the app
-	if _, err := p.Run(); err != nil {
+	_, err := p.Run()
+	model.Close()
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error running turtle: %v\n", err)
 		os.Exit(1)
 	}
 }
+
+// parseNow reads the time given to --now: a date, a date and time of day in the
+// local time zone, or an RFC 3339 timestamp.
+func parseNow(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("can't read %q as a time: want 2006-01-02, 2006-01-02T15:04 or RFC 3339", s)
+}
+
+// loadPacks loads the installed content packs and those in dir, then the content
+// itself, so a broken pack is reported before the TUI starts. Installed packs that
+// can't be read or don't fit with the content are skipped with a warning; a pack
+// given with --content-dir must load.
+func loadPacks(dir string) error {
+	installed, err := content.FindPacks(content.DefaultPackRoot())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "turtle: skipping content packs: %v\n", err)
+	}
+	var pending []*content.Pack
+	for _, d := range installed {
+		p, err := content.ReadPack(d, version)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "turtle: skipping content pack %v\n", err)
+			continue
+		}
+		pending = append(pending, p)
+	}
+
+	// Each installed pack has to load alongside those already accepted. Packs may
+	// build on each other, so go round again while any more are accepted.
+	var packs []*content.Pack
+	for accepted := true; accepted; {
+		accepted = false
+		var rest []*content.Pack
+		for _, p := range pending {
+			if content.CheckPacks(append(slices.Clone(packs), p)...) == nil {
+				packs = append(packs, p)
+				accepted = true
+			} else {
+				rest = append(rest, p)
+			}
+		}
+		pending = rest
+	}
+	for _, p := range pending {
+		err := content.CheckPacks(append(slices.Clone(packs), p)...)
+		fmt.Fprintf(os.Stderr, "turtle: skipping content pack %s: %v\n", p.Dir, err)
+	}
+
+	if dir != "" {
+		dirs, err := content.FindPacks(dir)
+		if err != nil {
+			return err
+		}
+		if len(dirs) == 0 {
+			return fmt.Errorf("no content packs in %s (a pack has a %s)", dir, content.PackManifestFile)
+		}
+		for _, d := range dirs {
+			p, err := content.ReadPack(d, version)
+			if err != nil {
+				return fmt.Errorf("content pack %w", err)
+			}
+			packs = append(packs, p)
+		}
+	}
+
+	content.UsePacks(packs...)
+	return content.LoadContent()
+}
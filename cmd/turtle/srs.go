@@ -0,0 +1,190 @@
+// ABOUTME: The "turtle srs" subcommands for tuning how flashcard reviews are scheduled
+// ABOUTME: scheduler picks SM-2 or FSRS, limits caps each day's cards, optimize fits FSRS to the review log
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/2389-research/turtle/internal/progress"
+	"github.com/2389-research/turtle/internal/srs"
+)
+
+const srsUsage = `usage: turtle srs <command> [arguments]
+
+commands:
+  scheduler [--retention R] [sm2|fsrs]   show the review scheduler, or switch to another
+  limits [--new N] [--reviews N]         show or set how many new cards and reviews a day brings
+  optimize [--dry-run]                   fit FSRS weights to your review history and save them
+`
+
+// runSRS runs an srs subcommand and returns the exit status.
+func runSRS(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, srsUsage)
+		return 2
+	}
+	switch args[0] {
+	case "scheduler":
+		return runScheduler(args[1:])
+	case "limits":
+		return runLimits(args[1:])
+	case "optimize":
+		return runOptimize(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "turtle srs: unknown command %q\n\n%s", args[0], srsUsage)
+		return 2
+	}
+}
+
+// runScheduler prints the scheduler saved with the learner's progress, or saves a
+// new choice. Cards keep their history: FSRS estimates a memory state for cards
+// SM-2 scheduled, and SM-2 picks up from the intervals FSRS set.
+func runScheduler(args []string) int {
+	fs := flag.NewFlagSet("turtle srs scheduler", flag.ContinueOnError)
+	retention := fs.Float64("retention", 0, fmt.Sprintf("Recall probability FSRS schedules reviews for (default %g)", srs.DefaultTargetRetention))
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 1 {
+		fmt.Fprintln(os.Stderr, "turtle srs scheduler: give at most one scheduler")
+		return 2
+	}
+
+	path := progress.GetDefaultPath()
+	userProgress, err := progress.Load(path, srs.SystemClock{})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "turtle srs scheduler: %v\n", err)
+		return 1
+	}
+
+	if name := fs.Arg(0); name != "" || *retention != 0 {
+		if name == "" {
+			name = userProgress.Scheduler.Name()
+		}
+		if *retention != 0 && name != srs.SchedulerFSRS {
+			fmt.Fprintf(os.Stderr, "turtle srs scheduler: --retention only applies to %s\n", srs.SchedulerFSRS)
+			return 2
+		}
+		scheduler, err := srs.NewScheduler(name, *retention)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "turtle srs scheduler: %v\n", err)
+			return 2
+		}
+		// Changing the target retention keeps FSRS weights fitted to the learner
+		if old, ok := userProgress.Scheduler.(*srs.FSRS); ok {
+			if fsrs, ok := scheduler.(*srs.FSRS); ok {
+				fsrs.Weights = old.Weights
+			}
+		}
+		userProgress.Scheduler = scheduler
+		if err := progress.Save(userProgress, path); err != nil {
+			fmt.Fprintf(os.Stderr, "turtle srs scheduler: %v\n", err)
+			return 1
+		}
+	}
+
+	fmt.Println(describeScheduler(userProgress.Scheduler))
+	return 0
+}
+
+// describeScheduler names a scheduler and its settings.
+func describeScheduler(s srs.Scheduler) string {
+	if fsrs, ok := s.(*srs.FSRS); ok {
+		return fmt.Sprintf("%s (target retention %.2f)", fsrs.Name(), fsrs.TargetRetention)
+	}
+	return s.Name()
+}
+
+// runLimits prints the daily limits on new cards and reviews saved with the
+// learner's progress, after changing any that are given.
+func runLimits(args []string) int {
+	fs := flag.NewFlagSet("turtle srs limits", flag.ContinueOnError)
+	newPerDay := fs.Int("new", -1, "New cards a day")
+	reviewsPerDay := fs.Int("reviews", -1, "Reviews a day")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "turtle srs limits: takes no arguments")
+		return 2
+	}
+
+	path := progress.GetDefaultPath()
+	userProgress, err := progress.Load(path, srs.SystemClock{})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "turtle srs limits: %v\n", err)
+		return 1
+	}
+
+	if *newPerDay >= 0 || *reviewsPerDay >= 0 {
+		if *newPerDay >= 0 {
+			userProgress.Limits.NewPerDay = *newPerDay
+		}
+		if *reviewsPerDay >= 0 {
+			userProgress.Limits.ReviewsPerDay = *reviewsPerDay
+		}
+		if err := progress.Save(userProgress, path); err != nil {
+			fmt.Fprintf(os.Stderr, "turtle srs limits: %v\n", err)
+			return 1
+		}
+	}
+
+	fmt.Printf("%d new cards and %d reviews a day\n", userProgress.Limits.NewPerDay, userProgress.Limits.ReviewsPerDay)
+	return 0
+}
+
+// runOptimize fits FSRS weights to the learner's review log and reports how well
+// the weights before and after predict what was recalled. The fitted weights are
+// saved if reviews are scheduled by FSRS, unless --dry-run is given.
+func runOptimize(args []string) int {
+	fs := flag.NewFlagSet("turtle srs optimize", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "Report the fit without saving the weights")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "turtle srs optimize: takes no arguments")
+		return 2
+	}
+
+	path := progress.GetDefaultPath()
+	userProgress, err := progress.Load(path, srs.SystemClock{})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "turtle srs optimize: %v\n", err)
+		return 1
+	}
+
+	fsrs, usingFSRS := userProgress.Scheduler.(*srs.FSRS)
+	weights := srs.DefaultFSRSWeights
+	if usingFSRS {
+		weights = fsrs.Weights
+	}
+	fit, err := srs.OptimizeFSRS(userProgress.Reviews, weights)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "turtle srs optimize: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Fitted FSRS to %d reviews of %d cards\n", fit.Reviews, fit.Cards)
+	fmt.Printf("  actual retention:     %5.1f%%\n", fit.Actual*100)
+	fmt.Printf("  predicted retention:  %5.1f%% before, %5.1f%% after\n", fit.Before*100, fit.After*100)
+	fmt.Printf("  log loss:             %6.3f before, %6.3f after\n", fit.LossBefore, fit.LossAfter)
+
+	switch {
+	case *dryRun:
+		fmt.Println("Dry run: weights not saved")
+	case !usingFSRS:
+		fmt.Printf("Reviews are scheduled by %s; run \"turtle srs scheduler fsrs\", then optimize again, to use fitted weights\n", userProgress.Scheduler.Name())
+	default:
+		fsrs.Weights = fit.Weights
+		if err := progress.Save(userProgress, path); err != nil {
+			fmt.Fprintf(os.Stderr, "turtle srs optimize: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Saved the fitted weights; reviews are scheduled for %.0f%% recall\n", fsrs.TargetRetention*100)
+	}
+	return 0
+}
@@ -0,0 +1,131 @@
+// ABOUTME: Normal forms of command-line options for comparing commands
+// ABOUTME: Splits options from operands, orders and combines flags, and merges alternative spellings
+
+package content
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// optionValues gives a getopt spec of the options that take a value, for commands
+// that have any; every other option is a switch.
+var optionValues = map[string]string{
+	"grep":  "e:f:m:A:B:C:",
+	"head":  "n:",
+	"tail":  "n:",
+	"mkdir": "m:",
+	"sed":   "e:f:",
+}
+
+// pathOperands are the commands whose operands are paths, by how many operands
+// come first that aren't: grep's pattern, sed's script, chmod's mode. Operands of
+// other commands are compared as typed.
+var pathOperands = map[string]int{
+	"cat": 0, "cd": 0, "cp": 0, "head": 0, "less": 0, "ls": 0, "mkdir": 0,
+	"mv": 0, "rm": 0, "rmdir": 0, "tail": 0, "touch": 0,
+	"chmod": 1, "grep": 1, "sed": 1,
+}
+
+// optionAliases are other spellings of a command's options, by the one they mean.
+var optionAliases = map[string]map[string]string{
+	"rm":    {"-R": "-r", "--recursive": "-r", "--force": "-f"},
+	"cp":    {"-R": "-r", "--recursive": "-r", "--force": "-f"},
+	"mkdir": {"--parents": "-p"},
+	"ls":    {"--all": "-a"},
+	"grep":  {"--ignore-case": "-i", "--recursive": "-r", "--invert-match": "-v", "--count": "-c"},
+}
+
+// literalArgs are commands whose arguments can't be reordered or tidied: echo
+// prints them as typed and find reads them as an expression.
+var literalArgs = []string{"echo", "find"}
+
+// OptionSpec returns the getopt spec of a command's options that take a value.
+func OptionSpec(name string) string {
+	return optionValues[name]
+}
+
+// LiteralArgs reports whether a command's arguments must be compared as typed.
+func LiteralArgs(name string) bool {
+	return slices.Contains(literalArgs, name)
+}
+
+// SplitOptions separates a command's options from its operands: "--" ends them,
+// "-la" is "-l -a", and if permute is set they may also follow operands, GNU style.
+// Options that take a value, by spec, come back as "-n=5", and other spellings of
+// an option ("rm -R", "rm --recursive") as the usual one ("-r"). Operands the
+// command reads as paths are cleaned; patterns and scripts are left as typed.
+func SplitOptions(name string, args []string, spec string, permute bool) (opts, operands []string, err error) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--" || !permute && len(operands) > 0:
+			if arg == "--" {
+				i++
+			}
+			operands = append(operands, args[i:]...)
+			return aliasOptions(name, opts), cleanPaths(name, opts, operands), nil
+		case strings.HasPrefix(arg, "--"):
+			opts = append(opts, arg)
+		case len(arg) < 2 || arg[0] != '-':
+			operands = append(operands, arg)
+		case (name == "head" || name == "tail") && strings.Trim(arg[1:], "0123456789") == "":
+			opts = append(opts, "-n="+arg[1:]) // head -5 is head -n 5
+		default:
+			for j := 1; j < len(arg); j++ {
+				c := arg[j]
+				pos := strings.IndexByte(spec, c)
+				if pos < 0 || pos+1 == len(spec) || spec[pos+1] != ':' {
+					opts = append(opts, "-"+string(c))
+					continue
+				}
+				value := arg[j+1:]
+				if value == "" {
+					if i+1 == len(args) {
+						return nil, nil, fmt.Errorf("%s: -%c expects an argument", name, c)
+					}
+					i++
+					value = args[i]
+				}
+				opts = append(opts, "-"+string(c)+"="+value)
+				break
+			}
+		}
+	}
+	return aliasOptions(name, opts), cleanPaths(name, opts, operands), nil
+}
+
+// aliasOptions replaces other spellings of a command's options with the usual one.
+func aliasOptions(name string, opts []string) []string {
+	aliases := optionAliases[name]
+	for i, opt := range opts {
+		if usual, ok := aliases[opt]; ok {
+			opts[i] = usual
+		}
+	}
+	return opts
+}
+
+// cleanPaths tidies the operands of a command that are paths, so "../" and
+// "./notes/" read as ".." and "notes". A pattern or script given with -e or -f
+// leaves every operand a path.
+func cleanPaths(name string, opts, operands []string) []string {
+	skip, ok := pathOperands[name]
+	if !ok {
+		return operands
+	}
+	if slices.ContainsFunc(opts, func(opt string) bool {
+		return strings.HasPrefix(opt, "-e=") || strings.HasPrefix(opt, "-f=")
+	}) {
+		skip = 0
+	}
+	out := slices.Clone(operands)
+	for i := min(skip, len(out)); i < len(out); i++ {
+		if strings.Contains(out[i], "/") {
+			out[i] = path.Clean(out[i])
+		}
+	}
+	return out
+}
@@ -6,6 +6,9 @@ package content
 import (
 	"embed"
 	"fmt"
+	"maps"
+	"math/rand/v2"
+	"slices"
 	"sync"
 
 	"gopkg.in/yaml.v3"
@@ -24,53 +27,75 @@ var (
 	loadErr        error
 )
 
-// LoadContent loads all embedded YAML content files.
-// Safe to call multiple times - only loads once.
+// LoadContent loads all embedded YAML content files, with any packs from UsePacks
+// merged in. Safe to call multiple times - only loads once.
 func LoadContent() error {
 	loadOnce.Do(func() {
-		loadErr = loadAll()
+		var b *bundle
+		b, loadErr = loadBundle(packs)
+		if loadErr == nil {
+			skillsData, challengesData, missionsData = b.skills, b.challenges, b.missions
+		}
 	})
 	return loadErr
 }
 
-func loadAll() error {
+// bundle is a full set of content: the embedded files with packs merged in.
+type bundle struct {
+	skills     *SkillsFile
+	challenges *ChallengesFile
+	missions   *MissionsFile
+}
+
+func loadBundle(packs []*Pack) (*bundle, error) {
+	b := &bundle{skills: &SkillsFile{}, challenges: &ChallengesFile{}, missions: &MissionsFile{}}
+
 	// Load skills
 	data, err := contentFS.ReadFile("skills.yaml")
 	if err != nil {
-		return fmt.Errorf("reading skills.yaml: %w", err)
+		return nil, fmt.Errorf("reading skills.yaml: %w", err)
 	}
-	skillsData = &SkillsFile{}
-	if err := yaml.Unmarshal(data, skillsData); err != nil {
-		return fmt.Errorf("parsing skills.yaml: %w", err)
+	if err := yaml.Unmarshal(data, b.skills); err != nil {
+		return nil, fmt.Errorf("parsing skills.yaml: %w", err)
 	}
 
 	// Load challenges
 	data, err = contentFS.ReadFile("challenges.yaml")
 	if err != nil {
-		return fmt.Errorf("reading challenges.yaml: %w", err)
+		return nil, fmt.Errorf("reading challenges.yaml: %w", err)
 	}
-	challengesData = &ChallengesFile{}
-	if err := yaml.Unmarshal(data, challengesData); err != nil {
-		return fmt.Errorf("parsing challenges.yaml: %w", err)
+	if err := yaml.Unmarshal(data, b.challenges); err != nil {
+		return nil, fmt.Errorf("parsing challenges.yaml: %w", err)
 	}
 
 	// Load missions
 	data, err = contentFS.ReadFile("missions.yaml")
 	if err != nil {
-		return fmt.Errorf("reading missions.yaml: %w", err)
+		return nil, fmt.Errorf("reading missions.yaml: %w", err)
 	}
-	missionsData = &MissionsFile{}
-	if err := yaml.Unmarshal(data, missionsData); err != nil {
-		return fmt.Errorf("parsing missions.yaml: %w", err)
+	if err := yaml.Unmarshal(data, b.missions); err != nil {
+		return nil, fmt.Errorf("parsing missions.yaml: %w", err)
+	}
+
+	// Every pack is merged before anything is checked, so packs can refer to each other.
+	seen := make(map[string]string, len(packs))
+	for _, p := range packs {
+		if dir, ok := seen[p.Name]; ok {
+			return nil, fmt.Errorf("two packs are named %s: %s and %s", p.Name, dir, p.Dir)
+		}
+		seen[p.Name] = p.Dir
+		if err := b.merge(p); err != nil {
+			return nil, fmt.Errorf("pack %s (%s): %w", p.Name, p.Dir, err)
+		}
 	}
 
-	return validate()
+	return b, b.validate()
 }
 
-func validate() error {
+func (b *bundle) validate() error {
 	// Build skill ID set for validation
 	skillIDs := make(map[string]bool)
-	for _, s := range skillsData.Skills {
+	for _, s := range b.skills.Skills {
 		if skillIDs[s.ID] {
 			return fmt.Errorf("duplicate skill ID: %s", s.ID)
 		}
@@ -78,7 +103,7 @@ func validate() error {
 	}
 
 	// Validate skill prerequisites
-	for _, s := range skillsData.Skills {
+	for _, s := range b.skills.Skills {
 		for _, prereq := range s.Prerequisites {
 			if !skillIDs[prereq] {
 				return fmt.Errorf("skill %s: unknown prerequisite %s", s.ID, prereq)
@@ -86,16 +111,29 @@ func validate() error {
 		}
 	}
 
-	// Validate challenge skill references
-	for skillID := range challengesData.Challenges {
+	// Validate challenge skill references and IDs
+	challengeIDs := make(map[string]bool)
+	for _, skillID := range slices.Sorted(maps.Keys(b.challenges.Challenges)) {
 		if !skillIDs[skillID] {
 			return fmt.Errorf("challenges reference unknown skill: %s", skillID)
 		}
+		for i, c := range b.challenges.Challenges[skillID] {
+			if c.ID == "" {
+				return fmt.Errorf("%s challenge %d has no id", skillID, i+1)
+			}
+			if challengeIDs[c.ID] {
+				return fmt.Errorf("duplicate challenge ID: %s", c.ID)
+			}
+			challengeIDs[c.ID] = true
+			if err := validateSetup(c.Context); err != nil {
+				return fmt.Errorf("%s challenge %d: context: %w", skillID, i+1, err)
+			}
+		}
 	}
 
 	// Validate mission skill references, goals, and uniqueness
 	missionIDs := make(map[string]bool)
-	for _, m := range missionsData.Missions {
+	for _, m := range b.missions.Missions {
 		if missionIDs[m.ID] {
 			return fmt.Errorf("duplicate mission ID: %s", m.ID)
 		}
@@ -104,14 +142,49 @@ func validate() error {
 		if !skillIDs[m.SkillID] {
 			return fmt.Errorf("mission %s: unknown skill_id %s", m.ID, m.SkillID)
 		}
-		if _, err := ParseGoal(m.Goal); err != nil {
-			return fmt.Errorf("mission %s: invalid goal: %w", m.ID, err)
+		// Goals are checked as a learner would get them, with templates filled in.
+		expanded, err := m.Expand(rand.New(rand.NewPCG(0, 0)))
+		if err != nil {
+			return fmt.Errorf("mission %s: %w", m.ID, err)
+		}
+		if err := validateMissionGoals(&expanded); err != nil {
+			return fmt.Errorf("mission %s: %w", m.ID, err)
+		}
+		if err := validateSetup(expanded.Setup); err != nil {
+			return fmt.Errorf("mission %s: %w", m.ID, err)
+		}
+		for i, stage := range expanded.Stages {
+			if err := validateSetup(stage.Setup); err != nil {
+				return fmt.Errorf("mission %s: stage %d: %w", m.ID, i+1, err)
+			}
 		}
 	}
 
 	return nil
 }
 
+// validateMissionGoals checks a mission's goal, or the goal of each of its stages.
+func validateMissionGoals(m *YAMLMission) error {
+	if len(m.Stages) == 0 {
+		if _, err := ParseGoal(m.Goal); err != nil {
+			return fmt.Errorf("invalid goal: %w", err)
+		}
+		return nil
+	}
+	if len(m.Goal) > 0 {
+		return fmt.Errorf("has both goal and stages; put the goal in the last stage")
+	}
+	for i, stage := range m.Stages {
+		if len(stage.Goal) == 0 {
+			return fmt.Errorf("stage %d has no goal", i+1)
+		}
+		if _, err := ParseGoal(stage.Goal); err != nil {
+			return fmt.Errorf("stage %d: invalid goal: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
 // GetSkillGraph returns a populated SkillGraph from the YAML content.
 func GetSkillGraph() (*skills.SkillGraph, error) {
 	if err := LoadContent(); err != nil {
@@ -4,6 +4,7 @@
 package content
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -79,3 +80,29 @@ func TestGetRawMissions(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateMissionGoals(t *testing.T) {
+	goal := map[string]any{"path_exists": "/tmp"}
+	tests := []struct {
+		name    string
+		mission YAMLMission
+		want    string
+	}{
+		{"single goal", YAMLMission{Goal: goal}, ""},
+		{"stages", YAMLMission{Stages: []YAMLStage{{Goal: goal}, {Goal: goal}}}, ""},
+		{"goal and stages", YAMLMission{Goal: goal, Stages: []YAMLStage{{Goal: goal}}}, "has both goal and stages"},
+		{"stage without goal", YAMLMission{Stages: []YAMLStage{{Goal: goal}, {Briefing: "x"}}}, "stage 2 has no goal"},
+		{"bad stage goal", YAMLMission{Stages: []YAMLStage{{Goal: map[string]any{"nope": 1}}}}, "stage 1: invalid goal: unknown goal operation: nope"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateMissionGoals(&tt.mission)
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+				t.Errorf("got %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
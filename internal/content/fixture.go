@@ -0,0 +1,285 @@
+// ABOUTME: Shared filesystem fixtures that mission setup can load by name
+// ABOUTME: Reads embedded directory trees and .tar/.tar.gz archives, keeping modes, mtimes and symlinks
+
+package content
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed all:fixtures
+var fixturesFS embed.FS
+
+// fixtureMetaFile sits at the top of a directory fixture and sets the modes and
+// mtimes that go:embed can't carry. It is not part of the tree it describes.
+const fixtureMetaFile = ".fixture.yaml"
+
+// FixtureEntry is one file, directory or symlink in a fixture.
+type FixtureEntry struct {
+	Path    string // Slash-separated, relative to the fixture's root
+	IsDir   bool
+	Link    string      // Symlink target; non-empty makes the entry a symlink
+	Content string      // For regular files
+	Mode    fs.FileMode // Permission bits; zero means the default
+	ModTime time.Time   // Zero means whenever the fixture is loaded
+}
+
+// fixtureMeta is the format of fixtureMetaFile: paths relative to the fixture root,
+// octal modes, and times as accepted by ParseSetupTime.
+type fixtureMeta struct {
+	Modes  map[string]string `yaml:"modes"`
+	Mtimes map[string]string `yaml:"mtimes"`
+}
+
+// packFixtures holds the fixtures directory of each content pack that has one,
+// by pack name. Their fixtures are named "pack/name".
+var packFixtures = map[string]fs.FS{}
+
+// FixtureNames lists the fixtures in the library, including those from packs.
+func FixtureNames() []string {
+	core, _ := fs.Sub(fixturesFS, "fixtures")
+	names := fixtureNames(core)
+	for pack, fsys := range packFixtures {
+		for _, name := range fixtureNames(fsys) {
+			names = append(names, pack+"/"+name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// fixtureNames lists the fixtures in a fixtures directory.
+func fixtureNames(fsys fs.FS) []string {
+	entries, _ := fs.ReadDir(fsys, ".")
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() {
+			var ok bool
+			if name, ok = cutArchiveExt(name); !ok {
+				continue
+			}
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// HasFixture reports whether the library has a fixture with the given name.
+func HasFixture(name string) bool {
+	return slices.Contains(FixtureNames(), name)
+}
+
+// LoadFixture reads a fixture from the library: a directory fixtures/NAME, or an
+// archive fixtures/NAME.tar or fixtures/NAME.tar.gz. A content pack's fixtures are
+// named PACK/NAME. Parents come before children.
+func LoadFixture(name string) ([]FixtureEntry, error) {
+	parts := strings.Split(name, "/")
+	if len(parts) > 2 || slices.ContainsFunc(parts, func(p string) bool {
+		return p == "" || strings.HasPrefix(p, ".") || strings.Contains(p, `\`)
+	}) {
+		return nil, fmt.Errorf("invalid fixture name %q", name)
+	}
+	fsys, _ := fs.Sub(fixturesFS, "fixtures")
+	base := parts[len(parts)-1]
+	if len(parts) == 2 {
+		var ok bool
+		if fsys, ok = packFixtures[parts[0]]; !ok {
+			return nil, fmt.Errorf("unknown fixture %q", name)
+		}
+	}
+	if info, err := fs.Stat(fsys, base); err == nil && info.IsDir() {
+		sub, err := fs.Sub(fsys, base)
+		if err != nil {
+			return nil, err
+		}
+		return ReadFixtureDir(sub, time.Now())
+	}
+	for _, ext := range []string{".tar", ".tar.gz", ".tgz"} {
+		data, err := fs.ReadFile(fsys, base+ext)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		entries, err := ReadFixtureArchive(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("fixture %s: %w", name, err)
+		}
+		return entries, nil
+	}
+	return nil, fmt.Errorf("unknown fixture %q", name)
+}
+
+// ReadFixtureDir reads a directory tree as a fixture, applying its .fixture.yaml.
+// Relative mtimes such as "3d ago" are measured from now.
+func ReadFixtureDir(fsys fs.FS, now time.Time) ([]FixtureEntry, error) {
+	var meta fixtureMeta
+	if data, err := fs.ReadFile(fsys, fixtureMetaFile); err == nil {
+		if err := yaml.Unmarshal(data, &meta); err != nil {
+			return nil, fmt.Errorf("%s: %w", fixtureMetaFile, err)
+		}
+	}
+
+	var entries []FixtureEntry
+	seen := make(map[string]bool)
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == "." || p == fixtureMetaFile {
+			return nil
+		}
+		entry := FixtureEntry{Path: p, IsDir: d.IsDir()}
+		if !d.IsDir() {
+			data, err := fs.ReadFile(fsys, p)
+			if err != nil {
+				return err
+			}
+			entry.Content = string(data)
+		}
+		if mode, ok := meta.Modes[p]; ok {
+			n, err := strconv.ParseUint(mode, 8, 32)
+			if err != nil || n > 0o777 {
+				return fmt.Errorf("%s: %s: invalid mode %q", fixtureMetaFile, p, mode)
+			}
+			entry.Mode = fs.FileMode(n)
+		}
+		if mtime, ok := meta.Mtimes[p]; ok {
+			t, err := ParseSetupTime(mtime, now)
+			if err != nil {
+				return fmt.Errorf("%s: %s: %w", fixtureMetaFile, p, err)
+			}
+			entry.ModTime = t
+		}
+		seen[p] = true
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range append(sortedStringKeys(meta.Modes), sortedStringKeys(meta.Mtimes)...) {
+		if !seen[p] {
+			return nil, fmt.Errorf("%s: no such path %s", fixtureMetaFile, p)
+		}
+	}
+	return entries, nil
+}
+
+// ReadFixtureArchive reads a tar archive, gzipped or not, as a fixture. Regular files,
+// directories and symlinks keep their modes and mtimes; other entry types are refused.
+// So that extracting it can't write outside its root, symlinks must point within the
+// archive and no entry may lie beneath one.
+func ReadFixtureArchive(r io.Reader) ([]FixtureEntry, error) {
+	var buf bytes.Buffer
+	head := make([]byte, 2)
+	n, _ := io.ReadFull(r, head)
+	r = io.MultiReader(bytes.NewReader(head[:n]), r)
+	if n == 2 && head[0] == 0x1f && head[1] == 0x8b {
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = zr.Close() }()
+		r = zr
+	}
+
+	var entries []FixtureEntry
+	dirs := make(map[string]bool)
+	links := make(map[string]bool)
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		p := path.Clean(strings.TrimPrefix(hdr.Name, "./"))
+		if p == "." {
+			continue
+		}
+		if !fs.ValidPath(p) {
+			return nil, fmt.Errorf("unsafe path in archive: %s", hdr.Name)
+		}
+		for dir := path.Dir(p); dir != "."; dir = path.Dir(dir) {
+			if links[dir] {
+				return nil, fmt.Errorf("unsafe path in archive: %s is under the symlink %s", hdr.Name, dir)
+			}
+		}
+		// Archives needn't list every directory; add missing parents with defaults.
+		var missing []FixtureEntry
+		for dir := path.Dir(p); dir != "." && !dirs[dir]; dir = path.Dir(dir) {
+			dirs[dir] = true
+			missing = append(missing, FixtureEntry{Path: dir, IsDir: true})
+		}
+		slices.Reverse(missing)
+		entries = append(entries, missing...)
+
+		entry := FixtureEntry{Path: p, Mode: fs.FileMode(hdr.Mode).Perm(), ModTime: hdr.ModTime}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if dirs[p] {
+				// Filled in as a parent above; the archive's own entry wins.
+				i := slices.IndexFunc(entries, func(e FixtureEntry) bool { return e.Path == p })
+				entries[i].Mode, entries[i].ModTime = entry.Mode, entry.ModTime
+				continue
+			}
+			dirs[p] = true
+			entry.IsDir = true
+		case tar.TypeReg:
+			buf.Reset()
+			if _, err := io.Copy(&buf, tr); err != nil {
+				return nil, err
+			}
+			entry.Content = buf.String()
+		case tar.TypeSymlink:
+			if dirs[p] || path.IsAbs(hdr.Linkname) || !fs.ValidPath(path.Join(path.Dir(p), hdr.Linkname)) {
+				return nil, fmt.Errorf("unsafe symlink in archive: %s -> %s", hdr.Name, hdr.Linkname)
+			}
+			links[p] = true
+			entry.Link = hdr.Linkname
+			entry.Mode = 0
+		default:
+			return nil, fmt.Errorf("unsupported archive entry %s (type %q)", hdr.Name, hdr.Typeflag)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// cutArchiveExt strips a .tar, .tar.gz or .tgz extension, reporting whether it had one.
+func cutArchiveExt(name string) (string, bool) {
+	for _, ext := range []string{".tar.gz", ".tgz", ".tar"} {
+		if base, ok := strings.CutSuffix(name, ext); ok {
+			return base, true
+		}
+	}
+	return name, false
+}
+
+func sortedStringKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
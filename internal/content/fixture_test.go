@@ -0,0 +1,161 @@
+// ABOUTME: Tests for the fixture library and its directory and archive readers
+// ABOUTME: Checks every shipped fixture loads and that modes, mtimes and symlinks survive
+
+package content
+
+import (
+	"archive/tar"
+	"bytes"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestFixtureLibrary(t *testing.T) {
+	names := FixtureNames()
+	for _, want := range []string{"go-repo", "messy-downloads", "node-project"} {
+		if !HasFixture(want) {
+			t.Errorf("expected fixture %s, have %v", want, names)
+		}
+	}
+	for _, name := range names {
+		entries, err := LoadFixture(name)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if len(entries) == 0 {
+			t.Errorf("%s is empty", name)
+		}
+	}
+}
+
+func TestLoadFixture_KeepsMetadata(t *testing.T) {
+	byPath := func(name string) map[string]FixtureEntry {
+		entries, err := LoadFixture(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := make(map[string]FixtureEntry, len(entries))
+		for _, e := range entries {
+			m[e.Path] = e
+		}
+		return m
+	}
+
+	node := byPath("node-project")
+	if node["scripts/build.sh"].Mode != 0o755 || node[".env"].Mode != 0o600 {
+		t.Error("directory fixtures should take modes from .fixture.yaml")
+	}
+	if age := time.Since(node["package-lock.json"].ModTime); age < 29*24*time.Hour {
+		t.Errorf("package-lock.json should be a month old, got %v", age)
+	}
+	if _, ok := node[fixtureMetaFile]; ok {
+		t.Error(".fixture.yaml should not be part of the tree")
+	}
+
+	downloads := byPath("messy-downloads")
+	if e := downloads["latest-invoice.pdf"]; e.Link != "invoice-2024-01.pdf" {
+		t.Errorf("archives should keep symlinks, got %+v", e)
+	}
+	if e := downloads["installer.sh"]; e.Mode != 0o755 || e.ModTime.Year() != 2024 {
+		t.Errorf("archives should keep modes and mtimes, got %+v", e)
+	}
+}
+
+func TestLoadFixture_Errors(t *testing.T) {
+	for name, want := range map[string]string{
+		"nope":       `unknown fixture "nope"`,
+		"../content": "invalid fixture name",
+		".hidden":    "invalid fixture name",
+		"acme/app":   `unknown fixture "acme/app"`,
+		"acme/a/b":   "invalid fixture name",
+	} {
+		if _, err := LoadFixture(name); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("LoadFixture(%q): got %v, want %q", name, err, want)
+		}
+	}
+}
+
+func TestReadFixtureDir_BadMeta(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.txt":         {Data: []byte("a")},
+		".fixture.yaml": {Data: []byte("modes:\n  b.txt: \"600\"\n")},
+	}
+	if _, err := ReadFixtureDir(fsys, time.Now()); err == nil || !strings.Contains(err.Error(), "no such path b.txt") {
+		t.Errorf("a mode for a missing path should fail, got %v", err)
+	}
+}
+
+func TestReadFixtureArchive(t *testing.T) {
+	mtime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	_ = tw.WriteHeader(&tar.Header{Name: "./deep/dir/run.sh", Mode: 0o700, Size: 2, ModTime: mtime, Typeflag: tar.TypeReg})
+	_, _ = tw.Write([]byte("hi"))
+	_ = tw.WriteHeader(&tar.Header{Name: "deep/", Mode: 0o750, ModTime: mtime, Typeflag: tar.TypeDir})
+	_ = tw.Close()
+
+	entries, err := ReadFixtureArchive(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 || entries[0].Path != "deep" || entries[1].Path != "deep/dir" || entries[2].Path != "deep/dir/run.sh" {
+		t.Fatalf("missing parents should be added first, got %+v", entries)
+	}
+	if entries[0].Mode != 0o750 || !entries[0].ModTime.Equal(mtime) {
+		t.Errorf("a directory listed after its children should still get its metadata, got %+v", entries[0])
+	}
+	if e := entries[2]; e.Content != "hi" || e.Mode != 0o700 || !e.ModTime.Equal(mtime) {
+		t.Errorf("unexpected file entry %+v", e)
+	}
+
+	buf.Reset()
+	tw = tar.NewWriter(&buf)
+	_ = tw.WriteHeader(&tar.Header{Name: "../escape", Typeflag: tar.TypeReg})
+	_ = tw.Close()
+	if _, err := ReadFixtureArchive(&buf); err == nil || !strings.Contains(err.Error(), "unsafe path") {
+		t.Errorf("paths leaving the fixture should be refused, got %v", err)
+	}
+}
+
+func TestReadFixtureArchive_Symlinks(t *testing.T) {
+	type entry struct{ name, link string }
+	archive := func(entries ...entry) *bytes.Buffer {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		for _, e := range entries {
+			if e.link != "" {
+				_ = tw.WriteHeader(&tar.Header{Name: e.name, Linkname: e.link, Typeflag: tar.TypeSymlink})
+			} else {
+				_ = tw.WriteHeader(&tar.Header{Name: e.name, Typeflag: tar.TypeReg})
+			}
+		}
+		_ = tw.Close()
+		return &buf
+	}
+
+	tests := []struct {
+		name    string
+		entries []entry
+		wantErr string
+	}{
+		{"link within the fixture", []entry{{"app/v2/run", ""}, {"app/current", "v2"}, {"top", "app/../app"}}, ""},
+		{"absolute target", []entry{{"link", "/etc"}}, "unsafe symlink"},
+		{"target climbing out", []entry{{"app/link", "../../etc"}}, "unsafe symlink"},
+		{"entry under a link", []entry{{"link", "app"}, {"link/passwd", ""}}, "under the symlink link"},
+		{"entry deep under a link", []entry{{"link", "."}, {"link/a/b", ""}}, "under the symlink link"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadFixtureArchive(archive(tt.entries...))
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("unexpected error %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
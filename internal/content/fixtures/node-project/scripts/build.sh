@@ -0,0 +1,5 @@
+#!/bin/sh
+set -e
+mkdir -p dist
+cp -r src dist/
+echo "built into dist/"
@@ -0,0 +1,10 @@
+const http = require("http");
+const { banner } = require("./utils");
+
+const port = process.env.PORT || 3000;
+
+http
+  .createServer((req, res) => {
+    res.end(banner("hello"));
+  })
+  .listen(port, () => console.log(`listening on ${port}`));
@@ -0,0 +1,8 @@
+const leftPad = require("left-pad");
+
+// TODO: make the width configurable
+function banner(text) {
+  return leftPad(text, 20) + "\n";
+}
+
+module.exports = { banner };
@@ -0,0 +1,7 @@
+const test = require("node:test");
+const assert = require("node:assert");
+const { banner } = require("../src/utils");
+
+test("banner pads to 20 characters", () => {
+  assert.strictEqual(banner("hi").length, 21);
+});
@@ -1,11 +1,14 @@
 // ABOUTME: Goal DSL parser and evaluator for mission completion
-// ABOUTME: Supports AND/OR/NOT logic combinators and filesystem predicates
+// ABOUTME: Supports AND/OR/NOT logic combinators, filesystem predicates and tmux predicates
 
 package content
 
 import (
 	"fmt"
+	"io/fs"
+	"slices"
 	"strings"
+	"time"
 )
 
 // GoalEvaluator provides filesystem and command operations needed for goal evaluation.
@@ -15,13 +18,49 @@ type GoalEvaluator interface {
 	Exists(path string) bool
 	IsDir(path string) bool
 	ReadFile(path string) (string, error)
+	// Stat describes a path without following a final symlink, or returns false if it doesn't exist.
+	Stat(path string) (FileInfo, bool)
+	// ListDir returns the names in a directory, including hidden ones.
+	ListDir(path string) ([]string, error)
 	// LastCommand returns the most recent command executed, or empty string if none.
 	LastCommand() string
+	// CommandHistory returns every command the learner typed, oldest first.
+	CommandHistory() []CommandRecord
+	// TmuxOperations returns every tmux operation performed so far, in order.
+	TmuxOperations() []TmuxOperation
+	// TmuxBuffer returns a tmux paste buffer's contents; an empty name means the most recent buffer.
+	TmuxBuffer(name string) (string, bool)
+	// TmuxSnapshot returns the tmux server's sessions, windows and panes, or nil if no server is running.
+	TmuxSnapshot() *TmuxSnapshot
+}
+
+// FileInfo describes a path for goal evaluation.
+type FileInfo struct {
+	IsDir     bool
+	IsSymlink bool
+	Mode      fs.FileMode // Permission bits
+	ModTime   time.Time
+}
+
+// CommandRecord is one command the learner typed and what came of it.
+type CommandRecord struct {
+	Command  string
+	Output   string // What the command printed
+	ExitCode int
+}
+
+// TmuxOperation records a single tmux command run during a mission.
+type TmuxOperation struct {
+	Command string // Canonical tmux command name (e.g. "split-window")
+	Prefix  string // Prefix key pressed before Key, or empty for root and copy-mode bindings
+	Key     string // Key that ran the command, or empty if the command was typed
 }
 
 // GoalNode represents a parsed goal condition that can be evaluated.
 type GoalNode interface {
 	Evaluate(fs GoalEvaluator) bool
+	// Describe says what the condition requires, for the learner's progress checklist.
+	Describe() string
 }
 
 // AlwaysGoal always returns true (use sparingly - prefer ran_command for command validation).
@@ -31,6 +70,10 @@ func (g *AlwaysGoal) Evaluate(_ GoalEvaluator) bool {
 	return true
 }
 
+func (*AlwaysGoal) Describe() string {
+	return "anything goes"
+}
+
 // RanCommandGoal checks if the last command matches any of the expected commands.
 // Matching is done by prefix to allow "ls" to match "ls -a" etc.
 type RanCommandGoal struct {
@@ -39,33 +82,62 @@ type RanCommandGoal struct {
 
 func (g *RanCommandGoal) Evaluate(fs GoalEvaluator) bool {
 	lastCmd := fs.LastCommand()
-	if lastCmd == "" {
-		return false
+	for _, expected := range g.Commands {
+		if commandMatches(lastCmd, expected) {
+			return true
+		}
 	}
+	return false
+}
+
+func (g *RanCommandGoal) Describe() string {
+	return "run " + quoteAll(g.Commands, " or ")
+}
+
+// commandMatches reports whether cmd is the expected command. A bare command name
+// matches any arguments ("ls" matches "ls -a"); otherwise expected must be a prefix,
+// or cmd must be the same command with at least expected's options, however they
+// are written, and operands that start with expected's ("rm -rf" matches "rm -fr x",
+// "rm -r -f x" and "rm -Rf x").
+func commandMatches(cmd, expected string) bool {
 	// Normalize: get just the base command for matching
-	lastCmdBase := strings.Fields(lastCmd)
-	if len(lastCmdBase) == 0 {
+	cmdBase := strings.Fields(cmd)
+	expectedBase := strings.Fields(expected)
+	if len(cmdBase) == 0 || len(expectedBase) == 0 || cmdBase[0] != expectedBase[0] {
 		return false
 	}
-	for _, expected := range g.Commands {
-		expectedBase := strings.Fields(expected)
-		if len(expectedBase) == 0 {
-			continue
-		}
-		// Match if base commands are the same
-		if lastCmdBase[0] == expectedBase[0] {
-			// If expected has more specifics, check those too
-			if len(expectedBase) == 1 {
-				// Just checking base command (e.g., "ls" matches "ls", "ls -a", "ls foo")
-				return true
-			}
-			// Check full command prefix match
-			if strings.HasPrefix(lastCmd, expected) {
-				return true
-			}
+	// Just checking base command (e.g., "ls" matches "ls", "ls -a", "ls foo")
+	if len(expectedBase) == 1 {
+		return true
+	}
+	// Check full command prefix match
+	if strings.HasPrefix(cmd, expected) {
+		return true
+	}
+	name := cmdBase[0]
+	if LiteralArgs(name) {
+		return false
+	}
+	opts, operands, err := SplitOptions(name, cmdBase[1:], OptionSpec(name), true)
+	wantOpts, wantOperands, wantErr := SplitOptions(name, expectedBase[1:], OptionSpec(name), true)
+	if err != nil || wantErr != nil || len(wantOperands) > len(operands) {
+		return false
+	}
+	for _, opt := range wantOpts {
+		if !slices.Contains(opts, opt) {
+			return false
 		}
 	}
-	return false
+	return slices.Equal(operands[:len(wantOperands)], wantOperands)
+}
+
+// quoteAll quotes each item and joins them with sep.
+func quoteAll(items []string, sep string) string {
+	quoted := make([]string, len(items))
+	for i, item := range items {
+		quoted[i] = fmt.Sprintf("%q", item)
+	}
+	return strings.Join(quoted, sep)
 }
 
 // PwdEqualsGoal checks if current directory matches the expected path.
@@ -77,6 +149,10 @@ func (g *PwdEqualsGoal) Evaluate(fs GoalEvaluator) bool {
 	return fs.Pwd() == g.Path
 }
 
+func (g *PwdEqualsGoal) Describe() string {
+	return "be in " + g.Path
+}
+
 // PathExistsGoal checks if a path exists.
 type PathExistsGoal struct {
 	Path string
@@ -86,6 +162,10 @@ func (g *PathExistsGoal) Evaluate(fs GoalEvaluator) bool {
 	return fs.Exists(g.Path)
 }
 
+func (g *PathExistsGoal) Describe() string {
+	return g.Path + " exists"
+}
+
 // PathNotExistsGoal checks if a path does not exist.
 type PathNotExistsGoal struct {
 	Path string
@@ -95,6 +175,10 @@ func (g *PathNotExistsGoal) Evaluate(fs GoalEvaluator) bool {
 	return !fs.Exists(g.Path)
 }
 
+func (g *PathNotExistsGoal) Describe() string {
+	return g.Path + " is gone"
+}
+
 // IsDirGoal checks if a path is a directory.
 type IsDirGoal struct {
 	Path string
@@ -104,6 +188,10 @@ func (g *IsDirGoal) Evaluate(fs GoalEvaluator) bool {
 	return fs.IsDir(g.Path)
 }
 
+func (g *IsDirGoal) Describe() string {
+	return g.Path + " is a directory"
+}
+
 // IsFileGoal checks if a path is a regular file (exists and not a directory).
 type IsFileGoal struct {
 	Path string
@@ -113,6 +201,10 @@ func (g *IsFileGoal) Evaluate(fs GoalEvaluator) bool {
 	return fs.Exists(g.Path) && !fs.IsDir(g.Path)
 }
 
+func (g *IsFileGoal) Describe() string {
+	return g.Path + " is a file"
+}
+
 // FileContainsGoal checks if a file contains a substring.
 type FileContainsGoal struct {
 	Path    string
@@ -127,6 +219,87 @@ func (g *FileContainsGoal) Evaluate(fs GoalEvaluator) bool {
 	return strings.Contains(content, g.Content)
 }
 
+func (g *FileContainsGoal) Describe() string {
+	return fmt.Sprintf("%s contains %q", g.Path, g.Content)
+}
+
+// TmuxUsedGoal checks if a tmux command was run, optionally via a specific input method.
+// Via is "keybinding", "command", or empty to accept either. Key and Prefix, when set,
+// require a particular binding, e.g. a prefix remapped in ~/.tmux.conf.
+type TmuxUsedGoal struct {
+	Command string
+	Via     string
+	Key     string
+	Prefix  string
+}
+
+func (g *TmuxUsedGoal) Evaluate(fs GoalEvaluator) bool {
+	for _, op := range fs.TmuxOperations() {
+		if op.Command != g.Command {
+			continue
+		}
+		if (g.Key != "" && op.Key != g.Key) || (g.Prefix != "" && op.Prefix != g.Prefix) {
+			continue
+		}
+		switch g.Via {
+		case "keybinding":
+			if op.Key != "" {
+				return true
+			}
+		case "command":
+			if op.Key == "" {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+func (g *TmuxUsedGoal) Describe() string {
+	desc := "use tmux " + g.Command
+	switch {
+	case g.Key != "":
+		desc += " with " + strings.TrimSpace(g.Prefix+" "+g.Key)
+	case g.Via == "keybinding":
+		desc += " with its key binding"
+	case g.Via == "command":
+		desc += " as a typed command"
+	}
+	return desc
+}
+
+// TmuxBufferGoal checks the contents of a tmux paste buffer.
+// An empty Name checks the most recent buffer; empty Contains/Equals are not checked.
+type TmuxBufferGoal struct {
+	Name     string
+	Contains string
+	Equals   *string
+}
+
+func (g *TmuxBufferGoal) Evaluate(fs GoalEvaluator) bool {
+	data, ok := fs.TmuxBuffer(g.Name)
+	if !ok {
+		return false
+	}
+	if g.Equals != nil && strings.TrimSuffix(data, "\n") != strings.TrimSuffix(*g.Equals, "\n") {
+		return false
+	}
+	return strings.Contains(data, g.Contains)
+}
+
+func (g *TmuxBufferGoal) Describe() string {
+	buffer := "the paste buffer"
+	if g.Name != "" {
+		buffer = "paste buffer " + g.Name
+	}
+	if g.Equals != nil {
+		return fmt.Sprintf("%s holds %q", buffer, *g.Equals)
+	}
+	return fmt.Sprintf("%s contains %q", buffer, g.Contains)
+}
+
 // AndGoal requires all child conditions to be true.
 type AndGoal struct {
 	Conditions []GoalNode
@@ -141,6 +314,10 @@ func (g *AndGoal) Evaluate(fs GoalEvaluator) bool {
 	return true
 }
 
+func (g *AndGoal) Describe() string {
+	return "all of these"
+}
+
 // OrGoal requires at least one child condition to be true.
 type OrGoal struct {
 	Conditions []GoalNode
@@ -155,6 +332,10 @@ func (g *OrGoal) Evaluate(fs GoalEvaluator) bool {
 	return false
 }
 
+func (g *OrGoal) Describe() string {
+	return "any of these"
+}
+
 // NotGoal negates a condition.
 type NotGoal struct {
 	Condition GoalNode
@@ -164,21 +345,34 @@ func (g *NotGoal) Evaluate(fs GoalEvaluator) bool {
 	return !g.Condition.Evaluate(fs)
 }
 
+func (g *NotGoal) Describe() string {
+	return "not: " + g.Condition.Describe()
+}
+
 // ParseGoal converts a YAML goal map to a GoalNode tree.
 func ParseGoal(raw map[string]any) (GoalNode, error) {
 	if len(raw) == 0 {
 		return &AlwaysGoal{}, nil
 	}
 
+	label, hint, rest, err := parseAnnotations(raw)
+	if err != nil {
+		return nil, err
+	}
+
 	// Handle single-key primitives and combinators
-	for key, value := range raw {
+	for key, value := range rest {
 		node, err := parseGoalKey(key, value)
 		if err != nil {
 			return nil, err
 		}
-		if node != nil {
-			return node, nil
+		if node == nil {
+			continue
+		}
+		if label != "" || hint != "" {
+			return &AnnotatedGoal{Goal: node, Label: label, Hint: hint}, nil
 		}
+		return node, nil
 	}
 
 	return nil, fmt.Errorf("empty goal")
@@ -202,6 +396,10 @@ func parseGoalKey(key string, value any) (GoalNode, error) {
 		return parseStringGoal(key, value, func(s string) GoalNode { return &IsFileGoal{Path: s} })
 	case "file_contains":
 		return parseFileContains(value)
+	case "tmux_used":
+		return parseTmuxUsed(value)
+	case "tmux_buffer":
+		return parseTmuxBuffer(value)
 	case "and":
 		return parseAnd(value)
 	case "or":
@@ -209,26 +407,44 @@ func parseGoalKey(key string, value any) (GoalNode, error) {
 	case "not":
 		return parseNot(value)
 	default:
+		if parse, ok := tmuxStateGoals[key]; ok {
+			return parse(value)
+		}
+		if parse, ok := historyGoals[key]; ok {
+			return parse(value)
+		}
+		if parse, ok := fileGoals[key]; ok {
+			return parse(value)
+		}
 		return nil, fmt.Errorf("unknown goal operation: %s", key)
 	}
 }
 
 func parseRanCommand(value any) (GoalNode, error) {
+	cmds, err := parseStringList("ran_command", value)
+	if err != nil {
+		return nil, err
+	}
+	return &RanCommandGoal{Commands: cmds}, nil
+}
+
+// parseStringList accepts a single string or an array of strings.
+func parseStringList(key string, value any) ([]string, error) {
 	switch v := value.(type) {
 	case string:
-		return &RanCommandGoal{Commands: []string{v}}, nil
+		return []string{v}, nil
 	case []any:
-		cmds := make([]string, 0, len(v))
+		items := make([]string, 0, len(v))
 		for i, item := range v {
 			s, ok := item.(string)
 			if !ok {
-				return nil, fmt.Errorf("ran_command[%d] expects string, got %T", i, item)
+				return nil, fmt.Errorf("%s[%d] expects string, got %T", key, i, item)
 			}
-			cmds = append(cmds, s)
+			items = append(items, s)
 		}
-		return &RanCommandGoal{Commands: cmds}, nil
+		return items, nil
 	default:
-		return nil, fmt.Errorf("ran_command expects string or array of strings, got %T", value)
+		return nil, fmt.Errorf("%s expects string or array of strings, got %T", key, value)
 	}
 }
 
@@ -259,6 +475,77 @@ func parseFileContains(value any) (GoalNode, error) {
 	return &FileContainsGoal{Path: path, Content: content}, nil
 }
 
+func parseTmuxUsed(value any) (GoalNode, error) {
+	switch v := value.(type) {
+	case string:
+		return &TmuxUsedGoal{Command: v}, nil
+	case map[string]any:
+		goal := &TmuxUsedGoal{}
+		for key, raw := range v {
+			str, ok := raw.(string)
+			if !ok {
+				return nil, fmt.Errorf("tmux_used.%s expects string", key)
+			}
+			switch key {
+			case "command":
+				goal.Command = str
+			case "via":
+				goal.Via = str
+			case "key":
+				goal.Key = str
+			case "prefix":
+				goal.Prefix = str
+			default:
+				return nil, fmt.Errorf("tmux_used: unknown field %q", key)
+			}
+		}
+		if goal.Command == "" {
+			return nil, fmt.Errorf("tmux_used.command expects string")
+		}
+		switch goal.Via {
+		case "", "any":
+			goal.Via = ""
+		case "keybinding", "command":
+		default:
+			return nil, fmt.Errorf("tmux_used.via must be keybinding, command, or any, got %q", goal.Via)
+		}
+		return goal, nil
+	default:
+		return nil, fmt.Errorf("tmux_used expects string or map with command, via, key, or prefix, got %T", value)
+	}
+}
+
+func parseTmuxBuffer(value any) (GoalNode, error) {
+	switch v := value.(type) {
+	case string:
+		return &TmuxBufferGoal{Contains: v}, nil
+	case map[string]any:
+		goal := &TmuxBufferGoal{}
+		for key, raw := range v {
+			str, ok := raw.(string)
+			if !ok {
+				return nil, fmt.Errorf("tmux_buffer.%s expects string, got %T", key, raw)
+			}
+			switch key {
+			case "name":
+				goal.Name = str
+			case "contains":
+				goal.Contains = str
+			case "equals":
+				goal.Equals = &str
+			default:
+				return nil, fmt.Errorf("tmux_buffer: unknown field %q", key)
+			}
+		}
+		if goal.Contains == "" && goal.Equals == nil {
+			return nil, fmt.Errorf("tmux_buffer needs contains or equals")
+		}
+		return goal, nil
+	default:
+		return nil, fmt.Errorf("tmux_buffer expects string or map with name, contains, or equals, got %T", value)
+	}
+}
+
 func parseAnd(value any) (GoalNode, error) {
 	items, ok := value.([]any)
 	if !ok {
@@ -0,0 +1,350 @@
+// ABOUTME: Goal predicates on file contents, directory shape and file metadata
+// ABOUTME: Covers regex and exact content, line counts, globs, symlinks, modes and mtimes
+
+package content
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FileMatchesGoal checks a file's contents against a regular expression.
+type FileMatchesGoal struct {
+	Path    string
+	Pattern *regexp.Regexp
+}
+
+func (g *FileMatchesGoal) Evaluate(fs GoalEvaluator) bool {
+	data, err := fs.ReadFile(g.Path)
+	return err == nil && g.Pattern.MatchString(data)
+}
+
+func (g *FileMatchesGoal) Describe() string {
+	return fmt.Sprintf("%s matches %q", g.Path, g.Pattern)
+}
+
+// FileEqualsGoal checks a file's exact contents; a missing final newline is forgiven.
+type FileEqualsGoal struct {
+	Path    string
+	Content string
+}
+
+func (g *FileEqualsGoal) Evaluate(fs GoalEvaluator) bool {
+	data, err := fs.ReadFile(g.Path)
+	return err == nil && strings.TrimSuffix(data, "\n") == strings.TrimSuffix(g.Content, "\n")
+}
+
+func (g *FileEqualsGoal) Describe() string {
+	return fmt.Sprintf("%s holds exactly %q", g.Path, strings.TrimSuffix(g.Content, "\n"))
+}
+
+// FileLineCountGoal checks how many lines a file has; a final line without a newline counts.
+type FileLineCountGoal struct {
+	Path  string
+	Count countRange
+}
+
+func (g *FileLineCountGoal) Evaluate(fs GoalEvaluator) bool {
+	data, err := fs.ReadFile(g.Path)
+	if err != nil {
+		return false
+	}
+	lines := strings.Count(data, "\n")
+	if data != "" && !strings.HasSuffix(data, "\n") {
+		lines++
+	}
+	return g.Count.contains(lines)
+}
+
+func (g *FileLineCountGoal) Describe() string {
+	return g.Path + " has " + g.Count.describe("line")
+}
+
+// FileEmptyGoal checks that a regular file exists and is empty.
+type FileEmptyGoal struct {
+	Path string
+}
+
+func (g *FileEmptyGoal) Evaluate(fs GoalEvaluator) bool {
+	info, ok := fs.Stat(g.Path)
+	if !ok || info.IsDir {
+		return false
+	}
+	data, err := fs.ReadFile(g.Path)
+	return err == nil && data == ""
+}
+
+func (g *FileEmptyGoal) Describe() string {
+	return g.Path + " is an empty file"
+}
+
+// DirContainsExactlyGoal checks that a directory holds exactly the named entries.
+// A name ending in "/" must be a directory.
+type DirContainsExactlyGoal struct {
+	Path  string
+	Names []string
+}
+
+func (g *DirContainsExactlyGoal) Evaluate(fs GoalEvaluator) bool {
+	names, err := fs.ListDir(g.Path)
+	if err != nil || len(names) != len(g.Names) {
+		return false
+	}
+	have := make(map[string]bool, len(names))
+	for _, name := range names {
+		have[name] = true
+	}
+	for _, want := range g.Names {
+		name, wantDir := strings.CutSuffix(want, "/")
+		if !have[name] {
+			return false
+		}
+		if wantDir {
+			if info, ok := fs.Stat(path.Join(g.Path, name)); !ok || !info.IsDir {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (g *DirContainsExactlyGoal) Describe() string {
+	return g.Path + " holds exactly " + strings.Join(g.Names, ", ")
+}
+
+// FileCountGoal counts the entries in a directory matching a glob such as "~/chaos/*.log".
+type FileCountGoal struct {
+	Glob  string
+	Count countRange
+}
+
+func (g *FileCountGoal) Evaluate(fs GoalEvaluator) bool {
+	dir, pattern := path.Split(g.Glob)
+	if dir == "" {
+		dir = "."
+	}
+	names, err := fs.ListDir(path.Clean(dir))
+	if err != nil {
+		return false
+	}
+	n := 0
+	for _, name := range names {
+		// Like the shell, * doesn't match a leading dot unless the pattern starts with one.
+		if strings.HasPrefix(name, ".") && !strings.HasPrefix(pattern, ".") {
+			continue
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			n++
+		}
+	}
+	return g.Count.contains(n)
+}
+
+func (g *FileCountGoal) Describe() string {
+	return g.Count.describe("file") + " match " + g.Glob
+}
+
+// IsSymlinkGoal checks that a path is a symbolic link.
+type IsSymlinkGoal struct {
+	Path string
+}
+
+func (g *IsSymlinkGoal) Evaluate(fs GoalEvaluator) bool {
+	info, ok := fs.Stat(g.Path)
+	return ok && info.IsSymlink
+}
+
+func (g *IsSymlinkGoal) Describe() string {
+	return g.Path + " is a symlink"
+}
+
+// HasModeGoal checks a path's permission bits.
+type HasModeGoal struct {
+	Path string
+	Mode fs.FileMode
+}
+
+func (g *HasModeGoal) Evaluate(fs GoalEvaluator) bool {
+	info, ok := fs.Stat(g.Path)
+	return ok && info.Mode.Perm() == g.Mode
+}
+
+func (g *HasModeGoal) Describe() string {
+	return fmt.Sprintf("%s has mode %o", g.Path, g.Mode)
+}
+
+// NewerThanGoal checks that a path was modified after another one, like find -newer.
+type NewerThanGoal struct {
+	Path string
+	Than string
+}
+
+func (g *NewerThanGoal) Evaluate(fs GoalEvaluator) bool {
+	info, ok := fs.Stat(g.Path)
+	other, otherOK := fs.Stat(g.Than)
+	return ok && otherOK && info.ModTime.After(other.ModTime)
+}
+
+func (g *NewerThanGoal) Describe() string {
+	return g.Path + " is newer than " + g.Than
+}
+
+// fileGoals parses the goals that inspect files and directories, by key.
+var fileGoals = map[string]func(any) (GoalNode, error){
+	"file_matches": func(v any) (GoalNode, error) {
+		f, err := parseFields("file_matches", v, "path", "pattern")
+		if err != nil {
+			return nil, err
+		}
+		re, err := regexp.Compile(f["pattern"])
+		if err != nil {
+			return nil, fmt.Errorf("file_matches: %w", err)
+		}
+		return &FileMatchesGoal{Path: f["path"], Pattern: re}, nil
+	},
+	"file_equals": func(v any) (GoalNode, error) {
+		f, err := parseFields("file_equals", v, "path", "content")
+		if err != nil {
+			return nil, err
+		}
+		return &FileEqualsGoal{Path: f["path"], Content: f["content"]}, nil
+	},
+	"file_line_count": func(v any) (GoalNode, error) {
+		p, count, err := parseCountAt("file_line_count", "path", v)
+		return &FileLineCountGoal{Path: p, Count: count}, err
+	},
+	"file_count": func(v any) (GoalNode, error) {
+		glob, count, err := parseCountAt("file_count", "glob", v)
+		return &FileCountGoal{Glob: glob, Count: count}, err
+	},
+	"file_empty": func(v any) (GoalNode, error) {
+		return parseStringGoal("file_empty", v, func(s string) GoalNode { return &FileEmptyGoal{Path: s} })
+	},
+	"is_symlink": func(v any) (GoalNode, error) {
+		return parseStringGoal("is_symlink", v, func(s string) GoalNode { return &IsSymlinkGoal{Path: s} })
+	},
+	"newer_than": func(v any) (GoalNode, error) {
+		f, err := parseFields("newer_than", v, "path", "than")
+		if err != nil {
+			return nil, err
+		}
+		return &NewerThanGoal{Path: f["path"], Than: f["than"]}, nil
+	},
+	"has_mode":             parseHasMode,
+	"dir_contains_exactly": parseDirContainsExactly,
+}
+
+// parseFields reads a map whose fields are all required strings.
+func parseFields(key string, value any, fields ...string) (map[string]string, error) {
+	m, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s expects map with %s, got %T", key, strings.Join(fields, " and "), value)
+	}
+	out := make(map[string]string, len(fields))
+	for _, field := range fields {
+		s, ok := m[field].(string)
+		if !ok {
+			return nil, fmt.Errorf("%s.%s expects string", key, field)
+		}
+		out[field] = s
+	}
+	for field := range m {
+		if _, ok := out[field]; !ok {
+			return nil, fmt.Errorf("%s: unknown field %q", key, field)
+		}
+	}
+	return out, nil
+}
+
+// parseCountAt parses a map with a string field (path or glob) and a count: an exact
+// count, min, or max.
+func parseCountAt(key, field string, value any) (string, countRange, error) {
+	m, ok := value.(map[string]any)
+	if !ok {
+		return "", countRange{}, fmt.Errorf("%s expects map with %s and count, min, or max, got %T", key, field, value)
+	}
+	where, ok := m[field].(string)
+	if !ok {
+		return "", countRange{}, fmt.Errorf("%s.%s expects string", key, field)
+	}
+	var count countRange
+	for name, raw := range m {
+		if name == field {
+			continue
+		}
+		n, ok := goalInt(raw)
+		switch {
+		case name != "count" && name != "min" && name != "max":
+			return "", count, fmt.Errorf("%s: unknown field %q", key, name)
+		case !ok:
+			return "", count, fmt.Errorf("%s.%s expects a number, got %T", key, name, raw)
+		}
+		if name == "count" || name == "min" {
+			count.Min = &n
+		}
+		if name == "count" || name == "max" {
+			count.Max = &n
+		}
+	}
+	if count.Min == nil && count.Max == nil {
+		return "", count, fmt.Errorf("%s needs count, min, or max", key)
+	}
+	return where, count, nil
+}
+
+// parseHasMode reads an octal mode. YAML reads an unquoted 755 as decimal, so the
+// digits are taken as written.
+func parseHasMode(value any) (GoalNode, error) {
+	m, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("has_mode expects map with path and mode, got %T", value)
+	}
+	p, ok := m["path"].(string)
+	if !ok {
+		return nil, fmt.Errorf("has_mode.path expects string")
+	}
+	var digits string
+	switch v := m["mode"].(type) {
+	case string:
+		digits = v
+	case int:
+		digits = strconv.Itoa(v)
+	default:
+		return nil, fmt.Errorf("has_mode.mode expects an octal mode like \"755\", got %T", m["mode"])
+	}
+	mode, err := strconv.ParseUint(digits, 8, 32)
+	if err != nil || mode > 0o777 {
+		return nil, fmt.Errorf("has_mode.mode: invalid mode %q", digits)
+	}
+	for field := range m {
+		if field != "path" && field != "mode" {
+			return nil, fmt.Errorf("has_mode: unknown field %q", field)
+		}
+	}
+	return &HasModeGoal{Path: p, Mode: fs.FileMode(mode)}, nil
+}
+
+func parseDirContainsExactly(value any) (GoalNode, error) {
+	m, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("dir_contains_exactly expects map with path and names, got %T", value)
+	}
+	p, ok := m["path"].(string)
+	if !ok {
+		return nil, fmt.Errorf("dir_contains_exactly.path expects string")
+	}
+	names, err := parseStringList("dir_contains_exactly.names", m["names"])
+	if err != nil {
+		return nil, err
+	}
+	for field := range m {
+		if field != "path" && field != "names" {
+			return nil, fmt.Errorf("dir_contains_exactly: unknown field %q", field)
+		}
+	}
+	return &DirContainsExactlyGoal{Path: p, Names: names}, nil
+}
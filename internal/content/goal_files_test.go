@@ -0,0 +1,112 @@
+// ABOUTME: Tests for the file content, directory shape and file metadata goal predicates
+// ABOUTME: Covers regex and exact content, line counts, globs, symlinks, modes and mtimes
+
+package content
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// filesFixture is a small tidied-up project with a script, a link and an empty file.
+func filesFixture() *mockFS {
+	fs := newMockFS()
+	fs.paths["/home"] = true
+	fs.paths["/home/project"] = true
+	fs.paths["/home/project/logs"] = true
+	fs.paths["/home/project/.git"] = true
+	fs.files["/home/project/notes.txt"] = "one\ntwo\nthree\n"
+	fs.files["/home/project/run.sh"] = "#!/bin/sh\necho hi"
+	fs.files["/home/project/empty.txt"] = ""
+	fs.files["/home/project/logs/a.log"] = "a"
+	fs.files["/home/project/logs/b.log"] = "b"
+	fs.files["/home/project/logs/.old.log"] = "old"
+	fs.files["/home/project/latest"] = ""
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	fs.info["/home/project/run.sh"] = FileInfo{Mode: 0o755, ModTime: base.Add(time.Hour)}
+	fs.info["/home/project/notes.txt"] = FileInfo{Mode: 0o644, ModTime: base}
+	fs.info["/home/project/latest"] = FileInfo{IsSymlink: true, Mode: 0o777}
+	return fs
+}
+
+func TestFileGoals(t *testing.T) {
+	tests := []struct {
+		name string
+		goal map[string]any
+		want bool
+	}{
+		{"matches", map[string]any{"file_matches": map[string]any{"path": "/home/project/run.sh", "pattern": `^#!/bin/sh`}}, true},
+		{"does not match", map[string]any{"file_matches": map[string]any{"path": "/home/project/run.sh", "pattern": `bash`}}, false},
+		{"matches missing file", map[string]any{"file_matches": map[string]any{"path": "/nope", "pattern": `.*`}}, false},
+		{"equals", map[string]any{"file_equals": map[string]any{"path": "/home/project/notes.txt", "content": "one\ntwo\nthree"}}, true},
+		{"not equal", map[string]any{"file_equals": map[string]any{"path": "/home/project/notes.txt", "content": "one\ntwo"}}, false},
+		{"line count", map[string]any{"file_line_count": map[string]any{"path": "/home/project/notes.txt", "count": 3}}, true},
+		{"line count without final newline", map[string]any{"file_line_count": map[string]any{"path": "/home/project/run.sh", "count": 2}}, true},
+		{"line count below min", map[string]any{"file_line_count": map[string]any{"path": "/home/project/notes.txt", "min": 4}}, false},
+		{"empty", map[string]any{"file_empty": "/home/project/empty.txt"}, true},
+		{"not empty", map[string]any{"file_empty": "/home/project/notes.txt"}, false},
+		{"directory is not an empty file", map[string]any{"file_empty": "/home/project/logs"}, false},
+		{"contains exactly", map[string]any{"dir_contains_exactly": map[string]any{
+			"path": "/home/project/logs", "names": []any{"a.log", "b.log", ".old.log"},
+		}}, true},
+		{"contains extra", map[string]any{"dir_contains_exactly": map[string]any{
+			"path": "/home/project/logs", "names": []any{"a.log", "b.log"},
+		}}, false},
+		{"contains a directory", map[string]any{"dir_contains_exactly": map[string]any{
+			"path": "/home", "names": []any{"project/"},
+		}}, true},
+		{"file where a directory is wanted", map[string]any{"dir_contains_exactly": map[string]any{
+			"path": "/home/project/logs", "names": []any{"a.log/", "b.log", ".old.log"},
+		}}, false},
+		{"glob skips dot files", map[string]any{"file_count": map[string]any{"glob": "/home/project/logs/*.log", "count": 2}}, true},
+		{"dot glob", map[string]any{"file_count": map[string]any{"glob": "/home/project/logs/.*", "count": 1}}, true},
+		{"glob over max", map[string]any{"file_count": map[string]any{"glob": "/home/project/logs/*", "max": 1}}, false},
+		{"symlink", map[string]any{"is_symlink": "/home/project/latest"}, true},
+		{"not a symlink", map[string]any{"is_symlink": "/home/project/run.sh"}, false},
+		{"mode", map[string]any{"has_mode": map[string]any{"path": "/home/project/run.sh", "mode": "755"}}, true},
+		{"mode as number", map[string]any{"has_mode": map[string]any{"path": "/home/project/run.sh", "mode": 755}}, true},
+		{"wrong mode", map[string]any{"has_mode": map[string]any{"path": "/home/project/notes.txt", "mode": "600"}}, false},
+		{"newer", map[string]any{"newer_than": map[string]any{"path": "/home/project/run.sh", "than": "/home/project/notes.txt"}}, true},
+		{"older", map[string]any{"newer_than": map[string]any{"path": "/home/project/notes.txt", "than": "/home/project/run.sh"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := ParseGoal(tt.goal)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := node.Evaluate(filesFixture()); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileGoals_ParseErrors(t *testing.T) {
+	tests := []struct {
+		goal map[string]any
+		want string
+	}{
+		{map[string]any{"file_matches": "x"}, "file_matches expects map with path and pattern"},
+		{map[string]any{"file_matches": map[string]any{"path": "x", "pattern": "("}}, "file_matches: error parsing regexp"},
+		{map[string]any{"file_equals": map[string]any{"path": "x"}}, "file_equals.content expects string"},
+		{map[string]any{"file_equals": map[string]any{"path": "x", "content": "", "trim": true}}, `file_equals: unknown field "trim"`},
+		{map[string]any{"file_line_count": map[string]any{"path": "x"}}, "file_line_count needs count, min, or max"},
+		{map[string]any{"file_count": map[string]any{"glob": "*", "count": "2"}}, "file_count.count expects a number"},
+		{map[string]any{"file_count": map[string]any{"path": "*", "count": 2}}, "file_count.glob expects string"},
+		{map[string]any{"file_empty": 1}, "file_empty expects string"},
+		{map[string]any{"is_symlink": []any{}}, "is_symlink expects string"},
+		{map[string]any{"has_mode": map[string]any{"path": "x", "mode": "789"}}, `has_mode.mode: invalid mode "789"`},
+		{map[string]any{"has_mode": map[string]any{"path": "x", "mode": "1755"}}, `has_mode.mode: invalid mode "1755"`},
+		{map[string]any{"has_mode": map[string]any{"path": "x", "mode": true}}, "has_mode.mode expects an octal mode"},
+		{map[string]any{"dir_contains_exactly": map[string]any{"path": "x", "names": 3}}, "dir_contains_exactly.names expects"},
+		{map[string]any{"newer_than": map[string]any{"path": "x"}}, "newer_than.than expects string"},
+	}
+	for _, tt := range tests {
+		_, err := ParseGoal(tt.goal)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseGoal(%v): got %v, want %q", tt.goal, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+// ABOUTME: Goal predicates over the commands the learner ran and what they printed
+// ABOUTME: Covers output checks, history order, exit codes, command par and forbidden commands
+
+package content
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// lastRecord returns the most recent command the learner typed.
+func lastRecord(fs GoalEvaluator) (CommandRecord, bool) {
+	history := fs.CommandHistory()
+	if len(history) == 0 {
+		return CommandRecord{}, false
+	}
+	return history[len(history)-1], true
+}
+
+// OutputContainsGoal checks what the last command printed, or with AnyCommand, what
+// any command so far printed.
+type OutputContainsGoal struct {
+	Text       string
+	AnyCommand bool
+}
+
+func (g *OutputContainsGoal) Evaluate(fs GoalEvaluator) bool {
+	if !g.AnyCommand {
+		last, ok := lastRecord(fs)
+		return ok && strings.Contains(last.Output, g.Text)
+	}
+	for _, record := range fs.CommandHistory() {
+		if strings.Contains(record.Output, g.Text) {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *OutputContainsGoal) Describe() string {
+	if g.AnyCommand {
+		return fmt.Sprintf("a command prints %q", g.Text)
+	}
+	return fmt.Sprintf("the last command prints %q", g.Text)
+}
+
+// OutputMatchesGoal checks the last command's output against a regular expression.
+type OutputMatchesGoal struct {
+	Pattern *regexp.Regexp
+}
+
+func (g *OutputMatchesGoal) Evaluate(fs GoalEvaluator) bool {
+	last, ok := lastRecord(fs)
+	return ok && g.Pattern.MatchString(last.Output)
+}
+
+func (g *OutputMatchesGoal) Describe() string {
+	return fmt.Sprintf("the last command prints something matching %q", g.Pattern)
+}
+
+// HistoryContainsGoal checks that any of the commands was run at some point.
+// Commands match as in ran_command.
+type HistoryContainsGoal struct {
+	Commands []string
+}
+
+func (g *HistoryContainsGoal) Evaluate(fs GoalEvaluator) bool {
+	for _, record := range fs.CommandHistory() {
+		for _, expected := range g.Commands {
+			if commandMatches(record.Command, expected) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (g *HistoryContainsGoal) Describe() string {
+	return "run " + quoteAll(g.Commands, " or ") + " at some point"
+}
+
+// RanCommandsInOrderGoal checks that the commands were all run in this order; other
+// commands in between are allowed.
+type RanCommandsInOrderGoal struct {
+	Commands []string
+}
+
+func (g *RanCommandsInOrderGoal) Evaluate(fs GoalEvaluator) bool {
+	next := 0
+	for _, record := range fs.CommandHistory() {
+		if next < len(g.Commands) && commandMatches(record.Command, g.Commands[next]) {
+			next++
+		}
+	}
+	return next == len(g.Commands)
+}
+
+func (g *RanCommandsInOrderGoal) Describe() string {
+	return "run " + quoteAll(g.Commands, " then ")
+}
+
+// ExitCodeGoal checks the last command's exit status.
+type ExitCodeGoal struct {
+	Code int
+}
+
+func (g *ExitCodeGoal) Evaluate(fs GoalEvaluator) bool {
+	last, ok := lastRecord(fs)
+	return ok && last.ExitCode == g.Code
+}
+
+func (g *ExitCodeGoal) Describe() string {
+	if g.Code == 0 {
+		return "the last command succeeds"
+	}
+	return fmt.Sprintf("the last command exits with status %d", g.Code)
+}
+
+// MaxCommandsGoal holds while the learner has typed at most Max commands (par for the mission).
+type MaxCommandsGoal struct {
+	Max int
+}
+
+func (g *MaxCommandsGoal) Evaluate(fs GoalEvaluator) bool {
+	return len(fs.CommandHistory()) <= g.Max
+}
+
+func (g *MaxCommandsGoal) Describe() string {
+	if g.Max == 1 {
+		return "use only 1 command"
+	}
+	return fmt.Sprintf("use at most %d commands", g.Max)
+}
+
+// NeverRanGoal holds while none of the commands has been run, e.g. to forbid "rm -rf".
+type NeverRanGoal struct {
+	Commands []string
+}
+
+func (g *NeverRanGoal) Evaluate(fs GoalEvaluator) bool {
+	return !(&HistoryContainsGoal{Commands: g.Commands}).Evaluate(fs)
+}
+
+func (g *NeverRanGoal) Describe() string {
+	return "never run " + quoteAll(g.Commands, " or ")
+}
+
+// historyGoals parses the goals that inspect command history, by key.
+var historyGoals = map[string]func(any) (GoalNode, error){
+	"output_contains": parseOutputContains,
+	"output_matches": func(v any) (GoalNode, error) {
+		pattern, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("output_matches expects string, got %T", v)
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("output_matches: %w", err)
+		}
+		return &OutputMatchesGoal{Pattern: re}, nil
+	},
+	"history_contains": func(v any) (GoalNode, error) {
+		cmds, err := parseStringList("history_contains", v)
+		return &HistoryContainsGoal{Commands: cmds}, err
+	},
+	"ran_commands_in_order": func(v any) (GoalNode, error) {
+		cmds, err := parseStringList("ran_commands_in_order", v)
+		if err == nil && len(cmds) == 0 {
+			err = fmt.Errorf("ran_commands_in_order needs at least one command")
+		}
+		return &RanCommandsInOrderGoal{Commands: cmds}, err
+	},
+	"never_ran": func(v any) (GoalNode, error) {
+		cmds, err := parseStringList("never_ran", v)
+		return &NeverRanGoal{Commands: cmds}, err
+	},
+	"exit_code": func(v any) (GoalNode, error) {
+		code, ok := goalInt(v)
+		if !ok {
+			return nil, fmt.Errorf("exit_code expects a number, got %T", v)
+		}
+		return &ExitCodeGoal{Code: code}, nil
+	},
+	"max_commands": func(v any) (GoalNode, error) {
+		n, ok := goalInt(v)
+		if !ok || n < 1 {
+			return nil, fmt.Errorf("max_commands expects a positive number, got %v", v)
+		}
+		return &MaxCommandsGoal{Max: n}, nil
+	},
+}
+
+func parseOutputContains(value any) (GoalNode, error) {
+	switch v := value.(type) {
+	case string:
+		return &OutputContainsGoal{Text: v}, nil
+	case map[string]any:
+		goal := &OutputContainsGoal{}
+		for key, raw := range v {
+			switch key {
+			case "text":
+				s, ok := raw.(string)
+				if !ok {
+					return nil, fmt.Errorf("output_contains.text expects string, got %T", raw)
+				}
+				goal.Text = s
+			case "any_command":
+				b, ok := raw.(bool)
+				if !ok {
+					return nil, fmt.Errorf("output_contains.any_command expects true or false, got %T", raw)
+				}
+				goal.AnyCommand = b
+			default:
+				return nil, fmt.Errorf("output_contains: unknown field %q", key)
+			}
+		}
+		if goal.Text == "" {
+			return nil, fmt.Errorf("output_contains needs text")
+		}
+		return goal, nil
+	default:
+		return nil, fmt.Errorf("output_contains expects string or map with text and any_command, got %T", value)
+	}
+}
@@ -0,0 +1,128 @@
+// ABOUTME: Tests for the command output and history goal predicates
+// ABOUTME: Covers output checks, ordering, exit codes, command par and forbidden commands
+
+package content
+
+import (
+	"strings"
+	"testing"
+)
+
+// historyFixture has run three commands: a failing ls, a grep, and a cat.
+func historyFixture() *mockFS {
+	fs := newMockFS()
+	fs.history = []CommandRecord{
+		{Command: "ls logs", Output: "", ExitCode: 2},
+		{Command: "grep ERROR app.log", Output: "10:00 ERROR: timeout\n10:05 ERROR: 500"},
+		{Command: "cat notes.txt", Output: "remember the milk"},
+	}
+	return fs
+}
+
+func TestHistoryGoals(t *testing.T) {
+	tests := []struct {
+		name string
+		goal map[string]any
+		want bool
+	}{
+		{"output of last command", map[string]any{"output_contains": "milk"}, true},
+		{"output of earlier command", map[string]any{"output_contains": "ERROR: timeout"}, false},
+		{"output of any command", map[string]any{"output_contains": map[string]any{"text": "ERROR: timeout", "any_command": true}}, true},
+		{"output matches", map[string]any{"output_matches": `^remember \w+`}, true},
+		{"output does not match", map[string]any{"output_matches": `ERROR`}, false},
+		{"history contains", map[string]any{"history_contains": "grep ERROR"}, true},
+		{"history contains any of", map[string]any{"history_contains": []any{"find", "ls"}}, true},
+		{"history lacks", map[string]any{"history_contains": "grep WARNING"}, false},
+		{"in order", map[string]any{"ran_commands_in_order": []any{"ls", "cat"}}, true},
+		{"out of order", map[string]any{"ran_commands_in_order": []any{"cat", "grep"}}, false},
+		{"exit code", map[string]any{"exit_code": 0}, true},
+		{"wrong exit code", map[string]any{"exit_code": 2}, false},
+		{"within par", map[string]any{"max_commands": 3}, true},
+		{"over par", map[string]any{"max_commands": 2}, false},
+		{"never ran", map[string]any{"never_ran": []any{"rm -rf", "sudo"}}, true},
+		{"ran forbidden command", map[string]any{"never_ran": "ls"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := ParseGoal(tt.goal)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := node.Evaluate(historyFixture()); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHistoryGoals_EmptyHistory(t *testing.T) {
+	fs := newMockFS()
+	for goal, want := range map[string]bool{
+		"output_contains": false,
+		"output_matches":  false,
+		"exit_code":       false,
+		"never_ran":       true,
+		"max_commands":    true,
+	} {
+		value := map[string]any{
+			"output_contains": "", "output_matches": ".*", "exit_code": 0, "never_ran": "rm", "max_commands": 1,
+		}[goal]
+		node, err := ParseGoal(map[string]any{goal: value})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := node.Evaluate(fs); got != want {
+			t.Errorf("%s with no history: got %v, want %v", goal, got, want)
+		}
+	}
+}
+
+func TestNeverRan_NormalisesFlags(t *testing.T) {
+	node, err := ParseGoal(map[string]any{"never_ran": "rm -rf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		command string
+		allowed bool
+	}{
+		{"rm -rf build", false},
+		{"rm -fr build", false},
+		{"rm -r -f build", false},
+		{"rm -Rf build", false},
+		{"rm --recursive --force build", false},
+		{"rm -rfv build", false},
+		{"rm -r build", true},
+		{"rm -f notes.txt", true},
+		{"rmdir build", true},
+	}
+	for _, tt := range tests {
+		fs := newMockFS()
+		fs.history = []CommandRecord{{Command: tt.command}}
+		if got := node.Evaluate(fs); got != tt.allowed {
+			t.Errorf("never_ran rm -rf after %q: got %v, want %v", tt.command, got, tt.allowed)
+		}
+	}
+}
+
+func TestHistoryGoals_ParseErrors(t *testing.T) {
+	tests := []struct {
+		goal map[string]any
+		want string
+	}{
+		{map[string]any{"output_contains": 3}, "output_contains expects string or map"},
+		{map[string]any{"output_contains": map[string]any{"any_command": true}}, "output_contains needs text"},
+		{map[string]any{"output_contains": map[string]any{"text": "x", "last": true}}, `output_contains: unknown field "last"`},
+		{map[string]any{"output_matches": "(unclosed"}, "output_matches: error parsing regexp"},
+		{map[string]any{"history_contains": []any{"ls", 2}}, "history_contains[1] expects string"},
+		{map[string]any{"ran_commands_in_order": []any{}}, "ran_commands_in_order needs at least one command"},
+		{map[string]any{"exit_code": "0"}, "exit_code expects a number"},
+		{map[string]any{"max_commands": 0}, "max_commands expects a positive number"},
+	}
+	for _, tt := range tests {
+		_, err := ParseGoal(tt.goal)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseGoal(%v): got %v, want %q", tt.goal, err, tt.want)
+		}
+	}
+}
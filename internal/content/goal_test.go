@@ -4,6 +4,7 @@
 package content
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -13,6 +14,16 @@ type mockFS struct {
 	paths       map[string]bool // true = directory, false = file
 	files       map[string]string
 	lastCommand string
+	history     []CommandRecord
+	tmuxOps     []TmuxOperation
+	buffers     []tmuxBuffer // Most recent first
+	tmux        *TmuxSnapshot
+	info        map[string]FileInfo // Symlink, mode and mtime by path
+}
+
+type tmuxBuffer struct {
+	name string
+	data string
 }
 
 func newMockFS() *mockFS {
@@ -20,6 +31,7 @@ func newMockFS() *mockFS {
 		pwd:   "/",
 		paths: make(map[string]bool),
 		files: make(map[string]string),
+		info:  make(map[string]FileInfo),
 	}
 }
 
@@ -46,10 +58,67 @@ func (m *mockFS) ReadFile(path string) (string, error) {
 	return content, nil
 }
 
+func (m *mockFS) Stat(path string) (FileInfo, bool) {
+	if !m.Exists(path) {
+		return FileInfo{}, false
+	}
+	info := m.info[path]
+	info.IsDir = m.IsDir(path)
+	return info, true
+}
+
+func (m *mockFS) ListDir(path string) ([]string, error) {
+	if !m.IsDir(path) {
+		return nil, &mockError{msg: "not a directory"}
+	}
+	var names []string
+	for p := range m.paths {
+		if dir, name := splitMockPath(p); dir == path {
+			names = append(names, name)
+		}
+	}
+	for p := range m.files {
+		if dir, name := splitMockPath(p); dir == path {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// splitMockPath splits "/a/b" into "/a" and "b".
+func splitMockPath(p string) (string, string) {
+	i := strings.LastIndex(p, "/")
+	if i == 0 {
+		return "/", p[1:]
+	}
+	return p[:i], p[i+1:]
+}
+
 func (m *mockFS) LastCommand() string {
 	return m.lastCommand
 }
 
+func (m *mockFS) CommandHistory() []CommandRecord {
+	return m.history
+}
+
+func (m *mockFS) TmuxOperations() []TmuxOperation {
+	return m.tmuxOps
+}
+
+func (m *mockFS) TmuxBuffer(name string) (string, bool) {
+	for _, b := range m.buffers {
+		if name == "" || b.name == name {
+			return b.data, true
+		}
+	}
+	return "", false
+}
+
+func (m *mockFS) TmuxSnapshot() *TmuxSnapshot {
+	return m.tmux
+}
+
 type mockError struct {
 	msg string
 }
@@ -516,3 +585,105 @@ func TestRanCommandGoal(t *testing.T) {
 		t.Error("ran_command array should match 'ls' variants")
 	}
 }
+
+func TestTmuxUsedGoal(t *testing.T) {
+	anyVia, err := ParseGoal(map[string]any{"tmux_used": "split-window"})
+	if err != nil {
+		t.Fatalf("ParseGoal failed: %v", err)
+	}
+	viaKey, err := ParseGoal(map[string]any{"tmux_used": map[string]any{"command": "split-window", "via": "keybinding"}})
+	if err != nil {
+		t.Fatalf("ParseGoal failed: %v", err)
+	}
+	viaCommand, err := ParseGoal(map[string]any{"tmux_used": map[string]any{"command": "split-window", "via": "command"}})
+	if err != nil {
+		t.Fatalf("ParseGoal failed: %v", err)
+	}
+
+	fs := newMockFS()
+	if anyVia.Evaluate(fs) {
+		t.Error("tmux_used should be false before any tmux operation")
+	}
+
+	fs.tmuxOps = []TmuxOperation{{Command: "split-window"}}
+	if !anyVia.Evaluate(fs) {
+		t.Error("tmux_used should accept a typed command")
+	}
+	if viaKey.Evaluate(fs) {
+		t.Error("tmux_used via keybinding should reject a typed command")
+	}
+	if !viaCommand.Evaluate(fs) {
+		t.Error("tmux_used via command should accept a typed command")
+	}
+
+	fs.tmuxOps = []TmuxOperation{{Command: "new-session"}, {Command: "split-window", Key: "%"}}
+	if !viaKey.Evaluate(fs) {
+		t.Error("tmux_used via keybinding should accept a prefix key")
+	}
+	if viaCommand.Evaluate(fs) {
+		t.Error("tmux_used via command should reject a prefix key")
+	}
+
+	if _, err := ParseGoal(map[string]any{"tmux_used": map[string]any{"command": "split-window", "via": "mouse"}}); err == nil {
+		t.Error("tmux_used should reject an unknown via")
+	}
+}
+
+func TestTmuxUsedGoal_Binding(t *testing.T) {
+	node, err := ParseGoal(map[string]any{"tmux_used": map[string]any{
+		"command": "split-window", "prefix": "C-a", "key": "|",
+	}})
+	if err != nil {
+		t.Fatalf("ParseGoal failed: %v", err)
+	}
+
+	fs := newMockFS()
+	fs.tmuxOps = []TmuxOperation{{Command: "split-window", Prefix: "C-b", Key: "%"}}
+	if node.Evaluate(fs) {
+		t.Error("tmux_used with prefix and key should reject the stock binding")
+	}
+	fs.tmuxOps = append(fs.tmuxOps, TmuxOperation{Command: "split-window", Prefix: "C-a", Key: "|"})
+	if !node.Evaluate(fs) {
+		t.Error("tmux_used with prefix and key should accept the remapped binding")
+	}
+
+	if _, err := ParseGoal(map[string]any{"tmux_used": map[string]any{"command": "split-window", "table": "root"}}); err == nil {
+		t.Error("tmux_used should reject unknown fields")
+	}
+}
+
+func TestTmuxBufferGoal(t *testing.T) {
+	fs := newMockFS()
+
+	contains, err := ParseGoal(map[string]any{"tmux_buffer": "timeout"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	named, err := ParseGoal(map[string]any{
+		"tmux_buffer": map[string]any{"name": "notes", "equals": "ERROR: Database connection timeout"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if contains.Evaluate(fs) {
+		t.Error("tmux_buffer should fail with no buffers")
+	}
+
+	fs.buffers = []tmuxBuffer{{name: "buffer0", data: "ERROR: Database connection timeout"}}
+	if !contains.Evaluate(fs) {
+		t.Error("tmux_buffer should match the most recent buffer")
+	}
+	if named.Evaluate(fs) {
+		t.Error("tmux_buffer with a name should only check that buffer")
+	}
+
+	fs.buffers = append(fs.buffers, tmuxBuffer{name: "notes", data: "ERROR: Database connection timeout\n"})
+	if !named.Evaluate(fs) {
+		t.Error("tmux_buffer equals should ignore a trailing newline")
+	}
+
+	if _, err := ParseGoal(map[string]any{"tmux_buffer": map[string]any{"name": "notes"}}); err == nil {
+		t.Error("tmux_buffer without contains or equals should be rejected")
+	}
+}
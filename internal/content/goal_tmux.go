@@ -0,0 +1,601 @@
+// ABOUTME: Goal predicates over tmux server state (sessions, windows, panes, layouts)
+// ABOUTME: Evaluated against a TmuxSnapshot so the simulator and real tmux share one check
+
+package content
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// TmuxSnapshot is the state of a tmux server as goals see it.
+type TmuxSnapshot struct {
+	Sessions []TmuxSessionInfo // In name order
+	Attached string            // Session the client is attached to, or empty when detached
+	Current  string            // Session commands target by default: the attached one, else the most recent
+}
+
+// TmuxSessionInfo describes one session.
+type TmuxSessionInfo struct {
+	Name    string
+	Windows []TmuxWindowInfo // In index order
+	Current int              // Index of the current window
+}
+
+// TmuxWindowInfo describes one window.
+type TmuxWindowInfo struct {
+	Index      int
+	Name       string
+	Panes      int
+	ActivePane int    // Index of the active pane, as tmux numbers it
+	Layout     string // The window's #{window_layout}
+}
+
+// session returns the named session, or the current one when name is empty.
+func (s *TmuxSnapshot) session(name string) (*TmuxSessionInfo, bool) {
+	if name == "" {
+		name = s.Current
+	}
+	for i := range s.Sessions {
+		if s.Sessions[i].Name == name {
+			return &s.Sessions[i], true
+		}
+	}
+	return nil, false
+}
+
+// window returns a window of the named (or current) session; index nil means its current window.
+func (s *TmuxSnapshot) window(session string, index *int) (*TmuxWindowInfo, bool) {
+	sess, ok := s.session(session)
+	if !ok {
+		return nil, false
+	}
+	want := sess.Current
+	if index != nil {
+		want = *index
+	}
+	for i := range sess.Windows {
+		if sess.Windows[i].Index == want {
+			return &sess.Windows[i], true
+		}
+	}
+	return nil, false
+}
+
+// tmuxTarget picks a session and window; empty fields mean the current ones.
+type tmuxTarget struct {
+	Session string
+	Window  *int
+}
+
+// countRange is an inclusive range; nil bounds are open.
+type countRange struct {
+	Min *int
+	Max *int
+}
+
+func (r countRange) contains(n int) bool {
+	return (r.Min == nil || n >= *r.Min) && (r.Max == nil || n <= *r.Max)
+}
+
+// describe phrases the range as a count of nouns, e.g. "at least 2 panes".
+func (r countRange) describe(noun string) string {
+	plural := func(n int) string {
+		if n == 1 {
+			return "1 " + noun
+		}
+		return fmt.Sprintf("%d %ss", n, noun)
+	}
+	switch {
+	case r.Min == nil:
+		return "at most " + plural(*r.Max)
+	case r.Max == nil:
+		return "at least " + plural(*r.Min)
+	case *r.Min == *r.Max:
+		return plural(*r.Min)
+	default:
+		return fmt.Sprintf("%d to %d %ss", *r.Min, *r.Max, noun)
+	}
+}
+
+// describe names the target window, e.g. "window 1 of work".
+func (t tmuxTarget) describe() string {
+	window := "the current window"
+	if t.Window != nil {
+		window = fmt.Sprintf("window %d", *t.Window)
+	}
+	if t.Session != "" {
+		window += " of " + t.Session
+	}
+	return window
+}
+
+// TmuxInSessionGoal checks whether the learner is inside tmux (a client is attached).
+type TmuxInSessionGoal struct {
+	Want bool
+}
+
+func (g *TmuxInSessionGoal) Evaluate(fs GoalEvaluator) bool {
+	snap := fs.TmuxSnapshot()
+	return (snap != nil && snap.Attached != "") == g.Want
+}
+
+func (g *TmuxInSessionGoal) Describe() string {
+	if g.Want {
+		return "be inside tmux"
+	}
+	return "be outside tmux"
+}
+
+// TmuxDetachedGoal checks whether sessions are running with no client attached.
+type TmuxDetachedGoal struct {
+	Want bool
+}
+
+func (g *TmuxDetachedGoal) Evaluate(fs GoalEvaluator) bool {
+	snap := fs.TmuxSnapshot()
+	detached := snap != nil && len(snap.Sessions) > 0 && snap.Attached == ""
+	return detached == g.Want
+}
+
+func (g *TmuxDetachedGoal) Describe() string {
+	if g.Want {
+		return "detach, leaving tmux running"
+	}
+	return "stay attached to tmux"
+}
+
+// TmuxSessionNamedGoal checks that a session with the given name exists.
+type TmuxSessionNamedGoal struct {
+	Name string
+}
+
+func (g *TmuxSessionNamedGoal) Evaluate(fs GoalEvaluator) bool {
+	snap := fs.TmuxSnapshot()
+	if snap == nil {
+		return false
+	}
+	_, ok := snap.session(g.Name)
+	return ok
+}
+
+func (g *TmuxSessionNamedGoal) Describe() string {
+	return fmt.Sprintf("a session named %q exists", g.Name)
+}
+
+// TmuxWindowCountGoal checks how many windows a session has.
+type TmuxWindowCountGoal struct {
+	Session string
+	Count   countRange
+}
+
+func (g *TmuxWindowCountGoal) Evaluate(fs GoalEvaluator) bool {
+	snap := fs.TmuxSnapshot()
+	if snap == nil {
+		return false
+	}
+	sess, ok := snap.session(g.Session)
+	return ok && g.Count.contains(len(sess.Windows))
+}
+
+func (g *TmuxWindowCountGoal) Describe() string {
+	session := "the current session"
+	if g.Session != "" {
+		session = g.Session
+	}
+	return session + " has " + g.Count.describe("window")
+}
+
+// TmuxPaneCountGoal checks how many panes a window has.
+type TmuxPaneCountGoal struct {
+	Target tmuxTarget
+	Count  countRange
+}
+
+func (g *TmuxPaneCountGoal) Evaluate(fs GoalEvaluator) bool {
+	w, ok := targetWindow(fs, g.Target)
+	return ok && g.Count.contains(w.Panes)
+}
+
+func (g *TmuxPaneCountGoal) Describe() string {
+	return g.Target.describe() + " has " + g.Count.describe("pane")
+}
+
+// TmuxActivePaneGoal checks which pane of a window is active.
+type TmuxActivePaneGoal struct {
+	Target tmuxTarget
+	Index  int
+}
+
+func (g *TmuxActivePaneGoal) Evaluate(fs GoalEvaluator) bool {
+	w, ok := targetWindow(fs, g.Target)
+	return ok && w.ActivePane == g.Index
+}
+
+func (g *TmuxActivePaneGoal) Describe() string {
+	return fmt.Sprintf("pane %d of %s is active", g.Index, g.Target.describe())
+}
+
+// TmuxLayoutGoal checks that a window is arranged like one of tmux's preset layouts.
+// Only the shape is compared, so a layout nudged by a resize still counts.
+type TmuxLayoutGoal struct {
+	Target tmuxTarget
+	Name   string
+}
+
+func (g *TmuxLayoutGoal) Evaluate(fs GoalEvaluator) bool {
+	w, ok := targetWindow(fs, g.Target)
+	if !ok {
+		return false
+	}
+	root, ok := parseWindowLayout(w.Layout)
+	return ok && root.matches(g.Name)
+}
+
+func (g *TmuxLayoutGoal) Describe() string {
+	return g.Target.describe() + " uses the " + g.Name + " layout"
+}
+
+// targetWindow resolves a goal's target against the evaluator's tmux server.
+func targetWindow(fs GoalEvaluator, t tmuxTarget) (*TmuxWindowInfo, bool) {
+	snap := fs.TmuxSnapshot()
+	if snap == nil {
+		return nil, false
+	}
+	return snap.window(t.Session, t.Window)
+}
+
+// tmuxLayouts are the preset layout names tmux_layout accepts.
+var tmuxLayouts = []string{"even-horizontal", "even-vertical", "main-horizontal", "main-vertical", "tiled"}
+
+// layoutNode is one cell of a parsed #{window_layout}.
+type layoutNode struct {
+	width, height int
+	split         byte // '{' left-right, '[' top-bottom, 0 for a pane
+	children      []*layoutNode
+}
+
+// parseWindowLayout parses tmux's layout format, e.g. "b25d,80x24,0,0{40x24,0,0,1,39x24,41,0,2}".
+func parseWindowLayout(layout string) (*layoutNode, bool) {
+	_, body, ok := strings.Cut(layout, ",")
+	if !ok {
+		return nil, false
+	}
+	p := &layoutParser{s: body}
+	node, ok := p.cell()
+	return node, ok && p.pos == len(p.s)
+}
+
+type layoutParser struct {
+	s   string
+	pos int
+}
+
+// cell parses "WxH,X,Y" followed by ",ID" for a pane or a bracketed list of children.
+func (p *layoutParser) cell() (*layoutNode, bool) {
+	node := &layoutNode{}
+	var ok bool
+	if node.width, ok = p.number('x'); !ok {
+		return nil, false
+	}
+	if node.height, ok = p.number(','); !ok {
+		return nil, false
+	}
+	if _, ok = p.number(','); !ok {
+		return nil, false
+	}
+	if _, ok = p.number(0); !ok {
+		return nil, false
+	}
+	if p.pos == len(p.s) {
+		return nil, false
+	}
+	switch open := p.s[p.pos]; open {
+	case ',':
+		p.pos++
+		_, ok = p.number(0)
+		return node, ok
+	case '{', '[':
+		node.split = open
+		closing := byte('}')
+		if open == '[' {
+			closing = ']'
+		}
+		for p.pos < len(p.s) && p.s[p.pos] != closing {
+			p.pos++ // Skip the opening bracket or the comma between children.
+			child, ok := p.cell()
+			if !ok {
+				return nil, false
+			}
+			node.children = append(node.children, child)
+		}
+		if p.pos == len(p.s) {
+			return nil, false
+		}
+		p.pos++
+		return node, len(node.children) > 0
+	}
+	return nil, false
+}
+
+// number reads digits up to (and consuming) sep; a zero sep stops at any non-digit.
+func (p *layoutParser) number(sep byte) (int, bool) {
+	start := p.pos
+	for p.pos < len(p.s) && p.s[p.pos] >= '0' && p.s[p.pos] <= '9' {
+		p.pos++
+	}
+	n, err := strconv.Atoi(p.s[start:p.pos])
+	if err != nil {
+		return 0, false
+	}
+	if sep != 0 {
+		if p.pos == len(p.s) || p.s[p.pos] != sep {
+			return 0, false
+		}
+		p.pos++
+	}
+	return n, true
+}
+
+// matches reports whether the layout has the shape of the named preset. A single
+// pane matches every preset, as it does in tmux.
+func (n *layoutNode) matches(name string) bool {
+	if n.split == 0 {
+		return true
+	}
+	switch name {
+	case "even-horizontal":
+		return n.split == '{' && n.evenLeaves(func(c *layoutNode) int { return c.width })
+	case "even-vertical":
+		return n.split == '[' && n.evenLeaves(func(c *layoutNode) int { return c.height })
+	case "main-horizontal":
+		return n.isMain('[', '{')
+	case "main-vertical":
+		return n.isMain('{', '[')
+	case "tiled":
+		return n.isTiled()
+	}
+	return false
+}
+
+// evenLeaves reports whether every child is a pane and their sizes differ by at most one.
+func (n *layoutNode) evenLeaves(size func(*layoutNode) int) bool {
+	lo, hi := size(n.children[0]), size(n.children[0])
+	for _, c := range n.children {
+		if c.split != 0 {
+			return false
+		}
+		lo, hi = min(lo, size(c)), max(hi, size(c))
+	}
+	return hi-lo <= 1
+}
+
+// isMain reports a main pane followed by one pane or a row/column of panes.
+func (n *layoutNode) isMain(split, rest byte) bool {
+	if n.split != split || len(n.children) != 2 || n.children[0].split != 0 {
+		return false
+	}
+	other := n.children[1]
+	return other.split == 0 || (other.split == rest && other.allLeaves())
+}
+
+// isTiled reports a grid: rows of panes, every row as wide as the first except a shorter last row.
+func (n *layoutNode) isTiled() bool {
+	if n.split == '{' {
+		return n.allLeaves()
+	}
+	columns := 0
+	for i, row := range n.children {
+		count := 1
+		if row.split != 0 {
+			if row.split != '{' || !row.allLeaves() {
+				return false
+			}
+			count = len(row.children)
+		}
+		switch {
+		case i == 0:
+			columns = count
+		case count > columns, count < columns && i < len(n.children)-1:
+			return false
+		}
+	}
+	return true
+}
+
+func (n *layoutNode) allLeaves() bool {
+	for _, c := range n.children {
+		if c.split != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// tmuxStateGoals parses the goals that inspect the tmux server, by key.
+var tmuxStateGoals = map[string]func(any) (GoalNode, error){
+	"tmux_in_session": func(v any) (GoalNode, error) {
+		want, err := parseBoolGoal("tmux_in_session", v)
+		return &TmuxInSessionGoal{Want: want}, err
+	},
+	"tmux_detached": func(v any) (GoalNode, error) {
+		want, err := parseBoolGoal("tmux_detached", v)
+		return &TmuxDetachedGoal{Want: want}, err
+	},
+	"tmux_session_named": func(v any) (GoalNode, error) {
+		return parseStringGoal("tmux_session_named", v, func(s string) GoalNode { return &TmuxSessionNamedGoal{Name: s} })
+	},
+	"tmux_window_count": parseTmuxWindowCount,
+	"tmux_pane_count":   parseTmuxPaneCount,
+	"tmux_active_pane":  parseTmuxActivePane,
+	"tmux_layout":       parseTmuxLayout,
+}
+
+func parseBoolGoal(key string, value any) (bool, error) {
+	b, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("%s expects true or false, got %T", key, value)
+	}
+	return b, nil
+}
+
+// goalInt accepts the integer types YAML and JSON decoding produce.
+func goalInt(value any) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), v == float64(int(v))
+	}
+	return 0, false
+}
+
+// parseTmuxFields reads a map form's target fields and hands every other field to
+// other, which returns false for fields it doesn't know.
+func parseTmuxFields(key string, m map[string]any, other func(field string, value any) (bool, error)) (tmuxTarget, error) {
+	var target tmuxTarget
+	for field, raw := range m {
+		switch field {
+		case "session":
+			s, ok := raw.(string)
+			if !ok {
+				return target, fmt.Errorf("%s.session expects string, got %T", key, raw)
+			}
+			target.Session = s
+		case "window":
+			n, ok := goalInt(raw)
+			if !ok {
+				return target, fmt.Errorf("%s.window expects a window index, got %T", key, raw)
+			}
+			target.Window = &n
+		default:
+			known, err := other(field, raw)
+			if err != nil {
+				return target, err
+			}
+			if !known {
+				return target, fmt.Errorf("%s: unknown field %q", key, field)
+			}
+		}
+	}
+	return target, nil
+}
+
+// parseTmuxCount parses a count goal: a number for an exact count, or a map with
+// min, max and a target.
+func parseTmuxCount(key string, value any) (tmuxTarget, countRange, error) {
+	if n, ok := goalInt(value); ok {
+		return tmuxTarget{}, countRange{Min: &n, Max: &n}, nil
+	}
+	m, ok := value.(map[string]any)
+	if !ok {
+		return tmuxTarget{}, countRange{}, fmt.Errorf("%s expects a number or map with min, max, session, or window, got %T", key, value)
+	}
+	var count countRange
+	target, err := parseTmuxFields(key, m, func(field string, raw any) (bool, error) {
+		if field != "min" && field != "max" {
+			return false, nil
+		}
+		n, ok := goalInt(raw)
+		if !ok {
+			return true, fmt.Errorf("%s.%s expects a number, got %T", key, field, raw)
+		}
+		if field == "min" {
+			count.Min = &n
+		} else {
+			count.Max = &n
+		}
+		return true, nil
+	})
+	if err != nil {
+		return target, count, err
+	}
+	if count.Min == nil && count.Max == nil {
+		return target, count, fmt.Errorf("%s needs min or max", key)
+	}
+	return target, count, nil
+}
+
+func parseTmuxWindowCount(value any) (GoalNode, error) {
+	target, count, err := parseTmuxCount("tmux_window_count", value)
+	if err != nil {
+		return nil, err
+	}
+	if target.Window != nil {
+		return nil, fmt.Errorf("tmux_window_count: unknown field %q", "window")
+	}
+	return &TmuxWindowCountGoal{Session: target.Session, Count: count}, nil
+}
+
+func parseTmuxPaneCount(value any) (GoalNode, error) {
+	target, count, err := parseTmuxCount("tmux_pane_count", value)
+	if err != nil {
+		return nil, err
+	}
+	return &TmuxPaneCountGoal{Target: target, Count: count}, nil
+}
+
+func parseTmuxActivePane(value any) (GoalNode, error) {
+	if n, ok := goalInt(value); ok {
+		return &TmuxActivePaneGoal{Index: n}, nil
+	}
+	m, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("tmux_active_pane expects a pane index or map with index, session, or window, got %T", value)
+	}
+	goal := &TmuxActivePaneGoal{Index: -1}
+	target, err := parseTmuxFields("tmux_active_pane", m, func(field string, raw any) (bool, error) {
+		if field != "index" {
+			return false, nil
+		}
+		n, ok := goalInt(raw)
+		if !ok {
+			return true, fmt.Errorf("tmux_active_pane.index expects a number, got %T", raw)
+		}
+		goal.Index = n
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if goal.Index < 0 {
+		return nil, fmt.Errorf("tmux_active_pane needs index")
+	}
+	goal.Target = target
+	return goal, nil
+}
+
+func parseTmuxLayout(value any) (GoalNode, error) {
+	goal := &TmuxLayoutGoal{}
+	switch v := value.(type) {
+	case string:
+		goal.Name = v
+	case map[string]any:
+		target, err := parseTmuxFields("tmux_layout", v, func(field string, raw any) (bool, error) {
+			if field != "name" {
+				return false, nil
+			}
+			s, ok := raw.(string)
+			if !ok {
+				return true, fmt.Errorf("tmux_layout.name expects string, got %T", raw)
+			}
+			goal.Name = s
+			return true, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		goal.Target = target
+	default:
+		return nil, fmt.Errorf("tmux_layout expects a layout name or map with name, session, or window, got %T", value)
+	}
+	for _, name := range tmuxLayouts {
+		if goal.Name == name {
+			return goal, nil
+		}
+	}
+	return nil, fmt.Errorf("tmux_layout: unknown layout %q (want one of %s)", goal.Name, strings.Join(tmuxLayouts, ", "))
+}
@@ -0,0 +1,163 @@
+// ABOUTME: Tests for the tmux state goal predicates
+// ABOUTME: Covers parsing, session/window targets and layout shape matching
+
+package content
+
+import (
+	"strings"
+	"testing"
+)
+
+// Layouts as tmux prints them for an 80x24 window.
+const (
+	layoutSingle     = "b25f,80x24,0,0,1"
+	layoutEvenH      = "8b65,80x24,0,0{40x24,0,0,1,39x24,41,0,2}"
+	layoutEvenV      = "2f1c,80x24,0,0[80x12,0,0,1,80x11,0,13,2]"
+	layoutMainV      = "5a0b,80x24,0,0{50x24,0,0,1,29x24,51,0[29x12,51,0,2,29x11,51,13,3]}"
+	layoutMainH      = "5a0b,80x24,0,0[80x15,0,0,1,80x8,0,16{40x8,0,16,2,39x8,41,16,3}]"
+	layoutTiled      = "c3d1,80x24,0,0[80x12,0,0{40x12,0,0,1,39x12,41,0,2},80x11,0,13{40x11,0,13,3,39x11,41,13,4}]"
+	layoutTiledThree = "c3d1,80x24,0,0[80x12,0,0{40x12,0,0,1,39x12,41,0,2},80x11,0,13,3]"
+)
+
+// tmuxFixture has an attached session "work" (window 1 current, two panes) and a
+// detached session "logs".
+func tmuxFixture() *mockFS {
+	fs := newMockFS()
+	fs.tmux = &TmuxSnapshot{
+		Attached: "work",
+		Current:  "work",
+		Sessions: []TmuxSessionInfo{
+			{Name: "logs", Current: 0, Windows: []TmuxWindowInfo{{Index: 0, Panes: 1, Layout: layoutSingle}}},
+			{Name: "work", Current: 1, Windows: []TmuxWindowInfo{
+				{Index: 0, Panes: 1, Layout: layoutSingle},
+				{Index: 1, Panes: 2, ActivePane: 1, Layout: layoutEvenH},
+			}},
+		},
+	}
+	return fs
+}
+
+func TestTmuxStateGoals(t *testing.T) {
+	tests := []struct {
+		name string
+		goal map[string]any
+		want bool
+	}{
+		{"in session", map[string]any{"tmux_in_session": true}, true},
+		{"not in session", map[string]any{"tmux_in_session": false}, false},
+		{"detached", map[string]any{"tmux_detached": true}, false},
+		{"session named", map[string]any{"tmux_session_named": "logs"}, true},
+		{"missing session", map[string]any{"tmux_session_named": "dev"}, false},
+		{"window count", map[string]any{"tmux_window_count": 2}, true},
+		{"window count of session", map[string]any{"tmux_window_count": map[string]any{"session": "logs", "min": 2}}, false},
+		{"pane count of current window", map[string]any{"tmux_pane_count": 2}, true},
+		{"pane count of window", map[string]any{"tmux_pane_count": map[string]any{"window": 0, "max": 1}}, true},
+		{"pane count range", map[string]any{"tmux_pane_count": map[string]any{"min": 3}}, false},
+		{"pane count in missing window", map[string]any{"tmux_pane_count": map[string]any{"window": 5, "min": 1}}, false},
+		{"active pane", map[string]any{"tmux_active_pane": 1}, true},
+		{"active pane of window", map[string]any{"tmux_active_pane": map[string]any{"index": 1, "window": 0}}, false},
+		{"layout", map[string]any{"tmux_layout": "even-horizontal"}, true},
+		{"wrong layout", map[string]any{"tmux_layout": "even-vertical"}, false},
+		{"single pane matches any layout", map[string]any{"tmux_layout": map[string]any{"name": "tiled", "session": "logs"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := ParseGoal(tt.goal)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := node.Evaluate(tmuxFixture()); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTmuxStateGoals_NoServer(t *testing.T) {
+	fs := newMockFS()
+	for _, goal := range []map[string]any{
+		{"tmux_in_session": true},
+		{"tmux_detached": true},
+		{"tmux_session_named": "work"},
+		{"tmux_pane_count": map[string]any{"max": 5}},
+		{"tmux_layout": "tiled"},
+	} {
+		node, err := ParseGoal(goal)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if node.Evaluate(fs) {
+			t.Errorf("%v should be false with no server", goal)
+		}
+	}
+
+	node, _ := ParseGoal(map[string]any{"tmux_in_session": false})
+	if !node.Evaluate(fs) {
+		t.Error("tmux_in_session: false should hold with no server")
+	}
+
+	fs.tmux = &TmuxSnapshot{Current: "work", Sessions: []TmuxSessionInfo{{Name: "work"}}}
+	node, _ = ParseGoal(map[string]any{"tmux_detached": true})
+	if !node.Evaluate(fs) {
+		t.Error("a running session with no client should count as detached")
+	}
+}
+
+func TestTmuxStateGoals_ParseErrors(t *testing.T) {
+	tests := []struct {
+		goal map[string]any
+		want string
+	}{
+		{map[string]any{"tmux_in_session": "yes"}, "tmux_in_session expects true or false"},
+		{map[string]any{"tmux_pane_count": "two"}, "tmux_pane_count expects a number or map"},
+		{map[string]any{"tmux_pane_count": map[string]any{"session": "work"}}, "tmux_pane_count needs min or max"},
+		{map[string]any{"tmux_pane_count": map[string]any{"min": "1"}}, "tmux_pane_count.min expects a number"},
+		{map[string]any{"tmux_window_count": map[string]any{"window": 1, "min": 1}}, `tmux_window_count: unknown field "window"`},
+		{map[string]any{"tmux_active_pane": map[string]any{"pane": 1}}, `tmux_active_pane: unknown field "pane"`},
+		{map[string]any{"tmux_active_pane": map[string]any{"window": 1}}, "tmux_active_pane needs index"},
+		{map[string]any{"tmux_layout": "spiral"}, `tmux_layout: unknown layout "spiral"`},
+		{map[string]any{"tmux_layout": map[string]any{"window": "one", "name": "tiled"}}, "tmux_layout.window expects a window index"},
+	}
+	for _, tt := range tests {
+		_, err := ParseGoal(tt.goal)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseGoal(%v): got %v, want %q", tt.goal, err, tt.want)
+		}
+	}
+}
+
+func TestLayoutMatches(t *testing.T) {
+	tests := []struct {
+		layout string
+		want   []string
+	}{
+		{layoutSingle, tmuxLayouts},
+		{layoutEvenH, []string{"even-horizontal", "main-vertical", "tiled"}},
+		{layoutEvenV, []string{"even-vertical", "main-horizontal", "tiled"}},
+		{layoutMainV, []string{"main-vertical"}},
+		{layoutMainH, []string{"main-horizontal"}},
+		{layoutTiled, []string{"tiled"}},
+		{layoutTiledThree, []string{"tiled"}},
+	}
+	for _, tt := range tests {
+		root, ok := parseWindowLayout(tt.layout)
+		if !ok {
+			t.Fatalf("failed to parse %s", tt.layout)
+		}
+		for _, name := range tmuxLayouts {
+			want := false
+			for _, w := range tt.want {
+				want = want || w == name
+			}
+			if got := root.matches(name); got != want {
+				t.Errorf("%s matches %s: got %v, want %v", tt.layout, name, got, want)
+			}
+		}
+	}
+
+	for _, bad := range []string{"", "80x24,0,0,1", "b25f,80x24,0,0{40x24,0,0,1", "b25f,80x24,0,0{}", "b25f,80x24,0,0,1junk"} {
+		if _, ok := parseWindowLayout(bad); ok {
+			t.Errorf("expected %q not to parse", bad)
+		}
+	}
+}
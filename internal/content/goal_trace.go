@@ -0,0 +1,140 @@
+// ABOUTME: Explains a goal node by node: which conditions hold, which don't, and what to try
+// ABOUTME: Powers the mission progress checklist and hints tailored to what is still missing
+
+package content
+
+import "fmt"
+
+// AnnotatedGoal is a goal written with a label or hint beside its condition, e.g.
+// {path_exists: /home/learner/src, label: a src folder, hint: mkdir makes folders}.
+type AnnotatedGoal struct {
+	Goal  GoalNode
+	Label string // Replaces the condition's own description
+	Hint  string // Shown when the condition doesn't hold yet
+}
+
+func (g *AnnotatedGoal) Evaluate(fs GoalEvaluator) bool {
+	return g.Goal.Evaluate(fs)
+}
+
+func (g *AnnotatedGoal) Describe() string {
+	if g.Label != "" {
+		return g.Label
+	}
+	return g.Goal.Describe()
+}
+
+// GoalTrace is the outcome of evaluating a goal, node by node.
+type GoalTrace struct {
+	Description string // What the node requires
+	Passed      bool
+	Hint        string // The goal author's hint for this node, if any
+	Children    []GoalTrace
+
+	all bool // An unlabelled and: on a checklist its children stand in for it
+}
+
+// Explain evaluates every condition in a goal, without short-circuiting, and records
+// which ones hold.
+func Explain(node GoalNode, fs GoalEvaluator) GoalTrace {
+	switch g := node.(type) {
+	case *AnnotatedGoal:
+		t := Explain(g.Goal, fs)
+		if g.Label != "" {
+			// A label sums up the whole subtree.
+			t.Description, t.Children, t.all = g.Label, nil, false
+		}
+		if g.Hint != "" {
+			t.Hint = g.Hint
+		}
+		return t
+	case *AndGoal:
+		t := GoalTrace{Description: g.Describe(), Passed: true, all: true}
+		for _, c := range g.Conditions {
+			child := Explain(c, fs)
+			t.Passed = t.Passed && child.Passed
+			t.Children = append(t.Children, child)
+		}
+		return t
+	case *OrGoal:
+		t := GoalTrace{Description: g.Describe()}
+		for _, c := range g.Conditions {
+			child := Explain(c, fs)
+			t.Passed = t.Passed || child.Passed
+			t.Children = append(t.Children, child)
+		}
+		return t
+	case *NotGoal:
+		inner := Explain(g.Condition, fs)
+		return GoalTrace{Description: "not: " + inner.Description, Passed: !inner.Passed, Hint: inner.Hint}
+	default:
+		return GoalTrace{Description: node.Describe(), Passed: node.Evaluate(fs)}
+	}
+}
+
+// NextHint returns the hint for the first unmet condition, preferring the most specific
+// one, or "" if the goal is met or no unmet condition has a hint.
+func (t GoalTrace) NextHint() string {
+	if t.Passed {
+		return ""
+	}
+	for _, c := range t.Children {
+		if hint := c.NextHint(); hint != "" {
+			return hint
+		}
+	}
+	return t.Hint
+}
+
+// ChecklistItem is one line of a goal's progress checklist.
+type ChecklistItem struct {
+	Depth       int // Nesting under "any of these" and similar groups
+	Description string
+	Passed      bool
+}
+
+// Checklist flattens the trace into lines. Nested "all of" groups are merged into their
+// parent, so a goal written as an and of conditions is listed one condition per line.
+func (t GoalTrace) Checklist() []ChecklistItem {
+	var items []ChecklistItem
+	t.appendItems(&items, 0, true)
+	return items
+}
+
+func (t GoalTrace) appendItems(items *[]ChecklistItem, depth int, inAll bool) {
+	if t.all && inAll {
+		for _, c := range t.Children {
+			c.appendItems(items, depth, true)
+		}
+		return
+	}
+	*items = append(*items, ChecklistItem{Depth: depth, Description: t.Description, Passed: t.Passed})
+	for _, c := range t.Children {
+		c.appendItems(items, depth+1, t.all)
+	}
+}
+
+// parseAnnotations removes the label and hint keys from a goal map.
+func parseAnnotations(raw map[string]any) (label, hint string, rest map[string]any, err error) {
+	rest = make(map[string]any, len(raw))
+	for key, value := range raw {
+		switch key {
+		case "label", "hint":
+			s, ok := value.(string)
+			if !ok {
+				return "", "", nil, fmt.Errorf("goal %s expects string, got %T", key, value)
+			}
+			if key == "label" {
+				label = s
+			} else {
+				hint = s
+			}
+		default:
+			rest[key] = value
+		}
+	}
+	if len(rest) == 0 {
+		return "", "", nil, fmt.Errorf("goal has a label or hint but no condition")
+	}
+	return label, hint, rest, nil
+}
@@ -0,0 +1,150 @@
+// ABOUTME: Tests for goal evaluation traces, checklists and tailored hints
+// ABOUTME: Covers combinators, labels and hints, and descriptions of every kind of goal
+
+package content
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// projectGoal wants src and tests folders, and either a README or a docs folder.
+func projectGoal(t *testing.T) GoalNode {
+	t.Helper()
+	node, err := ParseGoal(map[string]any{"and": []any{
+		map[string]any{"is_dir": "/p/src", "hint": "mkdir src"},
+		map[string]any{"is_dir": "/p/tests", "hint": "mkdir tests"},
+		map[string]any{"or": []any{
+			map[string]any{"path_exists": "/p/README.md"},
+			map[string]any{"and": []any{
+				map[string]any{"is_dir": "/p/docs"},
+				map[string]any{"not": map[string]any{"path_exists": "/p/docs/TODO"}},
+			}},
+		}},
+		map[string]any{"and": []any{
+			map[string]any{"ran_command": "ls"},
+		}, "label": "look around with ls", "hint": "try ls"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return node
+}
+
+func TestExplain(t *testing.T) {
+	fs := newMockFS()
+	fs.paths["/p/src"] = true
+	fs.paths["/p/docs"] = true
+	fs.lastCommand = "ls -a"
+
+	trace := Explain(projectGoal(t), fs)
+	if trace.Passed {
+		t.Error("tests is missing, so the goal isn't met")
+	}
+	want := []ChecklistItem{
+		{0, "/p/src is a directory", true},
+		{0, "/p/tests is a directory", false},
+		{0, "any of these", true},
+		{1, "/p/README.md exists", false},
+		{1, "all of these", true},
+		{2, "/p/docs is a directory", true},
+		{2, "not: /p/docs/TODO exists", true},
+		{0, "look around with ls", true},
+	}
+	if got := trace.Checklist(); !reflect.DeepEqual(got, want) {
+		t.Errorf("checklist:\n got %+v\nwant %+v", got, want)
+	}
+	if got := trace.NextHint(); got != "mkdir tests" {
+		t.Errorf("expected the hint for the missing folder, got %q", got)
+	}
+
+	fs.paths["/p/tests"] = true
+	trace = Explain(projectGoal(t), fs)
+	if !trace.Passed || trace.NextHint() != "" {
+		t.Errorf("expected the goal to be met with no hint, got %+v", trace)
+	}
+}
+
+func TestExplain_MatchesEvaluate(t *testing.T) {
+	node := projectGoal(t)
+	for _, lastCommand := range []string{"ls", "pwd"} {
+		fs := newMockFS()
+		fs.paths["/p/src"] = true
+		fs.paths["/p/tests"] = true
+		fs.files["/p/README.md"] = ""
+		fs.lastCommand = lastCommand
+		if got, want := Explain(node, fs).Passed, node.Evaluate(fs); got != want {
+			t.Errorf("after %s: trace says %v, Evaluate says %v", lastCommand, got, want)
+		}
+	}
+}
+
+func TestExplain_HintFallsBackToGroup(t *testing.T) {
+	node, err := ParseGoal(map[string]any{
+		"or":   []any{map[string]any{"path_exists": "/a"}, map[string]any{"path_exists": "/b"}},
+		"hint": "make /a or /b",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := Explain(node, newMockFS()).NextHint(); got != "make /a or /b" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		goal map[string]any
+		want string
+	}{
+		{map[string]any{"ran_command": []any{"ls", "ls -a"}}, `run "ls" or "ls -a"`},
+		{map[string]any{"pwd_equals": "/tmp"}, "be in /tmp"},
+		{map[string]any{"path_not_exists": "/tmp/x"}, "/tmp/x is gone"},
+		{map[string]any{"file_contains": map[string]any{"path": "/f", "content": "hi"}}, `/f contains "hi"`},
+		{map[string]any{"tmux_used": map[string]any{"command": "split-window", "via": "keybinding"}}, "use tmux split-window with its key binding"},
+		{map[string]any{"tmux_used": map[string]any{"command": "detach-client", "prefix": "C-a", "key": "d"}}, "use tmux detach-client with C-a d"},
+		{map[string]any{"tmux_buffer": map[string]any{"name": "b1", "equals": "x"}}, `paste buffer b1 holds "x"`},
+		{map[string]any{"tmux_pane_count": map[string]any{"min": 2}}, "the current window has at least 2 panes"},
+		{map[string]any{"tmux_pane_count": map[string]any{"session": "work", "window": 1, "min": 1, "max": 1}}, "window 1 of work has 1 pane"},
+		{map[string]any{"tmux_window_count": map[string]any{"min": 2, "max": 3}}, "the current session has 2 to 3 windows"},
+		{map[string]any{"tmux_layout": "tiled"}, "the current window uses the tiled layout"},
+		{map[string]any{"tmux_detached": true}, "detach, leaving tmux running"},
+		{map[string]any{"output_contains": map[string]any{"text": "ERROR", "any_command": true}}, `a command prints "ERROR"`},
+		{map[string]any{"ran_commands_in_order": []any{"cd", "ls"}}, `run "cd" then "ls"`},
+		{map[string]any{"exit_code": 0}, "the last command succeeds"},
+		{map[string]any{"max_commands": 3}, "use at most 3 commands"},
+		{map[string]any{"never_ran": "rm -rf"}, `never run "rm -rf"`},
+		{map[string]any{"file_line_count": map[string]any{"path": "/f", "max": 10}}, "/f has at most 10 lines"},
+		{map[string]any{"file_count": map[string]any{"glob": "/d/*.log", "count": 0}}, "0 files match /d/*.log"},
+		{map[string]any{"dir_contains_exactly": map[string]any{"path": "/d", "names": []any{"a/", "b"}}}, "/d holds exactly a/, b"},
+		{map[string]any{"has_mode": map[string]any{"path": "/f", "mode": "755"}}, "/f has mode 755"},
+		{map[string]any{"path_exists": "/x", "label": "a place for x"}, "a place for x"},
+	}
+	for _, tt := range tests {
+		node, err := ParseGoal(tt.goal)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := node.Describe(); got != tt.want {
+			t.Errorf("Describe(%v) = %q, want %q", tt.goal, got, tt.want)
+		}
+	}
+}
+
+func TestParseGoal_AnnotationErrors(t *testing.T) {
+	tests := []struct {
+		goal map[string]any
+		want string
+	}{
+		{map[string]any{"hint": "try harder"}, "goal has a label or hint but no condition"},
+		{map[string]any{"path_exists": "/x", "hint": 3}, "goal hint expects string"},
+		{map[string]any{"and": []any{map[string]any{"label": "x"}}}, "and[0]: goal has a label or hint but no condition"},
+	}
+	for _, tt := range tests {
+		_, err := ParseGoal(tt.goal)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseGoal(%v): got %v, want %q", tt.goal, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,689 @@
+// ABOUTME: Lints content files, reporting every problem with its file, line and column
+// ABOUTME: Covers unknown fields, incomplete challenges, bad references, unreachable skills and trivial goals
+
+package content
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Diagnostic is a problem Lint found in a content file.
+type Diagnostic struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Severity string `json:"severity"` // "error" or "warning"
+	Message  string `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s", d.File, d.Line, d.Column, d.Severity, d.Message)
+}
+
+// challengeFields lists, for each challenge type, the fields it needs and the
+// optional fields it uses; any other field is ignored by that type.
+var challengeFields = map[string]struct{ required, optional []string }{
+	"command":         {[]string{"prompt", "expected"}, []string{"accepted", "context", "accept_same_effect"}},
+	"translate":       {[]string{"prompt", "expected"}, []string{"accepted", "context", "accept_same_effect"}},
+	"fix_error":       {[]string{"prompt", "broken", "expected"}, []string{"accepted", "context", "accept_same_effect"}},
+	"multiple_choice": {[]string{"prompt", "options"}, []string{"correct"}},
+	"predict_output":  {[]string{"prompt", "command", "options"}, []string{"correct", "context"}},
+}
+
+// challengeCommonFields apply to every challenge type.
+var challengeCommonFields = []string{"id", "type", "hint", "explanation"}
+
+// yamlLinePattern finds the line number in yaml.v3's error messages.
+var yamlLinePattern = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// LintBuiltin lints the embedded content.
+func LintBuiltin() []Diagnostic {
+	return Lint(contentFS, nil)
+}
+
+// LintDir lints the content in dir: a pack, or a directory laid out like the
+// built-in content. References may use the built-in skills and those of the
+// packs given, by their namespaced IDs. File names are reported under dir.
+func LintDir(dir string, packs []*Pack) []Diagnostic {
+	known := builtinSkills()
+	for _, p := range packs {
+		for _, s := range p.Skills.Skills {
+			s.ID = p.Name + "/" + s.ID
+			known = append(known, s)
+		}
+	}
+	diags := Lint(os.DirFS(dir), known)
+	for i := range diags {
+		diags[i].File = filepath.Join(dir, diags[i].File)
+	}
+	return diags
+}
+
+// builtinSkills returns the embedded skills, or none if they don't parse.
+func builtinSkills() []YAMLSkill {
+	var f SkillsFile
+	data, _ := contentFS.ReadFile("skills.yaml")
+	_ = yaml.Unmarshal(data, &f)
+	return f.Skills
+}
+
+// Lint checks the content files in fsys (skills.yaml, challenges.yaml,
+// missions.yaml, and pack.yaml if it is a pack) and reports every problem,
+// in file order. known are skills defined elsewhere that the files may use.
+func Lint(fsys fs.FS, known []YAMLSkill) []Diagnostic {
+	l := &linter{fsys: fsys, known: make(map[string]*YAMLSkill, len(known)), local: make(map[string]*yaml.Node)}
+	for i := range known {
+		l.known[known[i].ID] = &known[i]
+	}
+
+	var manifest PackManifest
+	if root := l.parse(PackManifestFile, &manifest); root != nil {
+		l.pack = true
+		l.lintManifest(root, &manifest)
+	}
+	var skillsFile SkillsFile
+	var challengesFile ChallengesFile
+	var missionsFile MissionsFile
+	skillsRoot := l.parse("skills.yaml", &skillsFile)
+	challengesRoot := l.parse("challenges.yaml", &challengesFile)
+	missionsRoot := l.parse("missions.yaml", &missionsFile)
+	if l.found == 0 {
+		l.errorf(".", nil, "no skills.yaml, challenges.yaml or missions.yaml")
+	}
+
+	if skillsRoot != nil {
+		l.lintSkills(mappingValue(skillsRoot, "skills"), skillsFile.Skills)
+	}
+	if challengesRoot != nil {
+		l.lintChallenges(mappingValue(challengesRoot, "challenges"))
+	}
+	if missionsRoot != nil {
+		l.lintMissions(mappingValue(missionsRoot, "missions"), missionsFile.Missions)
+	}
+
+	slices.SortStableFunc(l.diags, func(a, b Diagnostic) int {
+		return cmp.Or(cmp.Compare(a.File, b.File), cmp.Compare(a.Line, b.Line), cmp.Compare(a.Column, b.Column))
+	})
+	return l.diags
+}
+
+type linter struct {
+	fsys  fs.FS
+	pack  bool
+	found int // Content files read
+	known map[string]*YAMLSkill
+	local map[string]*yaml.Node // Skill IDs defined in the files, to their id nodes
+	diags []Diagnostic
+}
+
+func (l *linter) report(severity, file string, n *yaml.Node, format string, args ...any) {
+	d := Diagnostic{File: file, Severity: severity, Message: fmt.Sprintf(format, args...)}
+	if n != nil {
+		d.Line, d.Column = n.Line, n.Column
+	}
+	l.diags = append(l.diags, d)
+}
+
+func (l *linter) errorf(file string, n *yaml.Node, format string, args ...any) {
+	l.report("error", file, n, format, args...)
+}
+
+func (l *linter) warnf(file string, n *yaml.Node, format string, args ...any) {
+	l.report("warning", file, n, format, args...)
+}
+
+// parse reads a file into v, reporting syntax errors, type mismatches and unknown
+// fields. It returns the document's top-level node, or nil if there is none.
+func (l *linter) parse(file string, v any) *yaml.Node {
+	data, err := fs.ReadFile(l.fsys, file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if file != PackManifestFile {
+		l.found++
+	}
+	if err != nil {
+		l.errorf(file, nil, "%v", err)
+		return nil
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		l.yamlError(file, err)
+		return nil
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	root := doc.Content[0]
+	l.checkFields(file, root, reflect.TypeOf(v).Elem())
+	if err := root.Decode(v); err != nil {
+		l.yamlError(file, err)
+	}
+	return root
+}
+
+// yamlError reports a yaml.v3 error, one diagnostic per line it mentions.
+func (l *linter) yamlError(file string, err error) {
+	msgs := []string{err.Error()}
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		msgs = typeErr.Errors
+	}
+	for _, msg := range msgs {
+		d := Diagnostic{File: file, Severity: "error", Message: strings.TrimPrefix(msg, "yaml: ")}
+		if m := yamlLinePattern.FindStringSubmatch(msg); m != nil {
+			d.Line, _ = strconv.Atoi(m[1])
+			d.Column, d.Message = 1, m[2]
+		}
+		l.diags = append(l.diags, d)
+	}
+}
+
+// checkFields reports mapping keys that aren't fields of the Go type they decode into.
+func (l *linter) checkFields(file string, n *yaml.Node, t reflect.Type) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if n.Kind == yaml.AliasNode {
+		return
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if n.Kind != yaml.MappingNode {
+			return
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			field, ok := fields[key.Value]
+			if !ok {
+				if guess := closest(key.Value, slices.Sorted(maps.Keys(fields))); guess != "" {
+					l.errorf(file, key, "unknown field %q (did you mean %q?)", key.Value, guess)
+				} else {
+					l.errorf(file, key, "unknown field %q", key.Value)
+				}
+				continue
+			}
+			l.checkFields(file, value, field)
+		}
+	case reflect.Map:
+		if n.Kind != yaml.MappingNode || t.Elem().Kind() == reflect.Interface {
+			return
+		}
+		for i := 1; i < len(n.Content); i += 2 {
+			l.checkFields(file, n.Content[i], t.Elem())
+		}
+	case reflect.Slice:
+		if n.Kind != yaml.SequenceNode {
+			return
+		}
+		for _, item := range n.Content {
+			l.checkFields(file, item, t.Elem())
+		}
+	}
+}
+
+// yamlFields maps a struct's YAML field names to their types.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		fields[cmp.Or(name, strings.ToLower(f.Name))] = f.Type
+	}
+	return fields
+}
+
+// closest returns the candidate within two edits of s, if there is one.
+func closest(s string, candidates []string) string {
+	best, bestDist := "", 3
+	for _, c := range candidates {
+		if d := editDistance(s, c); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// mappingValue finds the value for a key in a mapping node.
+func mappingValue(n *yaml.Node, key string) *yaml.Node {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// items returns a sequence node's items, or none if n isn't a sequence.
+func items(n *yaml.Node) []*yaml.Node {
+	if n == nil || n.Kind != yaml.SequenceNode {
+		return nil
+	}
+	return n.Content
+}
+
+// at returns the value node for key in n, falling back to n itself.
+func at(n *yaml.Node, key string) *yaml.Node {
+	if v := mappingValue(n, key); v != nil {
+		return v
+	}
+	return n
+}
+
+func (l *linter) lintManifest(root *yaml.Node, m *PackManifest) {
+	const file = PackManifestFile
+	switch {
+	case m.Name == "":
+		l.errorf(file, root, "missing name")
+	case !packNamePattern.MatchString(m.Name):
+		l.errorf(file, at(root, "name"), "invalid pack name %q: use lower-case letters, digits and '-'", m.Name)
+	}
+	if m.Version == "" {
+		l.errorf(file, root, "missing version")
+	}
+	if m.RequiresTurtle != "" {
+		if _, err := parseVersion(m.RequiresTurtle); err != nil {
+			l.errorf(file, at(root, "requires_turtle"), "%v", err)
+		}
+	}
+}
+
+// skillExists reports whether a reference names a skill in these files or elsewhere.
+func (l *linter) skillExists(id string) bool {
+	return l.local[id] != nil || l.known[id] != nil
+}
+
+func (l *linter) lintSkills(seq *yaml.Node, list []YAMLSkill) {
+	const file = "skills.yaml"
+	nodes := items(seq)
+	if len(nodes) != len(list) {
+		return // Already reported as a type error
+	}
+	for i, s := range list {
+		n := nodes[i]
+		switch {
+		case s.ID == "":
+			l.errorf(file, n, "skill has no id")
+			continue
+		case l.local[s.ID] != nil:
+			l.errorf(file, at(n, "id"), "duplicate skill ID %s (first defined on line %d)", s.ID, l.local[s.ID].Line)
+			continue
+		case l.pack && strings.Contains(s.ID, "/"):
+			l.errorf(file, at(n, "id"), "skill %s: IDs in a pack may not contain '/'", s.ID)
+		}
+		l.local[s.ID] = at(n, "id")
+		if s.Name == "" {
+			l.errorf(file, n, "skill %s has no name", s.ID)
+		}
+		if s.Category == "" {
+			l.errorf(file, n, "skill %s has no category", s.ID)
+		}
+		if s.CategoryThreshold < 0 || s.CategoryThreshold > 1 {
+			l.errorf(file, at(n, "category_threshold"), "skill %s: category_threshold must be between 0 and 1", s.ID)
+		}
+		if s.CategoryThreshold > 0 && s.RequiresCategory == "" {
+			l.warnf(file, at(n, "category_threshold"), "skill %s: category_threshold has no requires_category", s.ID)
+		}
+	}
+	for i, s := range list {
+		for j, prereq := range s.Prerequisites {
+			if !l.skillExists(prereq) {
+				l.errorf(file, items(mappingValue(nodes[i], "prerequisites"))[j], "skill %s: unknown prerequisite %s", s.ID, prereq)
+			}
+		}
+	}
+	l.lintReachability(nodes, list)
+}
+
+// lintReachability reports prerequisite cycles and skills that can never be
+// unlocked. Skills defined elsewhere are taken to be reachable.
+func (l *linter) lintReachability(nodes []*yaml.Node, list []YAMLSkill) {
+	const file = "skills.yaml"
+	byID := make(map[string]int, len(list))
+	for i, s := range list {
+		if _, dup := byID[s.ID]; !dup {
+			byID[s.ID] = i
+		}
+	}
+
+	// Cycles, found as strongly connected components (Tarjan's algorithm).
+	inCycle := make(map[string]bool)
+	index := make(map[string]int)
+	low := make(map[string]int)
+	var stack []string
+	onStack := make(map[string]bool)
+	var connect func(id string)
+	connect = func(id string) {
+		index[id], low[id] = len(index), len(index)
+		stack = append(stack, id)
+		onStack[id] = true
+		for _, next := range list[byID[id]].Prerequisites {
+			if _, ok := byID[next]; !ok {
+				continue
+			}
+			if _, seen := index[next]; !seen {
+				connect(next)
+				low[id] = min(low[id], low[next])
+			} else if onStack[next] {
+				low[id] = min(low[id], index[next])
+			}
+		}
+		if low[id] != index[id] {
+			return
+		}
+		var component []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+			if top == id {
+				break
+			}
+		}
+		if len(component) == 1 && !slices.Contains(list[byID[id]].Prerequisites, id) {
+			return
+		}
+		slices.SortFunc(component, func(a, b string) int { return cmp.Compare(byID[a], byID[b]) })
+		for _, member := range component {
+			inCycle[member] = true
+		}
+		l.errorf(file, at(nodes[byID[component[0]]], "id"), "prerequisite cycle: %s", describeCycle(component, list, byID))
+	}
+	for _, s := range list {
+		if _, seen := index[s.ID]; !seen {
+			connect(s.ID)
+		}
+	}
+
+	// Reachability, as a fixed point: a skill unlocks once its prerequisites do and
+	// its required category has another skill that can be practised.
+	reachable := make(map[string]bool)
+	categoryReachable := func(cat, except string) bool {
+		for id, s := range l.known {
+			if s.Category == cat && id != except {
+				return true
+			}
+		}
+		for id := range reachable {
+			if list[byID[id]].Category == cat && id != except {
+				return true
+			}
+		}
+		return false
+	}
+	unlocks := func(s YAMLSkill) bool {
+		for _, prereq := range s.Prerequisites {
+			if !reachable[prereq] && l.known[prereq] == nil {
+				return false
+			}
+		}
+		return s.RequiresCategory == "" || (s.CategoryThreshold <= 1 && categoryReachable(s.RequiresCategory, s.ID))
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, s := range list {
+			if !reachable[s.ID] && unlocks(s) {
+				reachable[s.ID], changed = true, true
+			}
+		}
+	}
+
+	for i, s := range list {
+		if reachable[s.ID] || inCycle[s.ID] || byID[s.ID] != i {
+			continue
+		}
+		if slices.ContainsFunc(s.Prerequisites, func(p string) bool { return !l.skillExists(p) }) {
+			continue // Reported as an unknown prerequisite
+		}
+		reason := fmt.Sprintf("requires_category %s has no other skills that can be unlocked", s.RequiresCategory)
+		for _, prereq := range s.Prerequisites {
+			if !reachable[prereq] && l.known[prereq] == nil {
+				reason = fmt.Sprintf("its prerequisite %s can't be", prereq)
+				if inCycle[prereq] {
+					reason = fmt.Sprintf("its prerequisite %s is in a cycle", prereq)
+				}
+				break
+			}
+		}
+		l.errorf(file, at(nodes[i], "id"), "skill %s can never be unlocked: %s", s.ID, reason)
+	}
+}
+
+// describeCycle follows prerequisites around a cycle from its first member, such
+// as "a -> b -> a", staying among the cycle's members.
+func describeCycle(members []string, list []YAMLSkill, byID map[string]int) string {
+	start := members[0]
+	path := []string{start}
+	seen := map[string]bool{start: true}
+	for id := start; ; {
+		prereqs := list[byID[id]].Prerequisites
+		next := start
+		if !slices.Contains(prereqs, start) {
+			i := slices.IndexFunc(prereqs, func(p string) bool { return slices.Contains(members, p) })
+			next = prereqs[i]
+		}
+		path = append(path, next)
+		if seen[next] {
+			return strings.Join(path, " -> ")
+		}
+		seen[next] = true
+		id = next
+	}
+}
+
+func (l *linter) lintChallenges(m *yaml.Node) {
+	const file = "challenges.yaml"
+	if m == nil || m.Kind != yaml.MappingNode {
+		return
+	}
+	ids := make(map[string]*yaml.Node)
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		key := m.Content[i]
+		if !l.skillExists(key.Value) {
+			l.errorf(file, key, "challenges for unknown skill %s", key.Value)
+		}
+		for j, n := range items(m.Content[i+1]) {
+			var c YAMLChallenge
+			if n.Decode(&c) != nil {
+				continue // Already reported as a type error
+			}
+			name := fmt.Sprintf("%s challenge %d", key.Value, j+1)
+			switch {
+			case c.ID == "":
+				l.errorf(file, n, "%s has no id", name)
+			case ids[c.ID] != nil:
+				l.errorf(file, at(n, "id"), "duplicate challenge ID %s (first defined on line %d)", c.ID, ids[c.ID].Line)
+			case l.pack && strings.Contains(c.ID, "/"):
+				l.errorf(file, at(n, "id"), "%s: IDs in a pack may not contain '/'", name)
+			default:
+				ids[c.ID] = at(n, "id")
+			}
+			l.lintChallenge(n, &c, name)
+		}
+	}
+}
+
+func (l *linter) lintChallenge(n *yaml.Node, c *YAMLChallenge, name string) {
+	const file = "challenges.yaml"
+	fields, ok := challengeFields[c.Type]
+	if !ok {
+		types := slices.Sorted(maps.Keys(challengeFields))
+		if c.Type == "" {
+			l.errorf(file, n, "%s has no type (want one of %s)", name, strings.Join(types, ", "))
+		} else {
+			l.errorf(file, at(n, "type"), "%s: unknown type %q (want one of %s)", name, c.Type, strings.Join(types, ", "))
+		}
+		return
+	}
+	for _, field := range fields.required {
+		if v := mappingValue(n, field); v == nil || v.Value == "" && len(v.Content) == 0 {
+			l.errorf(file, n, "%s: %s challenges need %s", name, c.Type, field)
+		}
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key := n.Content[i].Value
+		used := slices.Contains(fields.required, key) || slices.Contains(fields.optional, key) || slices.Contains(challengeCommonFields, key)
+		if _, known := yamlFields(reflect.TypeFor[YAMLChallenge]())[key]; known && !used {
+			l.warnf(file, n.Content[i], "%s: %s is not used by %s challenges", name, key, c.Type)
+		}
+	}
+	if slices.Contains(fields.optional, "correct") {
+		if len(c.Options) == 1 {
+			l.errorf(file, at(n, "options"), "%s: needs at least two options", name)
+		}
+		if len(c.Options) > 0 && (c.Correct < 0 || c.Correct >= len(c.Options)) {
+			l.errorf(file, at(n, "correct"), "%s: correct is %d, but options are numbered 0 to %d", name, c.Correct, len(c.Options)-1)
+		}
+	}
+	l.lintSetup(file, mappingValue(n, "context"), c.Context, name+": context")
+}
+
+func (l *linter) lintMissions(seq *yaml.Node, list []YAMLMission) {
+	const file = "missions.yaml"
+	nodes := items(seq)
+	if len(nodes) != len(list) {
+		return // Already reported as a type error
+	}
+	ids := make(map[string]*yaml.Node)
+	for i := range list {
+		m, n := &list[i], nodes[i]
+		name := "mission " + m.ID
+		switch {
+		case m.ID == "":
+			l.errorf(file, n, "mission has no id")
+			name = fmt.Sprintf("mission %d", i+1)
+		case ids[m.ID] != nil:
+			l.errorf(file, at(n, "id"), "duplicate mission ID %s (first defined on line %d)", m.ID, ids[m.ID].Line)
+		case l.pack && strings.Contains(m.ID, "/"):
+			l.errorf(file, at(n, "id"), "%s: IDs in a pack may not contain '/'", name)
+		default:
+			ids[m.ID] = at(n, "id")
+		}
+		switch {
+		case m.SkillID == "":
+			l.errorf(file, n, "%s has no skill_id", name)
+		case !l.skillExists(m.SkillID):
+			l.errorf(file, at(n, "skill_id"), "%s: unknown skill_id %s", name, m.SkillID)
+		}
+		for _, field := range []string{"title", "briefing"} {
+			if v := mappingValue(n, field); v == nil || v.Value == "" {
+				l.errorf(file, n, "%s has no %s", name, field)
+			}
+		}
+
+		// Goals and setup are checked as a learner would get them, with templates filled in.
+		expanded, err := m.Expand(rand.New(rand.NewPCG(0, 0)))
+		if err != nil {
+			l.errorf(file, n, "%s: %v", name, err)
+			continue
+		}
+		stageNodes := items(mappingValue(n, "stages"))
+		if len(expanded.Stages) == 0 {
+			l.lintGoal(at(n, "goal"), expanded.Goal, name)
+		} else {
+			if len(expanded.Goal) > 0 {
+				l.errorf(file, at(n, "goal"), "%s has both goal and stages; put the goal in the last stage", name)
+			}
+			for j, stage := range expanded.Stages {
+				sn := n
+				if j < len(stageNodes) {
+					sn = stageNodes[j]
+				}
+				stageName := fmt.Sprintf("%s stage %d", name, j+1)
+				if len(stage.Goal) == 0 {
+					l.errorf(file, sn, "%s has no goal", stageName)
+				} else {
+					l.lintGoal(at(sn, "goal"), stage.Goal, stageName)
+				}
+				l.lintSetup(file, mappingValue(sn, "setup"), stage.Setup, stageName)
+			}
+		}
+		l.lintSetup(file, mappingValue(n, "setup"), expanded.Setup, name)
+	}
+}
+
+func (l *linter) lintGoal(n *yaml.Node, goal map[string]any, name string) {
+	const file = "missions.yaml"
+	if len(goal) == 0 {
+		l.warnf(file, n, "%s has no goal, so it completes at once", name)
+		return
+	}
+	g, err := ParseGoal(goal)
+	if err != nil {
+		l.errorf(file, n, "%s: invalid goal: %v", name, err)
+		return
+	}
+	if isTrivialGoal(g) {
+		l.warnf(file, n, "%s: goal is always satisfied, so it completes at once", name)
+	}
+}
+
+// isTrivialGoal reports whether a goal holds whatever the learner does.
+func isTrivialGoal(g GoalNode) bool {
+	switch g := g.(type) {
+	case *AlwaysGoal:
+		return true
+	case *AnnotatedGoal:
+		return isTrivialGoal(g.Goal)
+	case *OrGoal:
+		return slices.ContainsFunc(g.Conditions, isTrivialGoal)
+	case *AndGoal:
+		return !slices.ContainsFunc(g.Conditions, func(c GoalNode) bool { return !isTrivialGoal(c) })
+	}
+	return false
+}
+
+func (l *linter) lintSetup(file string, seq *yaml.Node, actions []SetupAction, name string) {
+	nodes := items(seq)
+	for i := range actions {
+		if err := actions[i].Validate(); err != nil {
+			n := seq
+			if i < len(nodes) {
+				n = nodes[i]
+			}
+			l.errorf(file, n, "%s: setup %d: %v", name, i+1, err)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+// ABOUTME: Tests for the content linter
+// ABOUTME: Checks each kind of problem is reported at the right file, line and column
+
+package content
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLintBuiltin(t *testing.T) {
+	for _, d := range LintBuiltin() {
+		t.Error(d)
+	}
+}
+
+func TestLint(t *testing.T) {
+	fsys := fstest.MapFS{
+		"skills.yaml": {Data: []byte(`skills:
+  - id: basics
+    name: Basics
+    category: core
+  - id: loop-a
+    name: A
+    category: core
+    prerequisites: [loop-b]
+  - id: loop-b
+    name: B
+    category: core
+    prerequisites: [loop-a]
+  - id: after-loop
+    name: After
+    category: core
+    prerequisites: [loop-b]
+  - id: broken
+    name: Broken
+    category: core
+    prerequisites: [basics, missing]
+  - id: lonely
+    name: Lonely
+    category: island
+    requires_category: island
+    category_threshold: 0.5
+  - id: basics
+    name: Again
+    category: core
+    descripton: typo
+`)},
+		"challenges.yaml": {Data: []byte(`challenges:
+  basics:
+    - id: pick
+      type: multiple_choice
+      prompt: Pick one
+      options: [a, b]
+      correct: 2
+    - id: type
+      type: command
+      prompt: Type it
+      options: [x]
+    - id: predict
+      type: predict_output
+      prompt: What prints?
+      options: [a, b]
+    - type: quiz
+      prompt: "?"
+    - id: tidy
+      type: command
+      prompt: Tidy up
+      expected: rm notes.txt
+      context:
+        - touch: notes.txt
+          mkdir: x
+    - id: pick
+      type: multiple_choice
+      prompt: Which?
+      options: [a, b]
+      context: [{touch: x}]
+  nobody:
+    - {id: x, type: command, prompt: x, expected: x}
+`)},
+		"missions.yaml": {Data: []byte(`missions:
+  - id: free
+    skill_id: basics
+    title: Free
+    briefing: Nothing to do.
+    goal:
+      always: true
+  - id: staged
+    skill_id: basics
+    title: Staged
+    briefing: Two steps.
+    stages:
+      - briefing: First
+        goal: {ran_command: ls}
+      - briefing: Second
+        setup:
+          - mkdir: /x
+            touch: /y
+        goal:
+          or: [{ran_command: pwd}, {always: true}]
+  - id: free
+    skill_id: nope
+    title: Again
+    briefing: Duplicate.
+    goal: {pwd_equals: /}
+`)},
+	}
+
+	want := []string{
+		"challenges.yaml:7:16: error: basics challenge 1: correct is 2, but options are numbered 0 to 1",
+		"challenges.yaml:8:7: error: basics challenge 2: command challenges need expected",
+		"challenges.yaml:11:7: warning: basics challenge 2: options is not used by command challenges",
+		"challenges.yaml:12:7: error: basics challenge 3: predict_output challenges need command",
+		"challenges.yaml:16:7: error: basics challenge 4 has no id",
+		"challenges.yaml:16:13: error: basics challenge 4: unknown type \"quiz\"",
+		"challenges.yaml:23:11: error: basics challenge 5: context: setup 1: setup action has several operations",
+		"challenges.yaml:25:11: error: duplicate challenge ID pick (first defined on line 3)",
+		"challenges.yaml:29:7: warning: basics challenge 6: context is not used by multiple_choice challenges",
+		"challenges.yaml:30:3: error: challenges for unknown skill nobody",
+		"missions.yaml:7:7: warning: mission free: goal is always satisfied",
+		"missions.yaml:17:13: error: mission staged stage 2: setup 1: setup action has several operations",
+		"missions.yaml:20:11: warning: mission staged stage 2: goal is always satisfied",
+		"missions.yaml:21:9: error: duplicate mission ID free (first defined on line 2)",
+		"missions.yaml:22:15: error: mission free: unknown skill_id nope",
+		"skills.yaml:5:9: error: prerequisite cycle: loop-a -> loop-b -> loop-a",
+		"skills.yaml:13:9: error: skill after-loop can never be unlocked: its prerequisite loop-b is in a cycle",
+		"skills.yaml:20:29: error: skill broken: unknown prerequisite missing",
+		"skills.yaml:21:9: error: skill lonely can never be unlocked: requires_category island has no other skills",
+		"skills.yaml:26:9: error: duplicate skill ID basics (first defined on line 2)",
+		"skills.yaml:29:5: error: unknown field \"descripton\" (did you mean \"description\"?)",
+	}
+	diags := Lint(fsys, nil)
+	got := make([]string, len(diags))
+	for i, d := range diags {
+		got[i] = d.String()
+	}
+	for _, w := range want {
+		found := false
+		for _, g := range got {
+			found = found || strings.HasPrefix(g, w)
+		}
+		if !found {
+			t.Errorf("missing diagnostic %q", w)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("expected %d diagnostics, got %d:\n%s", len(want), len(got), strings.Join(got, "\n"))
+	}
+}
+
+func TestLint_Pack(t *testing.T) {
+	fsys := fstest.MapFS{
+		PackManifestFile: {Data: []byte("name: Acme\nrequires_turtle: soon\n")},
+		"skills.yaml": {Data: []byte(`skills:
+  - {id: deploy, name: Deploy, category: advanced, prerequisites: [ls, tools/ssh, tools/scp]}
+`)},
+		"missions.yaml": {Data: []byte("missions:\n  - {id: m, skill_id: deploy, title: x, briefing: x, goal: {ran_command: [5, 6}}\n")},
+	}
+	known := append(builtinSkills(), YAMLSkill{ID: "tools/ssh", Category: "advanced"})
+
+	var got []string
+	for _, d := range Lint(fsys, known) {
+		got = append(got, d.String())
+	}
+	want := []string{
+		`missions.yaml:1:1: error: did not find expected ',' or ']'`,
+		`pack.yaml:1:1: error: missing version`,
+		`pack.yaml:1:7: error: invalid pack name "Acme"`,
+		`pack.yaml:2:18: error: invalid version "soon"`,
+		`skills.yaml:2:83: error: skill deploy: unknown prerequisite tools/scp`,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d diagnostics, got:\n%s", len(want), strings.Join(got, "\n"))
+	}
+	for i := range want {
+		if !strings.HasPrefix(got[i], want[i]) {
+			t.Errorf("diagnostic %d: got %q, want %q...", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,302 @@
+// ABOUTME: Content packs: extra skills, challenges, missions and fixtures loaded from disk
+// ABOUTME: Each pack is merged into the embedded content under its own namespace
+
+package content
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PackManifestFile sits at the top of a pack directory and names the pack.
+const PackManifestFile = "pack.yaml"
+
+// PackManifest describes a content pack.
+type PackManifest struct {
+	Name           string `yaml:"name"` // The pack's namespace: its IDs become NAME/ID
+	Version        string `yaml:"version"`
+	RequiresTurtle string `yaml:"requires_turtle,omitempty"` // Oldest Turtle version the pack works with, e.g. "0.4"
+}
+
+// Pack is a content pack read from disk: any of skills.yaml, challenges.yaml and
+// missions.yaml, plus an optional fixtures directory, alongside pack.yaml.
+//
+// Inside a pack, IDs are written without the namespace. A reference to a skill the
+// pack defines means that skill; any other plain ID means a built-in skill, and
+// OTHER/ID refers to a skill from another pack. Fixtures resolve the same way.
+type Pack struct {
+	PackManifest
+	Dir        string
+	Skills     SkillsFile
+	Challenges ChallengesFile
+	Missions   MissionsFile
+
+	fixtures fs.FS // The pack's fixtures directory, or nil
+}
+
+var packNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// packs are merged into the content when it loads. See UsePacks.
+var packs []*Pack
+
+// UsePacks adds packs to the content. It only has an effect before the content is
+// first loaded, so call it at startup.
+func UsePacks(p ...*Pack) {
+	for _, pack := range p {
+		if pack.fixtures != nil {
+			packFixtures[pack.Name] = pack.fixtures
+		}
+	}
+	packs = append(packs, p...)
+}
+
+// CheckPacks reports whether the content loads with the given packs merged in,
+// without changing what LoadContent will use.
+func CheckPacks(p ...*Pack) error {
+	_, err := loadBundle(p)
+	return err
+}
+
+// DefaultPackRoot returns the directory packs are installed in:
+// $XDG_CONFIG_HOME/turtle/packs, or ~/.config/turtle/packs.
+func DefaultPackRoot() string {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		configHome = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configHome, "turtle", "packs")
+}
+
+// FindPacks returns the pack directories in dir: dir itself if it has a pack.yaml,
+// otherwise each subdirectory that does, in name order. A missing dir has no packs.
+func FindPacks(dir string) ([]string, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	if isPackDir(dir) {
+		return []string{dir}, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var dirs []string
+	for _, e := range entries {
+		if sub := filepath.Join(dir, e.Name()); e.IsDir() && isPackDir(sub) {
+			dirs = append(dirs, sub)
+		}
+	}
+	return dirs, nil
+}
+
+func isPackDir(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, PackManifestFile))
+	return err == nil
+}
+
+// ReadPack reads the pack in dir. turtleVersion is the running version, which must
+// be at least the pack's requires_turtle; development builds accept any pack.
+func ReadPack(dir, turtleVersion string) (*Pack, error) {
+	p, err := readPack(os.DirFS(dir), turtleVersion)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", dir, err)
+	}
+	p.Dir = dir
+	return p, nil
+}
+
+func readPack(fsys fs.FS, turtleVersion string) (*Pack, error) {
+	p := &Pack{}
+	data, err := fs.ReadFile(fsys, PackManifestFile)
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, &p.PackManifest); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", PackManifestFile, err)
+	}
+	switch {
+	case p.Name == "":
+		return nil, fmt.Errorf("%s: missing name", PackManifestFile)
+	case !packNamePattern.MatchString(p.Name):
+		return nil, fmt.Errorf("%s: invalid name %q: use lower-case letters, digits and '-'", PackManifestFile, p.Name)
+	case p.Version == "":
+		return nil, fmt.Errorf("%s: missing version", PackManifestFile)
+	}
+	if p.RequiresTurtle != "" {
+		ok, err := versionAtLeast(turtleVersion, p.RequiresTurtle)
+		if err != nil {
+			return nil, fmt.Errorf("%s: requires_turtle: %w", PackManifestFile, err)
+		}
+		if !ok {
+			return nil, fmt.Errorf("pack %s %s needs Turtle %s or later, this is %s", p.Name, p.Version, p.RequiresTurtle, turtleVersion)
+		}
+	}
+
+	found := false
+	for name, into := range map[string]any{
+		"skills.yaml":     &p.Skills,
+		"challenges.yaml": &p.Challenges,
+		"missions.yaml":   &p.Missions,
+	} {
+		data, err := fs.ReadFile(fsys, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.Unmarshal(data, into); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", name, err)
+		}
+		found = true
+	}
+	if !found {
+		return nil, fmt.Errorf("no skills.yaml, challenges.yaml or missions.yaml")
+	}
+
+	if info, err := fs.Stat(fsys, "fixtures"); err == nil && info.IsDir() {
+		if p.fixtures, err = fs.Sub(fsys, "fixtures"); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// merge adds a pack's content under its namespace. The result is checked by validate.
+func (b *bundle) merge(p *Pack) error {
+	local := make(map[string]bool, len(p.Skills.Skills))
+	for _, s := range p.Skills.Skills {
+		if strings.Contains(s.ID, "/") {
+			return fmt.Errorf("skill %s: IDs in a pack may not contain '/'", s.ID)
+		}
+		local[s.ID] = true
+	}
+	skillRef := func(id string) string {
+		if local[id] {
+			return p.Name + "/" + id
+		}
+		return id
+	}
+
+	for _, s := range p.Skills.Skills {
+		s.ID = p.Name + "/" + s.ID
+		prereqs := make([]string, len(s.Prerequisites))
+		for i, prereq := range s.Prerequisites {
+			prereqs[i] = skillRef(prereq)
+		}
+		s.Prerequisites = prereqs
+		b.skills.Skills = append(b.skills.Skills, s)
+	}
+
+	var fixtures map[string]bool
+	if p.fixtures != nil {
+		fixtures = make(map[string]bool)
+		for _, name := range fixtureNames(p.fixtures) {
+			fixtures[name] = true
+		}
+	}
+	setupRefs := func(actions []SetupAction) []SetupAction {
+		out := make([]SetupAction, len(actions))
+		for i, a := range actions {
+			if a.Fixture != nil && fixtures[a.Fixture.Name] {
+				a.Fixture = &FixtureAction{Name: p.Name + "/" + a.Fixture.Name, To: a.Fixture.To}
+			}
+			out[i] = a
+		}
+		return out
+	}
+	for skillID, challenges := range p.Challenges.Challenges {
+		if b.challenges.Challenges == nil {
+			b.challenges.Challenges = make(map[string][]YAMLChallenge)
+		}
+		ref := skillRef(skillID)
+		for _, c := range challenges {
+			if strings.Contains(c.ID, "/") {
+				return fmt.Errorf("challenge %s: IDs in a pack may not contain '/'", c.ID)
+			}
+			if c.ID != "" {
+				c.ID = p.Name + "/" + c.ID
+			}
+			c.Context = setupRefs(c.Context)
+			c.Pack = p.Name
+			b.challenges.Challenges[ref] = append(b.challenges.Challenges[ref], c)
+		}
+	}
+	for _, m := range p.Missions.Missions {
+		if strings.Contains(m.ID, "/") {
+			return fmt.Errorf("mission %s: IDs in a pack may not contain '/'", m.ID)
+		}
+		m.ID = p.Name + "/" + m.ID
+		m.SkillID = skillRef(m.SkillID)
+		m.Setup = setupRefs(m.Setup)
+		stages := make([]YAMLStage, len(m.Stages))
+		for i, stage := range m.Stages {
+			stage.Setup = setupRefs(stage.Setup)
+			stages[i] = stage
+		}
+		if m.Stages != nil {
+			m.Stages = stages
+		}
+		b.missions.Missions = append(b.missions.Missions, m)
+	}
+	return nil
+}
+
+// versionAtLeast compares dotted version numbers such as "0.4" and "v0.4.2-rc1"; a
+// leading "v" and anything after '-' or '+' are ignored. A version that isn't a
+// number, such as "dev", is a development build and satisfies any minimum.
+func versionAtLeast(version, minimum string) (bool, error) {
+	want, err := parseVersion(minimum)
+	if err != nil {
+		return false, err
+	}
+	have, err := parseVersion(version)
+	if err != nil {
+		return true, nil
+	}
+	for i := range max(len(have), len(want)) {
+		var h, w int
+		if i < len(have) {
+			h = have[i]
+		}
+		if i < len(want) {
+			w = want[i]
+		}
+		if h != w {
+			return h > w, nil
+		}
+	}
+	return true, nil
+}
+
+func parseVersion(s string) ([]int, error) {
+	v := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	parts := strings.Split(v, ".")
+	nums := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid version %q", s)
+		}
+		nums[i] = n
+	}
+	return nums, nil
+}
@@ -0,0 +1,187 @@
+// ABOUTME: Tests for reading content packs and merging them with the embedded content
+// ABOUTME: Covers manifests, version checks, namespacing and cross-pack references
+
+package content
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// testPack builds a pack filesystem from a manifest and file contents.
+func testPack(manifest string, files map[string]string) fstest.MapFS {
+	fsys := fstest.MapFS{PackManifestFile: {Data: []byte(manifest)}}
+	for name, data := range files {
+		fsys[name] = &fstest.MapFile{Data: []byte(data)}
+	}
+	return fsys
+}
+
+func TestReadPack(t *testing.T) {
+	fsys := testPack("name: acme\nversion: 1.2.0\nrequires_turtle: '0.4'\n", map[string]string{
+		"skills.yaml":               "skills:\n  - {id: deploy, name: Deploy, category: advanced}\n",
+		"fixtures/service/Makefile": "deploy:\n\t./deploy.sh\n",
+	})
+	p, err := readPack(fsys, "0.4.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "acme" || p.Version != "1.2.0" || len(p.Skills.Skills) != 1 {
+		t.Errorf("unexpected pack: %+v", p)
+	}
+	if names := fixtureNames(p.fixtures); !slices.Equal(names, []string{"service"}) {
+		t.Errorf("expected the pack's fixture, got %v", names)
+	}
+}
+
+func TestReadPack_Errors(t *testing.T) {
+	skills := map[string]string{"skills.yaml": "skills: []\n"}
+	tests := []struct {
+		name     string
+		manifest string
+		files    map[string]string
+		version  string
+		want     string
+	}{
+		{"no name", "version: '1'", skills, "1.0.0", "missing name"},
+		{"bad name", "name: Acme Tools\nversion: '1'", skills, "1.0.0", `invalid name "Acme Tools"`},
+		{"no version", "name: acme", skills, "1.0.0", "missing version"},
+		{"too new", "name: acme\nversion: '1'\nrequires_turtle: 0.5.0", skills, "0.4.9", "needs Turtle 0.5.0 or later, this is 0.4.9"},
+		{"bad requirement", "name: acme\nversion: '1'\nrequires_turtle: soon", skills, "1.0.0", `invalid version "soon"`},
+		{"empty", "name: acme\nversion: '1'", nil, "1.0.0", "no skills.yaml, challenges.yaml or missions.yaml"},
+		{"bad yaml", "name: acme\nversion: '1'", map[string]string{"missions.yaml": "missions: {"}, "1.0.0", "parsing missions.yaml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readPack(testPack(tt.manifest, tt.files), tt.version)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadBundle_Packs(t *testing.T) {
+	tools, err := readPack(testPack("name: tools\nversion: '1'", map[string]string{
+		"skills.yaml": "skills:\n  - {id: ssh, name: ssh, category: advanced, prerequisites: [ls]}\n",
+	}), "dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	acme, err := readPack(testPack("name: acme\nversion: '1'", map[string]string{
+		"skills.yaml": `skills:
+  - {id: deploy, name: Deploy, category: advanced, prerequisites: [setup, tools/ssh]}
+  - {id: setup, name: Setup, category: advanced, prerequisites: [cd]}
+`,
+		"challenges.yaml": `challenges:
+  deploy:
+    - {id: deploy, type: command, prompt: Deploy, expected: make deploy}
+  grep:
+    - {id: deploy-log, type: command, prompt: Search the deploy log, expected: grep ERROR deploy.log}
+`,
+		"missions.yaml": `missions:
+  - id: first-deploy
+    skill_id: deploy
+    level: 6
+    title: First Deploy
+    briefing: Deploy the service.
+    setup:
+      - fixture: {name: service, to: ~/service}
+      - fixture: {name: go-repo, to: ~/notes}
+    goal:
+      ran_command: make deploy
+`,
+		"fixtures/service/Makefile": "deploy:\n",
+	}), "dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := maps.Clone(packFixtures)
+	t.Cleanup(func() { packFixtures = saved })
+	packFixtures["acme"] = acme.fixtures
+
+	b, err := loadBundle([]*Pack{acme, tools})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var deploy *YAMLSkill
+	for i, s := range b.skills.Skills {
+		if s.ID == "acme/deploy" {
+			deploy = &b.skills.Skills[i]
+		}
+	}
+	if deploy == nil || !slices.Equal(deploy.Prerequisites, []string{"acme/setup", "tools/ssh"}) {
+		t.Fatalf("pack skills should be namespaced with their references resolved, got %+v", deploy)
+	}
+	if deploy := b.challenges.Challenges["acme/deploy"]; len(deploy) != 1 || deploy[0].ID != "acme/deploy" {
+		t.Error("challenges for the pack's own skills should be namespaced, and so should their IDs")
+	}
+	if grep := b.challenges.Challenges["grep"]; grep[len(grep)-1].Expected != "grep ERROR deploy.log" || grep[len(grep)-1].Pack != "acme" {
+		t.Error("packs should be able to add challenges to built-in skills")
+	}
+	m := b.missions.Missions[len(b.missions.Missions)-1]
+	if m.ID != "acme/first-deploy" || m.SkillID != "acme/deploy" {
+		t.Errorf("missions should be namespaced, got %s for %s", m.ID, m.SkillID)
+	}
+	if m.Setup[0].Fixture.Name != "acme/service" || m.Setup[1].Fixture.Name != "go-repo" {
+		t.Errorf("fixtures should resolve to the pack's own first, got %+v %+v", m.Setup[0].Fixture, m.Setup[1].Fixture)
+	}
+	if entries, err := LoadFixture("acme/service"); err != nil || len(entries) != 1 {
+		t.Errorf("pack fixtures should load by namespaced name, got %v, %v", entries, err)
+	}
+
+	// Without the tools pack, acme's prerequisite dangles.
+	if _, err := loadBundle([]*Pack{acme}); err == nil || !strings.Contains(err.Error(), "skill acme/deploy: unknown prerequisite tools/ssh") {
+		t.Errorf("expected an unknown prerequisite, got %v", err)
+	}
+	if _, err := loadBundle([]*Pack{tools, tools}); err == nil || !strings.Contains(err.Error(), "two packs are named tools") {
+		t.Errorf("expected a duplicate pack name, got %v", err)
+	}
+}
+
+func TestLoadBundle_PackErrors(t *testing.T) {
+	tests := []struct {
+		files map[string]string
+		want  string
+	}{
+		{map[string]string{"skills.yaml": "skills:\n  - {id: a/b, name: x, category: advanced}\n"}, "IDs in a pack may not contain '/'"},
+		{map[string]string{"challenges.yaml": "challenges:\n  nope:\n    - {id: x, type: command, prompt: x, expected: x}\n"}, "unknown skill: nope"},
+		{map[string]string{"challenges.yaml": "challenges:\n  ls:\n    - {id: a/b, type: command, prompt: x, expected: x}\n"}, "IDs in a pack may not contain '/'"},
+		{map[string]string{"challenges.yaml": "challenges:\n  ls:\n    - {type: command, prompt: x, expected: x}\n"}, "ls challenge 6 has no id"},
+		{map[string]string{"challenges.yaml": "challenges:\n  ls:\n    - {id: x, type: command, prompt: x, expected: x}\n    - {id: x, type: command, prompt: y, expected: y}\n"}, "duplicate challenge ID: acme/x"},
+		{map[string]string{"missions.yaml": "missions:\n  - {id: m, skill_id: ls, title: x, briefing: x, goal: {bogus: x}}\n"}, "mission acme/m: invalid goal"},
+	}
+	for _, tt := range tests {
+		p, err := readPack(testPack("name: acme\nversion: '1'", tt.files), "dev")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loadBundle([]*Pack{p}); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%v: got %v, want %q", tt.files, err, tt.want)
+		}
+	}
+}
+
+func TestVersionAtLeast(t *testing.T) {
+	tests := []struct {
+		version, minimum string
+		want             bool
+	}{
+		{"0.4.0", "0.4", true},
+		{"v0.4.2-rc1", "0.4.1", true},
+		{"0.10.0", "0.9", true},
+		{"0.3.9", "0.4", false},
+		{"1.0", "1.0.1", false},
+		{"dev", "9.9", true},
+	}
+	for _, tt := range tests {
+		got, err := versionAtLeast(tt.version, tt.minimum)
+		if err != nil || got != tt.want {
+			t.Errorf("versionAtLeast(%q, %q) = %v, %v; want %v", tt.version, tt.minimum, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+// ABOUTME: Validation for mission setup actions
+// ABOUTME: Catches malformed setup when content loads, rather than when a learner starts the mission
+
+package content
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// chmodPattern matches the octal and symbolic modes the sandbox's chmod understands.
+var chmodPattern = regexp.MustCompile(`^(?:[0-7]{1,3}|[ugoa]*[-+=][rwxX]*(?:,[ugoa]*[-+=][rwxX]*)*)$`)
+
+// Kind returns the name of the operation the action performs, or "" if it has none.
+// It is an error (reported by Validate) for an action to set more than one.
+func (a *SetupAction) Kind() string {
+	kinds := a.kinds()
+	if len(kinds) == 0 {
+		return ""
+	}
+	return kinds[0]
+}
+
+func (a *SetupAction) kinds() []string {
+	var kinds []string
+	add := func(set bool, kind string) {
+		if set {
+			kinds = append(kinds, kind)
+		}
+	}
+	add(a.Mkdir != "", "mkdir")
+	add(a.Cd != "", "cd")
+	add(a.Touch != "", "touch")
+	add(a.WriteFile != nil, "write_file")
+	add(a.Rm != "", "rm")
+	add(a.Copy != nil, "copy")
+	add(a.Symlink != nil, "symlink")
+	add(a.Chmod != nil, "chmod")
+	add(a.Chown != nil, "chown")
+	add(len(a.Env) > 0, "env")
+	add(len(a.SeedHistory) > 0, "seed_history")
+	add(a.TmuxSession != nil, "tmux_session")
+	add(a.StartProcess != nil, "start_process")
+	add(a.SetMtime != nil, "set_mtime")
+	add(a.Fixture != nil, "fixture")
+	return kinds
+}
+
+// Validate checks that the action does exactly one thing and has what it needs.
+func (a *SetupAction) Validate() error {
+	kinds := a.kinds()
+	switch len(kinds) {
+	case 0:
+		return fmt.Errorf("empty setup action")
+	case 1:
+	default:
+		return fmt.Errorf("setup action has several operations (%s); use one per action", strings.Join(kinds, ", "))
+	}
+
+	switch {
+	case a.WriteFile != nil:
+		return requireFields("write_file", "path", a.WriteFile.Path)
+	case a.Copy != nil:
+		return requireFields("copy", "from", a.Copy.From, "to", a.Copy.To)
+	case a.Symlink != nil:
+		return requireFields("symlink", "path", a.Symlink.Path, "target", a.Symlink.Target)
+	case a.Chmod != nil:
+		if err := requireFields("chmod", "path", a.Chmod.Path, "mode", a.Chmod.Mode); err != nil {
+			return err
+		}
+		if !chmodPattern.MatchString(a.Chmod.Mode) {
+			return fmt.Errorf("chmod: invalid mode %q", a.Chmod.Mode)
+		}
+	case a.Chown != nil:
+		return requireFields("chown", "path", a.Chown.Path, "owner", a.Chown.Owner)
+	case a.Env != nil:
+		for name := range a.Env {
+			if !isEnvName(name) {
+				return fmt.Errorf("env: invalid variable name %q", name)
+			}
+		}
+	case a.TmuxSession != nil:
+		return a.TmuxSession.validate()
+	case a.StartProcess != nil:
+		if strings.TrimSpace(a.StartProcess.Command) == "" {
+			return fmt.Errorf("start_process: missing command")
+		}
+	case a.SetMtime != nil:
+		if err := requireFields("set_mtime", "path", a.SetMtime.Path, "time", a.SetMtime.Time); err != nil {
+			return err
+		}
+		if _, err := ParseSetupTime(a.SetMtime.Time, time.Now()); err != nil {
+			return fmt.Errorf("set_mtime: %w", err)
+		}
+	case a.Fixture != nil:
+		if err := requireFields("fixture", "name", a.Fixture.Name, "to", a.Fixture.To); err != nil {
+			return err
+		}
+		if !HasFixture(a.Fixture.Name) {
+			return fmt.Errorf("fixture: unknown fixture %q (have %s)", a.Fixture.Name, strings.Join(FixtureNames(), ", "))
+		}
+	}
+	return nil
+}
+
+func (s *TmuxSessionAction) validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("tmux_session: missing name")
+	}
+	if strings.ContainsAny(s.Name, ":.") {
+		return fmt.Errorf("tmux_session: name %q may not contain ':' or '.'", s.Name)
+	}
+	for i, w := range s.Windows {
+		for j, p := range w.Panes {
+			switch p.Split {
+			case "", "horizontal", "vertical":
+			default:
+				return fmt.Errorf("tmux_session: window %d pane %d: split must be horizontal or vertical, got %q", i, j, p.Split)
+			}
+			if j == 0 && p.Split != "" {
+				return fmt.Errorf("tmux_session: window %d: the first pane has nothing to split", i)
+			}
+		}
+	}
+	return nil
+}
+
+// requireFields takes name/value pairs and reports the first empty value.
+func requireFields(action string, pairs ...string) error {
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] == "" {
+			return fmt.Errorf("%s: missing %s", action, pairs[i])
+		}
+	}
+	return nil
+}
+
+// isEnvName reports whether s is a valid shell variable name.
+func isEnvName(s string) bool {
+	for i, c := range s {
+		switch {
+		case c == '_', c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return s != ""
+}
+
+// validateSetup checks each of a list of setup actions.
+func validateSetup(actions []SetupAction) error {
+	for i := range actions {
+		if err := actions[i].Validate(); err != nil {
+			return fmt.Errorf("setup %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// ParseSetupTime reads a set_mtime time: RFC 3339, a date (2006-01-02), or an age
+// before now such as "3d ago", "36h ago" or "90m ago".
+func ParseSetupTime(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if age, ok := strings.CutSuffix(s, " ago"); ok {
+		age = strings.TrimSpace(age)
+		if days, ok := strings.CutSuffix(age, "d"); ok {
+			n, err := strconv.Atoi(days)
+			if err != nil || n < 0 {
+				return time.Time{}, fmt.Errorf("invalid age %q", age)
+			}
+			return now.AddDate(0, 0, -n), nil
+		}
+		d, err := time.ParseDuration(age)
+		if err != nil || d < 0 {
+			return time.Time{}, fmt.Errorf("invalid age %q", age)
+		}
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, s, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: want RFC 3339, YYYY-MM-DD or an age like \"3d ago\"", s)
+}
@@ -0,0 +1,83 @@
+// ABOUTME: Tests for setup action validation
+// ABOUTME: Covers one-operation-per-action, required fields, chmod modes, tmux sessions and times
+
+package content
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSetupAction_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		action SetupAction
+		want   string // Error substring; empty means valid
+	}{
+		{"mkdir", SetupAction{Mkdir: "~/x"}, ""},
+		{"copy", SetupAction{Copy: &CopyAction{From: "~/a", To: "~/b"}}, ""},
+		{"symlink", SetupAction{Symlink: &SymlinkAction{Path: "~/latest", Target: "v2"}}, ""},
+		{"octal chmod", SetupAction{Chmod: &ChmodAction{Path: "~/run.sh", Mode: "755"}}, ""},
+		{"symbolic chmod", SetupAction{Chmod: &ChmodAction{Path: "~/run.sh", Mode: "u+x,go-w"}}, ""},
+		{"env", SetupAction{Env: map[string]string{"EDITOR": "nano"}}, ""},
+		{"history", SetupAction{SeedHistory: []string{"ls", "cd /tmp"}}, ""},
+		{"tmux session", SetupAction{TmuxSession: &TmuxSessionAction{Name: "work", Windows: []TmuxWindowSetup{
+			{Name: "logs", Panes: []TmuxPaneSetup{{Cwd: "/var/log"}, {Split: "horizontal"}}},
+		}}}, ""},
+		{"age", SetupAction{SetMtime: &SetMtimeAction{Path: "~/old.log", Time: "3d ago"}}, ""},
+
+		{"empty", SetupAction{}, "empty setup action"},
+		{"two operations", SetupAction{Mkdir: "~/x", Touch: "~/y"}, "several operations (mkdir, touch)"},
+		{"copy without destination", SetupAction{Copy: &CopyAction{From: "~/a"}}, "copy: missing to"},
+		{"bad octal", SetupAction{Chmod: &ChmodAction{Path: "x", Mode: "1755"}}, `chmod: invalid mode "1755"`},
+		{"bad symbolic", SetupAction{Chmod: &ChmodAction{Path: "x", Mode: "u+q"}}, `chmod: invalid mode "u+q"`},
+		{"chown without owner", SetupAction{Chown: &ChownAction{Path: "x"}}, "chown: missing owner"},
+		{"bad env name", SetupAction{Env: map[string]string{"1X": "y"}}, `env: invalid variable name "1X"`},
+		{"blank process", SetupAction{StartProcess: &StartProcessAction{Command: " "}}, "start_process: missing command"},
+		{"unnamed session", SetupAction{TmuxSession: &TmuxSessionAction{}}, "tmux_session: missing name"},
+		{"bad split", SetupAction{TmuxSession: &TmuxSessionAction{Name: "w", Windows: []TmuxWindowSetup{
+			{Panes: []TmuxPaneSetup{{}, {Split: "diagonal"}}},
+		}}}, "split must be horizontal or vertical"},
+		{"first pane split", SetupAction{TmuxSession: &TmuxSessionAction{Name: "w", Windows: []TmuxWindowSetup{
+			{Panes: []TmuxPaneSetup{{Split: "vertical"}}},
+		}}}, "the first pane has nothing to split"},
+		{"bad time", SetupAction{SetMtime: &SetMtimeAction{Path: "x", Time: "last tuesday"}}, `invalid time "last tuesday"`},
+		{"fixture", SetupAction{Fixture: &FixtureAction{Name: "go-repo", To: "~/src/notes"}}, ""},
+		{"unknown fixture", SetupAction{Fixture: &FixtureAction{Name: "rails-app", To: "~/app"}}, `unknown fixture "rails-app"`},
+		{"fixture without destination", SetupAction{Fixture: &FixtureAction{Name: "go-repo"}}, "fixture: missing to"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.action.Validate()
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+				t.Errorf("got %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSetupTime(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"3d ago", now.AddDate(0, 0, -3)},
+		{"90m ago", now.Add(-90 * time.Minute)},
+		{"2024-01-02T03:04:05Z", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{"2024-01-02", time.Date(2024, 1, 2, 0, 0, 0, 0, time.Local)},
+	}
+	for _, tt := range tests {
+		got, err := ParseSetupTime(tt.in, now)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("ParseSetupTime(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+	if _, err := ParseSetupTime("-3d ago", now); err == nil {
+		t.Error("negative age: expected an error")
+	}
+}
@@ -0,0 +1,214 @@
+// ABOUTME: Template variables for missions, e.g. {{ pick filenames }} or {{ int 2 9 }}
+// ABOUTME: Expands a mission's setup, briefing and goal from a seeded generator for fresh replays
+
+package content
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// templatePattern matches a {{ expression }} in mission text.
+var templatePattern = regexp.MustCompile(`\{\{\s*(.*?)\s*\}\}`)
+
+// templatePools are the lists {{ pick NAME }} chooses from.
+var templatePools = map[string][]string{
+	"filenames": {"notes.txt", "report.txt", "todo.txt", "budget.csv", "draft.md", "recipes.txt", "ideas.txt", "data.json", "plan.md", "letter.txt"},
+	"dirnames":  {"archive", "backup", "drafts", "old", "photos", "reports", "scratch", "stash"},
+	"words":     {"apple", "cherry", "falcon", "harbor", "lantern", "meadow", "orbit", "pepper", "quartz", "river", "sparrow", "violet"},
+}
+
+// untemplated are the mission fields that identify it and stay fixed across instances.
+var untemplated = []string{"id", "skill_id", "level", "title", "vars"}
+
+// HasTemplates reports whether the mission uses template variables.
+func (m *YAMLMission) HasTemplates() bool {
+	if len(m.Vars) > 0 {
+		return true
+	}
+	data, err := yaml.Marshal(m)
+	return err == nil && templatePattern.Match(data)
+}
+
+// Expand returns a copy of the mission with its vars chosen and every {{ }} in its
+// setup, text and goals filled in.
+//
+// Each var is chosen once, so every {{ name }} in the mission gets the same value; a
+// var's expression may name other vars, in any order, but not itself. Anything else,
+// such as {{ pick filenames }} written straight into a field, is chosen afresh each
+// time it appears, so a value the setup and the goal must agree on belongs in vars.
+// Templates expand to text, and goals compare them as text: "{{ n }}" in
+// file_contains looks for the digits. Only a value that is just a template and
+// expands to a whole number becomes a number, so goal fields such as
+// {min: "{{ n }}"} work.
+func (m *YAMLMission) Expand(rng *rand.Rand) (YAMLMission, error) {
+	e := &expander{rng: rng, vars: make(map[string]string, len(m.Vars)), exprs: m.Vars, choosing: make(map[string]bool)}
+	names := make([]string, 0, len(m.Vars))
+	for name := range m.Vars {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if _, err := e.choose(name); err != nil {
+			return YAMLMission{}, err
+		}
+	}
+
+	// Going through a generic tree covers every field, including ones added later.
+	data, err := yaml.Marshal(m)
+	if err != nil {
+		return YAMLMission{}, err
+	}
+	var tree map[string]any
+	if err := yaml.Unmarshal(data, &tree); err != nil {
+		return YAMLMission{}, err
+	}
+	for _, key := range sortedKeys(tree) {
+		if slices.Contains(untemplated, key) {
+			continue
+		}
+		if tree[key], err = e.expandValue(tree[key]); err != nil {
+			return YAMLMission{}, fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	if data, err = yaml.Marshal(tree); err != nil {
+		return YAMLMission{}, err
+	}
+	var out YAMLMission
+	if err := yaml.Unmarshal(data, &out); err != nil {
+		return YAMLMission{}, err
+	}
+	return out, nil
+}
+
+type expander struct {
+	rng      *rand.Rand
+	vars     map[string]string // Values of the vars chosen so far
+	exprs    map[string]string // The mission's vars, by name
+	choosing map[string]bool   // Vars whose expressions are being evaluated
+}
+
+// choose returns a var's value, evaluating its expression the first time.
+func (e *expander) choose(name string) (string, error) {
+	if value, ok := e.vars[name]; ok {
+		return value, nil
+	}
+	if e.choosing[name] {
+		return "", fmt.Errorf("vars.%s refers to itself", name)
+	}
+	e.choosing[name] = true
+	value, err := e.eval(e.exprs[name])
+	if err != nil {
+		return "", fmt.Errorf("vars.%s: %w", name, err)
+	}
+	e.vars[name] = value
+	return value, nil
+}
+
+func (e *expander) expandValue(value any) (any, error) {
+	switch v := value.(type) {
+	case string:
+		return e.expandString(v)
+	case []any:
+		for i, item := range v {
+			expanded, err := e.expandValue(item)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = expanded
+		}
+	case map[string]any:
+		for _, key := range sortedKeys(v) {
+			expanded, err := e.expandValue(v[key])
+			if err != nil {
+				return nil, err
+			}
+			v[key] = expanded
+		}
+	}
+	return value, nil
+}
+
+// sortedKeys returns a map's keys in order, so a seed always draws the same values.
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// expandString fills in a string's templates. A string that is a single template
+// expanding to a whole number becomes an int.
+func (e *expander) expandString(s string) (any, error) {
+	var err error
+	out := templatePattern.ReplaceAllStringFunc(s, func(match string) string {
+		value, evalErr := e.eval(templatePattern.FindStringSubmatch(match)[1])
+		if evalErr != nil && err == nil {
+			err = fmt.Errorf("%s: %w", match, evalErr)
+		}
+		return value
+	})
+	if err != nil {
+		return nil, err
+	}
+	if loc := templatePattern.FindStringIndex(s); loc != nil && loc[0] == 0 && loc[1] == len(s) {
+		if n, convErr := strconv.Atoi(out); convErr == nil {
+			return n, nil
+		}
+	}
+	return out, nil
+}
+
+// eval evaluates one expression: a var name, "pick POOL", "pick A B C", "int MIN MAX",
+// or "word". Every pick, int and word draws a new value.
+func (e *expander) eval(expr string) (string, error) {
+	fields := strings.Fields(expr)
+	if len(fields) == 0 {
+		return "", fmt.Errorf("empty template")
+	}
+	name, args := fields[0], fields[1:]
+	if _, ok := e.exprs[name]; ok && len(args) == 0 {
+		return e.choose(name)
+	}
+	switch name {
+	case "pick":
+		options := args
+		if len(args) == 1 {
+			pool, ok := templatePools[args[0]]
+			if !ok {
+				return "", fmt.Errorf("pick: unknown list %q", args[0])
+			}
+			options = pool
+		}
+		if len(options) == 0 {
+			return "", fmt.Errorf("pick needs a list name or some options")
+		}
+		return options[e.rng.IntN(len(options))], nil
+	case "int":
+		if len(args) != 2 {
+			return "", fmt.Errorf("int needs a minimum and a maximum")
+		}
+		lo, errLo := strconv.Atoi(args[0])
+		hi, errHi := strconv.Atoi(args[1])
+		if errLo != nil || errHi != nil || hi < lo {
+			return "", fmt.Errorf("int: invalid range %s to %s", args[0], args[1])
+		}
+		return strconv.Itoa(lo + e.rng.IntN(hi-lo+1)), nil
+	case "word":
+		if len(args) != 0 {
+			return "", fmt.Errorf("word takes no arguments")
+		}
+		words := templatePools["words"]
+		return words[e.rng.IntN(len(words))], nil
+	default:
+		return "", fmt.Errorf("unknown variable or function %q", name)
+	}
+}
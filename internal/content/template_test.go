@@ -0,0 +1,158 @@
+// ABOUTME: Tests for mission template variables
+// ABOUTME: Covers vars, pick/int/word, numeric goal values, seeding and template errors
+
+package content
+
+import (
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+func templateMission() YAMLMission {
+	return YAMLMission{
+		ID:       "t",
+		Title:    "Keep {{ this }}",
+		Vars:     map[string]string{"file": "pick filenames", "n": "int 2 4"},
+		Briefing: "Copy {{ file }} {{ n }} times, then say {{ word }}.",
+		Commands: []string{"cp {{ file }} copy-{{ file }}"},
+		Setup:    []SetupAction{{Touch: "/home/{{ file }}"}, {WriteFile: &WriteFileAction{Path: "/n", Content: "{{ n }}"}}},
+		Goal: map[string]any{"and": []any{
+			map[string]any{"path_exists": "/home/copy-{{ file }}"},
+			map[string]any{"file_count": map[string]any{"glob": "/home/*", "min": "{{ n }}"}},
+			map[string]any{"pwd_equals": "/{{ pick red green }}"},
+		}},
+	}
+}
+
+func TestExpand(t *testing.T) {
+	m := templateMission()
+	got, err := m.Expand(rand.New(rand.NewPCG(1, 2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := strings.TrimPrefix(got.Setup[0].Touch, "/home/")
+	if !strings.Contains(strings.Join(templatePools["filenames"], " "), file) {
+		t.Fatalf("expected a file name from the pool, got %q", file)
+	}
+	n := got.Setup[1].WriteFile.Content
+	if n != "2" && n != "3" && n != "4" {
+		t.Errorf("expected a number from 2 to 4, got %q", n)
+	}
+	if !strings.HasPrefix(got.Briefing, "Copy "+file+" "+n+" times, then say ") || strings.Contains(got.Briefing, "{{") {
+		t.Errorf("briefing should use the same values as setup, got %q", got.Briefing)
+	}
+	if got.Commands[0] != "cp "+file+" copy-"+file {
+		t.Errorf("unexpected command %q", got.Commands[0])
+	}
+	if got.Title != m.Title || got.ID != "t" {
+		t.Errorf("id and title should stay as written, got %q %q", got.ID, got.Title)
+	}
+
+	and := got.Goal["and"].([]any)
+	if path := and[0].(map[string]any)["path_exists"]; path != "/home/copy-"+file {
+		t.Errorf("goal should use the same file as setup, got %v", path)
+	}
+	if min := and[1].(map[string]any)["file_count"].(map[string]any)["min"]; min != int(n[0]-'0') {
+		t.Errorf("a goal value that is just a number should become a number, got %#v", min)
+	}
+	if _, err := ParseGoal(got.Goal); err != nil {
+		t.Errorf("the expanded goal should parse: %v", err)
+	}
+	if m.Setup[0].Touch != "/home/{{ file }}" {
+		t.Error("Expand shouldn't change the original")
+	}
+}
+
+func TestExpand_Seeded(t *testing.T) {
+	m := templateMission()
+	briefings := make(map[string]bool)
+	for seed := range uint64(20) {
+		a, errA := m.Expand(rand.New(rand.NewPCG(seed, seed)))
+		b, errB := m.Expand(rand.New(rand.NewPCG(seed, seed)))
+		if errA != nil || errB != nil {
+			t.Fatal(errA, errB)
+		}
+		if a.Briefing != b.Briefing {
+			t.Errorf("seed %d gave %q then %q", seed, a.Briefing, b.Briefing)
+		}
+		briefings[a.Briefing] = true
+	}
+	if len(briefings) < 2 {
+		t.Error("different seeds should give different missions")
+	}
+}
+
+func TestExpand_Errors(t *testing.T) {
+	tests := []struct {
+		vars     map[string]string
+		briefing string
+		want     string
+	}{
+		{nil, "{{ nope }}", `briefing: {{ nope }}: unknown variable or function "nope"`},
+		{nil, "{{ }}", "empty template"},
+		{nil, "{{ pick colours }}", `pick: unknown list "colours"`},
+		{nil, "{{ int 5 1 }}", "int: invalid range 5 to 1"},
+		{nil, "{{ int 5 }}", "int needs a minimum and a maximum"},
+		{nil, "{{ word up }}", "word takes no arguments"},
+		{map[string]string{"x": "int a b"}, "{{ x }}", "vars.x: int: invalid range a to b"},
+		{map[string]string{"a": "b", "b": "a"}, "{{ a }}", "vars.a refers to itself"},
+	}
+	for _, tt := range tests {
+		m := YAMLMission{Vars: tt.vars, Briefing: tt.briefing}
+		_, err := m.Expand(rand.New(rand.NewPCG(0, 0)))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: got %v, want %q", tt.briefing, err, tt.want)
+		}
+	}
+}
+
+func TestExpand_VarsAgree(t *testing.T) {
+	m := YAMLMission{
+		Vars:  map[string]string{"copy": "file", "file": "pick filenames"}, // copy names a var after it
+		Setup: []SetupAction{{WriteFile: &WriteFileAction{Path: "~/{{ file }}", Content: "{{ pick red green blue }}"}}},
+		Goal: map[string]any{"and": []any{
+			map[string]any{"path_exists": "~/backup/{{ copy }}"},
+			map[string]any{"file_contains": map[string]any{"path": "~/{{ file }}", "text": "{{ pick red green blue }}"}},
+		}},
+	}
+	picksDiffer := false
+	for seed := range uint64(20) {
+		got, err := m.Expand(rand.New(rand.NewPCG(seed, seed)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		and := got.Goal["and"].([]any)
+		file := strings.TrimPrefix(got.Setup[0].WriteFile.Path, "~/")
+		if path := and[0].(map[string]any)["path_exists"]; path != "~/backup/"+file {
+			t.Errorf("seed %d: setup made %s but the goal looks for %v", seed, file, path)
+		}
+		if path := and[1].(map[string]any)["file_contains"].(map[string]any)["path"]; path != "~/"+file {
+			t.Errorf("seed %d: setup made %s but the goal reads %v", seed, file, path)
+		}
+		if and[1].(map[string]any)["file_contains"].(map[string]any)["text"] != got.Setup[0].WriteFile.Content {
+			picksDiffer = true
+		}
+	}
+	if !picksDiffer {
+		t.Error("a pick written in place should be chosen afresh each time it appears")
+	}
+}
+
+func TestHasTemplates(t *testing.T) {
+	tests := []struct {
+		mission YAMLMission
+		want    bool
+	}{
+		{YAMLMission{Briefing: "plain"}, false},
+		{YAMLMission{Briefing: "{{ word }}"}, true},
+		{YAMLMission{Vars: map[string]string{"x": "word"}}, true},
+		{YAMLMission{Goal: map[string]any{"path_exists": "/{{ word }}"}}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.mission.HasTemplates(); got != tt.want {
+			t.Errorf("HasTemplates(%+v) = %v, want %v", tt.mission, got, tt.want)
+		}
+	}
+}
@@ -28,15 +28,27 @@ type ChallengesFile struct {
 
 // YAMLChallenge represents a flashcard challenge in YAML.
 type YAMLChallenge struct {
+	ID          string   `yaml:"id"` // Stable across releases: progress is saved per challenge
 	Type        string   `yaml:"type"`
 	Prompt      string   `yaml:"prompt"`
 	Expected    string   `yaml:"expected,omitempty"`
+	Accepted    []string `yaml:"accepted,omitempty"` // Other answers that are also right
 	Hint        string   `yaml:"hint,omitempty"`
 	Explanation string   `yaml:"explanation,omitempty"`
 	Options     []string `yaml:"options,omitempty"`
 	Correct     int      `yaml:"correct,omitempty"`
 	Broken      string   `yaml:"broken,omitempty"`
 	Command     string   `yaml:"command,omitempty"`
+
+	// Context sets up the sandbox the answer is checked in, such as the files it
+	// works on; the default filesystem is used without it.
+	Context []SetupAction `yaml:"context,omitempty"`
+
+	// AcceptSameEffect also accepts any answer that, run in the sandbox set up by
+	// Context, leaves the same result as Expected.
+	AcceptSameEffect bool `yaml:"accept_same_effect,omitempty"`
+
+	Pack string `yaml:"-"` // Name of the content pack that added the challenge; empty if built in
 }
 
 // MissionsFile represents the top-level missions.yaml structure.
@@ -57,15 +69,40 @@ type YAMLMission struct {
 	Commands    []string       `yaml:"commands,omitempty"`
 	Setup       []SetupAction  `yaml:"setup,omitempty"`
 	Goal        map[string]any `yaml:"goal"`
+	Stages      []YAMLStage    `yaml:"stages,omitempty"` // Ordered objectives, replacing goal
+
+	// Vars are template variables, e.g. {name: pick filenames}, chosen afresh for each
+	// play and used elsewhere as {{ name }}. See Expand.
+	Vars map[string]string `yaml:"vars,omitempty"`
+}
+
+// YAMLStage is one objective of a multi-stage mission.
+type YAMLStage struct {
+	Briefing    string         `yaml:"briefing"`
+	Hint        string         `yaml:"hint,omitempty"`
+	Explanation string         `yaml:"explanation,omitempty"` // Shown when the stage is done
+	Setup       []SetupAction  `yaml:"setup,omitempty"`       // Applied on top of the learner's work when the stage begins
+	Goal        map[string]any `yaml:"goal"`
 }
 
 // SetupAction represents a single setup operation.
 // Only one field should be set per action.
 type SetupAction struct {
-	Mkdir     string           `yaml:"mkdir,omitempty"`
-	Cd        string           `yaml:"cd,omitempty"`
-	Touch     string           `yaml:"touch,omitempty"`
-	WriteFile *WriteFileAction `yaml:"write_file,omitempty"`
+	Mkdir        string              `yaml:"mkdir,omitempty"`
+	Cd           string              `yaml:"cd,omitempty"`
+	Touch        string              `yaml:"touch,omitempty"`
+	WriteFile    *WriteFileAction    `yaml:"write_file,omitempty"`
+	Rm           string              `yaml:"rm,omitempty"` // Removes a file, link or whole directory
+	Copy         *CopyAction         `yaml:"copy,omitempty"`
+	Symlink      *SymlinkAction      `yaml:"symlink,omitempty"`
+	Chmod        *ChmodAction        `yaml:"chmod,omitempty"`
+	Chown        *ChownAction        `yaml:"chown,omitempty"`
+	Env          map[string]string   `yaml:"env,omitempty"`          // Shell variables, e.g. {EDITOR: nano}
+	SeedHistory  []string            `yaml:"seed_history,omitempty"` // Commands already in the shell history, oldest first
+	TmuxSession  *TmuxSessionAction  `yaml:"tmux_session,omitempty"`
+	StartProcess *StartProcessAction `yaml:"start_process,omitempty"`
+	SetMtime     *SetMtimeAction     `yaml:"set_mtime,omitempty"`
+	Fixture      *FixtureAction      `yaml:"fixture,omitempty"`
 }
 
 // WriteFileAction represents a write_file setup operation.
@@ -73,3 +110,69 @@ type WriteFileAction struct {
 	Path    string `yaml:"path"`
 	Content string `yaml:"content"`
 }
+
+// CopyAction copies a file or directory tree, like cp -r.
+type CopyAction struct {
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+}
+
+// SymlinkAction creates Path as a symbolic link to Target, like ln -s Target Path.
+// A relative target is resolved from the link's directory.
+type SymlinkAction struct {
+	Path   string `yaml:"path"`
+	Target string `yaml:"target"`
+}
+
+// ChmodAction sets a path's permissions; Mode is octal ("600") or symbolic ("u+x").
+type ChmodAction struct {
+	Path string `yaml:"path"`
+	Mode string `yaml:"mode"`
+}
+
+// ChownAction sets a path's owner and, optionally, its group.
+type ChownAction struct {
+	Path  string `yaml:"path"`
+	Owner string `yaml:"owner"`
+	Group string `yaml:"group,omitempty"`
+}
+
+// TmuxSessionAction starts a tmux session before the learner arrives.
+type TmuxSessionAction struct {
+	Name    string            `yaml:"name"`
+	Attach  bool              `yaml:"attach,omitempty"` // Start with the learner inside the session
+	Windows []TmuxWindowSetup `yaml:"windows,omitempty"`
+}
+
+// TmuxWindowSetup is a window of a tmux_session; with no panes it has one, in home.
+type TmuxWindowSetup struct {
+	Name  string          `yaml:"name,omitempty"`
+	Panes []TmuxPaneSetup `yaml:"panes,omitempty"`
+}
+
+// TmuxPaneSetup is a pane of a tmux_session window. Each pane after the first splits
+// the one before it.
+type TmuxPaneSetup struct {
+	Cwd     string `yaml:"cwd,omitempty"`
+	Split   string `yaml:"split,omitempty"`   // "horizontal" (side by side) or "vertical" (the default, stacked)
+	Command string `yaml:"command,omitempty"` // Left running in the pane
+}
+
+// StartProcessAction leaves a process running for the learner to find, e.g. with ps.
+type StartProcessAction struct {
+	Command string `yaml:"command"`
+}
+
+// SetMtimeAction sets a path's modification time: an RFC 3339 time, a date such as
+// 2024-03-01, or an age such as "3d ago" or "90m ago".
+type SetMtimeAction struct {
+	Path string `yaml:"path"`
+	Time string `yaml:"time"`
+}
+
+// FixtureAction copies a tree from the fixture library (see LoadFixture) into To,
+// which is created if needed; entries already there are replaced.
+type FixtureAction struct {
+	Name string `yaml:"name"`
+	To   string `yaml:"to"`
+}
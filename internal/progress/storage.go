@@ -5,31 +5,70 @@ package progress
 
 import (
 	"encoding/json"
+	"fmt"
+	"log"
 	"os"
 	"path/filepath"
 	"time"
 
+	"github.com/2389-research/turtle/internal/content"
 	"github.com/2389-research/turtle/internal/skills"
 	"github.com/2389-research/turtle/internal/srs"
 )
 
+// CurrentVersion is the save format Save writes. Version 0 kept one card per
+// skill; version 1 keeps one per challenge or mission; version 2 adds the
+// scheduler and FSRS memory state. Saves from before version 2 were all
+// scheduled by SM-2, which a missing scheduler still means.
+const CurrentVersion = 2
+
 // SaveData is the serializable representation of user progress.
 type SaveData struct {
+	Version       int                  `json:"version"`
 	XP            int                  `json:"xp"`
 	Level         int                  `json:"level"`
 	CurrentStreak int                  `json:"current_streak"`
 	BestStreak    int                  `json:"best_streak"`
 	LastActive    time.Time            `json:"last_active"`
 	Cards         map[string]*CardData `json:"cards"`
+	Scheduler     *SchedulerData       `json:"scheduler,omitempty"`
+	Reviews       []ReviewData         `json:"reviews,omitempty"`
+	Limits        *LimitsData          `json:"limits,omitempty"`
+}
+
+// LimitsData records the learner's daily caps on new cards and reviews.
+type LimitsData struct {
+	NewPerDay     int `json:"new_per_day"`
+	ReviewsPerDay int `json:"reviews_per_day"`
+}
+
+// SchedulerData records which scheduler the learner chose and its settings.
+type SchedulerData struct {
+	Name            string    `json:"name"`
+	TargetRetention float64   `json:"target_retention,omitempty"`
+	Weights         []float64 `json:"weights,omitempty"`
 }
 
 // CardData is the serializable representation of an SRS card.
 type CardData struct {
+	ID           string    `json:"id"`
 	SkillID      string    `json:"skill_id"`
 	EaseFactor   float64   `json:"ease_factor"`
 	Interval     int       `json:"interval"`
 	Repetitions  int       `json:"repetitions"`
 	LastReviewed time.Time `json:"last_reviewed"`
+	Stability    float64   `json:"stability,omitempty"`
+	Difficulty   float64   `json:"difficulty,omitempty"`
+}
+
+// ReviewData is the serializable representation of one review in the log.
+type ReviewData struct {
+	Time        time.Time `json:"time"`
+	CardID      string    `json:"card_id"`
+	Grade       int       `json:"grade"`
+	ResponseMs  int64     `json:"response_ms"`
+	ElapsedDays float64   `json:"elapsed_days"`
+	Interval    int       `json:"interval"`
 }
 
 // GetDefaultPath returns the default save file location.
@@ -58,6 +97,7 @@ func Save(progress *skills.UserProgress, path string) error {
 
 	// Convert to saveable format
 	data := SaveData{
+		Version:       CurrentVersion,
 		XP:            progress.XP,
 		Level:         progress.Level,
 		CurrentStreak: progress.CurrentStreak,
@@ -66,15 +106,37 @@ func Save(progress *skills.UserProgress, path string) error {
 		Cards:         make(map[string]*CardData),
 	}
 
-	for skillID, card := range progress.Cards {
-		data.Cards[skillID] = &CardData{
+	for cardID, card := range progress.Cards {
+		data.Cards[cardID] = &CardData{
+			ID:           card.ID,
 			SkillID:      card.SkillID,
 			EaseFactor:   card.EaseFactor,
 			Interval:     card.Interval,
 			Repetitions:  card.Repetitions,
 			LastReviewed: card.LastReviewed,
+			Stability:    card.Stability,
+			Difficulty:   card.Difficulty,
 		}
 	}
+	for _, r := range progress.Reviews {
+		data.Reviews = append(data.Reviews, ReviewData{
+			Time:        r.Time,
+			CardID:      r.CardID,
+			Grade:       r.Grade,
+			ResponseMs:  r.ResponseTime.Milliseconds(),
+			ElapsedDays: r.ElapsedDays,
+			Interval:    r.Interval,
+		})
+	}
+	data.Limits = &LimitsData{
+		NewPerDay:     progress.Limits.NewPerDay,
+		ReviewsPerDay: progress.Limits.ReviewsPerDay,
+	}
+	data.Scheduler = &SchedulerData{Name: progress.Scheduler.Name()}
+	if fsrs, ok := progress.Scheduler.(*srs.FSRS); ok {
+		data.Scheduler.TargetRetention = fsrs.TargetRetention
+		data.Scheduler.Weights = fsrs.Weights
+	}
 
 	// Marshal to JSON
 	jsonData, err := json.MarshalIndent(data, "", "  ")
@@ -91,12 +153,14 @@ func Save(progress *skills.UserProgress, path string) error {
 	return os.Rename(tmpPath, path)
 }
 
-// Load reads user progress from disk.
-func Load(path string) (*skills.UserProgress, error) {
+// Load reads user progress from disk. The progress tells the time by clock.
+func Load(path string, clock srs.Clock) (*skills.UserProgress, error) {
 	// Check if file exists
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		// Return fresh progress
-		return skills.NewUserProgress(), nil
+		progress := skills.NewUserProgress()
+		progress.Clock = clock
+		return progress, nil
 	}
 
 	// Read file
@@ -110,23 +174,107 @@ func Load(path string) (*skills.UserProgress, error) {
 	if err := json.Unmarshal(jsonData, &data); err != nil {
 		return nil, err
 	}
+	if data.Version == 0 {
+		challenges, err := content.GetRawChallenges()
+		if err != nil {
+			return nil, fmt.Errorf("migrating %s: %w", path, err)
+		}
+		migrateSkillCards(&data, challenges)
+	}
+	scheduler, err := loadScheduler(data.Scheduler)
+	if err != nil {
+		// The cards and reviews are still good; only the choice of scheduler is lost.
+		log.Printf("%s: %v, falling back to %s", path, err, srs.SchedulerSM2)
+		scheduler = srs.SM2{}
+	}
 
 	// Convert back to UserProgress
 	progress := skills.NewUserProgress()
+	progress.Clock = clock
 	progress.XP = data.XP
 	progress.Level = data.Level
 	progress.CurrentStreak = data.CurrentStreak
 	progress.BestStreak = data.BestStreak
 	progress.LastActive = data.LastActive
+	progress.Scheduler = scheduler
+	if data.Limits != nil {
+		progress.Limits = skills.SessionLimits{NewPerDay: data.Limits.NewPerDay, ReviewsPerDay: data.Limits.ReviewsPerDay}
+	}
 
-	for skillID, cardData := range data.Cards {
-		card := srs.NewCard(skillID)
+	for cardID, cardData := range data.Cards {
+		card := srs.NewCard(cardID, cardData.SkillID)
 		card.EaseFactor = cardData.EaseFactor
 		card.Interval = cardData.Interval
 		card.Repetitions = cardData.Repetitions
 		card.LastReviewed = cardData.LastReviewed
-		progress.Cards[skillID] = card
+		card.Stability = cardData.Stability
+		card.Difficulty = cardData.Difficulty
+		progress.Cards[cardID] = card
+	}
+
+	for _, r := range data.Reviews {
+		progress.Reviews = append(progress.Reviews, srs.ReviewLog{
+			Time:         r.Time,
+			CardID:       r.CardID,
+			Grade:        r.Grade,
+			ResponseTime: time.Duration(r.ResponseMs) * time.Millisecond,
+			ElapsedDays:  r.ElapsedDays,
+			Interval:     r.Interval,
+		})
 	}
 
 	return progress, nil
 }
+
+// Backup moves an unreadable progress file out of the way, so fresh progress saved
+// in its place doesn't overwrite it. It returns where the file went.
+func Backup(path string, clock srs.Clock) (string, error) {
+	backup := fmt.Sprintf("%s.%s.bak", path, clock.Now().Format("20060102-150405"))
+	if err := os.Rename(path, backup); err != nil {
+		return "", err
+	}
+	return backup, nil
+}
+
+// loadScheduler rebuilds the saved scheduler; with none saved it is SM-2.
+func loadScheduler(data *SchedulerData) (srs.Scheduler, error) {
+	if data == nil {
+		return srs.SM2{}, nil
+	}
+	scheduler, err := srs.NewScheduler(data.Name, data.TargetRetention)
+	if err != nil {
+		return nil, err
+	}
+	if fsrs, ok := scheduler.(*srs.FSRS); ok && len(data.Weights) > 0 {
+		if len(data.Weights) != len(srs.DefaultFSRSWeights) {
+			return nil, fmt.Errorf("fsrs has %d weights, want %d", len(data.Weights), len(srs.DefaultFSRSWeights))
+		}
+		fsrs.Weights = data.Weights
+	}
+	return scheduler, nil
+}
+
+// migrateSkillCards converts a version 0 save, which kept one card per skill, to
+// one card per challenge: each challenge of a skill starts from a copy of that
+// skill's card, so its schedule and strength carry over. A skill without
+// challenges keeps its card under the skill's ID.
+func migrateSkillCards(data *SaveData, challenges map[string][]content.YAMLChallenge) {
+	cards := make(map[string]*CardData)
+	for skillID, old := range data.Cards {
+		if old.SkillID == "" {
+			old.SkillID = skillID
+		}
+		if len(challenges[skillID]) == 0 {
+			old.ID = skillID
+			cards[skillID] = old
+			continue
+		}
+		for _, c := range challenges[skillID] {
+			card := *old
+			card.ID = c.ID
+			cards[c.ID] = &card
+		}
+	}
+	data.Cards = cards
+	data.Version = CurrentVersion
+}
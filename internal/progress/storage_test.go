@@ -4,11 +4,16 @@
 package progress
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/2389-research/turtle/internal/content"
 	"github.com/2389-research/turtle/internal/skills"
+	"github.com/2389-research/turtle/internal/srs"
 )
 
 func TestSaveAndLoad(t *testing.T) {
@@ -22,10 +27,12 @@ func TestSaveAndLoad(t *testing.T) {
 	savePath := filepath.Join(tmpDir, "progress.json")
 
 	// Create progress with some data
+	clock := srs.NewFixedClock(time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC))
 	original := skills.NewUserProgress()
+	original.Clock = clock
 	original.AddXP(150)
-	original.Practice("pwd", 5)
-	original.Practice("ls", 4)
+	original.Practice("pwd", "pwd-print", 5, time.Second)
+	original.Practice("ls", "ls-list", 4, 2*time.Second)
 	original.RecordActivity()
 
 	// Save it
@@ -34,11 +41,15 @@ func TestSaveAndLoad(t *testing.T) {
 		t.Fatalf("failed to save: %v", err)
 	}
 
-	// Load it back
-	loaded, err := Load(savePath)
+	// Load it back, a week later
+	clock.AdvanceDays(7)
+	loaded, err := Load(savePath, clock)
 	if err != nil {
 		t.Fatalf("failed to load: %v", err)
 	}
+	if got, want := loaded.GetStrength("pwd"), original.GetStrength("pwd"); got != want {
+		t.Errorf("strength mismatch a week on: got %.3f, want %.3f", got, want)
+	}
 
 	// Verify data
 	if loaded.XP != original.XP {
@@ -52,21 +63,181 @@ func TestSaveAndLoad(t *testing.T) {
 	}
 
 	// Check card data
-	pwdCard := loaded.GetCard("pwd")
+	pwdCard := loaded.GetCard("pwd-print")
 	if pwdCard == nil {
-		t.Fatal("pwd card not found after load")
+		t.Fatal("pwd-print card not found after load")
+	}
+	if pwdCard.ID != "pwd-print" || pwdCard.SkillID != "pwd" {
+		t.Errorf("card identity mismatch: got %s/%s", pwdCard.SkillID, pwdCard.ID)
+	}
+
+	// Check the review log
+	if len(loaded.Reviews) != 2 {
+		t.Fatalf("expected 2 logged reviews, got %d", len(loaded.Reviews))
+	}
+	if r := loaded.Reviews[1]; r.CardID != "ls-list" || r.Grade != 4 || r.ResponseTime != 2*time.Second ||
+		r.Interval != 1 || !r.Time.Equal(original.Reviews[1].Time) {
+		t.Errorf("review log mismatch: got %+v, want %+v", r, original.Reviews[1])
 	}
 	if pwdCard.Repetitions == 0 {
 		t.Error("pwd card should have repetitions")
 	}
 }
 
+func TestLoadMigratesSkillCards(t *testing.T) {
+	savePath := filepath.Join(t.TempDir(), "progress.json")
+	reviewed := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	old := `{"xp": 40, "level": 1, "cards": {
+		"pwd": {"skill_id": "pwd", "ease_factor": 2.6, "interval": 6, "repetitions": 2, "last_reviewed": "2026-03-01T09:00:00Z"},
+		"no-challenges": {"skill_id": "no-challenges", "ease_factor": 2.5, "interval": 1, "repetitions": 1, "last_reviewed": "2026-03-01T09:00:00Z"}
+	}}`
+	if err := os.WriteFile(savePath, []byte(old), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(savePath, srs.SystemClock{})
+	if err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+
+	challenges, err := content.GetRawChallenges()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range challenges["pwd"] {
+		card := loaded.GetCard(c.ID)
+		if card == nil {
+			t.Errorf("expected a card for challenge %s", c.ID)
+			continue
+		}
+		if card.SkillID != "pwd" || card.Interval != 6 || card.Repetitions != 2 || !card.LastReviewed.Equal(reviewed) {
+			t.Errorf("card %s did not carry over the skill's schedule: %+v", c.ID, card)
+		}
+	}
+	if loaded.GetCard("pwd") != nil {
+		t.Error("expected the per-skill pwd card to be replaced by per-challenge cards")
+	}
+	if card := loaded.GetCard("no-challenges"); card == nil || card.Repetitions != 1 {
+		t.Errorf("expected a skill without challenges to keep its card, got %+v", card)
+	}
+
+	// Saving writes the new format, which loads without migrating again
+	if err := Save(loaded, savePath); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(savePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), fmt.Sprintf(`"version": %d`, CurrentVersion)) {
+		t.Errorf("expected saved version %d in %s", CurrentVersion, data)
+	}
+}
+
+func TestSaveAndLoadSettings(t *testing.T) {
+	savePath := filepath.Join(t.TempDir(), "progress.json")
+
+	original := skills.NewUserProgress()
+	original.Scheduler = srs.NewFSRS(0.85)
+	original.Limits = skills.SessionLimits{NewPerDay: 5, ReviewsPerDay: 40}
+	original.Practice("pwd", "pwd-print", 4, time.Second)
+	if err := Save(original, savePath); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(savePath, srs.SystemClock{})
+	if err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+	fsrs, ok := loaded.Scheduler.(*srs.FSRS)
+	if !ok {
+		t.Fatalf("expected the FSRS scheduler, got %s", loaded.Scheduler.Name())
+	}
+	if fsrs.TargetRetention != 0.85 {
+		t.Errorf("expected target retention 0.85, got %g", fsrs.TargetRetention)
+	}
+	if loaded.Limits != original.Limits {
+		t.Errorf("limits mismatch: got %+v, want %+v", loaded.Limits, original.Limits)
+	}
+	want := original.GetCard("pwd-print")
+	if got := loaded.GetCard("pwd-print"); got.Stability != want.Stability || got.Difficulty != want.Difficulty {
+		t.Errorf("FSRS state mismatch: got %.3f/%.3f, want %.3f/%.3f", got.Stability, got.Difficulty, want.Stability, want.Difficulty)
+	}
+
+	// A save from before schedulers were saved is scheduled by SM-2
+	if err := os.WriteFile(savePath, []byte(`{"version": 1, "level": 1, "cards": {}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err = Load(savePath, srs.SystemClock{})
+	if err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+	if loaded.Scheduler.Name() != srs.SchedulerSM2 {
+		t.Errorf("expected SM-2 for an old save, got %s", loaded.Scheduler.Name())
+	}
+	if loaded.Limits != skills.DefaultSessionLimits {
+		t.Errorf("expected the default limits for an old save, got %+v", loaded.Limits)
+	}
+}
+
+func TestLoadBadScheduler(t *testing.T) {
+	savePath := filepath.Join(t.TempDir(), "progress.json")
+	tests := []struct {
+		name      string
+		scheduler string
+	}{
+		{"unknown scheduler", `{"name": "leitner"}`},
+		{"wrong weight count", `{"name": "fsrs", "target_retention": 0.9, "weights": [1, 2, 3]}`},
+	}
+	for _, tt := range tests {
+		save := `{"version": 2, "level": 3, "xp": 250, "cards": {"pwd-print": {"id": "pwd-print", "skill_id": "pwd", "ease_factor": 2.5, "interval": 6, "repetitions": 2}}, "scheduler": ` + tt.scheduler + `}`
+		if err := os.WriteFile(savePath, []byte(save), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := Load(savePath, srs.SystemClock{})
+		if err != nil {
+			t.Fatalf("%s: failed to load: %v", tt.name, err)
+		}
+		if loaded.Scheduler.Name() != srs.SchedulerSM2 {
+			t.Errorf("%s: expected SM-2, got %s", tt.name, loaded.Scheduler.Name())
+		}
+		if loaded.XP != 250 || loaded.GetCard("pwd-print").Interval != 6 {
+			t.Errorf("%s: expected the rest of the progress to load, got %d XP and %+v", tt.name, loaded.XP, loaded.GetCard("pwd-print"))
+		}
+	}
+}
+
+func TestBackup(t *testing.T) {
+	savePath := filepath.Join(t.TempDir(), "progress.json")
+	if err := os.WriteFile(savePath, []byte("not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	clock := srs.NewFixedClock(time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC))
+	backup, err := Backup(savePath, clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := savePath + ".20260302-090000.bak"; backup != want {
+		t.Errorf("expected the backup at %s, got %s", want, backup)
+	}
+	if data, err := os.ReadFile(backup); err != nil || string(data) != "not json" {
+		t.Errorf("expected the backup to keep the file, got %q, %v", data, err)
+	}
+	if _, err := os.Stat(savePath); !os.IsNotExist(err) {
+		t.Errorf("expected the progress file moved away, got %v", err)
+	}
+}
+
 func TestLoadNonExistent(t *testing.T) {
 	// Loading non-existent file should return fresh progress
-	progress, err := Load("/nonexistent/path/progress.json")
+	clock := srs.NewFixedClock(time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC))
+	progress, err := Load("/nonexistent/path/progress.json", clock)
 	if err != nil {
 		t.Fatalf("load should not error on missing file: %v", err)
 	}
+	if progress.Clock != clock {
+		t.Error("expected fresh progress to tell the time by the given clock")
+	}
 	if progress.Level != 1 {
 		t.Errorf("expected fresh progress with level 1, got %d", progress.Level)
 	}
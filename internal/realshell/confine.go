@@ -0,0 +1,126 @@
+// ABOUTME: Keeps real-shell practice inside the temporary root standing in for "/"
+// ABOUTME: Maps absolute paths in commands into the root, and refuses ones that would leave it
+
+package realshell
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/2389-research/turtle/internal/sandbox"
+)
+
+// Bash isn't confined to the temporary root: an absolute path, or enough ".." to climb
+// out of the root, reaches the host's own files. So absolute paths in a command are
+// moved under the root, as the mission's own files were, and commands that would
+// still leave it are refused before bash sees them. This guards against slips, not
+// against a learner determined to get out; the simulator is the sandbox.
+
+// harmless are the absolute paths commands may name as they are: they lead nowhere.
+var harmless = []string{"/dev/null"}
+
+// wordBreaks are the characters that end a word, as far as finding paths goes.
+const wordBreaks = " \t\n;&|<>()`\"'="
+
+// outsideRoot returns the first word of a mission's reference commands that would
+// take bash out of the root, or "" if they stay in it.
+func outsideRoot(m *sandbox.Mission) string {
+	for _, command := range m.Commands {
+		if word := climbing(command, learnerHome); word != "" {
+			return word
+		}
+	}
+	return ""
+}
+
+// mapPaths rewrites a command line for bash, run from the mission directory pwd:
+// each absolute path moves under the root, so "/var/log" is the mission's /var/log.
+// It returns the first word it can't map, if any: an absolute path whose top
+// directory the mission doesn't have, which is more likely a pattern or the host's
+// own files than a slip, or a path whose ".." climbs above the root.
+func (s *Shell) mapPaths(input, pwd string) (string, string) {
+	if word := climbing(input, pwd); word != "" {
+		return "", word
+	}
+	var b strings.Builder
+	for i := 0; i < len(input); i++ {
+		startsWord := i == 0 || strings.IndexByte(wordBreaks, input[i-1]) >= 0
+		if input[i] != '/' || !startsWord {
+			b.WriteByte(input[i])
+			continue
+		}
+		end := i + strings.IndexAny(input[i:]+" ", wordBreaks)
+		word := input[i:end]
+		switch {
+		case slices.Contains(harmless, path.Clean(word)):
+			b.WriteString(word)
+		case s.inRoot(word):
+			// Cleaned, so ".." can't climb from the root's "/" to the host's.
+			b.WriteString(s.Root + path.Clean(word))
+			if strings.HasSuffix(word, "/") && word != "/" {
+				b.WriteByte('/')
+			}
+		default:
+			return "", word
+		}
+		i = end - 1
+	}
+	return b.String(), ""
+}
+
+// inRoot reports whether an absolute mission path starts in a directory the mission
+// has, or is "/" itself.
+func (s *Shell) inRoot(p string) bool {
+	top, _, _ := strings.Cut(strings.TrimPrefix(path.Clean(p), "/"), "/")
+	if top == "" {
+		return true
+	}
+	info, err := os.Lstat(filepath.Join(s.Root, top))
+	return err == nil && info.IsDir()
+}
+
+// missionText puts host paths in bash's output back in mission terms.
+func (s *Shell) missionText(output string) string {
+	output = strings.ReplaceAll(output, s.Root+"/", "/")
+	return strings.ReplaceAll(output, s.Root, "/")
+}
+
+// climbing returns the first word of a command line whose ".." would climb above
+// the root, run from the mission directory pwd, or "" if there is none.
+func climbing(input, pwd string) string {
+	words := strings.FieldsFunc(input, func(r rune) bool { return strings.ContainsRune(wordBreaks, r) })
+	for _, word := range words {
+		if !strings.HasPrefix(word, "/") && strings.Contains(word, "..") && climbsOut(word, pwd) {
+			return word
+		}
+	}
+	return ""
+}
+
+// climbsOut reports whether a relative path climbs above "/" from dir. ~ and $HOME
+// start from the learner's home.
+func climbsOut(word, dir string) bool {
+	for _, home := range []string{"~", "$HOME", "${HOME}"} {
+		if rest, ok := strings.CutPrefix(word, home); ok && (rest == "" || rest[0] == '/') {
+			word, dir = strings.TrimPrefix(rest, "/"), learnerHome
+			break
+		}
+	}
+	depth := len(strings.FieldsFunc(dir, func(r rune) bool { return r == '/' }))
+	for _, part := range strings.Split(word, "/") {
+		switch part {
+		case "", ".":
+		case "..":
+			if depth == 0 {
+				return true
+			}
+			depth--
+		default:
+			depth++
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+// ABOUTME: Goal evaluation against a real shell's files
+// ABOUTME: Implements content.GoalEvaluator by looking inside the shell's temporary root
+
+package realshell
+
+import (
+	"os"
+
+	"github.com/2389-research/turtle/internal/content"
+)
+
+// Shell implements content.GoalEvaluator so mission goals can be checked against it.
+var _ content.GoalEvaluator = (*Shell)(nil)
+
+// Pwd returns bash's working directory, in mission terms.
+func (s *Shell) Pwd() string {
+	return s.learnerPath(s.pwd)
+}
+
+// Exists reports whether a mission path exists.
+func (s *Shell) Exists(path string) bool {
+	_, err := os.Stat(s.HostPath(path))
+	return err == nil
+}
+
+// IsDir reports whether a mission path is a directory.
+func (s *Shell) IsDir(path string) bool {
+	info, err := os.Stat(s.HostPath(path))
+	return err == nil && info.IsDir()
+}
+
+// ReadFile returns the contents of a mission path.
+func (s *Shell) ReadFile(path string) (string, error) {
+	data, err := os.ReadFile(s.HostPath(path))
+	return string(data), err
+}
+
+// Stat describes a mission path without following a final symlink.
+func (s *Shell) Stat(path string) (content.FileInfo, bool) {
+	return statHost(s.HostPath(path))
+}
+
+// ListDir returns the names in a mission directory.
+func (s *Shell) ListDir(path string) ([]string, error) {
+	return listHost(s.HostPath(path))
+}
+
+// statHost describes a host path for goal evaluation.
+func statHost(host string) (content.FileInfo, bool) {
+	info, err := os.Lstat(host)
+	if err != nil {
+		return content.FileInfo{}, false
+	}
+	return content.FileInfo{
+		IsDir:     info.IsDir(),
+		IsSymlink: info.Mode()&os.ModeSymlink != 0,
+		Mode:      info.Mode().Perm(),
+		ModTime:   info.ModTime(),
+	}, true
+}
+
+// listHost returns the names in a host directory.
+func listHost(host string) ([]string, error) {
+	entries, err := os.ReadDir(host)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names, nil
+}
+
+// LastCommand returns the most recent command sent to bash.
+func (s *Shell) LastCommand() string {
+	return s.lastCommand
+}
+
+// CommandHistory returns the commands sent to bash. Output is what the terminal showed,
+// so it includes anything the command wrote to stderr.
+func (s *Shell) CommandHistory() []content.CommandRecord {
+	return s.history
+}
+
+// TmuxOperations returns nothing: tmux missions run in the simulator or real tmux.
+func (s *Shell) TmuxOperations() []content.TmuxOperation {
+	return nil
+}
+
+// TmuxBuffer reports that there are no paste buffers.
+func (s *Shell) TmuxBuffer(string) (string, bool) {
+	return "", false
+}
+
+// TmuxSnapshot reports that no tmux server is running.
+func (s *Shell) TmuxSnapshot() *content.TmuxSnapshot {
+	return nil
+}
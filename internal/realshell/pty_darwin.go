@@ -0,0 +1,39 @@
+// ABOUTME: Pseudo-terminal allocation on macOS
+// ABOUTME: Opens /dev/ptmx, grants and unlocks it and asks the kernel for the slave's name
+
+package realshell
+
+import (
+	"bytes"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// openPTY returns the master side of a new pseudo-terminal and the path of its slave.
+func openPTY() (*os.File, string, error) {
+	fd, err := unix.Open("/dev/ptmx", unix.O_RDWR|unix.O_NOCTTY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, "", err
+	}
+	master := os.NewFile(uintptr(fd), "/dev/ptmx")
+	if err := unix.IoctlSetInt(fd, unix.TIOCPTYGRANT, 0); err != nil {
+		_ = master.Close()
+		return nil, "", err
+	}
+	if err := unix.IoctlSetInt(fd, unix.TIOCPTYUNLK, 0); err != nil {
+		_ = master.Close()
+		return nil, "", err
+	}
+	// TIOCPTYGNAME fills a 128-byte buffer with the slave's path.
+	buf := make([]byte, 128)
+	//nolint:staticcheck // x/sys has no wrapper for ioctls that write a string
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), uintptr(unix.TIOCPTYGNAME),
+		uintptr(unsafe.Pointer(&buf[0]))); errno != 0 {
+		_ = master.Close()
+		return nil, "", errno
+	}
+	return master, string(buf[:bytes.IndexByte(buf, 0)]), nil
+}
@@ -0,0 +1,16 @@
+//go:build !linux && !darwin
+
+// ABOUTME: Pseudo-terminal stub for platforms without /dev/ptmx support here
+// ABOUTME: Real-shell missions report themselves unavailable instead of failing to build
+
+package realshell
+
+import (
+	"errors"
+	"os"
+)
+
+// openPTY is not supported on this platform.
+func openPTY() (*os.File, string, error) {
+	return nil, "", errors.New("real shell missions need Linux or macOS")
+}
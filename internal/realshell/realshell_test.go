@@ -0,0 +1,267 @@
+// ABOUTME: Tests for the real-shell mission backend
+// ABOUTME: Runs bash in a PTY when installed and checks goals against the materialised files
+
+package realshell
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/2389-research/turtle/internal/content"
+	"github.com/2389-research/turtle/internal/sandbox"
+)
+
+// startShell starts a shell for a mission, skipping if bash isn't installed.
+func startShell(t *testing.T, m *sandbox.Mission) *Shell {
+	t.Helper()
+	if !Available() {
+		t.Skip("bash is not installed")
+	}
+	s, err := Start(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+func TestShell_MaterialisesSetup(t *testing.T) {
+	s := startShell(t, &sandbox.Mission{Setup: func(fs *sandbox.Filesystem) {
+		_ = fs.Mkdir("/home/learner/projects/app")
+		_ = fs.WriteFile("/home/learner/projects/app/.env", "DEBUG=1\n")
+		_ = fs.Cd("/home/learner/projects")
+	}})
+
+	if got := s.Pwd(); got != "/home/learner/projects" {
+		t.Errorf("expected bash to start where setup left off, got %q", got)
+	}
+	if got := s.Execute("ls -A app").Output; got != ".env" {
+		t.Errorf("expected the hidden file to be a regular file, got %q", got)
+	}
+	if got := s.Execute("cat ~/readme.txt").Output; !strings.HasPrefix(got, "Welcome to the terminal!") {
+		t.Errorf("the default filesystem should be materialised, got %q", got)
+	}
+}
+
+func TestShell_SetupActions(t *testing.T) {
+	s := startShell(t, &sandbox.Mission{SetupActions: []content.SetupAction{
+		{WriteFile: &content.WriteFileAction{Path: "~/releases/v2/app", Content: "v2\n"}},
+		{Symlink: &content.SymlinkAction{Path: "~/current", Target: "/home/learner/releases/v2"}},
+		{Env: map[string]string{"EDITOR": "nano", "HOME": "/root"}},
+		{SeedHistory: []string{"ssh prod"}},
+	}})
+
+	if got := s.Execute("cat ~/current/app").Output; got != "v2" {
+		t.Errorf("an absolute link should point inside the root, got %q", got)
+	}
+	if got := s.Execute("echo $EDITOR $HOME").Output; got != "nano /home/learner" {
+		t.Errorf("setup may add variables but not move HOME, got %q", got)
+	}
+	if got := s.Execute("history").Output; !strings.Contains(got, "ssh prod") {
+		t.Errorf("history should include the seeded command, got %q", got)
+	}
+
+	if _, err := Start(&sandbox.Mission{SetupActions: []content.SetupAction{
+		{StartProcess: &content.StartProcessAction{Command: "sleep 100"}},
+	}}); err == nil || !strings.Contains(err.Error(), "only works in the simulator") {
+		t.Errorf("start_process should keep the mission in the simulator, got %v", err)
+	}
+}
+
+func TestShell_Execute(t *testing.T) {
+	s := startShell(t, &sandbox.Mission{})
+
+	if result := s.Execute("echo hello"); !result.Success || result.Output != "hello" {
+		t.Errorf("expected hello, got %+v", result)
+	}
+	if result := s.Execute("ls nowhere"); result.Success || !strings.Contains(result.Error, "No such file") {
+		t.Errorf("a failing command should report its stderr as an error, got %+v", result)
+	}
+	if result := s.Execute("false"); result.Error != "exit status 1" {
+		t.Errorf("a silent failure should report its status, got %+v", result)
+	}
+	if result := s.Execute("cd documents && pwd"); result.Output != "/home/learner/documents" {
+		t.Errorf("bash should run in the temporary root, got %+v", result)
+	}
+	if got := s.Location(); got != "~/documents" {
+		t.Errorf("expected ~/documents, got %q", got)
+	}
+
+	history := s.CommandHistory()
+	if len(history) != 4 || history[0].Output != "hello" || history[2].ExitCode != 1 || history[3].ExitCode != 0 {
+		t.Errorf("unexpected command history %+v", history)
+	}
+}
+
+func TestShell_StaysInRoot(t *testing.T) {
+	var absolute *sandbox.Mission
+	for _, m := range sandbox.GetMissionsForSkill("cd") {
+		if m.ID == "1.2-absolute-path" {
+			absolute = m
+		}
+	}
+	if absolute == nil {
+		t.Fatal("mission 1.2-absolute-path not found")
+	}
+	s := startShell(t, absolute)
+	var result sandbox.MissionResult
+	for _, command := range absolute.Commands {
+		result = s.Execute(command)
+	}
+	if !result.Completed {
+		t.Errorf("a mission using absolute paths should run in the root, got %+v", result)
+	}
+
+	s = startShell(t, &sandbox.Mission{})
+	if result := s.Execute("cd /var/log && touch x && pwd"); !result.Success || result.Output != "/var/log" {
+		t.Errorf("absolute paths should lead into the root, got %+v", result)
+	}
+	if !s.Exists("/var/log/x") {
+		t.Error("expected x in the mission's /var/log")
+	}
+	if result := s.Execute("ls /var/log/../../../../home"); result.Output != "learner" {
+		t.Errorf(".. in an absolute path should stop at the root, got %+v", result)
+	}
+
+	host := t.TempDir()
+	for _, input := range []string{
+		"touch /turtle-host-test",
+		"cd ../../../.. && touch x",
+		"touch ~/../../../x",
+	} {
+		if result := s.Execute(input); result.Error == "" || !strings.Contains(result.Error, "outside the practice directory") {
+			t.Errorf("%q should be refused, got %+v", input, result)
+		}
+	}
+	for _, input := range []string{"touch " + host + "/x", "echo x >" + host + "/x"} {
+		s.Execute(input)
+	}
+	for _, p := range []string{"/var/log/x", "/turtle-host-test", host + "/x", s.Root + "/../x"} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("%s should not have been created on the host", p)
+		}
+	}
+
+	if result := s.Execute("cd /home/learner/.. && pwd > /dev/null"); !result.Success || s.Pwd() != "/home" {
+		t.Errorf("climbing within the root should work, got %+v in %q", result, s.Pwd())
+	}
+}
+
+func TestShell_LinksStayInRoot(t *testing.T) {
+	s := startShell(t, &sandbox.Mission{SetupActions: []content.SetupAction{
+		{Symlink: &content.SymlinkAction{Path: "~/up", Target: "../../../../../etc"}},
+	}})
+
+	if result := s.Execute("touch up/turtle-link-test"); !result.Success {
+		t.Fatalf("expected touch to work, got %+v", result)
+	}
+	if !s.Exists("/etc/turtle-link-test") {
+		t.Error("a link climbing past / should stop at the root, as in the simulator")
+	}
+	if _, err := os.Stat("/etc/turtle-link-test"); !os.IsNotExist(err) {
+		_ = os.Remove("/etc/turtle-link-test")
+		t.Error("a link out of the root reached the host")
+	}
+}
+
+func TestShell_Environment(t *testing.T) {
+	t.Setenv("TURTLE_SECRET", "leak")
+	s := startShell(t, &sandbox.Mission{})
+
+	if got := s.Execute("echo x${TURTLE_SECRET}x").Output; got != "xx" {
+		t.Errorf("the environment should be scrubbed, got %q", got)
+	}
+	if got := s.Execute("cd && echo $HOME && pwd").Output; got != "/home/learner\n/home/learner" {
+		t.Errorf("HOME should be the learner's home in the root, shown as the mission names it, got %q", got)
+	}
+}
+
+func TestShell_Interrupts(t *testing.T) {
+	s := startShell(t, &sandbox.Mission{})
+	s.Timeout = 500 * time.Millisecond
+
+	if result := s.Execute("sleep 30"); !strings.Contains(result.Error, "interrupted") {
+		t.Errorf("a slow command should be interrupted, got %+v", result)
+	}
+	if result := s.Execute(`echo "open`); result.Error != "incomplete command - check your quotes" {
+		t.Errorf("an open quote should be cancelled, got %+v", result)
+	}
+	if got := s.Execute("echo still here").Output; got != "still here" {
+		t.Errorf("the shell should recover, got %q", got)
+	}
+}
+
+func TestShell_Goal(t *testing.T) {
+	goal, err := content.ParseGoal(map[string]any{"and": []any{
+		map[string]any{"is_dir": "~/projects/site"},
+		map[string]any{"file_contains": map[string]any{"path": "~/projects/site/index.html", "content": "hi"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := startShell(t, &sandbox.Mission{Goal: goal.Evaluate})
+
+	if s.Execute("mkdir -p projects/site").Completed {
+		t.Error("the goal needs the file too")
+	}
+	if !s.Execute("echo hi > projects/site/index.html").Completed {
+		t.Error("expected the goal to be met")
+	}
+	if s.LastCommand() != "echo hi > projects/site/index.html" {
+		t.Errorf("unexpected last command %q", s.LastCommand())
+	}
+}
+
+func TestShell_FileGoals(t *testing.T) {
+	goal, err := content.ParseGoal(map[string]any{"and": []any{
+		map[string]any{"is_symlink": "~/latest"},
+		map[string]any{"has_mode": map[string]any{"path": "~/run.sh", "mode": "700"}},
+		map[string]any{"file_count": map[string]any{"glob": "~/*.sh", "count": 1}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := startShell(t, &sandbox.Mission{Goal: goal.Evaluate, Setup: func(fs *sandbox.Filesystem) {
+		_ = fs.Touch("/home/learner/run.sh")
+		_ = fs.Chmod("750", "/home/learner/run.sh")
+	}})
+
+	info, err := os.Stat(s.HostPath("~/run.sh"))
+	if err != nil || info.Mode().Perm() != 0o750 {
+		t.Fatalf("expected setup's mode to carry over, got %v, %v", info, err)
+	}
+	if s.Execute("ln -s run.sh latest").Completed {
+		t.Error("the mode hasn't been changed yet")
+	}
+	if !s.Execute("chmod 700 run.sh").Completed {
+		t.Error("expected the goal to be met")
+	}
+}
+
+func TestShell_ResetAndClose(t *testing.T) {
+	s := startShell(t, &sandbox.Mission{})
+	old := s.Root
+	s.Execute("touch made.txt")
+	if err := s.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if s.Exists("~/made.txt") {
+		t.Error("reset should start from the mission setup")
+	}
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Error("reset should remove the old root")
+	}
+
+	if result := s.Execute("exit"); result.Error == "" {
+		t.Error("expected an error once bash has exited")
+	}
+	root := s.Root
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(root); !os.IsNotExist(err) {
+		t.Error("close should remove the root")
+	}
+}
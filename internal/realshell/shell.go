@@ -0,0 +1,435 @@
+// ABOUTME: Real-shell mission backend running bash in a throwaway directory
+// ABOUTME: Materialises a mission's sandbox into a temp root and drives bash --norc through a PTY
+
+package realshell
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/2389-research/turtle/internal/content"
+	"github.com/2389-research/turtle/internal/sandbox"
+)
+
+// DefaultTimeout is how long a command may run before it is interrupted.
+const DefaultTimeout = 10 * time.Second
+
+// The prompts bash prints are markers Turtle parses rather than text the learner sees:
+// PS1 reports the exit status and working directory, PS2 means bash wants more input.
+const (
+	promptMarker = "\x1e"
+	morePrompt   = "\x1f"
+	ps1          = promptMarker + "$? $PWD" + promptMarker
+)
+
+// promptPattern matches a PS1 marker, capturing the exit status and working directory.
+var promptPattern = regexp.MustCompile(promptMarker + `(\d+) ([^` + promptMarker + `]*)` + promptMarker)
+
+// learnerHome is the home directory missions are written against.
+const learnerHome = "/home/learner"
+
+// ErrNoBash is returned by Start when bash is not on the PATH.
+var ErrNoBash = errors.New("bash is not installed")
+
+// Shell runs a mission's commands in a real bash. The mission's filesystem is written
+// to a temporary directory, Root, which stands in for "/": mission paths such as
+// /home/learner/projects or /var/log live under it, and the learner's commands and
+// bash's output name them as the mission does.
+type Shell struct {
+	Root    string        // Temporary directory standing in for "/"
+	Timeout time.Duration // How long a command may run before it is interrupted
+
+	mission     *sandbox.Mission
+	bin         string
+	cmd         *exec.Cmd
+	pty         *os.File
+	output      chan []byte // Chunks read from the PTY; closed when bash exits
+	pending     string      // Output read past the last prompt
+	pwd         string      // Host working directory, from the last prompt
+	lastCommand string
+	history     []content.CommandRecord
+	setupEnv    map[string]string // Shell variables from the mission's setup
+	histFile    string            // Host file holding history seeded by setup, or empty
+}
+
+// hostOnly are the setup actions that only the simulator can perform: a real shell
+// can't give away files or leave made-up processes and tmux sessions running.
+var hostOnly = []string{"chown", "start_process", "tmux_session"}
+
+// Available returns true if bash is on the PATH.
+func Available() bool {
+	_, err := exec.LookPath("bash")
+	return err == nil
+}
+
+// Start sets up the mission in a new temporary root and starts bash there.
+func Start(m *sandbox.Mission) (*Shell, error) {
+	bin, err := exec.LookPath("bash")
+	if err != nil {
+		return nil, ErrNoBash
+	}
+	for _, action := range m.SetupActions {
+		if kind := action.Kind(); slices.Contains(hostOnly, kind) {
+			return nil, fmt.Errorf("%s setup only works in the simulator", kind)
+		}
+	}
+	if word := outsideRoot(m); word != "" {
+		return nil, fmt.Errorf("this mission uses %s, which climbs out of the practice directory, so it only works in the simulator", word)
+	}
+	s := &Shell{Timeout: DefaultTimeout, mission: m, bin: bin}
+	if err := s.start(); err != nil {
+		_ = s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// start materialises the mission and launches bash in the directory setup left it in.
+func (s *Shell) start() error {
+	root, err := os.MkdirTemp("", "turtle-shell-")
+	if err != nil {
+		return err
+	}
+	s.Root = root
+
+	// The simulator's setup is the single source of truth for what a mission starts with.
+	runner := sandbox.NewMissionRunner(s.mission)
+	if runner.SetupErr != nil {
+		return runner.SetupErr
+	}
+	fs := runner.FS
+	if err := materialize(fs.Root, root, root); err != nil {
+		return err
+	}
+	s.setupEnv = runner.Env
+	s.histFile = ""
+	if len(runner.History) > 0 {
+		s.histFile = s.HostPath(learnerHome + "/.bash_history")
+		if err := os.WriteFile(s.histFile, []byte(strings.Join(runner.History, "\n")+"\n"), 0o600); err != nil {
+			return err
+		}
+	}
+	s.pwd = s.HostPath(fs.CwdPath)
+	s.lastCommand = ""
+	s.history = nil
+	return s.launch()
+}
+
+// materialize writes a sandbox directory's children into dir, keeping their modes and
+// modification times. Absolute symlink targets are moved under root, as are relative
+// ones that would climb out of it: in the sandbox, ".." at "/" stays there.
+func materialize(node *sandbox.File, dir, root string) error {
+	for _, child := range node.Children {
+		path := filepath.Join(dir, child.Name)
+		if child.IsSymlink() {
+			target := child.Link
+			if filepath.IsAbs(target) {
+				target = filepath.Join(root, target)
+			} else if rel, err := filepath.Rel(root, filepath.Join(dir, target)); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				sandboxDir := strings.TrimPrefix(dir, root)
+				target = filepath.Join(root, filepath.Clean("/"+filepath.Join(sandboxDir, target)))
+			}
+			if err := os.Symlink(target, path); err != nil {
+				return err
+			}
+			continue
+		}
+		if child.IsDir() {
+			if err := os.MkdirAll(path, 0o700); err != nil {
+				return err
+			}
+			if err := materialize(child, path, root); err != nil {
+				return err
+			}
+		} else if err := os.WriteFile(path, []byte(child.Content), 0o600); err != nil {
+			return err
+		}
+		if err := os.Chmod(path, child.Perm()); err != nil {
+			return err
+		}
+		if !child.ModTime.IsZero() {
+			if err := os.Chtimes(path, child.ModTime, child.ModTime); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// launch starts bash on a new PTY and waits for its first prompt.
+func (s *Shell) launch() error {
+	master, slavePath, err := openPTY()
+	if err != nil {
+		return err
+	}
+	slave, err := os.OpenFile(slavePath, os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		_ = master.Close()
+		return err
+	}
+	defer func() { _ = slave.Close() }()
+
+	s.cmd = exec.Command(s.bin, "--norc", "--noprofile", "--noediting", "-i")
+	s.cmd.Dir = s.pwd
+	s.cmd.Env = s.env()
+	s.cmd.Stdin, s.cmd.Stdout, s.cmd.Stderr = slave, slave, slave
+	s.cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true}
+	if err := s.cmd.Start(); err != nil {
+		_ = master.Close()
+		return err
+	}
+	s.pty = master
+	s.output = make(chan []byte)
+	s.pending = ""
+	go readLoop(master, s.output)
+
+	if _, err := s.wait(); err != nil {
+		return err
+	}
+	// The terminal would echo every command back; the TUI already shows what was typed.
+	if _, err := s.pty.WriteString("stty -echo\n"); err != nil {
+		return err
+	}
+	_, err = s.wait()
+	return err
+}
+
+// env is a scrubbed environment: nothing from the learner's own session leaks in.
+func (s *Shell) env() []string {
+	histFile := "/dev/null"
+	if s.histFile != "" {
+		histFile = s.histFile
+	}
+	env := []string{
+		"HOME=" + s.HostPath(learnerHome),
+		"PWD=" + s.pwd,
+		"USER=learner",
+		"LOGNAME=learner",
+		"SHELL=" + s.bin,
+		"PATH=/usr/local/bin:/usr/bin:/bin",
+		"TERM=dumb",
+		"LANG=C.UTF-8",
+		"HISTFILE=" + histFile,
+		"PS1=" + ps1,
+		"PS2=" + morePrompt,
+	}
+	// Setup can add variables but not replace the ones that keep bash in its sandbox.
+	names := make([]string, 0, len(s.setupEnv))
+	for name := range s.setupEnv {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if !slices.ContainsFunc(env, func(kv string) bool { return strings.HasPrefix(kv, name+"=") }) {
+			env = append(env, name+"="+s.setupEnv[name])
+		}
+	}
+	return env
+}
+
+// readLoop copies PTY output onto a channel until bash goes away.
+func readLoop(pty *os.File, out chan<- []byte) {
+	defer close(out)
+	buf := make([]byte, 4096)
+	for {
+		n, err := pty.Read(buf)
+		if n > 0 {
+			out <- append([]byte(nil), buf[:n]...)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// prompt is what bash reported when it was ready for the next command.
+type prompt struct {
+	output string
+	status int
+	more   bool // bash is waiting for the rest of an incomplete command
+}
+
+// errExited is returned when bash has gone away, e.g. after the learner types exit.
+var errExited = errors.New("the shell has exited - press ctrl+r to start over")
+
+// errTimeout is returned when a command is still running after the timeout.
+var errTimeout = errors.New("timed out")
+
+// wait reads output until bash prints a prompt.
+func (s *Shell) wait() (prompt, error) {
+	timer := time.NewTimer(s.Timeout)
+	defer timer.Stop()
+	for {
+		if p, ok := s.takePrompt(); ok {
+			return p, nil
+		}
+		select {
+		case chunk, ok := <-s.output:
+			if !ok {
+				return prompt{output: s.pending}, errExited
+			}
+			s.pending += strings.ReplaceAll(string(chunk), "\r", "")
+		case <-timer.C:
+			return prompt{output: s.pending}, errTimeout
+		}
+	}
+}
+
+// takePrompt removes output up to and including the first prompt from pending.
+func (s *Shell) takePrompt() (prompt, bool) {
+	if i := strings.Index(s.pending, morePrompt); i >= 0 {
+		p := prompt{output: s.pending[:i], more: true}
+		s.pending = s.pending[i+len(morePrompt):]
+		return p, true
+	}
+	loc := promptPattern.FindStringSubmatchIndex(s.pending)
+	if loc == nil {
+		return prompt{}, false
+	}
+	status, _ := strconv.Atoi(s.pending[loc[2]:loc[3]])
+	s.pwd = s.pending[loc[4]:loc[5]]
+	p := prompt{output: s.pending[:loc[0]], status: status}
+	s.pending = s.pending[loc[1]:]
+	return p, true
+}
+
+// interrupt sends Ctrl-C and waits for bash to prompt again.
+func (s *Shell) interrupt() {
+	if _, err := s.pty.Write([]byte{3}); err != nil {
+		return
+	}
+	for {
+		p, err := s.wait()
+		if err != nil || !p.more {
+			return
+		}
+	}
+}
+
+// Execute runs a command in bash and checks the mission goal afterwards.
+func (s *Shell) Execute(input string) sandbox.MissionResult {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return sandbox.MissionResult{Success: true}
+	}
+	line, word := s.mapPaths(input, s.Pwd())
+	if word != "" {
+		return sandbox.MissionResult{Error: fmt.Sprintf("%s is outside the practice directory - use ~ or a relative path", word)}
+	}
+	s.lastCommand = input
+	if _, err := s.pty.WriteString(line + "\n"); err != nil {
+		return sandbox.MissionResult{Error: errExited.Error()}
+	}
+
+	p, err := s.wait()
+	output := s.missionText(strings.TrimRight(p.output, "\n"))
+	var result sandbox.MissionResult
+	switch {
+	case errors.Is(err, errTimeout):
+		s.interrupt()
+		result.Output = output
+		result.Error = fmt.Sprintf("%s: still running after %s, interrupted", input, s.Timeout)
+	case err != nil:
+		result.Output = output
+		result.Error = err.Error()
+	case p.more:
+		s.interrupt()
+		result.Error = "incomplete command - check your quotes"
+	case p.status != 0:
+		result.Error = output
+		if output == "" {
+			result.Error = fmt.Sprintf("exit status %d", p.status)
+		}
+	default:
+		result.Output = output
+		result.Success = true
+	}
+	record := content.CommandRecord{Command: input, Output: output, ExitCode: p.status}
+	if err != nil || p.more {
+		// Interrupted: bash reports 130 for a command stopped by Ctrl-C.
+		record.ExitCode = 130
+	}
+	s.history = append(s.history, record)
+
+	if s.mission.Goal != nil && s.mission.Goal(s) {
+		result.Completed = true
+	}
+	return result
+}
+
+// Location returns the current directory for display, with ~ for the learner's home.
+func (s *Shell) Location() string {
+	pwd := s.Pwd()
+	if pwd == learnerHome {
+		return "~"
+	}
+	if rest, ok := strings.CutPrefix(pwd, learnerHome+"/"); ok {
+		return "~/" + rest
+	}
+	return pwd
+}
+
+// Reset throws the directory away and starts the mission again from its setup.
+func (s *Shell) Reset() error {
+	if err := s.Close(); err != nil {
+		return err
+	}
+	return s.start()
+}
+
+// Close stops bash and removes the temporary root.
+func (s *Shell) Close() error {
+	if s.cmd != nil && s.cmd.Process != nil {
+		_ = s.cmd.Process.Kill()
+		_ = s.cmd.Wait()
+		s.cmd = nil
+	}
+	if s.pty != nil {
+		_ = s.pty.Close()
+		s.pty = nil
+	}
+	if s.output != nil {
+		for range s.output {
+		}
+		s.output = nil
+	}
+	if s.Root == "" {
+		return nil
+	}
+	return os.RemoveAll(s.Root)
+}
+
+// HostPath maps a mission path into the temporary root. Relative paths are taken from
+// the shell's working directory and ~ is the learner's home.
+func (s *Shell) HostPath(p string) string {
+	switch {
+	case p == "~":
+		p = learnerHome
+	case strings.HasPrefix(p, "~/"):
+		p = learnerHome + p[1:]
+	case !filepath.IsAbs(p):
+		p = filepath.Join(s.Pwd(), p)
+	}
+	return filepath.Join(s.Root, filepath.Clean("/"+p))
+}
+
+// learnerPath maps a host path back into mission terms. Paths outside the root (the
+// learner can cd anywhere) are returned unchanged.
+func (s *Shell) learnerPath(p string) string {
+	if p == s.Root {
+		return "/"
+	}
+	if rest, ok := strings.CutPrefix(p, s.Root+"/"); ok {
+		return "/" + rest
+	}
+	return p
+}
@@ -0,0 +1,178 @@
+// ABOUTME: Goal evaluation against a real tmux server
+// ABOUTME: Implements content.GoalEvaluator by querying tmux and the server's temporary HOME
+
+package realtmux
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/2389-research/turtle/internal/content"
+)
+
+// Server implements content.GoalEvaluator so mission goals can be checked against it.
+var _ content.GoalEvaluator = (*Server)(nil)
+
+// Pwd returns the working directory of the active pane, in mission terms.
+func (s *Server) Pwd() string {
+	out, err := s.Command("display-message", "-p", "#{pane_current_path}")
+	if err != nil {
+		return ""
+	}
+	return s.learnerPath(out)
+}
+
+// Exists reports whether a mission path exists.
+func (s *Server) Exists(path string) bool {
+	_, err := os.Stat(s.HostPath(path))
+	return err == nil
+}
+
+// IsDir reports whether a mission path is a directory.
+func (s *Server) IsDir(path string) bool {
+	info, err := os.Stat(s.HostPath(path))
+	return err == nil && info.IsDir()
+}
+
+// Stat describes a mission path without following a final symlink.
+func (s *Server) Stat(path string) (content.FileInfo, bool) {
+	info, err := os.Lstat(s.HostPath(path))
+	if err != nil {
+		return content.FileInfo{}, false
+	}
+	return content.FileInfo{
+		IsDir:     info.IsDir(),
+		IsSymlink: info.Mode()&os.ModeSymlink != 0,
+		Mode:      info.Mode().Perm(),
+		ModTime:   info.ModTime(),
+	}, true
+}
+
+// ListDir returns the names in a mission directory.
+func (s *Server) ListDir(path string) ([]string, error) {
+	entries, err := os.ReadDir(s.HostPath(path))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names, nil
+}
+
+// ReadFile returns the contents of a mission path.
+func (s *Server) ReadFile(path string) (string, error) {
+	data, err := os.ReadFile(s.HostPath(path))
+	return string(data), err
+}
+
+// LastCommand returns the most recent command the learner ran in bash, taken from the
+// history file the shells append to after every command.
+func (s *Server) LastCommand() string {
+	return lastLine(filepath.Join(s.Home, ".bash_history"))
+}
+
+// CommandHistory returns the commands the learner ran in bash. Their output and exit
+// status aren't captured, so Output is empty and ExitCode is 0.
+func (s *Server) CommandHistory() []content.CommandRecord {
+	var history []content.CommandRecord
+	for _, line := range readLines(filepath.Join(s.Home, ".bash_history")) {
+		history = append(history, content.CommandRecord{Command: line})
+	}
+	return history
+}
+
+// TmuxOperations returns the commands logged by the server's hooks, in order.
+func (s *Server) TmuxOperations() []content.TmuxOperation {
+	out, err := s.Command("show-options", "-gqv", logOption)
+	if err != nil {
+		return nil
+	}
+	return parseLog(out)
+}
+
+// parseLog decodes hook entries of the form "command key prefix @@ ".
+func parseLog(log string) []content.TmuxOperation {
+	var ops []content.TmuxOperation
+	for _, entry := range strings.Split(log, logSeparator) {
+		fields := strings.Split(strings.TrimSpace(entry), " ")
+		if len(fields) != 3 {
+			continue
+		}
+		op := content.TmuxOperation{Command: fields[0]}
+		if fields[1] != "" {
+			op.Key, op.Prefix = fields[1], fields[2]
+		}
+		ops = append(ops, op)
+	}
+	return ops
+}
+
+// TmuxBuffer returns a paste buffer's contents; an empty name means the most recent one.
+func (s *Server) TmuxBuffer(name string) (string, bool) {
+	args := []string{"show-buffer"}
+	if name != "" {
+		args = append(args, "-b", name)
+	}
+	out, err := s.Command(args...)
+	return out, err == nil
+}
+
+// windowFormat lists a window's fields for TmuxSnapshot; pane_index is the active pane.
+// tmux escapes control characters in its output, so fields are separated by "|" and the
+// free-text name comes last.
+const windowFormat = "#{session_id}|#{window_index}|#{window_active}|#{window_panes}|#{pane_index}|#{window_layout}|#{window_name}"
+
+// TmuxSnapshot describes the server's sessions and windows. Goals are checked after the
+// learner detaches, so a session only counts as attached if another client is on it.
+func (s *Server) TmuxSnapshot() *content.TmuxSnapshot {
+	sessions, err := s.Command("list-sessions", "-F", "#{session_id}|#{session_name}")
+	if err != nil {
+		return nil
+	}
+	snap := &content.TmuxSnapshot{}
+	byID := map[string]int{}
+	for _, line := range strings.Split(sessions, "\n") {
+		if id, name, ok := strings.Cut(line, "|"); ok {
+			byID[id] = len(snap.Sessions)
+			snap.Sessions = append(snap.Sessions, content.TmuxSessionInfo{Name: name})
+		}
+	}
+
+	windows, err := s.Command("list-windows", "-a", "-F", windowFormat)
+	if err != nil {
+		return nil
+	}
+	for _, line := range strings.Split(windows, "\n") {
+		f := strings.SplitN(line, "|", 7)
+		i, ok := byID[f[0]]
+		if len(f) != 7 || !ok {
+			continue
+		}
+		sess := &snap.Sessions[i]
+		index, _ := strconv.Atoi(f[1])
+		panes, _ := strconv.Atoi(f[3])
+		active, _ := strconv.Atoi(f[4])
+		if f[2] == "1" {
+			sess.Current = index
+		}
+		sess.Windows = append(sess.Windows, content.TmuxWindowInfo{
+			Index: index, Name: f[6], Panes: panes, ActivePane: active, Layout: f[5],
+		})
+	}
+
+	if clients, err := s.Command("list-clients", "-F", "#{session_name}"); err == nil && clients != "" {
+		snap.Attached = strings.Split(clients, "\n")[0]
+	}
+	snap.Current = snap.Attached
+	if snap.Current == "" {
+		// The most recently used session, as tmux picks for attach with no target.
+		if recent, err := s.Command("display-message", "-p", "#{session_name}"); err == nil {
+			snap.Current = recent
+		}
+	}
+	return snap
+}
@@ -0,0 +1,273 @@
+// ABOUTME: Tests for the private real-tmux server
+// ABOUTME: Uses the tmux binary when installed; pure helpers are tested everywhere
+
+package realtmux
+
+import (
+	"os"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/2389-research/turtle/internal/content"
+)
+
+// startServer starts a private server for a test, skipping if tmux isn't installed.
+func startServer(t *testing.T, actions []content.SetupAction) *Server {
+	t.Helper()
+	if !Available() {
+		t.Skip("tmux is not installed")
+	}
+	s, err := New("test-" + strconv.Itoa(os.Getpid()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+
+	startDir, err := s.ApplySetup(actions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Start(startDir); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestServer_GoalEvaluator(t *testing.T) {
+	s := startServer(t, []content.SetupAction{
+		{Mkdir: "~/projects"},
+		{WriteFile: &content.WriteFileAction{Path: "/home/learner/.tmux.conf", Content: "set -g prefix C-a\n"}},
+		{Cd: "~/projects"},
+	})
+
+	if got := s.Pwd(); got != "/home/learner/projects" {
+		t.Errorf("expected the session to start in ~/projects, got %q", got)
+	}
+	if !s.IsDir("/home/learner/projects") || s.IsDir("~/.tmux.conf") || !s.Exists("~/.tmux.conf") {
+		t.Error("mission paths should map into the temporary HOME")
+	}
+	if prefix, _ := s.Command("show-options", "-gv", "prefix"); prefix != "C-a" {
+		t.Errorf("the learner's ~/.tmux.conf should be loaded, got prefix %q", prefix)
+	}
+
+	if ops := s.TmuxOperations(); len(ops) != 0 {
+		t.Errorf("start-up commands should not be logged, got %+v", ops)
+	}
+	if _, err := s.Command("split-window", "-h"); err != nil {
+		t.Fatal(err)
+	}
+	want := []content.TmuxOperation{{Command: "split-window"}}
+	if ops := s.TmuxOperations(); !reflect.DeepEqual(ops, want) {
+		t.Errorf("expected %+v, got %+v", want, ops)
+	}
+
+	if _, ok := s.TmuxBuffer(""); ok {
+		t.Error("a new server should have no buffers")
+	}
+	if _, err := s.Command("set-buffer", "-b", "notes", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if data, ok := s.TmuxBuffer("notes"); !ok || data != "hello" {
+		t.Errorf("expected buffer notes = hello, got %q, %v", data, ok)
+	}
+}
+
+func TestServer_TmuxSnapshot(t *testing.T) {
+	s := startServer(t, nil)
+	for _, args := range [][]string{
+		{"split-window", "-h"},
+		{"new-window", "-n", "logs"},
+		{"new-session", "-d", "-s", "extra"},
+	} {
+		if _, err := s.Command(args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	snap := s.TmuxSnapshot()
+	if snap == nil || len(snap.Sessions) != 2 {
+		t.Fatalf("expected two sessions, got %+v", snap)
+	}
+	if snap.Attached != "" {
+		t.Errorf("no client is attached, got %q", snap.Attached)
+	}
+	for _, goal := range []map[string]any{
+		{"tmux_detached": true},
+		{"tmux_session_named": "extra"},
+		{"tmux_window_count": map[string]any{"session": "turtle", "min": 2}},
+		{"tmux_pane_count": map[string]any{"session": "turtle", "window": 0, "min": 2}},
+		{"tmux_active_pane": map[string]any{"session": "turtle", "window": 0, "index": 1}},
+		{"tmux_layout": map[string]any{"session": "turtle", "window": 0, "name": "even-horizontal"}},
+	} {
+		node, err := content.ParseGoal(goal)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !node.Evaluate(s) {
+			t.Errorf("expected %v to hold against real tmux: %+v", goal, snap)
+		}
+	}
+}
+
+func TestServer_Close(t *testing.T) {
+	s := startServer(t, nil)
+	home := s.Home
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(home); !os.IsNotExist(err) {
+		t.Error("Close should remove the temporary HOME")
+	}
+	if _, err := s.Command("has-session"); err == nil {
+		t.Error("Close should kill the server")
+	}
+}
+
+func TestServer_SetupSessions(t *testing.T) {
+	s := startServer(t, []content.SetupAction{
+		{Mkdir: "~/projects"},
+		{Fixture: &content.FixtureAction{Name: "go-repo", To: "~/projects/notes"}},
+		{Symlink: &content.SymlinkAction{Path: "~/p", Target: "~/projects"}},
+		{Chmod: &content.ChmodAction{Path: "~/projects", Mode: "700"}},
+		{TmuxSession: &content.TmuxSessionAction{Name: "work", Attach: true, Windows: []content.TmuxWindowSetup{
+			{Name: "code", Panes: []content.TmuxPaneSetup{{Cwd: "~/projects"}, {Split: "horizontal"}}},
+			{Name: "notes"},
+		}}},
+	})
+
+	if info, ok := s.Stat("~/p"); !ok || !info.IsSymlink {
+		t.Error("setup should create the symlink")
+	}
+	if info, ok := s.Stat("~/p/notes/scripts/release.sh"); !ok || info.Mode != 0o755 || info.ModTime.Year() != 2024 {
+		t.Errorf("the fixture should keep its modes and mtimes, got %+v", info)
+	}
+	if info, _ := s.Stat("~/projects"); info.Mode != 0o700 {
+		t.Errorf("setup should chmod, got %o", info.Mode)
+	}
+	if ops := s.TmuxOperations(); len(ops) != 0 {
+		t.Errorf("setup commands should not be logged, got %+v", ops)
+	}
+	snap := s.TmuxSnapshot()
+	var work *content.TmuxSessionInfo
+	for i := range snap.Sessions {
+		if snap.Sessions[i].Name == "work" {
+			work = &snap.Sessions[i]
+		}
+	}
+	if work == nil || len(work.Windows) != 2 || work.Windows[0].Name != "code" || work.Windows[0].Panes != 2 ||
+		work.Windows[0].ActivePane != 0 || work.Current != work.Windows[0].Index {
+		t.Fatalf("expected the work session with a split code window, got %+v", snap)
+	}
+	if args := s.AttachCommand().Args; args[len(args)-1] != "work" {
+		t.Errorf("the learner should attach to the setup session, got %v", args)
+	}
+}
+
+func TestApplySetup_OutsideHome(t *testing.T) {
+	if !Available() {
+		t.Skip("tmux is not installed")
+	}
+	outside := t.TempDir()
+	newServer := func(t *testing.T) *Server {
+		s, err := New("test-setup")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = s.Close() })
+		return s
+	}
+
+	for name, actions := range map[string][]content.SetupAction{
+		"absolute path":          {{Mkdir: "/var/log"}},
+		"climbing out":           {{WriteFile: &content.WriteFileAction{Path: "~/../../x", Content: "x"}}},
+		"absolute link target":   {{Symlink: &content.SymlinkAction{Path: "~/link", Target: outside}}},
+		"relative link target":   {{Symlink: &content.SymlinkAction{Path: "~/link", Target: "../../.."}}},
+		"writing through a link": {{Symlink: &content.SymlinkAction{Path: "~/link", Target: "/etc"}}, {WriteFile: &content.WriteFileAction{Path: "~/link/x"}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := newServer(t).ApplySetup(actions); err == nil {
+				t.Error("setup outside the learner's home should be rejected")
+			}
+		})
+	}
+
+	// A link the learner made themselves doesn't let later setup out either.
+	s := newServer(t)
+	if err := os.Symlink(outside, s.HostPath("~/link")); err != nil {
+		t.Fatal(err)
+	}
+	for _, action := range []content.SetupAction{
+		{WriteFile: &content.WriteFileAction{Path: "~/link/x", Content: "x"}},
+		{Touch: "~/link/sub/x"},
+		{Fixture: &content.FixtureAction{Name: "node-project", To: "~/link"}},
+	} {
+		if _, err := s.ApplySetup([]content.SetupAction{action}); err == nil {
+			t.Errorf("%s through a link out of home should be rejected", action.Kind())
+		}
+	}
+	if entries, _ := os.ReadDir(outside); len(entries) != 0 {
+		t.Errorf("setup wrote outside the learner's home: %v", entries)
+	}
+
+	if _, err := newServer(t).ApplySetup([]content.SetupAction{
+		{Mkdir: "~/releases/v2"},
+		{Symlink: &content.SymlinkAction{Path: "~/current", Target: "/home/learner/releases/v2"}},
+		{Symlink: &content.SymlinkAction{Path: "~/previous", Target: "releases"}},
+		{WriteFile: &content.WriteFileAction{Path: "~/current/app", Content: "v2"}},
+	}); err != nil {
+		t.Errorf("links inside home should be allowed, got %v", err)
+	}
+}
+
+func TestExtractFixture_StaysInside(t *testing.T) {
+	dir, outside := t.TempDir(), t.TempDir()
+	if err := os.Symlink(outside, dir+"/src"); err != nil {
+		t.Fatal(err)
+	}
+	if err := extractFixture("node-project", dir); err == nil {
+		t.Error("extracting through a link out of the directory should fail")
+	}
+	if entries, _ := os.ReadDir(outside); len(entries) != 0 {
+		t.Errorf("the fixture was written outside its directory: %v", entries)
+	}
+
+	if err := extractFixture("node-project", t.TempDir()); err != nil {
+		t.Errorf("a fresh directory should take the fixture, got %v", err)
+	}
+}
+
+func TestWrapBinding(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{`bind-key    -T prefix \%      split-window -h`,
+			`bind-key -T prefix \% set -g @turtle-key \% \; split-window -h`},
+		{`bind-key -r -T prefix       C-Up                  resize-pane -U`,
+			`bind-key -r -T prefix C-Up set -g @turtle-key C-Up \; resize-pane -U`},
+		{`bind-key    -T prefix \$      command-prompt -I "#S" { rename-session "%%" }`,
+			`bind-key -T prefix \$ set -g @turtle-key \$ \; command-prompt -I "#S" { rename-session "%%" }`},
+	}
+	for _, tt := range tests {
+		got, ok := wrapBinding(tt.line)
+		if !ok || got != tt.want {
+			t.Errorf("wrapBinding(%q) =\n  %q\nwant\n  %q", tt.line, got, tt.want)
+		}
+	}
+	if _, ok := wrapBinding("unexpected output"); ok {
+		t.Error("lines that aren't bindings should be skipped")
+	}
+}
+
+func TestParseLog(t *testing.T) {
+	got := parseLog("new-window  C-b @@ split-window % C-a @@ select-pane @ C-a @@")
+	want := []content.TmuxOperation{
+		{Command: "new-window"},
+		{Command: "split-window", Prefix: "C-a", Key: "%"},
+		{Command: "select-pane", Prefix: "C-a", Key: "@"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseLog = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,655 @@
+// ABOUTME: A private tmux server for practising missions against real tmux
+// ABOUTME: Runs tmux on an isolated socket with a throwaway HOME and tears it down afterwards
+
+package realtmux
+
+import (
+	"bufio"
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/2389-research/turtle/internal/content"
+)
+
+// SessionName is the session Turtle creates and attaches the learner to.
+const SessionName = "turtle"
+
+// LearnerHome is the home directory missions are written against; it maps to Server.Home.
+const LearnerHome = "/home/learner"
+
+// logOption is the user option the hooks append operations to.
+const logOption = "@turtle-log"
+
+// keyOption holds the key that triggered the command about to run.
+const keyOption = "@turtle-key"
+
+// logSeparator ends each entry in logOption. No tmux key is spelled "@@".
+const logSeparator = "@@"
+
+// hookedCommands are the commands whose use is logged for tmux_used goals.
+var hookedCommands = []string{
+	"attach-session", "break-pane", "copy-mode", "delete-buffer", "detach-client",
+	"join-pane", "kill-pane", "kill-session", "kill-window", "last-pane", "last-window",
+	"list-buffers", "list-panes", "list-sessions", "list-windows", "new-session",
+	"new-window", "next-layout", "next-window", "paste-buffer", "previous-layout",
+	"previous-window", "rename-session", "rename-window", "resize-pane", "select-layout",
+	"select-pane", "select-window", "send-keys", "set-buffer", "show-buffer",
+	"source-file", "split-window", "swap-pane", "switch-client",
+}
+
+// ErrNotInstalled is returned by New when tmux is not on the PATH.
+var ErrNotInstalled = errors.New("tmux is not installed")
+
+// Server is a private tmux server on its own socket (tmux -L), with a temporary HOME
+// so the learner's real config and sessions are never touched.
+type Server struct {
+	Socket string // Socket name passed to -L
+	Home   string // Temporary HOME for the server and its shells
+	bin    string
+	dir    string // Parent of Home; removed by Close
+	socket string // Socket path, removed by Close
+
+	setupEnv map[string]string            // Shell variables from setup
+	sessions []*content.TmuxSessionAction // Sessions from setup, created by Start
+	attach   string                       // Session the learner attaches to; empty means SessionName
+}
+
+// Available returns true if a tmux binary is on the PATH.
+func Available() bool {
+	_, err := exec.LookPath("tmux")
+	return err == nil
+}
+
+// New creates a server's temporary HOME without starting tmux, so missions can set
+// up files first. The socket is named turtle-<id>.
+func New(id string) (*Server, error) {
+	bin, err := exec.LookPath("tmux")
+	if err != nil {
+		return nil, ErrNotInstalled
+	}
+	dir, err := os.MkdirTemp("", "turtle-tmux-")
+	if err != nil {
+		return nil, err
+	}
+	home := filepath.Join(dir, "home")
+	if err := os.Mkdir(home, 0o700); err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+	return &Server{Socket: "turtle-" + id, Home: home, bin: bin, dir: dir}, nil
+}
+
+// Start launches the server with a detached session in startDir (a learner path,
+// empty for home). The learner's ~/.tmux.conf is read as usual; Turtle's hooks and
+// key wrappers are installed after it so they see the learner's bindings.
+func (s *Server) Start(startDir string) error {
+	conf := filepath.Join(s.dir, "turtle.conf")
+	if err := os.WriteFile(conf, []byte(serverConfig()), 0o600); err != nil {
+		return err
+	}
+	if startDir == "" {
+		startDir = LearnerHome
+	}
+	if _, err := s.Command("-f", conf, "new-session", "-d", "-s", SessionName,
+		"-x", "80", "-y", "24", "-c", s.HostPath(startDir)); err != nil {
+		return err
+	}
+	s.socket, _ = s.Command("display-message", "-p", "#{socket_path}")
+	if err := s.wrapPrefixKeys(); err != nil {
+		return err
+	}
+	if err := s.startSessions(); err != nil {
+		return err
+	}
+	// Forget the commands Turtle itself ran while starting up.
+	_, err := s.Command("set-option", "-gu", logOption)
+	return err
+}
+
+// serverConfig reads the learner's ~/.tmux.conf (tmux shows its errors in the first
+// pane), keeps the server alive when the learner closes every session, and installs
+// a logging hook for each command.
+func serverConfig() string {
+	var b strings.Builder
+	b.WriteString("source-file -q ~/.tmux.conf\n")
+	b.WriteString("set -s exit-empty off\n")
+	for _, name := range hookedCommands {
+		fmt.Fprintf(&b, "set-hook -g after-%s 'set -gaF %s \"%s #{%s} #{prefix} %s \" ; set -gu %s'\n",
+			name, logOption, name, keyOption, logSeparator, keyOption)
+	}
+	return b.String()
+}
+
+// wrapPrefixKeys rebinds every prefix-table key to record itself before running its
+// command, so the log can tell key bindings from typed commands.
+func (s *Server) wrapPrefixKeys() error {
+	out, err := s.Command("list-keys", "-T", "prefix")
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	for _, line := range strings.Split(out, "\n") {
+		if wrapped, ok := wrapBinding(line); ok {
+			b.WriteString(wrapped + "\n")
+		}
+	}
+	conf := filepath.Join(s.dir, "keys.conf")
+	if err := os.WriteFile(conf, []byte(b.String()), 0o600); err != nil {
+		return err
+	}
+	_, err = s.Command("source-file", conf)
+	return err
+}
+
+// wrapBinding rewrites a list-keys line so the binding sets keyOption first:
+//
+//	bind-key -T prefix \% split-window -h
+//	bind-key -T prefix \% set -g @turtle-key \% \; split-window -h
+func wrapBinding(line string) (string, bool) {
+	fields := strings.Fields(line)
+	// bind-key [-r] -T table key command ...
+	i := 1
+	if i < len(fields) && fields[i] == "-r" {
+		i++
+	}
+	if len(fields) < i+4 || fields[0] != "bind-key" || fields[i] != "-T" {
+		return "", false
+	}
+	key := fields[i+2]
+	head := strings.Join(fields[:i+3], " ")
+	return fmt.Sprintf("%s set -g %s %s \\; %s", head, keyOption, key, afterFields(line, i+3)), true
+}
+
+// afterFields returns the rest of line after its first n whitespace-separated fields.
+func afterFields(line string, n int) string {
+	rest := strings.TrimLeft(line, " \t")
+	for ; n > 0; n-- {
+		end := strings.IndexAny(rest, " \t")
+		if end < 0 {
+			return ""
+		}
+		rest = strings.TrimLeft(rest[end:], " \t")
+	}
+	return rest
+}
+
+// Command runs a tmux command against the private server and returns its output.
+func (s *Server) Command(args ...string) (string, error) {
+	cmd := exec.Command(s.bin, append([]string{"-L", s.Socket}, args...)...)
+	cmd.Env = s.env()
+	out, err := cmd.CombinedOutput()
+	text := strings.TrimRight(string(out), "\n")
+	if err != nil {
+		if text != "" {
+			return "", errors.New(text)
+		}
+		return "", err
+	}
+	return text, nil
+}
+
+// AttachCommand returns the command that attaches the learner's terminal to the session.
+func (s *Server) AttachCommand() *exec.Cmd {
+	cmd := exec.Command(s.bin, "-L", s.Socket, "attach-session", "-t", cmp.Or(s.attach, SessionName))
+	cmd.Env = s.env()
+	return cmd
+}
+
+// reservedEnv are the variables env sets itself, which neither the learner's own
+// environment nor mission setup may override.
+var reservedEnv = []string{"HOME", "TMUX", "TMUX_PANE", "HISTFILE", "PROMPT_COMMAND"}
+
+// env is the environment for tmux and the shells it starts: the temporary HOME, no
+// $TMUX (so tmux doesn't refuse to nest), and bash history written after every command.
+func (s *Server) env() []string {
+	env := []string{
+		"HOME=" + s.Home,
+		"HISTFILE=" + filepath.Join(s.Home, ".bash_history"),
+		"PROMPT_COMMAND=history -a",
+	}
+	for _, kv := range os.Environ() {
+		name := strings.SplitN(kv, "=", 2)[0]
+		if _, set := s.setupEnv[name]; !set && !slices.Contains(reservedEnv, name) {
+			env = append(env, kv)
+		}
+	}
+	for name, value := range s.setupEnv {
+		if !slices.Contains(reservedEnv, name) {
+			env = append(env, name+"="+value)
+		}
+	}
+	return env
+}
+
+// Close kills the server and removes its socket and temporary HOME.
+func (s *Server) Close() error {
+	_, _ = s.Command("kill-server")
+	if s.socket != "" {
+		_ = os.Remove(s.socket)
+	}
+	return os.RemoveAll(s.dir)
+}
+
+// HostPath maps a mission path (~/x or /home/learner/x) into the temporary HOME.
+// Other absolute paths refer to the real filesystem.
+func (s *Server) HostPath(p string) string {
+	switch {
+	case p == "~" || p == LearnerHome:
+		return s.Home
+	case strings.HasPrefix(p, "~/"):
+		return filepath.Join(s.Home, p[2:])
+	case strings.HasPrefix(p, LearnerHome+"/"):
+		return filepath.Join(s.Home, strings.TrimPrefix(p, LearnerHome+"/"))
+	}
+	return p
+}
+
+// learnerPath maps a host path back into mission terms.
+func (s *Server) learnerPath(p string) string {
+	if p == s.Home {
+		return LearnerHome
+	}
+	if rest, ok := strings.CutPrefix(p, s.Home+"/"); ok {
+		return LearnerHome + "/" + rest
+	}
+	return p
+}
+
+// ApplySetup performs mission setup actions inside the temporary HOME and returns the
+// directory the session should start in. Actions outside the learner's home can't be
+// sandboxed and are rejected, as are ones only the simulator can fake; tmux sessions
+// are created by Start.
+func (s *Server) ApplySetup(actions []content.SetupAction) (string, error) {
+	startDir := ""
+	for i := range actions {
+		action := &actions[i]
+		if err := action.Validate(); err != nil {
+			return "", err
+		}
+		var err error
+		switch {
+		case action.Cd != "":
+			if _, err = s.hostPath(action.Cd); err == nil {
+				startDir = action.Cd
+			}
+		case action.Mkdir != "":
+			err = s.onHost(action.Mkdir, func(host string) error { return os.MkdirAll(host, 0o755) })
+		case action.Touch != "":
+			err = s.onHost(action.Touch, func(host string) error { return writeFile(host, "", false) })
+		case action.WriteFile != nil:
+			err = s.onHost(action.WriteFile.Path, func(host string) error {
+				return writeFile(host, action.WriteFile.Content, true)
+			})
+		case action.Rm != "":
+			err = s.onHost(action.Rm, func(host string) error {
+				if _, err := os.Lstat(host); err != nil {
+					return err
+				}
+				return os.RemoveAll(host)
+			})
+		case action.Copy != nil:
+			err = s.copy(action.Copy.From, action.Copy.To)
+		case action.Symlink != nil:
+			err = s.onHost(action.Symlink.Path, func(host string) error {
+				target, err := s.linkTarget(action.Symlink.Target, host)
+				if err != nil {
+					return err
+				}
+				return os.Symlink(target, host)
+			})
+		case action.Chmod != nil:
+			err = s.onHost(action.Chmod.Path, func(host string) error {
+				_, err := run("chmod", action.Chmod.Mode, host)
+				return err
+			})
+		case action.SetMtime != nil:
+			err = s.onHost(action.SetMtime.Path, func(host string) error {
+				t, err := content.ParseSetupTime(action.SetMtime.Time, time.Now())
+				if err != nil {
+					return err
+				}
+				return os.Chtimes(host, t, t)
+			})
+		case action.Fixture != nil:
+			err = s.onHost(action.Fixture.To, func(host string) error { return extractFixture(action.Fixture.Name, host) })
+		case len(action.Env) > 0:
+			if s.setupEnv == nil {
+				s.setupEnv = make(map[string]string)
+			}
+			for name, value := range action.Env {
+				s.setupEnv[name] = value
+			}
+		case len(action.SeedHistory) > 0:
+			err = appendFile(filepath.Join(s.Home, ".bash_history"), strings.Join(action.SeedHistory, "\n")+"\n")
+		case action.TmuxSession != nil:
+			err = s.addSession(action.TmuxSession)
+		default:
+			err = fmt.Errorf("%s setup only works in the simulator", action.Kind())
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return startDir, nil
+}
+
+// hostPath maps a mission path into the temporary HOME, rejecting paths outside it,
+// whether written that way ("~/../x") or reached through a symlink.
+func (s *Server) hostPath(p string) (string, error) {
+	host := s.HostPath(p)
+	if host == p || !within(host, s.Home) {
+		return "", fmt.Errorf("setup writes outside %s: %s", LearnerHome, p)
+	}
+	home, err := filepath.EvalSymlinks(s.Home)
+	if err != nil {
+		return "", err
+	}
+	real, err := resolveExisting(host)
+	if err != nil {
+		return "", err
+	}
+	if !within(real, home) {
+		return "", fmt.Errorf("setup writes outside %s through a symlink: %s", LearnerHome, p)
+	}
+	return host, nil
+}
+
+// linkTarget maps a symlink target into the temporary HOME, rejecting targets that
+// lead outside it. A relative target is taken from the link's directory, host.
+func (s *Server) linkTarget(target, host string) (string, error) {
+	if filepath.IsAbs(target) || strings.HasPrefix(target, "~") {
+		mapped := s.HostPath(target)
+		if mapped == target || !within(mapped, s.Home) {
+			return "", fmt.Errorf("symlink points outside %s: %s", LearnerHome, target)
+		}
+		return mapped, nil
+	}
+	if !within(filepath.Join(filepath.Dir(host), target), s.Home) {
+		return "", fmt.Errorf("symlink points outside %s: %s", LearnerHome, target)
+	}
+	return target, nil
+}
+
+// resolveExisting resolves the symlinks in as much of a path as exists, keeping the
+// rest as written.
+func resolveExisting(p string) (string, error) {
+	rest := ""
+	for {
+		real, err := filepath.EvalSymlinks(p)
+		if err == nil {
+			return filepath.Join(real, rest), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		parent := filepath.Dir(p)
+		if parent == p {
+			return "", err
+		}
+		rest = filepath.Join(filepath.Base(p), rest)
+		p = parent
+	}
+}
+
+// within reports whether path p is dir or inside it.
+func within(p, dir string) bool {
+	return p == dir || strings.HasPrefix(p, dir+string(filepath.Separator))
+}
+
+// onHost runs fn on the host path for a mission path inside the learner's home.
+func (s *Server) onHost(p string, fn func(host string) error) error {
+	host, err := s.hostPath(p)
+	if err != nil {
+		return err
+	}
+	return fn(host)
+}
+
+// copy copies a file or directory tree like cp -r, into to if it is a directory.
+func (s *Server) copy(from, to string) error {
+	src, err := s.hostPath(from)
+	if err != nil {
+		return err
+	}
+	dst, err := s.hostPath(to)
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(dst); err == nil && info.IsDir() {
+		dst = filepath.Join(dst, filepath.Base(src))
+	}
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return os.CopyFS(dst, os.DirFS(src))
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, info.Mode().Perm())
+}
+
+// addSession checks a tmux_session's directories and queues it for Start.
+func (s *Server) addSession(spec *content.TmuxSessionAction) error {
+	if spec.Name == SessionName {
+		return fmt.Errorf("tmux_session: %s is the session Turtle starts itself", SessionName)
+	}
+	for _, w := range spec.Windows {
+		for _, p := range w.Panes {
+			if p.Cwd == "" {
+				continue
+			}
+			host, err := s.hostPath(p.Cwd)
+			if err != nil {
+				return err
+			}
+			if info, err := os.Stat(host); err != nil || !info.IsDir() {
+				return fmt.Errorf("tmux_session: not a directory: %s", p.Cwd)
+			}
+		}
+	}
+	if spec.Attach {
+		if s.attach != "" {
+			return fmt.Errorf("already attaching to %s; only one tmux_session can attach", s.attach)
+		}
+		s.attach = spec.Name
+	}
+	s.sessions = append(s.sessions, spec)
+	return nil
+}
+
+// startSessions creates the sessions queued by setup, each pane splitting the one
+// before it and the first pane of each window left active.
+func (s *Server) startSessions() error {
+	for _, spec := range s.sessions {
+		windows := spec.Windows
+		if len(windows) == 0 {
+			windows = []content.TmuxWindowSetup{{}}
+		}
+		for i, w := range windows {
+			panes := w.Panes
+			if len(panes) == 0 {
+				panes = []content.TmuxPaneSetup{{}}
+			}
+			args := []string{"new-window", "-t", "=" + spec.Name + ":"}
+			if i == 0 {
+				args = []string{"new-session", "-d", "-s", spec.Name, "-x", "80", "-y", "24"}
+			}
+			if w.Name != "" {
+				args = append(args, "-n", w.Name)
+			}
+			var first, prev string
+			for j, p := range panes {
+				if j > 0 {
+					args = []string{"split-window", "-t", prev}
+					if p.Split == "horizontal" {
+						args = append(args, "-h")
+					}
+				}
+				args = append(args, "-P", "-F", "#{pane_id}", "-c", s.HostPath(cmp.Or(p.Cwd, LearnerHome)))
+				id, err := s.Command(args...)
+				if err != nil {
+					return fmt.Errorf("tmux_session %s: %w", spec.Name, err)
+				}
+				if p.Command != "" {
+					if _, err := s.Command("send-keys", "-t", id, p.Command, "Enter"); err != nil {
+						return err
+					}
+				}
+				if j == 0 {
+					first = id
+				}
+				prev = id
+			}
+			if _, err := s.Command("select-pane", "-t", first); err != nil {
+				return err
+			}
+		}
+		if _, err := s.Command("select-window", "-t", "="+spec.Name+":^"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extractFixture writes a fixture from the library into dir, keeping modes and mtimes.
+func extractFixture(name, dir string) error {
+	entries, err := content.LoadFixture(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		p := filepath.Join(dir, filepath.FromSlash(e.Path))
+		// Links already in dir, or made by earlier entries, mustn't lead outside it;
+		// a symlink entry replaces whatever is at its own path.
+		check := p
+		if e.Link != "" {
+			check = filepath.Dir(p)
+		}
+		real, err := resolveExisting(check)
+		if err != nil {
+			return err
+		}
+		if !within(real, root) {
+			return fmt.Errorf("fixture %s: %s leads outside %s", name, e.Path, dir)
+		}
+		switch {
+		case e.IsDir:
+			err = os.MkdirAll(p, 0o755)
+		case e.Link != "":
+			_ = os.Remove(p)
+			err = os.Symlink(e.Link, p)
+		default:
+			err = writeFile(p, e.Content, true)
+		}
+		if err == nil && e.Mode != 0 && e.Link == "" {
+			err = os.Chmod(p, e.Mode)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	// Directory times go last, after their contents stop changing them.
+	for i := len(entries) - 1; i >= 0; i-- {
+		if e := entries[i]; !e.ModTime.IsZero() {
+			p := filepath.Join(dir, filepath.FromSlash(e.Path))
+			if e.Link != "" {
+				continue
+			}
+			if err := os.Chtimes(p, e.ModTime, e.ModTime); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// run runs a host command, returning its combined output.
+func run(name string, args ...string) (string, error) {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%s: %s", name, strings.TrimSpace(string(out)))
+	}
+	return string(out), nil
+}
+
+// appendFile adds data to the end of a file, creating it if needed.
+func appendFile(path, data string) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeFile creates a file and its parent directories; touch leaves existing content.
+func writeFile(path, data string, truncate bool) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	flags := os.O_CREATE | os.O_WRONLY
+	if truncate {
+		flags |= os.O_TRUNC
+	}
+	f, err := os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// lastLine returns the last non-empty line of a file.
+func lastLine(path string) string {
+	lines := readLines(path)
+	if len(lines) == 0 {
+		return ""
+	}
+	return lines[len(lines)-1]
+}
+
+// readLines returns the non-empty lines of a file, trimmed; a missing file has none.
+func readLines(path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = f.Close() }()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,245 @@
+// ABOUTME: Shell command lines (quoting, variables, pipes) and the simulated head, tail, less,
+// ABOUTME: man, which and exit; commands that read text take it from a pipe when no file is named
+
+package sandbox
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// manual is the NAME line of each simulated command's manual page.
+var manual = map[string]string{
+	"cat":      "concatenate files and print on the standard output",
+	"cd":       "change the working directory",
+	"chmod":    "change file mode bits",
+	"clear":    "clear the terminal screen",
+	"cp":       "copy files and directories",
+	"echo":     "display a line of text",
+	"env":      "run a program in a modified environment",
+	"exit":     "exit the shell",
+	"find":     "search for files in a directory hierarchy",
+	"grep":     "print lines that match patterns",
+	"head":     "output the first part of files",
+	"help":     "display information about builtin commands",
+	"history":  "display the command history list",
+	"kill":     "send a signal to a process",
+	"less":     "view a file one screen at a time",
+	"ls":       "list directory contents",
+	"man":      "an interface to the system reference manuals",
+	"mkdir":    "make directories",
+	"mv":       "move (rename) files",
+	"printenv": "print all or part of environment",
+	"ps":       "report a snapshot of the current processes",
+	"pwd":      "print name of current/working directory",
+	"rm":       "remove files or directories",
+	"tail":     "output the last part of files",
+	"tmux":     "terminal multiplexer",
+	"touch":    "change file timestamps",
+	"which":    "locate a command",
+}
+
+// shellBuiltins are the commands that exist only inside the shell, so which can't find them.
+var shellBuiltins = []string{"cd", "exit", "help", "history"}
+
+// parseCommandLine splits a command line into a pipeline of commands, each a list
+// of words, as the shell does: single quotes keep text as it is, double quotes still
+// expand $NAME and ${NAME} from env, a backslash escapes the next character and an
+// unquoted "|" separates commands. Unset variables expand to nothing, as in bash.
+//
+//nolint:gocyclo // Quote-aware tokenizer handles each syntax character in one switch
+func parseCommandLine(line string, env map[string]string) ([][]string, error) {
+	commands := [][]string{nil}
+	var word, pending strings.Builder // pending holds text still to be expanded
+	inWord := false
+	var quote byte
+
+	expand := func() {
+		word.WriteString(expandVars(pending.String(), env))
+		pending.Reset()
+	}
+	flush := func() {
+		expand()
+		if inWord {
+			commands[len(commands)-1] = append(commands[len(commands)-1], word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			} else {
+				word.WriteByte(c)
+			}
+		case quote == '"':
+			switch {
+			case c == '"':
+				quote = 0
+			case c == '\\' && i+1 < len(line) && strings.IndexByte(`"\$`, line[i+1]) >= 0:
+				i++
+				expand()
+				word.WriteByte(line[i])
+			default:
+				pending.WriteByte(c)
+			}
+		case c == '\'':
+			expand()
+			quote, inWord = c, true
+		case c == '"':
+			quote, inWord = c, true
+		case c == '\\' && i+1 < len(line):
+			i++
+			expand()
+			word.WriteByte(line[i])
+			inWord = true
+		case c == ' ' || c == '\t':
+			flush()
+		case c == '|':
+			flush()
+			if len(commands[len(commands)-1]) == 0 {
+				return nil, fmt.Errorf("syntax error near unexpected token `|'")
+			}
+			commands = append(commands, nil)
+		default:
+			pending.WriteByte(c)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unexpected EOF while looking for matching `%c'", quote)
+	}
+	flush()
+	if len(commands) > 1 && len(commands[len(commands)-1]) == 0 {
+		return nil, fmt.Errorf("syntax error near unexpected token `|'")
+	}
+	return commands, nil
+}
+
+// input returns the text a command reads: its file operands in turn, or what was
+// piped to it if there are none.
+func (r *MissionRunner) input(cmd string, files []string) (string, error) {
+	if len(files) == 0 {
+		if !r.piped {
+			return "", fmt.Errorf("%s: missing file operand", cmd)
+		}
+		return r.stdin, nil
+	}
+	var texts []string
+	for _, path := range files {
+		text, err := r.FS.Cat(path)
+		if err != nil {
+			return "", err
+		}
+		texts = append(texts, strings.TrimSuffix(text, "\n"))
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+// headTail runs head or tail: the first or last ten lines, or as many as -n asks for.
+func (r *MissionRunner) headTail(cmd string, args []string) MissionResult {
+	count := 10
+	var files []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		var value string
+		switch {
+		case arg == "-n" && i+1 < len(args):
+			value = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "-n"):
+			value = strings.TrimPrefix(arg, "-n")
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			value = strings.TrimPrefix(arg, "-")
+		default:
+			files = append(files, arg)
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return MissionResult{Error: fmt.Sprintf("%s: invalid number of lines: '%s'", cmd, value)}
+		}
+		count = n
+	}
+
+	text, err := r.input(cmd, files)
+	if err != nil {
+		return MissionResult{Error: err.Error()}
+	}
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	count = min(count, len(lines))
+	if cmd == "head" {
+		lines = lines[:count]
+	} else {
+		lines = lines[len(lines)-count:]
+	}
+	return MissionResult{Output: strings.Join(lines, "\n"), Success: true}
+}
+
+// less shows a whole file: the sandbox's output isn't a terminal to page through.
+func (r *MissionRunner) less(args []string) MissionResult {
+	var files []string
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			files = append(files, arg)
+		}
+	}
+	text, err := r.input("less", files)
+	if err != nil {
+		return MissionResult{Error: err.Error()}
+	}
+	return MissionResult{Output: text, Success: true}
+}
+
+// man shows the start of a command's manual page.
+func (r *MissionRunner) man(args []string) MissionResult {
+	if len(args) == 0 {
+		return MissionResult{Error: "What manual page do you want?\nFor example, try 'man man'."}
+	}
+	name := args[len(args)-1]
+	summary, ok := manual[name]
+	if !ok {
+		return MissionResult{Error: "No manual entry for " + name}
+	}
+	title := fmt.Sprintf("%s(1)", strings.ToUpper(name))
+	return MissionResult{
+		Output:  fmt.Sprintf("%s\n\nNAME\n       %s - %s", title, name, summary),
+		Success: true,
+	}
+}
+
+// which prints where each named command's program is installed.
+func (r *MissionRunner) which(args []string) MissionResult {
+	if len(args) == 0 {
+		return MissionResult{Error: "which: missing command name"}
+	}
+	var paths []string
+	for _, name := range args {
+		if _, ok := manual[name]; !ok || slices.Contains(shellBuiltins, name) {
+			return MissionResult{
+				Output: strings.Join(paths, "\n"),
+				Error:  fmt.Sprintf("which: no %s in (/usr/local/bin:/usr/bin:/bin)", name),
+			}
+		}
+		paths = append(paths, "/usr/bin/"+name)
+	}
+	return MissionResult{Output: strings.Join(paths, "\n"), Success: true}
+}
+
+// exit closes the shell. Inside tmux that closes the pane it runs in; the sandbox's
+// own terminal stays open.
+func (r *MissionRunner) exit() MissionResult {
+	if !r.InTmuxSession() {
+		return MissionResult{Error: "exit: this would close your terminal; the sandbox keeps it open"}
+	}
+	if result := r.executeTmux([]string{"kill-pane"}); result.Error != "" {
+		return result
+	}
+	return MissionResult{Success: true}
+}
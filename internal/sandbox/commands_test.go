@@ -0,0 +1,62 @@
+// ABOUTME: Tests for head, tail, less, man, which and exit, and for quoting and pipelines
+// ABOUTME: Each case runs commands in a sandbox with a numbered file and checks the last result
+
+package sandbox
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCommands(t *testing.T) {
+	var numbers []string
+	for i := 1; i <= 12; i++ {
+		numbers = append(numbers, fmt.Sprint(i))
+	}
+	tests := []struct {
+		name     string
+		commands []string
+		output   string
+		err      string
+	}{
+		{"head", []string{"head numbers.txt"}, "1\n2\n3\n4\n5\n6\n7\n8\n9\n10", ""},
+		{"head -n", []string{"head -n 2 numbers.txt"}, "1\n2", ""},
+		{"tail -N", []string{"tail -3 numbers.txt"}, "10\n11\n12", ""},
+		{"tail more than there are", []string{"tail -n50 short.txt"}, "a\nb", ""},
+		{"head bad count", []string{"head -n x numbers.txt"}, "", "head: invalid number of lines: 'x'"},
+		{"tail no file", []string{"tail"}, "", "tail: missing file operand"},
+		{"less", []string{"less short.txt"}, "a\nb", ""},
+		{"man", []string{"man ls"}, "LS(1)\n\nNAME\n       ls - list directory contents", ""},
+		{"man unknown", []string{"man vim"}, "", "No manual entry for vim"},
+		{"which", []string{"which grep tmux"}, "/usr/bin/grep\n/usr/bin/tmux", ""},
+		{"which builtin", []string{"which cd"}, "", "which: no cd in (/usr/local/bin:/usr/bin:/bin)"},
+		{"pipe to grep", []string{"ls | grep .txt"}, "numbers.txt\nreadme.txt\nshort.txt", ""},
+		{"pipe to head", []string{"cat numbers.txt | tail -4 | head -n 1"}, "9", ""},
+		{"pipe stops at failure", []string{"cat missing.txt | head"}, "", "no such file or directory: /home/learner/missing.txt"},
+		{"dangling pipe", []string{"ls |"}, "", "syntax error near unexpected token `|'"},
+		{"quoting", []string{`echo 'a  $HOME' "in $HOME" \$HOME`}, "a  $HOME in /home/learner $HOME", ""},
+		{"quoted pipe", []string{"echo '|' | grep '|'"}, "|", ""},
+		{"unclosed quote", []string{`echo "hi`}, "", "unexpected EOF while looking for matching `\"'"},
+		{"exit outside tmux", []string{"exit"}, "", "exit: this would close your terminal; the sandbox keeps it open"},
+		{"exit closes the pane", []string{"tmux", "tmux split-window", "exit", "tmux list-panes"}, "0: [80x24]", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewMissionRunner(&Mission{Setup: func(fs *Filesystem) {
+				_ = fs.WriteFile("/home/learner/numbers.txt", strings.Join(numbers, "\n")+"\n")
+				_ = fs.WriteFile("/home/learner/short.txt", "a\nb\n")
+			}})
+			var result MissionResult
+			for _, cmd := range tt.commands {
+				result = r.Execute(cmd)
+			}
+			if result.Error != tt.err {
+				t.Errorf("error: got %q, want %q", result.Error, tt.err)
+			}
+			if tt.err == "" && !strings.HasPrefix(result.Output, tt.output) {
+				t.Errorf("output: got %q, want %q", result.Output, tt.output)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+// ABOUTME: Decides whether two command lines are the same answer to a challenge
+// ABOUTME: Normalises flag order, combined flags, quoting and paths, or compares effects in a sandbox
+
+package sandbox
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/2389-research/turtle/internal/content"
+)
+
+// SameCommand reports whether two command lines are the same command, written
+// differently: flags in another order, combined or spelled another way ("ls -la",
+// "ls -a -l", "ls -al"; "rm -R" for "rm -r"), other quoting, a tmux command by its
+// alias, redirections spaced differently ("echo hi >out.txt"), or paths spelled
+// with a redundant "./" or trailing slash ("cd ../" for "cd .."). Lines that can't be parsed, such as ones with an unclosed quote, only
+// match word for word.
+func SameCommand(a, b string) bool {
+	env := NewMissionRunner(&Mission{}).environ()
+	ca, errA := canonicalCommand(a, env)
+	cb, errB := canonicalCommand(b, env)
+	if errA != nil || errB != nil {
+		return slices.Equal(strings.Fields(a), strings.Fields(b))
+	}
+	return slices.EqualFunc(ca, cb, slices.Equal)
+}
+
+// canonicalCommand parses a command line into a normal form: for each command in
+// the pipeline its name, its options sorted, then "--" and its operands in order.
+func canonicalCommand(line string, env map[string]string) ([][]string, error) {
+	commands, err := parseCommandLine(strings.TrimSpace(line), env)
+	if err != nil {
+		return nil, err
+	}
+	for i, words := range commands {
+		if len(words) == 0 {
+			continue
+		}
+		words, redirects := splitRedirects(words)
+		if len(words) == 0 {
+			commands[i] = redirects
+			continue
+		}
+		name, args := words[0], words[1:]
+		spec, permute := content.OptionSpec(name), true
+		if name == "tmux" {
+			if len(args) == 0 {
+				args = []string{"new-session"}
+			}
+			cmd, err := lookupTmuxCommand(args[0])
+			if err != nil {
+				continue
+			}
+			// tmux subcommands have their own specs, and tmux reads options only up to
+			// the first operand, which may be a command with options of its own
+			// (bind x split-window -h).
+			name, args, spec, permute = "tmux "+cmd.Name, args[1:], cmd.Flags, false
+		}
+		if content.LiteralArgs(name) {
+			commands[i] = slices.Concat(words, redirects)
+			continue
+		}
+		opts, operands, err := content.SplitOptions(name, args, spec, permute)
+		if err != nil {
+			return nil, err
+		}
+		slices.Sort(opts)
+		commands[i] = slices.Concat([]string{name}, opts, []string{"--"}, operands, redirects)
+	}
+	return commands, nil
+}
+
+// redirectOps are the redirections a command line may carry, longest first so
+// ">>" isn't read as ">".
+var redirectOps = []string{"2>>", "2>", ">>", ">", "<"}
+
+// splitRedirects takes a command's redirections out of its words, each as the
+// operator joined to its cleaned target, so "> out.txt" and ">out.txt" read the
+// same wherever they are written.
+func splitRedirects(words []string) (rest, redirects []string) {
+	for i := 0; i < len(words); i++ {
+		op := ""
+		for _, o := range redirectOps {
+			if strings.HasPrefix(words[i], o) {
+				op = o
+				break
+			}
+		}
+		if op == "" {
+			rest = append(rest, words[i])
+			continue
+		}
+		target := strings.TrimPrefix(words[i], op)
+		if target == "" && i+1 < len(words) {
+			i++
+			target = words[i]
+		}
+		redirects = append(redirects, op+path.Clean(target))
+	}
+	return rest, redirects
+}
+
+// SameEffect reports whether two command lines, each run in a fresh sandbox
+// prepared by setup, both succeed and leave the same result: the same output,
+// working directory, files and tmux state.
+func SameEffect(a, b string, setup []content.SetupAction) bool {
+	ra, rb := NewMissionRunner(&Mission{SetupActions: setup}), NewMissionRunner(&Mission{SetupActions: setup})
+	if ra.SetupErr != nil {
+		return false
+	}
+	resA, resB := ra.Execute(a), rb.Execute(b)
+	return resA.Error == "" && resB.Error == "" &&
+		resA.Output == resB.Output &&
+		ra.FS.Pwd() == rb.FS.Pwd() &&
+		reflect.DeepEqual(fileState(ra.FS), fileState(rb.FS)) &&
+		reflect.DeepEqual(ra.Tmux.Snapshot(), rb.Tmux.Snapshot())
+}
+
+// fileState describes every entry in a filesystem by path: its kind, permissions,
+// owner and content or link target. Modification times are left out, since two
+// runs never match on them.
+func fileState(fs *Filesystem) map[string]string {
+	state := make(map[string]string)
+	var walk func(f *File, p string)
+	walk = func(f *File, p string) {
+		state[p] = fmt.Sprintf("dir=%t mode=%v owner=%s:%s link=%q content=%q", f.IsDir(), f.Perm(), f.Owner, f.Group, f.Link, f.Content)
+		for _, child := range f.Children {
+			walk(child, path.Join(p, child.Name))
+		}
+	}
+	walk(fs.Root, "/")
+	return state
+}
@@ -0,0 +1,85 @@
+// ABOUTME: Tests for deciding whether two command lines are the same answer
+// ABOUTME: Covers flag order, combined flags, quoting, paths, tmux aliases and sandbox effects
+
+package sandbox
+
+import (
+	"testing"
+
+	"github.com/2389-research/turtle/internal/content"
+)
+
+func TestSameCommand(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"ls -la", "ls -al", true},
+		{"ls -la", "ls -a -l", true},
+		{"ls -l docs -a", "ls -la docs", true},
+		{"ls -a", "ls -A", false},
+		{"ls -la", "ls -l", false},
+		{"cd ..", "cd ../", true},
+		{"cd ~/", "cd ~", true},
+		{"cat ./notes/a.txt", "cat notes/a.txt", true},
+		{"  pwd ", "pwd", true},
+		{"cp a b", "cp b a", false},
+		{"rm -Rf old", "rm -r -f old", true},
+		{"rm --recursive old", "rm -r old", true},
+		{"ls -R", "ls -r", false},
+		{`grep "error" log.txt`, "grep error log.txt", true},
+		{"find . -name '*.txt'", `find . -name "*.txt"`, true},
+		{"find . -name a -type f", "find . -type f -name a", false},
+		{"echo Hello World", "echo World Hello", false},
+		{"head -n 5 f", "head -5 f", true},
+		{"head -n5 f", "head -n 5 f", true},
+		{"head -n 5 f", "head -n 6 f", false},
+		{"tmux new -s work", "tmux new-session -s work", true},
+		{"tmux new -s work", "tmux new -swork", true},
+		{"tmux", "tmux new-session", true},
+		{"tmux new -s work", "tmux new -s play", false},
+		{"tmux bind '|' split-window -h", "tmux bind | split-window -h", false},
+		{"tmux bind '|' split-window -h", "tmux bind '|' split-window", false},
+		{"ls | grep .txt", "ls|grep .txt", true},
+		{"cd $HOME", "cd /home/learner", true},
+		{`echo "hi`, `echo "hi`, true},
+		{"PWD", "pwd", false},
+		{"sed 's/a/b/' f", "sed 's/a/b' f", false},
+		{"sed -e 's/a/b/' ./f", "sed -e s/a/b/ f", true},
+		{"grep a/../b f", "grep b f", false},
+		{"grep err ./logs/", "grep err logs", true},
+		{"echo test >output.txt", "echo test > output.txt", true},
+		{"echo test >> output.txt", "echo test > output.txt", false},
+		{"cat < ./in.txt", "cat <in.txt", true},
+		{"ls > out.txt -a", "ls -a >out.txt", true},
+	}
+	for _, tt := range tests {
+		if got := SameCommand(tt.a, tt.b); got != tt.want {
+			t.Errorf("SameCommand(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSameEffect(t *testing.T) {
+	notes := []content.SetupAction{{Touch: "notes.txt"}}
+	tests := []struct {
+		a, b  string
+		setup []content.SetupAction
+		want  bool
+	}{
+		{"cd", "cd ~", nil, true},
+		{"cd", "cd /home/learner/projects/..", nil, true},
+		{"mkdir -p a/b", "mkdir a a/b", nil, true},
+		{"rm notes.txt", "rm ./notes.txt", notes, true},
+		{"mv notes.txt old.txt", "cp notes.txt old.txt", notes, false},
+		{"ls", "ls -a", nil, false},
+		{"cat missing.txt", "cat missing.txt", nil, false},
+		{"tmux", "tmux new", nil, true},
+		{"tmux new -s a", "tmux new -s b", nil, false},
+	}
+	for _, tt := range tests {
+		if got := SameEffect(tt.a, tt.b, tt.setup); got != tt.want {
+			t.Errorf("SameEffect(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
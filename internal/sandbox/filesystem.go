@@ -5,10 +5,13 @@ package sandbox
 
 import (
 	"fmt"
+	iofs "io/fs"
 	"path/filepath"
 	"sort"
 	"strings"
 	"time"
+
+	"github.com/2389-research/turtle/internal/content"
 )
 
 // FileType represents the type of filesystem entry.
@@ -24,11 +27,41 @@ const (
 type File struct {
 	Name     string
 	Type     FileType
-	Content  string    // For regular files
-	Children []*File   // For directories
-	Parent   *File     // Parent directory (nil for root)
-	ModTime  time.Time // Modification time
-	Size     int       // File size in bytes
+	Content  string        // For regular files
+	Children []*File       // For directories
+	Parent   *File         // Parent directory (nil for root)
+	ModTime  time.Time     // Modification time
+	Size     int           // File size in bytes
+	Mode     iofs.FileMode // Permission bits; zero means the default (see Perm)
+	Link     string        // Symlink target; non-empty makes the entry a symbolic link
+	Owner    string        // Owning user; empty means the filesystem's user
+	Group    string        // Owning group; empty means the filesystem's user's group
+}
+
+// IsSymlink reports whether the entry is a symbolic link.
+func (f *File) IsSymlink() bool {
+	return f.Link != ""
+}
+
+// IsDir reports whether the entry is a directory. Hidden files and hidden directories
+// share a type, but only directories have a child list.
+func (f *File) IsDir() bool {
+	return f.Type == FileTypeDirectory || (f.Type == FileTypeHidden && f.Children != nil)
+}
+
+// Perm returns the entry's permission bits: 0755 for directories and 0644 for files
+// unless chmod has changed them. Symlinks are always 0777, like on Linux.
+func (f *File) Perm() iofs.FileMode {
+	switch {
+	case f.IsSymlink():
+		return 0o777
+	case f.Mode != 0:
+		return f.Mode
+	case f.IsDir():
+		return 0o755
+	default:
+		return 0o644
+	}
 }
 
 // Filesystem represents the complete sandbox environment.
@@ -97,12 +130,19 @@ func (fs *Filesystem) Mkdir(path string) error {
 	parts := splitPath(path)
 
 	current := fs.Root
-	for _, part := range parts {
+	for i, part := range parts {
 		if part == "" {
 			continue
 		}
 
 		child := findChild(current, part)
+		if child != nil && child.IsSymlink() {
+			target, err := fs.getNode("/" + strings.Join(parts[:i+1], "/"))
+			if err != nil {
+				return fmt.Errorf("not a directory: %s", part)
+			}
+			child = target
+		}
 		switch {
 		case child == nil:
 			// Create new directory
@@ -262,23 +302,100 @@ func (fs *Filesystem) Ls(path string, showHidden bool) ([]string, error) {
 	return names, nil
 }
 
-// Rm removes a file.
+// LsLong lists directory contents in ls -l format: type and mode, owner, group,
+// size, modification time and name, with symlinks showing their targets.
+func (fs *Filesystem) LsLong(path string, showHidden bool) ([]string, error) {
+	if path == "" {
+		path = fs.CwdPath
+	}
+
+	node, err := fs.Lstat(path)
+	if err != nil {
+		return nil, err
+	}
+	if node.IsSymlink() && strings.HasSuffix(path, "/") {
+		node, err = fs.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !node.IsDir() {
+		return []string{fs.longEntry(node)}, nil
+	}
+
+	children := make([]*File, 0, len(node.Children))
+	for _, child := range node.Children {
+		if showHidden || !strings.HasPrefix(child.Name, ".") {
+			children = append(children, child)
+		}
+	}
+	sort.Slice(children, func(i, j int) bool { return children[i].Name < children[j].Name })
+
+	lines := make([]string, 0, len(children))
+	for _, child := range children {
+		lines = append(lines, fs.longEntry(child))
+	}
+	return lines, nil
+}
+
+// longEntry formats one ls -l line.
+func (fs *Filesystem) longEntry(f *File) string {
+	kind, size := "-", f.Size
+	switch {
+	case f.IsSymlink():
+		kind = "l"
+	case f.IsDir():
+		kind, size = "d", 4096
+	}
+	owner, group := f.Owner, f.Group
+	if owner == "" {
+		owner = fs.User
+	}
+	if group == "" {
+		group = fs.User
+	}
+	name := f.Name
+	if f.IsSymlink() {
+		name += " -> " + f.Link
+	}
+	return fmt.Sprintf("%s%s %-8s %-8s %6d %s %s", kind, f.Perm().String()[1:], owner, group, size,
+		f.ModTime.Format("Jan _2 15:04"), name)
+}
+
+// Rm removes a file. A symlink is removed, not what it points to.
 func (fs *Filesystem) Rm(path string) error {
-	resolved := fs.resolvePath(path)
-	node, err := fs.getNode(resolved)
+	node, err := fs.lookup(fs.resolvePath(path), false)
 	if err != nil {
 		return err
 	}
 
-	if node.Type == FileTypeDirectory {
+	if node.IsDir() {
 		return fmt.Errorf("is a directory (use rm -r): %s", path)
 	}
 
+	return unlink(node)
+}
+
+// RemoveAll removes a file, symlink or whole directory tree, like rm -r.
+func (fs *Filesystem) RemoveAll(path string) error {
+	node, err := fs.lookup(fs.resolvePath(path), false)
+	if err != nil {
+		return err
+	}
+	for n := fs.Cwd; n != nil; n = n.Parent {
+		if n == node {
+			return fmt.Errorf("cannot remove the current directory or one of its parents: %s", path)
+		}
+	}
+	return unlink(node)
+}
+
+// unlink detaches a node from its parent directory.
+func unlink(node *File) error {
 	if node.Parent == nil {
 		return fmt.Errorf("cannot remove root")
 	}
 
-	// Remove from parent's children
 	parent := node.Parent
 	for i, child := range parent.Children {
 		if child == node {
@@ -301,9 +418,135 @@ func (fs *Filesystem) Cp(src, dst string) error {
 		return fmt.Errorf("is a directory (use cp -r): %s", src)
 	}
 
+	// Copying onto a directory puts the copy inside it.
+	if dstNode, err := fs.Stat(dst); err == nil && dstNode.IsDir() {
+		dst = filepath.Join(fs.resolvePath(dst), srcNode.Name)
+	}
 	return fs.WriteFile(dst, srcNode.Content)
 }
 
+// CopyTree copies a file, symlink or directory tree, like cp -r. Copying onto an
+// existing directory puts the copy inside it.
+func (fs *Filesystem) CopyTree(src, dst string) error {
+	srcResolved := fs.resolvePath(src)
+	srcNode, err := fs.lookup(srcResolved, false)
+	if err != nil {
+		return err
+	}
+
+	dstResolved := fs.resolvePath(dst)
+	if dstNode, err := fs.getNode(dstResolved); err == nil && dstNode.IsDir() {
+		dstResolved = filepath.Join(dstResolved, srcNode.Name)
+	}
+	if srcNode.IsDir() && (dstResolved == srcResolved || strings.HasPrefix(dstResolved, srcResolved+"/")) {
+		return fmt.Errorf("cannot copy a directory into itself: %s", src)
+	}
+
+	if err := fs.Mkdir(filepath.Dir(dstResolved)); err != nil {
+		return err
+	}
+	parent, err := fs.getNode(filepath.Dir(dstResolved))
+	if err != nil {
+		return err
+	}
+	if existing := findChild(parent, filepath.Base(dstResolved)); existing != nil {
+		if existing.IsDir() {
+			return fmt.Errorf("cannot overwrite directory: %s", dst)
+		}
+		_ = unlink(existing)
+	}
+
+	clone := cloneFile(srcNode, parent)
+	clone.Name = filepath.Base(dstResolved)
+	parent.Children = append(parent.Children, clone)
+	return nil
+}
+
+// Symlink creates path as a symbolic link to target, like ln -s target path. The
+// target need not exist; a relative one is resolved from the link's directory.
+func (fs *Filesystem) Symlink(target, path string) error {
+	resolved := fs.resolvePath(path)
+	if fs.lexists(resolved) {
+		return fmt.Errorf("failed to create symbolic link '%s': File exists", path)
+	}
+	if err := fs.Touch(resolved); err != nil {
+		return err
+	}
+	node, err := fs.lookup(resolved, false)
+	if err != nil {
+		return err
+	}
+	node.Link = target
+	node.Size = len(target)
+	return nil
+}
+
+// Extract adds a fixture's entries under dir, creating dir if needed. Entries keep
+// their modes, mtimes and symlink targets; an existing file in the way is replaced.
+func (fs *Filesystem) Extract(dir string, entries []content.FixtureEntry) error {
+	dir = fs.resolvePath(dir)
+	if err := fs.Mkdir(dir); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		p := filepath.Join(dir, filepath.FromSlash(e.Path))
+		if existing, err := fs.lookup(p, false); err == nil && !(e.IsDir && existing.IsDir()) {
+			if existing.IsDir() {
+				return fmt.Errorf("cannot overwrite directory: %s", p)
+			}
+			_ = unlink(existing)
+		}
+
+		var err error
+		switch {
+		case e.IsDir:
+			err = fs.Mkdir(p)
+		case e.Link != "":
+			err = fs.Symlink(e.Link, p)
+		default:
+			err = fs.WriteFile(p, e.Content)
+		}
+		if err != nil {
+			return err
+		}
+
+		node, err := fs.lookup(p, false)
+		if err != nil {
+			return err
+		}
+		if e.Mode != 0 && e.Link == "" {
+			node.Mode = e.Mode
+		}
+		if !e.ModTime.IsZero() {
+			node.ModTime = e.ModTime
+		}
+	}
+	return nil
+}
+
+// Chown changes an entry's owner and, if group isn't empty, its group.
+func (fs *Filesystem) Chown(owner, group, path string) error {
+	node, err := fs.lookup(fs.resolvePath(path), false)
+	if err != nil {
+		return fmt.Errorf("cannot access '%s': No such file or directory", path)
+	}
+	node.Owner = owner
+	if group != "" {
+		node.Group = group
+	}
+	return nil
+}
+
+// SetModTime sets an entry's modification time, like touch -d.
+func (fs *Filesystem) SetModTime(path string, t time.Time) error {
+	node, err := fs.getNode(fs.resolvePath(path))
+	if err != nil {
+		return err
+	}
+	node.ModTime = t
+	return nil
+}
+
 // Mv moves/renames a file or directory.
 func (fs *Filesystem) Mv(src, dst string) error {
 	srcResolved := fs.resolvePath(src)
@@ -444,6 +687,10 @@ func cloneFile(f *File, parent *File) *File {
 		Parent:  parent,
 		ModTime: f.ModTime,
 		Size:    f.Size,
+		Mode:    f.Mode,
+		Link:    f.Link,
+		Owner:   f.Owner,
+		Group:   f.Group,
 	}
 
 	for _, child := range f.Children {
@@ -453,6 +700,114 @@ func cloneFile(f *File, parent *File) *File {
 	return clone
 }
 
+// Stat returns the entry at path, following symlinks.
+func (fs *Filesystem) Stat(path string) (*File, error) {
+	return fs.getNode(fs.resolvePath(path))
+}
+
+// Lstat returns the entry at path; a final symlink is returned itself, not followed.
+func (fs *Filesystem) Lstat(path string) (*File, error) {
+	return fs.lookup(fs.resolvePath(path), false)
+}
+
+// lexists reports whether an absolute path names an entry, even a dangling symlink.
+func (fs *Filesystem) lexists(path string) bool {
+	_, err := fs.lookup(path, false)
+	return err == nil
+}
+
+// Chmod changes an entry's permissions. Mode is octal ("755") or symbolic ("u+x,go-w").
+func (fs *Filesystem) Chmod(mode, path string) error {
+	node, err := fs.getNode(fs.resolvePath(path))
+	if err != nil {
+		return fmt.Errorf("cannot access '%s': No such file or directory", path)
+	}
+	perm, err := applyChmod(mode, node.Perm(), node.IsDir())
+	if err != nil {
+		return err
+	}
+	node.Mode = perm
+	node.ModTime = time.Now()
+	return nil
+}
+
+// applyChmod applies an octal or symbolic chmod mode to the current permissions.
+func applyChmod(mode string, current iofs.FileMode, isDir bool) (iofs.FileMode, error) {
+	invalid := fmt.Errorf("invalid mode: '%s'", mode)
+	if mode == "" {
+		return 0, invalid
+	}
+	if mode[0] >= '0' && mode[0] <= '7' {
+		var n uint64
+		for _, c := range mode {
+			if c < '0' || c > '7' || n > 0o777 {
+				return 0, invalid
+			}
+			n = n*8 + uint64(c-'0')
+		}
+		if n > 0o777 {
+			return 0, invalid
+		}
+		return iofs.FileMode(n), nil
+	}
+
+	for _, clause := range strings.Split(mode, ",") {
+		who := strings.TrimLeft(clause, "ugoa")
+		mask := chmodWho(clause[:len(clause)-len(who)])
+		if who == "" || !strings.ContainsRune("+-=", rune(who[0])) {
+			return 0, invalid
+		}
+		op, perms := who[0], who[1:]
+		var bits iofs.FileMode
+		for _, c := range perms {
+			switch c {
+			case 'r':
+				bits |= 0o444
+			case 'w':
+				bits |= 0o222
+			case 'x':
+				bits |= 0o111
+			case 'X':
+				if isDir || current&0o111 != 0 {
+					bits |= 0o111
+				}
+			default:
+				return 0, invalid
+			}
+		}
+		switch op {
+		case '+':
+			current |= bits & mask
+		case '-':
+			current &^= bits & mask
+		case '=':
+			current = current&^mask | bits&mask
+		}
+	}
+	return current, nil
+}
+
+// chmodWho returns the permission bits a chmod "who" (u, g, o, a) applies to; none means all.
+func chmodWho(who string) iofs.FileMode {
+	if who == "" {
+		return 0o777
+	}
+	var mask iofs.FileMode
+	for _, c := range who {
+		switch c {
+		case 'u':
+			mask |= 0o700
+		case 'g':
+			mask |= 0o070
+		case 'o':
+			mask |= 0o007
+		case 'a':
+			mask |= 0o777
+		}
+	}
+	return mask
+}
+
 // resolvePath converts relative paths to absolute.
 func (fs *Filesystem) resolvePath(path string) string {
 	if path == "" {
@@ -476,28 +831,53 @@ func (fs *Filesystem) resolvePath(path string) string {
 	return filepath.Clean(filepath.Join(fs.CwdPath, path))
 }
 
-// getNode finds a node by path.
+// maxSymlinks is how many symlinks a lookup follows before giving up, as Linux does.
+const maxSymlinks = 40
+
+// getNode finds a node by path, following symlinks.
 func (fs *Filesystem) getNode(path string) (*File, error) {
-	if path == "/" {
-		return fs.Root, nil
-	}
+	return fs.lookup(path, true)
+}
+
+// lookup finds a node by absolute path. Symlinks part-way along are always followed;
+// a final one only if follow is set.
+func (fs *Filesystem) lookup(path string, follow bool) (*File, error) {
+	node, _, err := fs.walk(path, follow, 0)
+	return node, err
+}
 
+// walk does the work of lookup, also returning the node's path with symlinks resolved.
+func (fs *Filesystem) walk(path string, follow bool, depth int) (*File, string, error) {
 	parts := splitPath(path)
-	current := fs.Root
+	current, real := fs.Root, "/"
 
-	for _, part := range parts {
+	for i, part := range parts {
 		if part == "" {
 			continue
 		}
 
 		child := findChild(current, part)
 		if child == nil {
-			return nil, fmt.Errorf("no such file or directory: %s", path)
+			return nil, "", fmt.Errorf("no such file or directory: %s", path)
+		}
+		real = filepath.Join(real, part)
+		if child.IsSymlink() && (follow || i < len(parts)-1) {
+			if depth >= maxSymlinks {
+				return nil, "", fmt.Errorf("too many levels of symbolic links: %s", path)
+			}
+			target := child.Link
+			if !strings.HasPrefix(target, "/") {
+				target = filepath.Join(filepath.Dir(real), target)
+			}
+			var err error
+			if child, real, err = fs.walk(filepath.Clean(target), true, depth+1); err != nil {
+				return nil, "", fmt.Errorf("no such file or directory: %s", path)
+			}
 		}
 		current = child
 	}
 
-	return current, nil
+	return current, real, nil
 }
 
 func splitPath(path string) []string {
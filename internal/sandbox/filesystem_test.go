@@ -4,7 +4,10 @@
 package sandbox
 
 import (
+	iofs "io/fs"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewFilesystem(t *testing.T) {
@@ -145,6 +148,19 @@ func TestCp(t *testing.T) {
 	}
 }
 
+func TestCp_IntoDirectory(t *testing.T) {
+	fs := NewFilesystem()
+	_ = fs.WriteFile("/original.txt", "content")
+	_ = fs.Mkdir("/backup")
+
+	if err := fs.Cp("/original.txt", "/backup"); err != nil {
+		t.Fatalf("Cp failed: %v", err)
+	}
+	if content, _ := fs.ReadFile("/backup/original.txt"); content != "content" {
+		t.Errorf("Expected the copy inside /backup, got %q", content)
+	}
+}
+
 func TestMv(t *testing.T) {
 	fs := NewFilesystem()
 	_ = fs.WriteFile("/old.txt", "content")
@@ -186,6 +202,54 @@ func TestRmDirectory(t *testing.T) {
 	}
 }
 
+func TestChmod(t *testing.T) {
+	tests := []struct {
+		mode string
+		dir  bool
+		want iofs.FileMode
+	}{
+		{"700", false, 0o700},
+		{"0644", false, 0o644},
+		{"+x", false, 0o755},
+		{"u+x", false, 0o744},
+		{"go-r", false, 0o600},
+		{"a=r", false, 0o444},
+		{"u=rwx,g=rx,o=", false, 0o750},
+		{"a-x,u+X", true, 0o744},
+		{"o-rx", true, 0o750},
+	}
+	for _, tt := range tests {
+		fs := NewFilesystem()
+		path := "/file"
+		if tt.dir {
+			_ = fs.Mkdir(path)
+		} else {
+			_ = fs.Touch(path)
+		}
+		if err := fs.Chmod(tt.mode, path); err != nil {
+			t.Errorf("chmod %s: %v", tt.mode, err)
+			continue
+		}
+		node, _ := fs.Stat(path)
+		if got := node.Perm(); got != tt.want {
+			t.Errorf("chmod %s: got %o, want %o", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestChmod_Errors(t *testing.T) {
+	fs := NewFilesystem()
+	_ = fs.Touch("/file")
+	for _, mode := range []string{"", "789", "1000", "u+q", "u", "z+x"} {
+		if err := fs.Chmod(mode, "/file"); err == nil {
+			t.Errorf("chmod %q: expected an error", mode)
+		}
+	}
+	if err := fs.Chmod("755", "/missing"); err == nil {
+		t.Error("chmod on a missing file should fail")
+	}
+}
+
 func TestGrep(t *testing.T) {
 	fs := NewFilesystem()
 	_ = fs.WriteFile("/log.txt", "line 1\nERROR: something failed\nline 3\n")
@@ -251,3 +315,93 @@ func TestDefaultFilesystem(t *testing.T) {
 		t.Error("/tmp should exist")
 	}
 }
+
+func TestSymlink(t *testing.T) {
+	fs := NewDefaultFilesystem()
+	_ = fs.Mkdir("~/releases/v2")
+	_ = fs.WriteFile("~/releases/v2/app", "v2")
+
+	if err := fs.Symlink("releases/v2", "~/current"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := fs.ReadFile("~/current/app"); err != nil || got != "v2" {
+		t.Errorf("reading through a relative link: got %q, %v", got, err)
+	}
+	if err := fs.Cd("~/current"); err != nil {
+		t.Errorf("cd through a link: %v", err)
+	}
+	if node, _ := fs.Lstat("~/current"); !node.IsSymlink() || node.Perm() != 0o777 {
+		t.Errorf("Lstat should return the link itself, got %+v", node)
+	}
+	if err := fs.Symlink("/nowhere", "~/current"); err == nil {
+		t.Error("a link over an existing entry should fail")
+	}
+
+	_ = fs.Symlink("/nowhere", "~/dangling")
+	if fs.Exists("~/dangling") {
+		t.Error("a dangling link should not exist when followed")
+	}
+	if err := fs.Rm("~/dangling"); err != nil {
+		t.Errorf("rm should remove the link itself: %v", err)
+	}
+
+	_ = fs.Symlink("loop", "~/loop")
+	if _, err := fs.Stat("~/loop"); err == nil {
+		t.Error("a link to itself should not resolve")
+	}
+}
+
+func TestRemoveAllAndCopyTree(t *testing.T) {
+	fs := NewDefaultFilesystem()
+	_ = fs.WriteFile("~/site/index.html", "<h1>hi</h1>")
+	_ = fs.Chmod("600", "~/site/index.html")
+
+	if err := fs.CopyTree("~/site", "~/projects"); err != nil {
+		t.Fatal(err)
+	}
+	node, err := fs.Stat("~/projects/site/index.html")
+	if err != nil || node.Content != "<h1>hi</h1>" || node.Perm() != 0o600 {
+		t.Errorf("copy into a directory should keep contents and modes, got %+v, %v", node, err)
+	}
+	_ = fs.WriteFile("~/site/index.html", "changed")
+	if got, _ := fs.ReadFile("~/projects/site/index.html"); got != "<h1>hi</h1>" {
+		t.Error("the copy should be independent of the original")
+	}
+	if err := fs.CopyTree("~/site", "~/site/inner"); err == nil {
+		t.Error("copying a directory into itself should fail")
+	}
+
+	if err := fs.RemoveAll("~/site"); err != nil || fs.Exists("~/site") {
+		t.Errorf("RemoveAll should remove the tree: %v", err)
+	}
+	if err := fs.RemoveAll("~"); err == nil {
+		t.Error("removing the current directory should fail")
+	}
+}
+
+func TestLsLong(t *testing.T) {
+	fs := NewDefaultFilesystem()
+	_ = fs.WriteFile("~/run.sh", "echo hi\n")
+	_ = fs.Chmod("755", "~/run.sh")
+	_ = fs.Chown("root", "wheel", "~/run.sh")
+	_ = fs.SetModTime("~/run.sh", time.Date(2024, 3, 1, 9, 30, 0, 0, time.Local))
+	_ = fs.Symlink("run.sh", "~/go")
+
+	lines, err := fs.LsLong("~", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"drwxr-xr-x learner  learner    4096",
+		"lrwxrwxrwx learner  learner       6",
+		"-rwxr-xr-x root     wheel         8 Mar  1 09:30 run.sh",
+	}
+	if len(lines) != 6 || !strings.HasSuffix(lines[2], "go -> run.sh") {
+		t.Fatalf("unexpected listing:\n%s", strings.Join(lines, "\n"))
+	}
+	for i, line := range []string{lines[0], lines[2], lines[5]} {
+		if !strings.HasPrefix(line, want[i]) {
+			t.Errorf("line %q should start with %q", line, want[i])
+		}
+	}
+}
@@ -5,6 +5,9 @@ package sandbox
 
 import (
 	"fmt"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/2389-research/turtle/internal/content"
@@ -14,6 +17,9 @@ import (
 type goalContext struct {
 	fs          *Filesystem
 	lastCommand string
+	history     []content.CommandRecord
+	tmuxOps     []content.TmuxOperation
+	tmux        *TmuxState
 }
 
 func (g *goalContext) Pwd() string {
@@ -32,79 +38,176 @@ func (g *goalContext) ReadFile(path string) (string, error) {
 	return g.fs.ReadFile(path)
 }
 
+func (g *goalContext) Stat(path string) (content.FileInfo, bool) {
+	node, err := g.fs.Lstat(path)
+	if err != nil {
+		return content.FileInfo{}, false
+	}
+	return content.FileInfo{IsDir: node.IsDir(), IsSymlink: node.IsSymlink(), Mode: node.Perm(), ModTime: node.ModTime}, true
+}
+
+func (g *goalContext) ListDir(path string) ([]string, error) {
+	node, err := g.fs.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !node.IsDir() {
+		return nil, fmt.Errorf("not a directory: %s", path)
+	}
+	names := make([]string, 0, len(node.Children))
+	for _, child := range node.Children {
+		names = append(names, child.Name)
+	}
+	return names, nil
+}
+
 func (g *goalContext) LastCommand() string {
 	return g.lastCommand
 }
 
+func (g *goalContext) CommandHistory() []content.CommandRecord {
+	return g.history
+}
+
+func (g *goalContext) TmuxOperations() []content.TmuxOperation {
+	return g.tmuxOps
+}
+
+func (g *goalContext) TmuxBuffer(name string) (string, bool) {
+	if g.tmux == nil {
+		return "", false
+	}
+	b, ok := g.tmux.Buffer(name)
+	if !ok {
+		return "", false
+	}
+	return b.Data, true
+}
+
+func (g *goalContext) TmuxSnapshot() *content.TmuxSnapshot {
+	if g.tmux == nil {
+		return nil
+	}
+	return g.tmux.Snapshot()
+}
+
 // Mission represents a goal-based learning challenge.
 type Mission struct {
-	ID          string
-	SkillID     string                           // Which skill this teaches
-	Level       int                              // 0-5 difficulty
-	Title       string                           // Short mission name
-	Briefing    string                           // What the learner needs to do
-	Hint        string                           // Help if stuck
-	Setup       func(*Filesystem)                // Prepares the filesystem
-	Goal        func(content.GoalEvaluator) bool // Returns true if mission complete
-	Explanation string                           // Shown after success
-	Commands    []string                         // Commands that could solve this (for reference)
+	ID           string
+	SkillID      string                                        // Which skill this teaches
+	Level        int                                           // 0-5 difficulty
+	Title        string                                        // Short mission name
+	Briefing     string                                        // What the learner needs to do
+	Hint         string                                        // Help if stuck
+	Setup        func(*Filesystem)                             // Prepares the filesystem (hand-written missions)
+	SetupActions []content.SetupAction                         // Setup as data (YAML missions), applied after Setup
+	Goal         func(content.GoalEvaluator) bool              // Returns true if mission complete
+	Trace        func(content.GoalEvaluator) content.GoalTrace // Goal progress, condition by condition; nil for hand-written goals
+	Explanation  string                                        // Shown after success
+	Commands     []string                                      // Commands that could solve this (for reference)
+	Stages       []*Stage                                      // Ordered objectives; when set, Goal and Trace are unused
+	Variant      func() *Mission                               // Fills in a templated mission afresh; nil if it has no templates
 }
 
-// MissionResult represents the outcome of a command.
-type MissionResult struct {
-	Output    string // What to show the learner
-	Success   bool   // Command executed successfully
-	Error     string // Error message if failed
-	Completed bool   // Mission goal achieved
+// Stage is one objective of a multi-stage mission. Each stage has its own goal, and the
+// goal sees only the commands run since the stage began.
+type Stage struct {
+	Briefing     string
+	Hint         string
+	Explanation  string                                        // Shown when the stage is done
+	Setup        func(*Filesystem)                             // Applied on top of the learner's work when the stage begins
+	SetupActions []content.SetupAction                         // Setup as data, applied after Setup
+	Goal         func(content.GoalEvaluator) bool              // Returns true if the stage is done
+	Trace        func(content.GoalEvaluator) content.GoalTrace // Goal progress, condition by condition
 }
 
-// TmuxState tracks simulated tmux session state.
-type TmuxState struct {
-	InSession     bool
-	SessionName   string
-	Panes         int
-	Windows       int
-	CurrentPane   int
-	CurrentWindow int
-	Detached      bool
+// MissionResult represents the outcome of a command.
+type MissionResult struct {
+	Output      string // What to show the learner
+	Success     bool   // Command executed successfully
+	Error       string // Error message if failed
+	Completed   bool   // Mission goal achieved
+	StageDone   bool   // A stage of a multi-stage mission was finished and the next one has begun
+	Prompt      string // Non-empty when tmux is waiting for prompt input (the prompt label)
+	PromptInput string // Initial text for the prompt
+	Paste       string // Text tmux typed into the pane (paste-buffer, send-keys)
 }
 
 // MissionRunner executes missions in the sandbox.
 type MissionRunner struct {
 	FS        *Filesystem
 	Mission   *Mission
-	InitialFS *Filesystem // For reset
 	Attempts  int
 	Completed bool
-	History   []string  // Commands entered
-	Tmux      TmuxState // Tmux simulation state
+	History   []string                // Commands entered, after any seeded by setup
+	Records   []content.CommandRecord // Non-empty commands with their output and exit status
+	Tmux      TmuxState               // Tmux simulation state
+	Env       map[string]string       // Shell variables set by setup
+	Processes []Process               // Background processes, for ps and kill
+	SetupErr  error                   // Why the mission's setup couldn't be completed, if it couldn't
+
+	TmuxLog       []content.TmuxOperation // Successful tmux operations, typed or via keybinding
+	PromptPending bool                    // Waiting for input at the tmux command prompt
+	prompt        *tmuxPrompt
+	sourceDepth   int    // Nesting of source-file commands
+	lastCommand   string // What the goal sees as the last command
+	stdin         string // Output of the previous command in a pipeline
+	piped         bool   // The running command is reading stdin from a pipe
+	pipingOut     bool   // The running command's output goes to the next in a pipeline
+
+	Stage        int // Index of the current stage of a multi-stage mission
+	stageRecords int // Records before the current stage began
+	stageTmuxOps int // TmuxLog entries before the current stage began
 }
 
 // NewMissionRunner creates a runner for a mission.
 func NewMissionRunner(m *Mission) *MissionRunner {
-	// Start with a default filesystem that has common directories
-	fs := NewDefaultFilesystem()
+	r := &MissionRunner{Mission: m}
+	r.setup()
+	return r
+}
+
+// setup builds the mission's starting state: a default filesystem with the common
+// directories, then the mission's setup and its first stage's. A setup error is kept
+// in SetupErr; the learner gets whatever state was reached.
+func (r *MissionRunner) setup() {
+	r.FS = NewDefaultFilesystem()
+	r.History = []string{}
+	r.Tmux = TmuxState{}
+	r.Env = nil
+	r.Processes = nil
 
-	// Run mission-specific setup
+	m := r.Mission
 	if m.Setup != nil {
-		m.Setup(fs)
+		m.Setup(r.FS)
 	}
+	r.SetupErr = r.ApplySetup(m.SetupActions)
+	if len(m.Stages) > 0 {
+		r.setupStage(m.Stages[0])
+	}
+}
 
-	return &MissionRunner{
-		FS:        fs,
-		Mission:   m,
-		InitialFS: fs.Clone(),
-		Attempts:  0,
-		Completed: false,
-		History:   []string{},
+// setupStage applies a stage's setup on top of the current state.
+func (r *MissionRunner) setupStage(stage *Stage) {
+	if stage.Setup != nil {
+		stage.Setup(r.FS)
+	}
+	if err := r.ApplySetup(stage.SetupActions); err != nil && r.SetupErr == nil {
+		r.SetupErr = fmt.Errorf("stage %d: %w", r.Stage+1, err)
 	}
 }
 
-// Reset restores the filesystem to initial state.
+// Reset restores the mission to its starting state.
 func (r *MissionRunner) Reset() {
-	r.FS = r.InitialFS.Clone()
 	r.Attempts = 0
-	r.History = []string{}
+	r.Records = nil
+	r.TmuxLog = nil
+	r.lastCommand = ""
+	r.Stage = 0
+	r.stageRecords = 0
+	r.stageTmuxOps = 0
+	r.CancelPrompt()
+	r.setup()
 }
 
 // Execute runs a command and returns the result.
@@ -117,28 +220,127 @@ func (r *MissionRunner) Execute(input string) MissionResult {
 		return MissionResult{Output: "", Success: true}
 	}
 
-	parts := strings.Fields(input)
-	if len(parts) == 0 {
-		return MissionResult{Output: "", Success: true}
+	// Output lands in the pane the command was typed in, for copy mode. Each pane
+	// keeps its own working directory.
+	var pane *TmuxPane
+	if r.InTmuxSession() {
+		pane = r.Tmux.CurrentPane()
+		if pane.Cwd != "" && pane.Cwd != r.FS.CwdPath {
+			if err := r.FS.Cd(pane.Cwd); err != nil {
+				pane.Cwd = ""
+			}
+		}
+	}
+
+	var result MissionResult
+	if commands, err := parseCommandLine(input, r.environ()); err != nil {
+		result = MissionResult{Error: err.Error()}
+	} else {
+		result = r.executePipeline(commands)
+	}
+	if pane != nil {
+		pane.recordOutput(input, result)
+		pane.Cwd = r.FS.CwdPath
 	}
+	r.Records = append(r.Records, content.CommandRecord{Command: input, Output: result.Output, ExitCode: exitCode(result)})
+
+	r.checkGoal(input, &result)
 
-	cmd := parts[0]
-	args := parts[1:]
+	return result
+}
 
-	result := r.executeCommand(cmd, args)
+// executePipeline runs a command, or a pipeline of commands each reading what the
+// one before it printed. It stops at the first command that fails.
+func (r *MissionRunner) executePipeline(commands [][]string) MissionResult {
+	defer func() { r.stdin, r.piped, r.pipingOut = "", false, false }()
 
-	// Check if mission is complete using goalContext for command tracking
-	if r.Mission.Goal != nil {
-		ctx := &goalContext{fs: r.FS, lastCommand: input}
-		if r.Mission.Goal(ctx) {
-			result.Completed = true
-			r.Completed = true
+	var result MissionResult
+	for i, command := range commands {
+		r.stdin, r.piped, r.pipingOut = result.Output, i > 0, i < len(commands)-1
+		cmd, args := command[0], command[1:]
+		result = r.executeCommand(cmd, args)
+		if cmd == "tmux" && result.Error == "" {
+			r.recordTmux(args, "", "")
+		}
+		if result.Error != "" {
+			break
 		}
 	}
-
 	return result
 }
 
+// checkGoal marks the result completed if the mission goal is now satisfied. In a
+// multi-stage mission, finishing a stage other than the last moves on to the next.
+func (r *MissionRunner) checkGoal(lastCommand string, result *MissionResult) {
+	r.lastCommand = lastCommand
+	goal := r.Mission.Goal
+	if stage := r.CurrentStage(); stage != nil {
+		goal = stage.Goal
+	}
+	if goal == nil || !goal(r.goalContext()) {
+		return
+	}
+	if len(r.Mission.Stages) > 0 && r.Stage < len(r.Mission.Stages)-1 {
+		r.nextStage()
+		result.StageDone = true
+		return
+	}
+	result.Completed = true
+	r.Completed = true
+}
+
+// CurrentStage returns the stage the learner is on, or nil for a single-goal mission.
+func (r *MissionRunner) CurrentStage() *Stage {
+	if r.Stage >= len(r.Mission.Stages) {
+		return nil
+	}
+	return r.Mission.Stages[r.Stage]
+}
+
+// nextStage starts the next stage: its goal sees only what happens from here on.
+func (r *MissionRunner) nextStage() {
+	r.Stage++
+	r.stageRecords = len(r.Records)
+	r.stageTmuxOps = len(r.TmuxLog)
+	r.setupStage(r.Mission.Stages[r.Stage])
+}
+
+// GoalTrace explains which of the mission's (or current stage's) goal conditions hold
+// so far. It returns false for missions whose goal can't explain itself.
+func (r *MissionRunner) GoalTrace() (content.GoalTrace, bool) {
+	trace := r.Mission.Trace
+	if stage := r.CurrentStage(); stage != nil {
+		trace = stage.Trace
+	}
+	if trace == nil {
+		return content.GoalTrace{}, false
+	}
+	return trace(r.goalContext()), true
+}
+
+func (r *MissionRunner) goalContext() *goalContext {
+	return &goalContext{
+		fs:          r.FS,
+		lastCommand: r.lastCommand,
+		history:     r.Records[r.stageRecords:],
+		tmuxOps:     r.TmuxLog[r.stageTmuxOps:],
+		tmux:        &r.Tmux,
+	}
+}
+
+// exitCode is the status a shell would report for a result: 127 for an unknown command,
+// 1 for any other error.
+func exitCode(result MissionResult) int {
+	switch {
+	case result.Error == "":
+		return 0
+	case strings.HasSuffix(result.Error, ": command not found"):
+		return 127
+	default:
+		return 1
+	}
+}
+
 // executeCommand handles individual commands.
 //
 //nolint:gocognit,gocyclo,funlen // Command dispatcher requires many branches
@@ -152,20 +354,33 @@ func (r *MissionRunner) executeCommand(cmd string, args []string) MissionResult
 
 	case "ls":
 		path := ""
-		showHidden := false
+		showHidden, long := false, false
 		for _, arg := range args {
-			if arg == "-a" || arg == "-la" || arg == "-al" {
-				showHidden = true
-			} else if !strings.HasPrefix(arg, "-") {
+			if strings.HasPrefix(arg, "-") {
+				showHidden = showHidden || strings.Contains(arg, "a")
+				long = long || strings.Contains(arg, "l")
+			} else {
 				path = arg
 			}
 		}
+		if long {
+			lines, err := r.FS.LsLong(path, showHidden)
+			if err != nil {
+				return MissionResult{Error: err.Error()}
+			}
+			return MissionResult{Output: strings.Join(lines, "\n"), Success: true}
+		}
 		files, err := r.FS.Ls(path, showHidden)
 		if err != nil {
 			return MissionResult{Error: err.Error()}
 		}
+		// Names go one per line when the output isn't going to the terminal.
+		sep := "  "
+		if r.pipingOut {
+			sep = "\n"
+		}
 		return MissionResult{
-			Output:  strings.Join(files, "  "),
+			Output:  strings.Join(files, sep),
 			Success: true,
 		}
 
@@ -205,6 +420,9 @@ func (r *MissionRunner) executeCommand(cmd string, args []string) MissionResult
 		return MissionResult{Success: true}
 
 	case "cat":
+		if len(args) == 0 && r.piped {
+			return MissionResult{Output: r.stdin, Success: true}
+		}
 		if len(args) == 0 {
 			return MissionResult{Error: "cat: missing file operand"}
 		}
@@ -222,24 +440,19 @@ func (r *MissionRunner) executeCommand(cmd string, args []string) MissionResult
 		}
 
 	case "cp":
-		if len(args) < 2 {
-			return MissionResult{Error: "cp: missing destination file operand"}
-		}
-		src := args[len(args)-2]
-		dst := args[len(args)-1]
-		if err := r.FS.Cp(src, dst); err != nil {
-			return MissionResult{Error: err.Error()}
-		}
-		return MissionResult{Success: true}
+		return r.eachSource(cmd, args, r.FS.Cp)
 
 	case "mv":
+		return r.eachSource(cmd, args, r.FS.Mv)
+
+	case "chmod":
 		if len(args) < 2 {
-			return MissionResult{Error: "mv: missing destination file operand"}
+			return MissionResult{Error: "chmod: missing operand"}
 		}
-		src := args[len(args)-2]
-		dst := args[len(args)-1]
-		if err := r.FS.Mv(src, dst); err != nil {
-			return MissionResult{Error: err.Error()}
+		for _, path := range args[1:] {
+			if err := r.FS.Chmod(args[0], path); err != nil {
+				return MissionResult{Error: "chmod: " + err.Error()}
+			}
 		}
 		return MissionResult{Success: true}
 
@@ -257,6 +470,15 @@ func (r *MissionRunner) executeCommand(cmd string, args []string) MissionResult
 		return MissionResult{Success: true}
 
 	case "grep":
+		if len(args) == 1 && r.piped {
+			var matches []string
+			for line := range strings.SplitSeq(r.stdin, "\n") {
+				if strings.Contains(line, args[0]) {
+					matches = append(matches, line)
+				}
+			}
+			return MissionResult{Output: strings.Join(matches, "\n"), Success: true}
+		}
 		if len(args) < 2 {
 			return MissionResult{Error: "grep: missing pattern or file"}
 		}
@@ -342,9 +564,44 @@ func (r *MissionRunner) executeCommand(cmd string, args []string) MissionResult
 			Success: true,
 		}
 
+	case "env", "printenv":
+		return r.printEnv(cmd, args)
+
+	case "history":
+		lines := make([]string, len(r.History))
+		for i, line := range r.History {
+			lines[i] = fmt.Sprintf("%5d  %s", i+1, line)
+		}
+		return MissionResult{Output: strings.Join(lines, "\n"), Success: true}
+
+	case "ps":
+		lines := []string{"    PID TTY          TIME CMD", fmt.Sprintf("%7d pts/0    00:00:00 bash", firstPID-1)}
+		for _, p := range r.Processes {
+			lines = append(lines, fmt.Sprintf("%7d pts/0    00:00:00 %s", p.PID, p.Command))
+		}
+		return MissionResult{Output: strings.Join(lines, "\n"), Success: true}
+
+	case "kill":
+		return r.kill(args)
+
+	case "head", "tail":
+		return r.headTail(cmd, args)
+
+	case "less":
+		return r.less(args)
+
+	case "man":
+		return r.man(args)
+
+	case "which":
+		return r.which(args)
+
+	case "exit":
+		return r.exit()
+
 	case "help":
 		return MissionResult{
-			Output:  "Available: pwd, ls, cd, mkdir, touch, cat, cp, mv, rm, echo, grep, find, clear, tmux",
+			Output:  "Available: pwd, ls, cd, mkdir, touch, cat, head, tail, less, cp, mv, rm, chmod, echo, grep, find, env, history, ps, kill, man, which, clear, exit, tmux",
 			Success: true,
 		}
 
@@ -358,206 +615,88 @@ func (r *MissionRunner) executeCommand(cmd string, args []string) MissionResult
 	}
 }
 
-// GetCurrentLocation returns a user-friendly description of where they are.
-func (r *MissionRunner) GetCurrentLocation() string {
-	path := r.FS.Pwd()
-	if path == r.FS.Home {
-		return "~ (your home directory)"
+// printEnv runs env or printenv: every variable, or (printenv) just the named ones.
+func (r *MissionRunner) printEnv(cmd string, args []string) MissionResult {
+	env := r.environ()
+	if cmd == "printenv" && len(args) > 0 {
+		var values []string
+		for _, name := range args {
+			value, ok := env[name]
+			if !ok {
+				return MissionResult{Output: strings.Join(values, "\n"), Error: "printenv: " + name + " is not set"}
+			}
+			values = append(values, value)
+		}
+		return MissionResult{Output: strings.Join(values, "\n"), Success: true}
 	}
-	if strings.HasPrefix(path, r.FS.Home) {
-		return "~" + strings.TrimPrefix(path, r.FS.Home)
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
 	}
-	return path
+	sort.Strings(names)
+	lines := make([]string, len(names))
+	for i, name := range names {
+		lines[i] = name + "=" + env[name]
+	}
+	return MissionResult{Output: strings.Join(lines, "\n"), Success: true}
 }
 
-// executeTmux handles tmux commands.
-//
-//nolint:funlen,gocognit,gocyclo // Tmux command dispatcher requires many branches
-func (r *MissionRunner) executeTmux(args []string) MissionResult {
+// kill stops background processes by PID; a leading signal option such as -9 is accepted.
+func (r *MissionRunner) kill(args []string) MissionResult {
+	if len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		args = args[1:]
+	}
 	if len(args) == 0 {
-		// Just 'tmux' starts a new session
-		if r.Tmux.InSession {
-			return MissionResult{Error: "sessions should be nested with care, unset $TMUX to force"}
-		}
-		r.Tmux.InSession = true
-		r.Tmux.SessionName = "0"
-		r.Tmux.Panes = 1
-		r.Tmux.Windows = 1
-		r.Tmux.CurrentPane = 0
-		r.Tmux.CurrentWindow = 0
-		r.Tmux.Detached = false
-		return MissionResult{
-			Output:  "[new session 0 created]",
-			Success: true,
-		}
+		return MissionResult{Error: "kill: usage: kill [-s sigspec | -signum] pid ..."}
 	}
-
-	subCmd := args[0]
-	subArgs := args[1:]
-
-	switch subCmd {
-	case "new", "new-session":
-		if r.Tmux.InSession && !r.Tmux.Detached {
-			return MissionResult{Error: "sessions should be nested with care, unset $TMUX to force"}
-		}
-		sessionName := "0"
-		for i, arg := range subArgs {
-			if arg == "-s" && i+1 < len(subArgs) {
-				sessionName = subArgs[i+1]
-			}
-		}
-		r.Tmux.InSession = true
-		r.Tmux.SessionName = sessionName
-		r.Tmux.Panes = 1
-		r.Tmux.Windows = 1
-		r.Tmux.CurrentPane = 0
-		r.Tmux.CurrentWindow = 0
-		r.Tmux.Detached = false
-		return MissionResult{
-			Output:  fmt.Sprintf("[new session %s created]", sessionName),
-			Success: true,
-		}
-
-	case "attach", "attach-session", "a":
-		if r.Tmux.InSession && !r.Tmux.Detached {
-			return MissionResult{Error: "sessions should be nested with care"}
-		}
-		if !r.Tmux.Detached && r.Tmux.SessionName == "" {
-			return MissionResult{Error: "no sessions"}
-		}
-		r.Tmux.Detached = false
-		r.Tmux.InSession = true
-		return MissionResult{
-			Output:  fmt.Sprintf("[attached to session %s]", r.Tmux.SessionName),
-			Success: true,
-		}
-
-	case "detach", "detach-client", "d":
-		if !r.Tmux.InSession || r.Tmux.Detached {
-			return MissionResult{Error: "no current client"}
-		}
-		r.Tmux.Detached = true
-		return MissionResult{
-			Output:  fmt.Sprintf("[detached (from session %s)]", r.Tmux.SessionName),
-			Success: true,
-		}
-
-	case "ls", "list-sessions":
-		if r.Tmux.SessionName == "" {
-			return MissionResult{
-				Output:  "no server running",
-				Success: true,
-			}
-		}
-		attached := ""
-		if r.Tmux.InSession && !r.Tmux.Detached {
-			attached = " (attached)"
-		}
-		return MissionResult{
-			Output:  fmt.Sprintf("%s: %d windows%s", r.Tmux.SessionName, r.Tmux.Windows, attached),
-			Success: true,
-		}
-
-	case "split-window", "split":
-		if !r.Tmux.InSession || r.Tmux.Detached {
-			return MissionResult{Error: "no current session"}
-		}
-		r.Tmux.Panes++
-		direction := "horizontally"
-		for _, arg := range subArgs {
-			if arg == "-v" {
-				direction = "vertically"
-			}
-		}
-		return MissionResult{
-			Output:  fmt.Sprintf("[split %s, now %d panes]", direction, r.Tmux.Panes),
-			Success: true,
-		}
-
-	case "select-pane":
-		if !r.Tmux.InSession || r.Tmux.Detached {
-			return MissionResult{Error: "no current session"}
-		}
-		// Handle -L, -R, -U, -D for direction
-		direction := "next"
-		for _, arg := range subArgs {
-			switch arg {
-			case "-L":
-				direction = "left"
-			case "-R":
-				direction = "right"
-			case "-U":
-				direction = "up"
-			case "-D":
-				direction = "down"
-			}
-		}
-		r.Tmux.CurrentPane = (r.Tmux.CurrentPane + 1) % r.Tmux.Panes
-		return MissionResult{
-			Output:  fmt.Sprintf("[moved %s to pane %d]", direction, r.Tmux.CurrentPane),
-			Success: true,
-		}
-
-	case "new-window":
-		if !r.Tmux.InSession || r.Tmux.Detached {
-			return MissionResult{Error: "no current session"}
-		}
-		r.Tmux.Windows++
-		r.Tmux.CurrentWindow = r.Tmux.Windows - 1
-		return MissionResult{
-			Output:  fmt.Sprintf("[new window %d created]", r.Tmux.CurrentWindow),
-			Success: true,
-		}
-
-	case "select-window":
-		if !r.Tmux.InSession || r.Tmux.Detached {
-			return MissionResult{Error: "no current session"}
-		}
-		// Handle -n (next) and -p (previous)
-		for _, arg := range subArgs {
-			switch arg {
-			case "-n":
-				r.Tmux.CurrentWindow = (r.Tmux.CurrentWindow + 1) % r.Tmux.Windows
-			case "-p":
-				r.Tmux.CurrentWindow = (r.Tmux.CurrentWindow - 1 + r.Tmux.Windows) % r.Tmux.Windows
-			}
+	for _, arg := range args {
+		pid, err := strconv.Atoi(arg)
+		if err != nil {
+			return MissionResult{Error: fmt.Sprintf("kill: %s: arguments must be process or job IDs", arg)}
 		}
-		return MissionResult{
-			Output:  fmt.Sprintf("[switched to window %d]", r.Tmux.CurrentWindow),
-			Success: true,
+		i := slices.IndexFunc(r.Processes, func(p Process) bool { return p.PID == pid })
+		if i < 0 {
+			return MissionResult{Error: fmt.Sprintf("kill: (%d) - No such process", pid)}
 		}
+		r.Processes = slices.Delete(r.Processes, i, i+1)
+	}
+	return MissionResult{Success: true}
+}
 
-	case "kill-session":
-		if r.Tmux.SessionName == "" {
-			return MissionResult{Error: "no sessions"}
+// eachSource copies or moves each source operand of cp or mv to the last operand,
+// which must be a directory if there are several sources. Flags are ignored.
+func (r *MissionRunner) eachSource(cmd string, args []string, op func(src, dst string) error) MissionResult {
+	var operands []string
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			operands = append(operands, arg)
 		}
-		name := r.Tmux.SessionName
-		r.Tmux = TmuxState{}
-		return MissionResult{
-			Output:  fmt.Sprintf("[killed session %s]", name),
-			Success: true,
+	}
+	if len(operands) < 2 {
+		return MissionResult{Error: cmd + ": missing destination file operand"}
+	}
+	sources, dst := operands[:len(operands)-1], operands[len(operands)-1]
+	if len(sources) > 1 {
+		if node, err := r.FS.Stat(dst); err != nil || !node.IsDir() {
+			return MissionResult{Error: fmt.Sprintf("%s: target '%s' is not a directory", cmd, dst)}
 		}
-
-	default:
-		return MissionResult{
-			Error: fmt.Sprintf("tmux: unknown command: %s", subCmd),
+	}
+	for _, src := range sources {
+		if err := op(src, dst); err != nil {
+			return MissionResult{Error: err.Error()}
 		}
 	}
+	return MissionResult{Success: true}
 }
 
-// InTmuxSession returns true if currently in a tmux session.
-func (r *MissionRunner) InTmuxSession() bool {
-	return r.Tmux.InSession && !r.Tmux.Detached
-}
-
-// GetTmuxStatus returns a description of the current tmux state.
-func (r *MissionRunner) GetTmuxStatus() string {
-	if !r.Tmux.InSession && r.Tmux.SessionName == "" {
-		return "not in tmux"
+// GetCurrentLocation returns a user-friendly description of where they are.
+func (r *MissionRunner) GetCurrentLocation() string {
+	path := r.FS.Pwd()
+	if path == r.FS.Home {
+		return "~ (your home directory)"
 	}
-	if r.Tmux.Detached {
-		return fmt.Sprintf("detached from session '%s'", r.Tmux.SessionName)
+	if strings.HasPrefix(path, r.FS.Home) {
+		return "~" + strings.TrimPrefix(path, r.FS.Home)
 	}
-	return fmt.Sprintf("session '%s' [%d windows, %d panes]",
-		r.Tmux.SessionName, r.Tmux.Windows, r.Tmux.Panes)
+	return path
 }
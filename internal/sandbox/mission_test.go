@@ -4,6 +4,8 @@
 package sandbox
 
 import (
+	"reflect"
+	"slices"
 	"strings"
 	"testing"
 
@@ -167,6 +169,39 @@ func TestMissionRunner_Grep(t *testing.T) {
 	}
 }
 
+func TestMissionRunner_CpMvSources(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		err     string
+		want    []string
+	}{
+		{"copy into a directory", "cp a.txt docs", "", []string{"/home/learner/a.txt", "/home/learner/docs/a.txt"}},
+		{"copy several", "cp -v a.txt b.txt docs", "", []string{"/home/learner/docs/a.txt", "/home/learner/docs/b.txt"}},
+		{"move several", "mv a.txt b.txt docs/", "", []string{"/home/learner/docs/a.txt", "/home/learner/docs/b.txt"}},
+		{"several onto a file", "cp a.txt b.txt c.txt", "cp: target 'c.txt' is not a directory", nil},
+		{"no destination", "mv a.txt", "mv: missing destination file operand", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := NewMissionRunner(&Mission{Setup: func(fs *Filesystem) {
+				_ = fs.WriteFile("/home/learner/a.txt", "a")
+				_ = fs.WriteFile("/home/learner/b.txt", "b")
+				_ = fs.Mkdir("/home/learner/docs")
+			}})
+			result := runner.Execute(tt.command)
+			if result.Error != tt.err {
+				t.Fatalf("error: got %q, want %q", result.Error, tt.err)
+			}
+			for _, path := range tt.want {
+				if !runner.FS.Exists(path) {
+					t.Errorf("expected %s to exist", path)
+				}
+			}
+		})
+	}
+}
+
 func TestMissionRunner_CommandNotFound(t *testing.T) {
 	mission := &Mission{Setup: func(fs *Filesystem) {}}
 	runner := NewMissionRunner(mission)
@@ -276,3 +311,221 @@ func TestMission_CreateDir(t *testing.T) {
 		t.Error("Should be complete after mkdir workspace")
 	}
 }
+
+// findMission returns a mission from the YAML content by ID.
+func findMission(t *testing.T, id string) *Mission {
+	t.Helper()
+	for _, missions := range GetAllMissions() {
+		for _, m := range missions {
+			if m.ID == id {
+				return m
+			}
+		}
+	}
+	t.Fatalf("mission %s not found", id)
+	return nil
+}
+
+func TestMissionRunner_Records(t *testing.T) {
+	runner := NewMissionRunner(&Mission{})
+	runner.Execute("echo hi")
+	runner.Execute("   ")
+	runner.Execute("cat missing.txt")
+	runner.Execute("frobnicate")
+
+	want := []content.CommandRecord{
+		{Command: "echo hi", Output: "hi"},
+		{Command: "cat missing.txt", ExitCode: 1},
+		{Command: "frobnicate", ExitCode: 127},
+	}
+	if len(runner.Records) != len(want) {
+		t.Fatalf("expected %d records, got %+v", len(want), runner.Records)
+	}
+	for i, r := range runner.Records {
+		if r != want[i] {
+			t.Errorf("record %d: got %+v, want %+v", i, r, want[i])
+		}
+	}
+	runner.Reset()
+	if len(runner.Records) != 0 {
+		t.Error("reset should clear the records")
+	}
+}
+
+func TestMission_LogInvestigation(t *testing.T) {
+	runner := NewMissionRunner(findMission(t, "5.4-log-investigation"))
+	if runner.Execute("cat app.log").Completed {
+		t.Error("reading the whole log shouldn't count as using grep")
+	}
+
+	runner.Reset()
+	if runner.Execute("grep ERROR app.log").Completed {
+		t.Error("the warnings haven't been checked yet")
+	}
+	if !runner.Execute("grep WARNING app.log").Completed {
+		t.Error("expected the mission to complete once both greps have run")
+	}
+}
+
+func TestMission_EnvCheck(t *testing.T) {
+	runner := NewMissionRunner(findMission(t, "5.8-env-check"))
+	runAll(t, runner, "pwd", "ls")
+	if !runner.Execute("ls -a").Completed {
+		t.Error("expected pwd, ls, ls -a to complete the mission")
+	}
+
+	runner.Reset()
+	runAll(t, runner, "ls", "ls", "pwd")
+	if runner.Execute("ls -a").Completed {
+		t.Error("four commands is over par")
+	}
+}
+
+func TestMission_OrganizeMess(t *testing.T) {
+	runner := NewMissionRunner(findMission(t, "5.7-organize-mess"))
+	runAll(t, runner, "mkdir logs src docs", "touch docs/notes.md", "mv app.log logs/", "mv error.log logs/",
+		"mv main.py src/", "mv utils.py src/", "mv README.md docs/")
+	if runner.Execute("mv CHANGELOG.md docs/").Completed {
+		t.Error("a stray file in docs/ shouldn't count")
+	}
+	if !runner.Execute("rm docs/notes.md").Completed {
+		t.Error("expected the mission to complete once every file is filed")
+	}
+}
+
+func TestMissionRunner_Chmod(t *testing.T) {
+	runner := NewMissionRunner(&Mission{Setup: func(fs *Filesystem) { _ = fs.Touch("/run.sh") }})
+	if result := runner.Execute("chmod +x /run.sh"); !result.Success {
+		t.Fatalf("chmod failed: %s", result.Error)
+	}
+	if node, _ := runner.FS.Stat("/run.sh"); node.Perm() != 0o755 {
+		t.Errorf("got mode %o, want 755", node.Perm())
+	}
+	if result := runner.Execute("chmod 9 /run.sh"); result.Error != "chmod: invalid mode: '9'" {
+		t.Errorf("got error %q", result.Error)
+	}
+	if result := runner.Execute("chmod 755"); result.Error != "chmod: missing operand" {
+		t.Errorf("got error %q", result.Error)
+	}
+}
+
+func TestMissionRunner_GoalTrace(t *testing.T) {
+	runner := NewMissionRunner(findMission(t, "5.4-log-investigation"))
+	runAll(t, runner, "grep ERROR app.log")
+
+	trace, ok := runner.GoalTrace()
+	if !ok {
+		t.Fatal("YAML missions should explain their goals")
+	}
+	var passed []bool
+	for _, item := range trace.Checklist() {
+		passed = append(passed, item.Passed)
+	}
+	if want := []bool{true, true, true, false}; !reflect.DeepEqual(passed, want) {
+		t.Errorf("got %v, want %v", passed, want)
+	}
+	if got := trace.NextHint(); !strings.Contains(got, "WARNING") {
+		t.Errorf("expected a hint about the warnings, got %q", got)
+	}
+
+	runner.Reset()
+	if trace, _ := runner.GoalTrace(); trace.Checklist()[0].Passed {
+		t.Error("reset should clear the command history the goal sees")
+	}
+
+	legacy := NewMissionRunner(&Mission{Goal: func(content.GoalEvaluator) bool { return false }})
+	if _, ok := legacy.GoalTrace(); ok {
+		t.Error("hand-written goals can't explain themselves")
+	}
+}
+
+func TestMission_IncidentWatch(t *testing.T) {
+	runner := NewMissionRunner(findMission(t, "5.9-incident-watch"))
+	if runner.CurrentStage() == nil || runner.Mission.Goal != nil {
+		t.Fatal("expected a multi-stage mission")
+	}
+
+	// Work done before a stage begins doesn't count towards it.
+	runAll(t, runner, "grep ERROR /var/log/app.log")
+	if result := runner.Execute("tmux new -s watch"); !result.StageDone || runner.Stage != 1 {
+		t.Fatalf("expected stage 1 to be done, got %+v at stage %d", result, runner.Stage)
+	}
+	if result := runner.Execute("tmux split-window"); !result.StageDone || runner.Stage != 2 {
+		t.Fatalf("expected stage 2 to be done, got %+v at stage %d", result, runner.Stage)
+	}
+	if got := runner.Execute("grep WARNING /var/log/app.log").Output; got != "" {
+		t.Errorf("the warning shouldn't be logged until the last stage, got %q", got)
+	}
+	if result := runner.Execute("grep ERROR /var/log/app.log"); !result.StageDone || runner.Stage != 3 {
+		t.Fatalf("expected stage 3 to be done, got %+v at stage %d", result, runner.Stage)
+	}
+
+	// The last stage's setup adds to the log without undoing the learner's tmux work.
+	if !runner.InTmuxSession() {
+		t.Error("starting a stage shouldn't disturb tmux")
+	}
+	if runner.Execute("grep WARNING /var/log/app.log").Completed {
+		t.Error("the warnings need to be found from the other pane")
+	}
+	runAll(t, runner, "tmux select-pane -t 0")
+	if result := runner.Execute("grep WARNING /var/log/app.log"); !result.Completed || result.StageDone {
+		t.Errorf("expected the last stage to complete the mission, got %+v", result)
+	}
+
+	runner.Reset()
+	if runner.Stage != 0 {
+		t.Errorf("reset should go back to the first stage, got stage %d", runner.Stage)
+	}
+	if got, _ := runner.FS.ReadFile("/var/log/app.log"); strings.Contains(got, "WARNING") {
+		t.Error("reset should undo later stages' setup")
+	}
+}
+
+func TestConvertMission_Variant(t *testing.T) {
+	raw, err := content.GetRawMissions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var template *content.YAMLMission
+	for i := range raw {
+		if raw[i].ID == "2.3-copy-file" {
+			template = &raw[i]
+		}
+	}
+	if template == nil {
+		t.Fatal("mission 2.3-copy-file not found")
+	}
+
+	play := func() []string {
+		SetSeed(42)
+		mission, err := convertMission(template)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var briefings []string
+		for range 10 {
+			instance := mission.Variant()
+			briefings = append(briefings, instance.Briefing)
+
+			// Each instance is consistent: its own example solution completes it.
+			runner := NewMissionRunner(instance)
+			if !runner.Execute(instance.Commands[0]).Completed {
+				t.Errorf("%q didn't complete %q", instance.Commands[0], instance.Briefing)
+			}
+		}
+		return briefings
+	}
+
+	first := play()
+	if again := play(); !reflect.DeepEqual(first, again) {
+		t.Errorf("the same seed should replay the same missions:\n%v\n%v", first, again)
+	}
+	if len(slices.Compact(slices.Sorted(slices.Values(first)))) < 2 {
+		t.Errorf("replays should vary, got %v", first)
+	}
+
+	plain := findMission(t, "2.1-create-dir")
+	if plain.Variant != nil {
+		t.Error("missions without templates don't need variants")
+	}
+}
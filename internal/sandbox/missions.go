@@ -4,7 +4,9 @@
 package sandbox
 
 import (
+	"fmt"
 	"log"
+	"math/rand/v2"
 	"strings"
 
 	"github.com/2389-research/turtle/internal/content"
@@ -37,46 +39,113 @@ func convertMissions(raw []content.YAMLMission) map[int][]*Mission {
 	return result
 }
 
+// missionRand picks the values of template variables.
+var missionRand = rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+
+// SetSeed makes templated missions reproducible: the same seed and the same order of
+// play give the same file names, numbers and words.
+func SetSeed(seed uint64) {
+	missionRand = rand.New(rand.NewPCG(seed, seed))
+}
+
+// convertMission converts a YAML mission. A mission with template variables gets a
+// Variant that fills them in afresh.
 func convertMission(ym *content.YAMLMission) (*Mission, error) {
+	if !ym.HasTemplates() {
+		return convertInstance(ym)
+	}
+
+	template := *ym
+	var variant func() *Mission
+	newInstance := func() (*Mission, error) {
+		expanded, err := template.Expand(missionRand)
+		if err != nil {
+			return nil, err
+		}
+		mission, err := convertInstance(&expanded)
+		if err != nil {
+			return nil, err
+		}
+		mission.Variant = variant
+		return mission, nil
+	}
+
+	first, err := newInstance()
+	if err != nil {
+		return nil, err
+	}
+	variant = func() *Mission {
+		mission, err := newInstance()
+		if err != nil {
+			// Expansion errors come from the template, not the values picked, so
+			// having worked once it keeps working; this is just belt and braces.
+			return first
+		}
+		return mission
+	}
+	first.Variant = variant
+	return first, nil
+}
+
+// convertInstance converts a YAML mission whose templates, if any, are filled in.
+func convertInstance(ym *content.YAMLMission) (*Mission, error) {
+	mission := &Mission{
+		ID:           ym.ID,
+		SkillID:      ym.SkillID,
+		Level:        ym.Level,
+		Title:        ym.Title,
+		Briefing:     ym.Briefing,
+		Hint:         ym.Hint,
+		Explanation:  ym.Explanation,
+		Commands:     ym.Commands,
+		SetupActions: ym.Setup,
+	}
+
+	if len(ym.Stages) > 0 {
+		for i := range ym.Stages {
+			stage, err := convertStage(&ym.Stages[i])
+			if err != nil {
+				return nil, fmt.Errorf("stage %d: %w", i+1, err)
+			}
+			mission.Stages = append(mission.Stages, stage)
+		}
+		return mission, nil
+	}
+
 	goalNode, err := content.ParseGoal(ym.Goal)
 	if err != nil {
 		return nil, err
 	}
+	mission.Goal, mission.Trace = goalFuncs(goalNode)
+	return mission, nil
+}
 
-	// Capture setup actions for closure
-	setupActions := ym.Setup
-
-	return &Mission{
-		ID:          ym.ID,
-		SkillID:     ym.SkillID,
-		Level:       ym.Level,
-		Title:       ym.Title,
-		Briefing:    ym.Briefing,
-		Hint:        ym.Hint,
-		Explanation: ym.Explanation,
-		Commands:    ym.Commands,
-		Setup: func(fs *Filesystem) {
-			executeSetup(fs, setupActions)
-		},
-		Goal: func(ev content.GoalEvaluator) bool {
-			return goalNode.Evaluate(ev)
-		},
-	}, nil
+func convertStage(ys *content.YAMLStage) (*Stage, error) {
+	goalNode, err := content.ParseGoal(ys.Goal)
+	if err != nil {
+		return nil, err
+	}
+	goal, trace := goalFuncs(goalNode)
+	stage := &Stage{
+		Briefing:     ys.Briefing,
+		Hint:         ys.Hint,
+		Explanation:  ys.Explanation,
+		SetupActions: ys.Setup,
+		Goal:         goal,
+		Trace:        trace,
+	}
+	return stage, nil
 }
 
-func executeSetup(fs *Filesystem, actions []content.SetupAction) {
-	for _, action := range actions {
-		switch {
-		case action.Mkdir != "":
-			_ = fs.Mkdir(action.Mkdir)
-		case action.Cd != "":
-			_ = fs.Cd(action.Cd)
-		case action.Touch != "":
-			_ = fs.Touch(action.Touch)
-		case action.WriteFile != nil:
-			_ = fs.WriteFile(action.WriteFile.Path, action.WriteFile.Content)
-		}
+// goalFuncs wraps a parsed goal in the functions a Mission or Stage holds.
+func goalFuncs(goalNode content.GoalNode) (func(content.GoalEvaluator) bool, func(content.GoalEvaluator) content.GoalTrace) {
+	goal := func(ev content.GoalEvaluator) bool {
+		return goalNode.Evaluate(ev)
+	}
+	trace := func(ev content.GoalEvaluator) content.GoalTrace {
+		return content.Explain(goalNode, ev)
 	}
+	return goal, trace
 }
 
 // GetAllMissionsLegacy returns missions organized by level (legacy hardcoded version).
@@ -0,0 +1,218 @@
+// ABOUTME: Applies YAML setup actions to a mission runner before the learner starts
+// ABOUTME: Covers files, fixtures, links, permissions, mtimes, shell variables and history, processes and tmux sessions
+
+package sandbox
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/2389-research/turtle/internal/content"
+)
+
+// Process is a background process set up for the learner to find with ps.
+type Process struct {
+	PID     int
+	Command string
+}
+
+// firstPID is the PID given to the first setup process; later ones count up from it.
+const firstPID = 4200
+
+// ApplySetup performs setup actions in order, stopping at the first that fails.
+func (r *MissionRunner) ApplySetup(actions []content.SetupAction) error {
+	for i := range actions {
+		if err := r.applySetupAction(&actions[i]); err != nil {
+			return fmt.Errorf("setup %d (%s): %w", i+1, actions[i].Kind(), err)
+		}
+	}
+	return nil
+}
+
+func (r *MissionRunner) applySetupAction(a *content.SetupAction) error {
+	if err := a.Validate(); err != nil {
+		return err
+	}
+	fs := r.FS
+	switch {
+	case a.Mkdir != "":
+		return fs.Mkdir(a.Mkdir)
+	case a.Cd != "":
+		return fs.Cd(a.Cd)
+	case a.Touch != "":
+		return fs.Touch(a.Touch)
+	case a.WriteFile != nil:
+		return fs.WriteFile(a.WriteFile.Path, a.WriteFile.Content)
+	case a.Rm != "":
+		return fs.RemoveAll(a.Rm)
+	case a.Copy != nil:
+		return fs.CopyTree(a.Copy.From, a.Copy.To)
+	case a.Symlink != nil:
+		return fs.Symlink(a.Symlink.Target, a.Symlink.Path)
+	case a.Chmod != nil:
+		return fs.Chmod(a.Chmod.Mode, a.Chmod.Path)
+	case a.Chown != nil:
+		return fs.Chown(a.Chown.Owner, a.Chown.Group, a.Chown.Path)
+	case a.SetMtime != nil:
+		t, err := content.ParseSetupTime(a.SetMtime.Time, time.Now())
+		if err != nil {
+			return err
+		}
+		return fs.SetModTime(a.SetMtime.Path, t)
+	case a.Fixture != nil:
+		entries, err := content.LoadFixture(a.Fixture.Name)
+		if err != nil {
+			return err
+		}
+		return fs.Extract(a.Fixture.To, entries)
+	case len(a.Env) > 0:
+		if r.Env == nil {
+			r.Env = make(map[string]string, len(a.Env))
+		}
+		for name, value := range a.Env {
+			r.Env[name] = value
+		}
+	case len(a.SeedHistory) > 0:
+		r.History = append(r.History, a.SeedHistory...)
+	case a.StartProcess != nil:
+		r.startProcess(a.StartProcess.Command)
+	case a.TmuxSession != nil:
+		return r.setupTmuxSession(a.TmuxSession)
+	}
+	return nil
+}
+
+// startProcess adds a background process with the next free PID.
+func (r *MissionRunner) startProcess(command string) *Process {
+	pid := firstPID
+	if n := len(r.Processes); n > 0 {
+		pid = r.Processes[n-1].PID + 1
+	}
+	r.Processes = append(r.Processes, Process{PID: pid, Command: command})
+	return &r.Processes[len(r.Processes)-1]
+}
+
+// setupTmuxSession starts a detached session with the windows and panes described,
+// attaching to it if asked. Like starting tmux for real, the first session reads the
+// config files; their errors appear in the session's first pane.
+func (r *MissionRunner) setupTmuxSession(spec *content.TmuxSessionAction) error {
+	t := &r.Tmux
+	if t.Session(spec.Name) != nil {
+		return fmt.Errorf("duplicate session: %s", spec.Name)
+	}
+
+	var configErrs []string
+	if !t.serverUp() {
+		t.starting = true
+		configErrs = r.loadTmuxConfig()
+		t.starting = false
+	}
+
+	windows := spec.Windows
+	if len(windows) == 0 {
+		windows = []content.TmuxWindowSetup{{}}
+	}
+	s := t.newSession(spec.Name, windows[0].Name)
+	s.Windows[0].Active.Lines = configErrs
+	for i, ws := range windows {
+		w := s.Windows[0]
+		if i > 0 {
+			w = t.newWindow(s, s.nextFreeIndex(t.baseIndex()), ws.Name)
+		}
+		if err := r.setupPanes(w, ws.Panes); err != nil {
+			return fmt.Errorf("window %d: %w", i, err)
+		}
+	}
+
+	if spec.Attach {
+		if t.Client != nil {
+			return fmt.Errorf("already attached to %s; only one tmux_session can attach", t.Client.Name)
+		}
+		t.attach(s)
+	} else if t.Last == nil {
+		t.Last = s
+	}
+	return nil
+}
+
+// setupPanes splits a new window into the panes described, each splitting the one
+// before it, and leaves the first pane active.
+func (r *MissionRunner) setupPanes(w *TmuxWindow, panes []content.TmuxPaneSetup) error {
+	t := &r.Tmux
+	prev := w.Active
+	for i, ps := range panes {
+		pane := prev
+		if i > 0 {
+			pane = &TmuxPane{ID: t.nextPaneID}
+			if _, err := splitCell(prev.cell, ps.Split == "horizontal", 0, false, pane); err != nil {
+				return fmt.Errorf("pane %d: %w", i, err)
+			}
+			t.nextPaneID++
+			w.layout = layoutRoot(pane.cell)
+			w.Panes = layoutLeaves(w.layout)
+		}
+		if ps.Cwd != "" {
+			node, err := r.FS.Stat(ps.Cwd)
+			if err != nil || !node.IsDir() {
+				return fmt.Errorf("pane %d: not a directory: %s", i, ps.Cwd)
+			}
+			pane.Cwd = r.FS.resolvePath(ps.Cwd)
+		}
+		if ps.Command != "" {
+			pane.Lines = append(pane.Lines, "$ "+ps.Command)
+			r.startProcess(ps.Command)
+		}
+		prev = pane
+	}
+	return nil
+}
+
+// environ returns the shell's variables: the usual login ones, then any from setup.
+func (r *MissionRunner) environ() map[string]string {
+	env := map[string]string{
+		"HOME":  r.FS.Home,
+		"USER":  r.FS.User,
+		"PWD":   r.FS.Pwd(),
+		"SHELL": "/bin/bash",
+		"PATH":  "/usr/local/bin:/usr/bin:/bin",
+	}
+	for name, value := range r.Env {
+		env[name] = value
+	}
+	return env
+}
+
+// expandVars expands $NAME and ${NAME}; a "$" not followed by a name is kept.
+func expandVars(s string, env map[string]string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		rest := s[i+1:]
+		if strings.HasPrefix(rest, "{") {
+			if end := strings.IndexByte(rest, '}'); end > 0 {
+				b.WriteString(env[rest[1:end]])
+				i += end + 1
+				continue
+			}
+		}
+		n := 0
+		for n < len(rest) && (rest[n] == '_' || isAlnum(rest[n])) && !(n == 0 && rest[n] >= '0' && rest[n] <= '9') {
+			n++
+		}
+		if n == 0 {
+			b.WriteByte('$')
+			continue
+		}
+		b.WriteString(env[rest[:n]])
+		i += n
+	}
+	return b.String()
+}
+
+func isAlnum(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
@@ -0,0 +1,192 @@
+// ABOUTME: Tests for applying YAML setup actions to a mission runner
+// ABOUTME: Covers filesystem actions, shell state, processes, tmux sessions and setup errors
+
+package sandbox
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/2389-research/turtle/internal/content"
+)
+
+func TestApplySetup_Filesystem(t *testing.T) {
+	m := &Mission{ID: "test", SetupActions: []content.SetupAction{
+		{WriteFile: &content.WriteFileAction{Path: "~/app/run.sh", Content: "echo hi\n"}},
+		{Chmod: &content.ChmodAction{Path: "~/app/run.sh", Mode: "u+x"}},
+		{Chown: &content.ChownAction{Path: "~/app/run.sh", Owner: "root"}},
+		{Copy: &content.CopyAction{From: "~/app", To: "~/backup"}},
+		{Symlink: &content.SymlinkAction{Path: "~/latest", Target: "backup"}},
+		{SetMtime: &content.SetMtimeAction{Path: "~/backup/run.sh", Time: "3d ago"}},
+		{Rm: "~/app"},
+	}}
+	r := NewMissionRunner(m)
+	if r.SetupErr != nil {
+		t.Fatal(r.SetupErr)
+	}
+
+	node, err := r.FS.Stat("~/latest/run.sh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node.Perm() != 0o744 || node.Owner != "root" {
+		t.Errorf("copy should keep mode and owner, got %o %s", node.Perm(), node.Owner)
+	}
+	if age := time.Since(node.ModTime); age < 71*time.Hour || age > 73*time.Hour {
+		t.Errorf("mtime should be 3 days ago, got %v ago", age)
+	}
+	if r.FS.Exists("~/app") {
+		t.Error("rm should remove the whole directory")
+	}
+	if info, _ := r.goalContext().Stat("~/latest"); !info.IsSymlink {
+		t.Error("goals should see the symlink")
+	}
+}
+
+func TestApplySetup_Fixture(t *testing.T) {
+	m := &Mission{ID: "test", SetupActions: []content.SetupAction{
+		{WriteFile: &content.WriteFileAction{Path: "~/webapp/package.json", Content: "{}"}},
+		{Fixture: &content.FixtureAction{Name: "node-project", To: "~/webapp"}},
+		{Fixture: &content.FixtureAction{Name: "messy-downloads", To: "~/downloads"}},
+	}}
+	r := NewMissionRunner(m)
+	if r.SetupErr != nil {
+		t.Fatal(r.SetupErr)
+	}
+
+	if got, _ := r.FS.ReadFile("~/webapp/package.json"); !strings.Contains(got, `"name": "webapp"`) {
+		t.Errorf("the fixture should replace files in the way, got %q", got)
+	}
+	if node, _ := r.FS.Stat("~/webapp/scripts/build.sh"); node == nil || node.Perm() != 0o755 {
+		t.Error("fixture modes should be kept")
+	}
+	if got, _ := r.FS.ReadFile("~/downloads/latest-invoice.pdf"); !strings.Contains(got, "January") {
+		t.Errorf("fixture symlinks should resolve, got %q", got)
+	}
+	if node, _ := r.FS.Stat("~/downloads/old/resume-2019.doc"); node == nil || node.ModTime.Year() != 2019 {
+		t.Error("fixture mtimes should be kept")
+	}
+	if got := r.Execute("ls ~/webapp").Output; !strings.Contains(got, "node_modules/") {
+		t.Errorf("ls should show the fixture, got %q", got)
+	}
+}
+
+func TestApplySetup_ShellState(t *testing.T) {
+	m := &Mission{ID: "test", SetupActions: []content.SetupAction{
+		{Env: map[string]string{"EDITOR": "nano", "LOGS": "/var/log"}},
+		{SeedHistory: []string{"ssh prod", "tail -f app.log"}},
+		{StartProcess: &content.StartProcessAction{Command: "python server.py"}},
+		{StartProcess: &content.StartProcessAction{Command: "sleep 1000"}},
+	}}
+	r := NewMissionRunner(m)
+
+	if got := r.Execute("echo $EDITOR in ${LOGS}/x $HOME $UNSET.").Output; got != "nano in /var/log/x /home/learner ." {
+		t.Errorf("echo with variables: got %q", got)
+	}
+	if got := r.Execute("printenv EDITOR").Output; got != "nano" {
+		t.Errorf("printenv: got %q", got)
+	}
+	if out := r.Execute("history").Output; !strings.Contains(out, "    1  ssh prod\n    2  tail -f app.log") {
+		t.Errorf("history should start with the seeded commands, got:\n%s", out)
+	}
+
+	ps := r.Execute("ps").Output
+	if !strings.Contains(ps, "4200 pts/0    00:00:00 python server.py") || !strings.Contains(ps, "4201") {
+		t.Errorf("ps should list the setup processes, got:\n%s", ps)
+	}
+	if res := r.Execute("kill -9 4200"); res.Error != "" {
+		t.Fatal(res.Error)
+	}
+	if res := r.Execute("kill 4200"); !strings.Contains(res.Error, "No such process") {
+		t.Errorf("killing twice: got %q", res.Error)
+	}
+	if len(r.Processes) != 1 {
+		t.Errorf("one process should be left, got %+v", r.Processes)
+	}
+
+	r.Reset()
+	if len(r.Processes) != 2 || len(r.History) != 2 || r.Env["EDITOR"] != "nano" {
+		t.Errorf("reset should restore the setup state, got %+v %v %v", r.Processes, r.History, r.Env)
+	}
+}
+
+func TestApplySetup_TmuxSession(t *testing.T) {
+	m := &Mission{ID: "test", SetupActions: []content.SetupAction{
+		{TmuxSession: &content.TmuxSessionAction{Name: "work", Attach: true, Windows: []content.TmuxWindowSetup{
+			{Name: "code", Panes: []content.TmuxPaneSetup{
+				{Cwd: "~/projects"},
+				{Cwd: "/var/log", Split: "horizontal", Command: "tail -f syslog"},
+			}},
+			{Name: "notes"},
+		}}},
+		{TmuxSession: &content.TmuxSessionAction{Name: "spare"}},
+	}}
+	r := NewMissionRunner(m)
+	if r.SetupErr != nil {
+		t.Fatal(r.SetupErr)
+	}
+
+	snap := r.Tmux.Snapshot()
+	if snap.Attached != "work" || len(snap.Sessions) != 2 {
+		t.Fatalf("expected to be attached to work with two sessions, got %+v", snap)
+	}
+	code := snap.Sessions[1].Windows[0]
+	if code.Name != "code" || code.Panes != 2 || code.ActivePane != 0 || !strings.Contains(code.Layout, "{") {
+		t.Errorf("code window should have two side-by-side panes with the first active, got %+v", code)
+	}
+	if len(r.Processes) != 1 {
+		t.Errorf("the pane's command should be running, got %+v", r.Processes)
+	}
+
+	// Each pane keeps its own working directory.
+	if got := r.Execute("pwd").Output; got != "/home/learner/projects" {
+		t.Errorf("first pane: got %q", got)
+	}
+	r.Execute("tmux select-pane -t 1")
+	if got := r.Execute("pwd").Output; got != "/var/log" {
+		t.Errorf("second pane: got %q", got)
+	}
+	r.Execute("cd /tmp")
+	r.Execute("tmux select-pane -t 0")
+	if got := r.Execute("pwd").Output; got != "/home/learner/projects" {
+		t.Errorf("back in the first pane: got %q", got)
+	}
+}
+
+func TestApplySetup_Errors(t *testing.T) {
+	tests := []struct {
+		action content.SetupAction
+		want   string
+	}{
+		{content.SetupAction{Rm: "~/missing"}, "setup 1 (rm): no such file"},
+		{content.SetupAction{Chmod: &content.ChmodAction{Path: "~/missing", Mode: "600"}}, "setup 1 (chmod): cannot access"},
+		{content.SetupAction{Mkdir: "~/readme.txt/x"}, "setup 1 (mkdir): not a directory"},
+		{content.SetupAction{Mkdir: "~/x", Touch: "~/y"}, "setup 1 (mkdir): setup action has several operations"},
+		{content.SetupAction{TmuxSession: &content.TmuxSessionAction{Name: "w", Windows: []content.TmuxWindowSetup{
+			{Panes: []content.TmuxPaneSetup{{Cwd: "~/nowhere"}}},
+		}}}, "not a directory: ~/nowhere"},
+	}
+	for _, tt := range tests {
+		r := NewMissionRunner(&Mission{ID: "test", SetupActions: []content.SetupAction{tt.action}})
+		if r.SetupErr == nil || !strings.Contains(r.SetupErr.Error(), tt.want) {
+			t.Errorf("%+v: got %v, want %q", tt.action, r.SetupErr, tt.want)
+		}
+	}
+}
+
+func TestYAMLMissions_SetupApplies(t *testing.T) {
+	for level, missions := range GetAllMissions() {
+		for _, m := range missions {
+			r := NewMissionRunner(m)
+			if r.SetupErr != nil {
+				t.Errorf("level %d mission %s: %v", level, m.ID, r.SetupErr)
+			}
+			for i, stage := range m.Stages[min(1, len(m.Stages)):] {
+				if err := r.ApplySetup(stage.SetupActions); err != nil {
+					t.Errorf("mission %s stage %d: %v", m.ID, i+2, err)
+				}
+			}
+		}
+	}
+}
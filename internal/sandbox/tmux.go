@@ -0,0 +1,1158 @@
+// ABOUTME: Simulated tmux server with sessions, windows, and panes
+// ABOUTME: Parses -t targets and dispatches tmux subcommands with tmux's default output formats
+
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/2389-research/turtle/internal/content"
+)
+
+// errNoServer is what tmux prints when no server is running on the default socket.
+var errNoServer = errors.New("no server running on /tmp/tmux-1000/default")
+
+// errNested is what tmux prints when starting or attaching from inside a session.
+var errNested = errors.New("sessions should be nested with care, unset $TMUX to force")
+
+// TmuxState is the simulated tmux server and the learner's single client.
+type TmuxState struct {
+	Sessions []*TmuxSession                 // Sorted by name, like tmux's session tree
+	Client   *TmuxSession                   // Session the client is attached to (nil when detached)
+	Last     *TmuxSession                   // Most recently used session (default for attach)
+	Previous *TmuxSession                   // Session before the last switch (switch-client -l)
+	Options  map[string]string              // Global options set with set-option (unset = default)
+	Bindings map[string]map[string][]string // Key tables by name (nil = defaults)
+	Buffers  []*TmuxBuffer                  // Paste buffers, most recent first
+
+	starting      bool // Reading the config files before the first session exists
+	nextSessionID int
+	nextWindowID  int
+	nextPaneID    int
+	nextBufferID  int
+}
+
+// TmuxSession is a simulated tmux session.
+type TmuxSession struct {
+	ID      int
+	Name    string
+	Created time.Time
+	Windows []*TmuxWindow // Sorted by index
+	Current *TmuxWindow
+	Last    *TmuxWindow
+}
+
+// TmuxWindow is a simulated tmux window.
+type TmuxWindow struct {
+	ID       int
+	Index    int
+	Name     string
+	Panes    []*TmuxPane // In layout order (pane index order)
+	Active   *TmuxPane
+	LastPane *TmuxPane
+	Zoomed   bool   // Active pane temporarily fills the window (resize-pane -Z)
+	Preset   string // Last preset applied by select-layout
+	layout   *layoutCell
+}
+
+// TmuxPane is a simulated tmux pane.
+type TmuxPane struct {
+	ID    int
+	Lines []string // Scrollback: commands run in the pane and their output
+	Cwd   string   // The pane's working directory; empty until it has one of its own
+	cell  *layoutCell
+	copy  *copyMode
+}
+
+// Width returns the pane width in cells.
+func (p *TmuxPane) Width() int { return p.cell.SX }
+
+// Height returns the pane height in cells.
+func (p *TmuxPane) Height() int { return p.cell.SY }
+
+// PaneSize returns the visible size of a pane, which fills the window while zoomed.
+func (w *TmuxWindow) PaneSize(p *TmuxPane) (int, int) {
+	if w.Zoomed && p == w.Active {
+		return TmuxWindowWidth, TmuxWindowHeight
+	}
+	return p.Width(), p.Height()
+}
+
+// Running returns true if the tmux server has any sessions.
+func (t *TmuxState) Running() bool {
+	return len(t.Sessions) > 0
+}
+
+// CurrentSession returns the attached session, or the most recent one when detached.
+func (t *TmuxState) CurrentSession() *TmuxSession {
+	if t.Client != nil {
+		return t.Client
+	}
+	return t.Last
+}
+
+// CurrentWindow returns the current window of the current session.
+func (t *TmuxState) CurrentWindow() *TmuxWindow {
+	if s := t.CurrentSession(); s != nil {
+		return s.Current
+	}
+	return nil
+}
+
+// CurrentPane returns the active pane of the current window.
+func (t *TmuxState) CurrentPane() *TmuxPane {
+	if w := t.CurrentWindow(); w != nil {
+		return w.Active
+	}
+	return nil
+}
+
+// Snapshot describes the server for goal evaluation, or returns nil if it isn't running.
+func (t *TmuxState) Snapshot() *content.TmuxSnapshot {
+	if !t.Running() {
+		return nil
+	}
+	snap := &content.TmuxSnapshot{}
+	if t.Client != nil {
+		snap.Attached = t.Client.Name
+	}
+	if s := t.CurrentSession(); s != nil {
+		snap.Current = s.Name
+	}
+	for _, s := range t.Sessions {
+		info := content.TmuxSessionInfo{Name: s.Name, Current: s.Current.Index}
+		for _, w := range s.Windows {
+			info.Windows = append(info.Windows, content.TmuxWindowInfo{
+				Index:      w.Index,
+				Name:       w.Name,
+				Panes:      len(w.Panes),
+				ActivePane: w.PaneIndex(w.Active),
+				Layout:     w.Layout(),
+			})
+		}
+		snap.Sessions = append(snap.Sessions, info)
+	}
+	return snap
+}
+
+// Session returns the session with the given name, or nil.
+func (t *TmuxState) Session(name string) *TmuxSession {
+	for _, s := range t.Sessions {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// PaneIndex returns the index of a pane within its window, or -1.
+func (w *TmuxWindow) PaneIndex(p *TmuxPane) int {
+	for i, wp := range w.Panes {
+		if wp == p {
+			return i
+		}
+	}
+	return -1
+}
+
+// Layout returns the window layout in tmux's #{window_layout} format.
+func (w *TmuxWindow) Layout() string {
+	return layoutString(w.layout)
+}
+
+// Window returns the window with the given index, or nil.
+func (s *TmuxSession) Window(index int) *TmuxWindow {
+	for _, w := range s.Windows {
+		if w.Index == index {
+			return w
+		}
+	}
+	return nil
+}
+
+// newSession creates a session with one window and one pane.
+func (t *TmuxState) newSession(name, windowName string) *TmuxSession {
+	if name == "" {
+		for n := t.nextSessionID; ; n++ {
+			if t.Session(strconv.Itoa(n)) == nil {
+				name = strconv.Itoa(n)
+				break
+			}
+		}
+	}
+	s := &TmuxSession{ID: t.nextSessionID, Name: name, Created: time.Now()}
+	t.nextSessionID++
+	t.newWindow(s, t.baseIndex(), windowName)
+	t.Sessions = append(t.Sessions, s)
+	t.sortSessions()
+	return s
+}
+
+// newWindow adds a window with a single pane at the given index.
+func (t *TmuxState) newWindow(s *TmuxSession, index int, name string) *TmuxWindow {
+	if name == "" {
+		name = "bash"
+	}
+	pane := &TmuxPane{ID: t.nextPaneID}
+	t.nextPaneID++
+	w := &TmuxWindow{ID: t.nextWindowID, Index: index, Name: name, Panes: []*TmuxPane{pane}, Active: pane}
+	t.nextWindowID++
+	w.layout = newLayout(pane, TmuxWindowWidth, TmuxWindowHeight)
+	s.addWindow(w)
+	return w
+}
+
+// addWindow links a window into a session, keeping windows sorted by index.
+func (s *TmuxSession) addWindow(w *TmuxWindow) {
+	s.Windows = append(s.Windows, w)
+	sort.Slice(s.Windows, func(i, j int) bool { return s.Windows[i].Index < s.Windows[j].Index })
+	if s.Current == nil {
+		s.Current = w
+	}
+}
+
+// nextFreeIndex returns the lowest unused window index from base upwards.
+func (s *TmuxSession) nextFreeIndex(base int) int {
+	for i := base; ; i++ {
+		if s.Window(i) == nil {
+			return i
+		}
+	}
+}
+
+func (t *TmuxState) sortSessions() {
+	sort.Slice(t.Sessions, func(i, j int) bool { return t.Sessions[i].Name < t.Sessions[j].Name })
+}
+
+// selectWindow makes w the current window of s, remembering the previous one.
+func (s *TmuxSession) selectWindow(w *TmuxWindow) {
+	if s.Current != w {
+		s.Last = s.Current
+		s.Current = w
+	}
+}
+
+// selectPane makes p the active pane of w, remembering the previous one.
+// Moving to another pane unzooms the window.
+func (w *TmuxWindow) selectPane(p *TmuxPane) {
+	if w.Active != p {
+		w.Zoomed = false
+		w.LastPane = w.Active
+		w.Active = p
+	}
+}
+
+// attach points the client at a session, remembering the previous one.
+func (t *TmuxState) attach(s *TmuxSession) {
+	if t.Client != nil && t.Client != s {
+		t.Previous = t.Client
+	}
+	t.Client = s
+	t.Last = s
+}
+
+// removeSession destroys a session, detaching the client if it was attached to it.
+func (t *TmuxState) removeSession(s *TmuxSession) {
+	for i, ss := range t.Sessions {
+		if ss == s {
+			t.Sessions = append(t.Sessions[:i], t.Sessions[i+1:]...)
+			break
+		}
+	}
+	if t.Client == s {
+		t.Client = nil
+	}
+	if t.Previous == s {
+		t.Previous = nil
+	}
+	if t.Last == s {
+		t.Last = nil
+		if len(t.Sessions) > 0 {
+			t.Last = t.Sessions[0]
+		}
+	}
+	if len(t.Sessions) == 0 {
+		t.reset()
+	}
+}
+
+// reset returns the server to its not-running state (tmux kill-server).
+func (t *TmuxState) reset() {
+	*t = TmuxState{}
+}
+
+// currentOrError returns the current session, or tmux's error when there is none.
+func (t *TmuxState) currentOrError() (*TmuxSession, error) {
+	if !t.Running() {
+		return nil, errNoServer
+	}
+	if s := t.CurrentSession(); s != nil {
+		return s, nil
+	}
+	return nil, errors.New("no current session")
+}
+
+// resolveSession resolves a target-session: "name", a unique name prefix, "=name" or "$id".
+func (t *TmuxState) resolveSession(spec string) (*TmuxSession, error) {
+	if !t.Running() {
+		return nil, errNoServer
+	}
+	if spec == "" {
+		return t.currentOrError()
+	}
+	if id, ok := strings.CutPrefix(spec, "$"); ok {
+		for _, s := range t.Sessions {
+			if strconv.Itoa(s.ID) == id {
+				return s, nil
+			}
+		}
+		return nil, fmt.Errorf("can't find session: %s", spec)
+	}
+	if name, ok := strings.CutPrefix(spec, "="); ok {
+		if s := t.Session(name); s != nil {
+			return s, nil
+		}
+		return nil, fmt.Errorf("can't find session: %s", name)
+	}
+	if s := t.Session(spec); s != nil {
+		return s, nil
+	}
+
+	var matches []*TmuxSession
+	for _, s := range t.Sessions {
+		if strings.HasPrefix(s.Name, spec) {
+			matches = append(matches, s)
+		}
+	}
+	if len(matches) == 1 {
+		return matches[0], nil
+	}
+	return nil, fmt.Errorf("can't find session: %s", spec)
+}
+
+// resolveWindow resolves a target-window: "session:window", or a bare window in the
+// current session, falling back to a session name like tmux does.
+func (t *TmuxState) resolveWindow(spec string) (*TmuxSession, *TmuxWindow, error) {
+	if !t.Running() {
+		return nil, nil, errNoServer
+	}
+	if sessPart, winPart, ok := strings.Cut(spec, ":"); ok {
+		s, err := t.resolveSession(sessPart)
+		if err != nil {
+			return nil, nil, err
+		}
+		winPart, _, _ = strings.Cut(winPart, ".")
+		w, err := s.findWindow(winPart)
+		return s, w, err
+	}
+
+	if spec == "" {
+		s, err := t.currentOrError()
+		if err != nil {
+			return nil, nil, err
+		}
+		return s, s.Current, nil
+	}
+	if strings.HasPrefix(spec, "@") {
+		return t.windowByID(spec)
+	}
+
+	if s, err := t.currentOrError(); err == nil {
+		if w, err := s.findWindow(spec); err == nil {
+			return s, w, nil
+		}
+	}
+	if s, err := t.resolveSession(spec); err == nil {
+		return s, s.Current, nil
+	}
+	return nil, nil, fmt.Errorf("can't find window: %s", spec)
+}
+
+// resolvePane resolves a target-pane: "session:window.pane", "window.pane", "%id",
+// or a bare pane index in the current window.
+func (t *TmuxState) resolvePane(spec string) (*TmuxSession, *TmuxWindow, *TmuxPane, error) {
+	if !t.Running() {
+		return nil, nil, nil, errNoServer
+	}
+	if strings.HasPrefix(spec, "%") {
+		return t.paneByID(spec)
+	}
+
+	winSpec, paneSpec := spec, ""
+	switch {
+	case strings.Contains(spec, ":"):
+		sessPart, rest, _ := strings.Cut(spec, ":")
+		winPart, panePart, _ := strings.Cut(rest, ".")
+		winSpec, paneSpec = sessPart+":"+winPart, panePart
+	case strings.Contains(spec, "."):
+		winSpec, paneSpec, _ = strings.Cut(spec, ".")
+		winSpec = ":" + winSpec
+	case spec != "":
+		// A bare value is a pane index in the current window if one matches.
+		if w := t.CurrentWindow(); w != nil {
+			if p, err := w.findPane(spec); err == nil {
+				return t.CurrentSession(), w, p, nil
+			}
+		}
+	}
+
+	s, w, err := t.resolveWindow(winSpec)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	p, err := w.findPane(paneSpec)
+	return s, w, p, err
+}
+
+func (t *TmuxState) windowByID(spec string) (*TmuxSession, *TmuxWindow, error) {
+	for _, s := range t.Sessions {
+		for _, w := range s.Windows {
+			if "@"+strconv.Itoa(w.ID) == spec {
+				return s, w, nil
+			}
+		}
+	}
+	return nil, nil, fmt.Errorf("can't find window: %s", spec)
+}
+
+func (t *TmuxState) paneByID(spec string) (*TmuxSession, *TmuxWindow, *TmuxPane, error) {
+	for _, s := range t.Sessions {
+		for _, w := range s.Windows {
+			for _, p := range w.Panes {
+				if "%"+strconv.Itoa(p.ID) == spec {
+					return s, w, p, nil
+				}
+			}
+		}
+	}
+	return nil, nil, nil, fmt.Errorf("can't find pane: %s", spec)
+}
+
+// findWindow resolves the window part of a target within a session.
+func (s *TmuxSession) findWindow(spec string) (*TmuxWindow, error) {
+	switch {
+	case spec == "":
+		return s.Current, nil
+	case spec == "!":
+		if s.Last == nil {
+			return nil, errors.New("no last window")
+		}
+		return s.Last, nil
+	case spec == "^":
+		return s.Windows[0], nil
+	case spec == "$":
+		return s.Windows[len(s.Windows)-1], nil
+	case strings.HasPrefix(spec, "+") || strings.HasPrefix(spec, "-"):
+		return s.Windows[offsetIndex(s.windowPos(s.Current), spec, len(s.Windows))], nil
+	case strings.HasPrefix(spec, "@"):
+		for _, w := range s.Windows {
+			if "@"+strconv.Itoa(w.ID) == spec {
+				return w, nil
+			}
+		}
+	default:
+		spec = strings.TrimPrefix(spec, "=")
+		if idx, err := strconv.Atoi(spec); err == nil {
+			if w := s.Window(idx); w != nil {
+				return w, nil
+			}
+			break
+		}
+		for _, w := range s.Windows {
+			if w.Name == spec {
+				return w, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("can't find window: %s", spec)
+}
+
+func (s *TmuxSession) windowPos(w *TmuxWindow) int {
+	for i, sw := range s.Windows {
+		if sw == w {
+			return i
+		}
+	}
+	return 0
+}
+
+// findPane resolves the pane part of a target within a window.
+func (w *TmuxWindow) findPane(spec string) (*TmuxPane, error) {
+	switch {
+	case spec == "":
+		return w.Active, nil
+	case spec == "!":
+		if w.LastPane == nil {
+			return nil, errors.New("no last pane")
+		}
+		return w.LastPane, nil
+	case strings.HasPrefix(spec, "+") || strings.HasPrefix(spec, "-"):
+		return w.Panes[offsetIndex(w.PaneIndex(w.Active), spec, len(w.Panes))], nil
+	case strings.HasPrefix(spec, "%"):
+		for _, p := range w.Panes {
+			if "%"+strconv.Itoa(p.ID) == spec {
+				return p, nil
+			}
+		}
+	default:
+		if idx, err := strconv.Atoi(spec); err == nil && idx >= 0 && idx < len(w.Panes) {
+			return w.Panes[idx], nil
+		}
+	}
+	return nil, fmt.Errorf("can't find pane: %s", spec)
+}
+
+// offsetIndex applies a "+N"/"-N" target offset to pos, wrapping within n items.
+func offsetIndex(pos int, spec string, n int) int {
+	offset := 1
+	if len(spec) > 1 {
+		if v, err := strconv.Atoi(spec[1:]); err == nil {
+			offset = v
+		}
+	}
+	if spec[0] == '-' {
+		offset = -offset
+	}
+	return ((pos+offset)%n + n) % n
+}
+
+// tmuxFlags holds parsed getopt-style flags for a tmux command.
+type tmuxFlags struct {
+	set    map[byte]bool
+	values map[byte]string
+	args   []string
+}
+
+func (f tmuxFlags) has(c byte) bool      { return f.set[c] }
+func (f tmuxFlags) value(c byte) string  { return f.values[c] }
+func (f tmuxFlags) hasValue(c byte) bool { _, ok := f.values[c]; return ok }
+func (f tmuxFlags) target() string       { return f.values['t'] }
+func (f tmuxFlags) arg(i int) (string, bool) {
+	if i < len(f.args) {
+		return f.args[i], true
+	}
+	return "", false
+}
+
+// tmuxCommand describes one tmux subcommand.
+type tmuxCommand struct {
+	Name    string
+	Alias   string
+	Flags   string // getopt spec: letters, with ':' after those that take a value
+	MaxArgs int    // Maximum positional arguments (-1 = unlimited)
+	Usage   string
+	Run     func(r *MissionRunner, f tmuxFlags) MissionResult
+}
+
+// tmuxCommands is the table of subcommands the simulator understands.
+var tmuxCommands []*tmuxCommand
+
+func init() {
+	tmuxCommands = []*tmuxCommand{
+		{"attach-session", "attach", "t:", 0, "[-t target-session]", (*MissionRunner).tmuxAttach},
+		{"bind-key", "bind", "nrN:T:", -1, "[-nr] [-T key-table] [-N note] key [command [argument ...]]", (*MissionRunner).tmuxBindKey},
+		{"break-pane", "breakp", "dn:s:t:", 0, "[-d] [-n window-name] [-s src-pane] [-t dst-window]", (*MissionRunner).tmuxBreakPane},
+		{"copy-mode", "", "ut:", 0, "[-u] [-t target-pane]", (*MissionRunner).tmuxCopyMode},
+		{"delete-buffer", "deleteb", "b:", 0, "[-b buffer-name]", (*MissionRunner).tmuxDeleteBuffer},
+		{"detach-client", "detach", "s:t:", 0, "[-s target-session]", (*MissionRunner).tmuxDetach},
+		{"display-message", "display", "pt:", 1, "[-p] [-t target-pane] [message]", (*MissionRunner).tmuxDisplayMessage},
+		{"has-session", "has", "t:", 0, "[-t target-session]", (*MissionRunner).tmuxHasSession},
+		{"join-pane", "joinp", "bdhvl:s:t:", 0, "[-bdhv] [-l size] [-s src-pane] [-t dst-pane]", (*MissionRunner).tmuxJoinPane},
+		{"kill-pane", "killp", "at:", 0, "[-a] [-t target-pane]", (*MissionRunner).tmuxKillPane},
+		{"kill-server", "", "", 0, "", (*MissionRunner).tmuxKillServer},
+		{"kill-session", "", "at:", 0, "[-a] [-t target-session]", (*MissionRunner).tmuxKillSession},
+		{"kill-window", "killw", "at:", 0, "[-a] [-t target-window]", (*MissionRunner).tmuxKillWindow},
+		{"last-pane", "lastp", "t:", 0, "[-t target-window]", (*MissionRunner).tmuxLastPane},
+		{"last-window", "last", "t:", 0, "[-t target-session]", (*MissionRunner).tmuxLastWindow},
+		{"list-buffers", "lsb", "", 0, "", (*MissionRunner).tmuxListBuffers},
+		{"list-keys", "lsk", "T:", 0, "[-T key-table]", (*MissionRunner).tmuxListKeys},
+		{"list-panes", "lsp", "ast:", 0, "[-as] [-t target-window]", (*MissionRunner).tmuxListPanes},
+		{"list-sessions", "ls", "", 0, "", (*MissionRunner).tmuxListSessions},
+		{"list-windows", "lsw", "at:", 0, "[-a] [-t target-session]", (*MissionRunner).tmuxListWindows},
+		{"new-session", "new", "dc:n:s:x:y:", 0, "[-d] [-c start-directory] [-n window-name] [-s session-name]", (*MissionRunner).tmuxNewSession},
+		{"new-window", "neww", "dc:n:t:", 0, "[-d] [-c start-directory] [-n window-name] [-t target-window]", (*MissionRunner).tmuxNewWindow},
+		{"next-layout", "nextl", "t:", 0, "[-t target-window]", (*MissionRunner).tmuxNextLayout},
+		{"next-window", "next", "t:", 0, "[-t target-session]", (*MissionRunner).tmuxNextWindow},
+		{"paste-buffer", "pasteb", "db:t:", 0, "[-d] [-b buffer-name] [-t target-pane]", (*MissionRunner).tmuxPasteBuffer},
+		{"previous-layout", "prevl", "t:", 0, "[-t target-window]", (*MissionRunner).tmuxPreviousLayout},
+		{"previous-window", "prev", "t:", 0, "[-t target-session]", (*MissionRunner).tmuxPreviousWindow},
+		{"rename-session", "rename", "t:", 1, "[-t target-session] new-name", (*MissionRunner).tmuxRenameSession},
+		{"rename-window", "renamew", "t:", 1, "[-t target-window] new-name", (*MissionRunner).tmuxRenameWindow},
+		{"resize-pane", "resizep", "DLRUZt:", 1, "[-DLRUZ] [-t target-pane] [adjustment]", (*MissionRunner).tmuxResizePane},
+		{"save-buffer", "saveb", "ab:", 1, "[-a] [-b buffer-name] path", (*MissionRunner).tmuxSaveBuffer},
+		{"select-layout", "selectl", "npt:", 1, "[-np] [-t target-pane] [layout-name]", (*MissionRunner).tmuxSelectLayout},
+		{"select-pane", "selectp", "DLRUlt:", 0, "[-DLRUl] [-t target-pane]", (*MissionRunner).tmuxSelectPane},
+		{"select-window", "selectw", "lnpt:", 0, "[-lnp] [-t target-window]", (*MissionRunner).tmuxSelectWindow},
+		{"send-keys", "send", "Xt:", -1, "[-X] [-t target-pane] key ...", (*MissionRunner).tmuxSendKeys},
+		{"send-prefix", "", "2t:", 0, "[-2] [-t target-pane]", (*MissionRunner).tmuxSendPrefix},
+		{"set-buffer", "setb", "ab:", 1, "[-a] [-b buffer-name] data", (*MissionRunner).tmuxSetBuffer},
+		{"set-option", "set", "agoqsuwt:", 2, "[-agoqsuw] [-t target-pane] option [value]", (*MissionRunner).tmuxSetOption},
+		{"set-window-option", "setw", "agoqut:", 2, "[-agoqu] [-t target-window] option [value]", (*MissionRunner).tmuxSetOption},
+		{"show-buffer", "showb", "b:", 0, "[-b buffer-name]", (*MissionRunner).tmuxShowBuffer},
+		{"show-options", "show", "gqsvwt:", 1, "[-gqsvw] [-t target-pane] [option]", (*MissionRunner).tmuxShowOptions},
+		{"source-file", "source", "nqv", -1, "[-nqv] path ...", (*MissionRunner).tmuxSourceFile},
+		{"split-window", "splitw", "bdhvc:l:t:", 0, "[-bdhv] [-c start-directory] [-l size] [-t target-pane]", (*MissionRunner).tmuxSplitWindow},
+		{"swap-pane", "swapp", "dDUs:t:", 0, "[-dDU] [-s src-pane] [-t dst-pane]", (*MissionRunner).tmuxSwapPane},
+		{"switch-client", "switchc", "lnpt:", 0, "[-lnp] [-t target-session]", (*MissionRunner).tmuxSwitchClient},
+		{"unbind-key", "unbind", "anqT:", 1, "[-anq] [-T key-table] key", (*MissionRunner).tmuxUnbindKey},
+	}
+}
+
+// lookupTmuxCommand finds a command by full name, alias, or unambiguous prefix.
+func lookupTmuxCommand(name string) (*tmuxCommand, error) {
+	var matches []*tmuxCommand
+	for _, c := range tmuxCommands {
+		if c.Name == name || (c.Alias != "" && c.Alias == name) {
+			return c, nil
+		}
+		if strings.HasPrefix(c.Name, name) {
+			matches = append(matches, c)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("unknown command: %s", name)
+	case 1:
+		return matches[0], nil
+	default:
+		names := make([]string, 0, len(matches))
+		for _, c := range matches {
+			names = append(names, c.Name)
+		}
+		return nil, fmt.Errorf("ambiguous command: %s, could be: %s", name, strings.Join(names, ", "))
+	}
+}
+
+// canonicalTmuxCommand resolves an alias or prefix to the full command name.
+func canonicalTmuxCommand(name string) string {
+	if c, err := lookupTmuxCommand(name); err == nil {
+		return c.Name
+	}
+	return name
+}
+
+// parseTmuxFlags parses getopt-style flags ("-dP", "-t x", "-tx") against spec.
+func parseTmuxFlags(args []string, spec string) (tmuxFlags, error) {
+	f := tmuxFlags{set: map[byte]bool{}, values: map[byte]string{}}
+	i := 0
+	for ; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			i++
+			break
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			break
+		}
+		for j := 1; j < len(arg); j++ {
+			c := arg[j]
+			pos := strings.IndexByte(spec, c)
+			if pos < 0 || c == ':' {
+				return f, fmt.Errorf("unknown flag -%c", c)
+			}
+			if pos+1 < len(spec) && spec[pos+1] == ':' {
+				switch {
+				case j+1 < len(arg):
+					f.values[c] = arg[j+1:]
+				case i+1 < len(args):
+					i++
+					f.values[c] = args[i]
+				default:
+					return f, fmt.Errorf("-%c expects an argument", c)
+				}
+				break
+			}
+			f.set[c] = true
+		}
+	}
+	f.args = args[i:]
+	return f, nil
+}
+
+// executeTmux handles tmux commands.
+func (r *MissionRunner) executeTmux(args []string) MissionResult {
+	if len(args) == 0 {
+		// Just 'tmux' starts a new session
+		args = []string{"new-session"}
+	}
+
+	cmd, err := lookupTmuxCommand(args[0])
+	if err != nil {
+		return MissionResult{Error: err.Error()}
+	}
+	flags, err := parseTmuxFlags(args[1:], cmd.Flags)
+	if err != nil || (cmd.MaxArgs >= 0 && len(flags.args) > cmd.MaxArgs) {
+		return MissionResult{Error: strings.TrimSpace(fmt.Sprintf("usage: %s %s", cmd.Name, cmd.Usage))}
+	}
+	return cmd.Run(r, flags)
+}
+
+func tmuxError(err error) MissionResult {
+	return MissionResult{Error: err.Error()}
+}
+
+func tmuxOK(format string, a ...any) MissionResult {
+	return MissionResult{Output: fmt.Sprintf(format, a...), Success: true}
+}
+
+func (r *MissionRunner) tmuxNewSession(f tmuxFlags) MissionResult {
+	t := &r.Tmux
+	if t.Client != nil && !f.has('d') {
+		return tmuxError(errNested)
+	}
+	name := f.value('s')
+	if name != "" && t.Session(name) != nil {
+		return tmuxError(fmt.Errorf("duplicate session: %s", name))
+	}
+
+	// Starting the server reads the config files; their errors are shown, not fatal.
+	var configErrs []string
+	if !t.serverUp() {
+		t.starting = true
+		configErrs = r.loadTmuxConfig()
+		t.starting = false
+	}
+
+	s := t.newSession(name, f.value('n'))
+	message := fmt.Sprintf("[new session %s created]", s.Name)
+	if f.has('d') {
+		if t.Last == nil {
+			t.Last = s
+		}
+		message = fmt.Sprintf("[new session %s created, detached]", s.Name)
+	} else {
+		t.attach(s)
+	}
+	return tmuxOK("%s", strings.Join(append(configErrs, message), "\n"))
+}
+
+func (r *MissionRunner) tmuxAttach(f tmuxFlags) MissionResult {
+	t := &r.Tmux
+	if !t.Running() {
+		return tmuxError(errors.New("no sessions"))
+	}
+	if t.Client != nil {
+		return tmuxError(errNested)
+	}
+	s, err := t.resolveSession(f.target())
+	if err != nil {
+		return tmuxError(err)
+	}
+	t.attach(s)
+	return tmuxOK("[attached to session %s]", s.Name)
+}
+
+func (r *MissionRunner) tmuxDetach(_ tmuxFlags) MissionResult {
+	t := &r.Tmux
+	if t.Client == nil {
+		return tmuxError(errors.New("no current client"))
+	}
+	name := t.Client.Name
+	t.Client = nil
+	return tmuxOK("[detached (from session %s)]", name)
+}
+
+func (r *MissionRunner) tmuxHasSession(f tmuxFlags) MissionResult {
+	if _, err := r.Tmux.resolveSession(f.target()); err != nil {
+		return tmuxError(err)
+	}
+	return MissionResult{Success: true}
+}
+
+func (r *MissionRunner) tmuxKillServer(_ tmuxFlags) MissionResult {
+	if !r.Tmux.Running() {
+		return tmuxError(errNoServer)
+	}
+	r.Tmux.reset()
+	return tmuxOK("[server exited]")
+}
+
+func (r *MissionRunner) tmuxKillSession(f tmuxFlags) MissionResult {
+	t := &r.Tmux
+	s, err := t.resolveSession(f.target())
+	if err != nil {
+		return tmuxError(err)
+	}
+	if f.has('a') {
+		for _, other := range append([]*TmuxSession(nil), t.Sessions...) {
+			if other != s {
+				t.removeSession(other)
+			}
+		}
+		return tmuxOK("[killed all sessions except %s]", s.Name)
+	}
+	t.removeSession(s)
+	return tmuxOK("[killed session %s]", s.Name)
+}
+
+func (r *MissionRunner) tmuxRenameSession(f tmuxFlags) MissionResult {
+	t := &r.Tmux
+	name, ok := f.arg(0)
+	if !ok {
+		return tmuxError(errors.New("usage: rename-session [-t target-session] new-name"))
+	}
+	s, err := t.resolveSession(f.target())
+	if err != nil {
+		return tmuxError(err)
+	}
+	if other := t.Session(name); other != nil && other != s {
+		return tmuxError(fmt.Errorf("duplicate session: %s", name))
+	}
+	old := s.Name
+	s.Name = name
+	t.sortSessions()
+	return tmuxOK("[renamed session %s to %s]", old, name)
+}
+
+func (r *MissionRunner) tmuxSwitchClient(f tmuxFlags) MissionResult {
+	t := &r.Tmux
+	if t.Client == nil {
+		return tmuxError(errors.New("no current client"))
+	}
+
+	var s *TmuxSession
+	switch {
+	case f.has('l'):
+		if t.Previous == nil {
+			return tmuxError(errors.New("can't find last session"))
+		}
+		s = t.Previous
+	case f.has('n'), f.has('p'):
+		pos := 0
+		for i, ss := range t.Sessions {
+			if ss == t.Client {
+				pos = i
+			}
+		}
+		spec := "+"
+		if f.has('p') {
+			spec = "-"
+		}
+		s = t.Sessions[offsetIndex(pos, spec, len(t.Sessions))]
+	default:
+		var err error
+		if s, err = t.resolveSession(f.target()); err != nil {
+			return tmuxError(err)
+		}
+	}
+	t.attach(s)
+	return tmuxOK("[switched to session %s]", s.Name)
+}
+
+func (r *MissionRunner) tmuxListSessions(_ tmuxFlags) MissionResult {
+	t := &r.Tmux
+	if !t.Running() {
+		return tmuxError(errNoServer)
+	}
+	lines := make([]string, 0, len(t.Sessions))
+	for _, s := range t.Sessions {
+		line := fmt.Sprintf("%s: %d windows (created %s)", s.Name, len(s.Windows), s.Created.Format(time.ANSIC))
+		if s == t.Client {
+			line += " (attached)"
+		}
+		lines = append(lines, line)
+	}
+	return tmuxOK("%s", strings.Join(lines, "\n"))
+}
+
+func (r *MissionRunner) tmuxListWindows(f tmuxFlags) MissionResult {
+	t := &r.Tmux
+	sessions := t.Sessions
+	if !f.has('a') {
+		s, err := t.resolveSession(f.target())
+		if err != nil {
+			return tmuxError(err)
+		}
+		sessions = []*TmuxSession{s}
+	} else if !t.Running() {
+		return tmuxError(errNoServer)
+	}
+
+	var lines []string
+	for _, s := range sessions {
+		for _, w := range s.Windows {
+			prefix := ""
+			if f.has('a') {
+				prefix = s.Name + ":"
+			}
+			line := fmt.Sprintf("%s%d: %s%s (%d panes) [%dx%d] [layout %s] @%d",
+				prefix, w.Index, w.Name, s.windowFlags(w), len(w.Panes),
+				TmuxWindowWidth, TmuxWindowHeight, w.Layout(), w.ID)
+			if w == s.Current {
+				line += " (active)"
+			}
+			lines = append(lines, line)
+		}
+	}
+	return tmuxOK("%s", strings.Join(lines, "\n"))
+}
+
+// windowFlags returns tmux's #{window_flags}: * for current, - for last, Z for zoomed.
+func (s *TmuxSession) windowFlags(w *TmuxWindow) string {
+	flags := ""
+	switch w {
+	case s.Current:
+		flags = "*"
+	case s.Last:
+		flags = "-"
+	}
+	if w.Zoomed {
+		flags += "Z"
+	}
+	return flags
+}
+
+func (r *MissionRunner) tmuxListPanes(f tmuxFlags) MissionResult {
+	t := &r.Tmux
+	type scope struct {
+		s *TmuxSession
+		w *TmuxWindow
+	}
+	var scopes []scope
+
+	switch {
+	case f.has('a'):
+		if !t.Running() {
+			return tmuxError(errNoServer)
+		}
+		for _, s := range t.Sessions {
+			for _, w := range s.Windows {
+				scopes = append(scopes, scope{s, w})
+			}
+		}
+	case f.has('s'):
+		s, err := t.resolveSession(f.target())
+		if err != nil {
+			return tmuxError(err)
+		}
+		for _, w := range s.Windows {
+			scopes = append(scopes, scope{s, w})
+		}
+	default:
+		s, w, err := t.resolveWindow(f.target())
+		if err != nil {
+			return tmuxError(err)
+		}
+		scopes = append(scopes, scope{s, w})
+	}
+
+	var lines []string
+	for _, sc := range scopes {
+		for i, p := range sc.w.Panes {
+			prefix := ""
+			switch {
+			case f.has('a'):
+				prefix = fmt.Sprintf("%s:%d.", sc.s.Name, sc.w.Index)
+			case f.has('s'):
+				prefix = fmt.Sprintf("%d.", sc.w.Index)
+			}
+			width, height := sc.w.PaneSize(p)
+			line := fmt.Sprintf("%s%d: [%dx%d] [history 0/2000, 0 bytes] %%%d",
+				prefix, i, width, height, p.ID)
+			if p == sc.w.Active {
+				line += " (active)"
+			}
+			lines = append(lines, line)
+		}
+	}
+	return tmuxOK("%s", strings.Join(lines, "\n"))
+}
+
+func (r *MissionRunner) tmuxNewWindow(f tmuxFlags) MissionResult {
+	t := &r.Tmux
+	s, index, err := t.resolveNewWindow(f.target())
+	if err != nil {
+		return tmuxError(err)
+	}
+
+	w := t.newWindow(s, index, f.value('n'))
+	if !f.has('d') {
+		s.selectWindow(w)
+	}
+	return tmuxOK("[new window %d created]", w.Index)
+}
+
+// resolveNewWindow resolves the -t of commands that create a window ("session:index")
+// to a session and a free index.
+func (t *TmuxState) resolveNewWindow(target string) (*TmuxSession, int, error) {
+	sessPart, winPart, hasColon := strings.Cut(target, ":")
+	if !hasColon {
+		winPart, sessPart = target, ""
+	}
+	s, err := t.resolveSession(sessPart)
+	if err != nil {
+		return nil, 0, err
+	}
+	if winPart == "" {
+		return s, s.nextFreeIndex(t.baseIndex()), nil
+	}
+
+	idx, err := strconv.Atoi(strings.TrimPrefix(winPart, "="))
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid index: %s", winPart)
+	}
+	if s.Window(idx) != nil {
+		return nil, 0, fmt.Errorf("index %d in use", idx)
+	}
+	return s, idx, nil
+}
+
+func (r *MissionRunner) tmuxSelectWindow(f tmuxFlags) MissionResult {
+	t := &r.Tmux
+	var s *TmuxSession
+	var w *TmuxWindow
+	var err error
+
+	switch {
+	case f.has('n'), f.has('p'), f.has('l'):
+		if s, err = t.resolveSession(strings.Split(f.target(), ":")[0]); err != nil {
+			return tmuxError(err)
+		}
+		switch {
+		case f.has('l'):
+			if s.Last == nil {
+				return tmuxError(errors.New("no last window"))
+			}
+			w = s.Last
+		case f.has('n'):
+			w, _ = s.findWindow("+")
+		default:
+			w, _ = s.findWindow("-")
+		}
+	default:
+		if s, w, err = t.resolveWindow(f.target()); err != nil {
+			return tmuxError(err)
+		}
+	}
+
+	s.selectWindow(w)
+	return tmuxOK("[switched to window %d]", w.Index)
+}
+
+func (r *MissionRunner) tmuxNextWindow(f tmuxFlags) MissionResult {
+	return r.tmuxSelectWindow(tmuxFlags{set: map[byte]bool{'n': true}, values: f.values})
+}
+
+func (r *MissionRunner) tmuxPreviousWindow(f tmuxFlags) MissionResult {
+	return r.tmuxSelectWindow(tmuxFlags{set: map[byte]bool{'p': true}, values: f.values})
+}
+
+func (r *MissionRunner) tmuxLastWindow(f tmuxFlags) MissionResult {
+	return r.tmuxSelectWindow(tmuxFlags{set: map[byte]bool{'l': true}, values: f.values})
+}
+
+func (r *MissionRunner) tmuxSplitWindow(f tmuxFlags) MissionResult {
+	t := &r.Tmux
+	_, w, p, err := t.resolvePane(f.target())
+	if err != nil {
+		return tmuxError(err)
+	}
+
+	size := 0
+	if f.hasValue('l') {
+		if size, err = strconv.Atoi(strings.TrimSuffix(f.value('l'), "%")); err != nil || size < 1 {
+			return tmuxError(fmt.Errorf("size is invalid: %s", f.value('l')))
+		}
+		if strings.HasSuffix(f.value('l'), "%") {
+			total := p.Height()
+			if f.has('h') {
+				total = p.Width()
+			}
+			size = total * size / 100
+		}
+	}
+
+	pane := &TmuxPane{ID: t.nextPaneID}
+	if _, err := splitCell(p.cell, f.has('h'), size, f.has('b'), pane); err != nil {
+		return tmuxError(err)
+	}
+	t.nextPaneID++
+	w.Zoomed = false
+	w.layout = layoutRoot(pane.cell)
+	w.Panes = layoutLeaves(w.layout)
+	if !f.has('d') {
+		w.selectPane(pane)
+	}
+
+	direction := "top/bottom"
+	if f.has('h') {
+		direction = "left/right"
+	}
+	return tmuxOK("[split %s, now %d panes]", direction, len(w.Panes))
+}
+
+func (r *MissionRunner) tmuxSelectPane(f tmuxFlags) MissionResult {
+	t := &r.Tmux
+	_, w, p, err := t.resolvePane(f.target())
+	if err != nil {
+		return tmuxError(err)
+	}
+
+	if f.has('l') {
+		if w.LastPane == nil {
+			return tmuxError(errors.New("no last pane"))
+		}
+		p = w.LastPane
+	}
+	for _, dir := range []byte{'L', 'R', 'U', 'D'} {
+		if f.has(dir) {
+			if next := paneInDirection(w.Panes, p, string(dir)); next != nil {
+				p = next
+			}
+		}
+	}
+
+	w.selectPane(p)
+	return tmuxOK("[moved to pane %d]", w.PaneIndex(p))
+}
+
+func (r *MissionRunner) tmuxLastPane(f tmuxFlags) MissionResult {
+	_, w, err := r.Tmux.resolveWindow(f.target())
+	if err != nil {
+		return tmuxError(err)
+	}
+	if w.LastPane == nil {
+		return tmuxError(errors.New("no last pane"))
+	}
+	w.selectPane(w.LastPane)
+	return tmuxOK("[moved to pane %d]", w.PaneIndex(w.Active))
+}
+
+// InTmuxSession returns true if currently in a tmux session.
+func (r *MissionRunner) InTmuxSession() bool {
+	return r.Tmux.Client != nil
+}
+
+// GetTmuxStatus returns a description of the current tmux state.
+func (r *MissionRunner) GetTmuxStatus() string {
+	t := &r.Tmux
+	if !t.Running() {
+		return "not in tmux"
+	}
+	if t.Client == nil {
+		if t.Last != nil {
+			return fmt.Sprintf("detached from session '%s'", t.Last.Name)
+		}
+		return "detached"
+	}
+	return fmt.Sprintf("session '%s' [%d windows, %d panes]",
+		t.Client.Name, len(t.Client.Windows), len(t.Client.Current.Panes))
+}
+
+// TmuxStatusLine renders the attached session like tmux's status bar: "[work] 0:bash* 1:bash-".
+func (r *MissionRunner) TmuxStatusLine() string {
+	s := r.Tmux.Client
+	if s == nil {
+		return ""
+	}
+	parts := []string{"[" + s.Name + "]"}
+	for _, w := range s.Windows {
+		parts = append(parts, fmt.Sprintf("%d:%s%s", w.Index, w.Name, s.windowFlags(w)))
+	}
+	return strings.Join(parts, " ")
+}
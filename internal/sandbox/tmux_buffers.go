@@ -0,0 +1,174 @@
+// ABOUTME: Paste buffers for the simulated tmux
+// ABOUTME: Implements set-buffer, paste-buffer, list-buffers, show-buffer, delete-buffer and save-buffer
+
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// tmuxBufferLimit is tmux's default buffer-limit for automatically named buffers.
+const tmuxBufferLimit = 50
+
+// TmuxBuffer is a tmux paste buffer.
+type TmuxBuffer struct {
+	Name      string
+	Data      string
+	automatic bool // Named bufferN by tmux rather than by the user
+}
+
+// Buffer returns the named buffer, or the most recent one if name is empty.
+func (t *TmuxState) Buffer(name string) (*TmuxBuffer, bool) {
+	if name == "" {
+		if len(t.Buffers) == 0 {
+			return nil, false
+		}
+		return t.Buffers[0], true
+	}
+	for _, b := range t.Buffers {
+		if b.Name == name {
+			return b, true
+		}
+	}
+	return nil, false
+}
+
+// addBuffer pushes a new automatically named buffer, dropping the oldest past the limit.
+func (t *TmuxState) addBuffer(data string) {
+	b := &TmuxBuffer{Name: fmt.Sprintf("buffer%d", t.nextBufferID), Data: data, automatic: true}
+	t.nextBufferID++
+	t.Buffers = append([]*TmuxBuffer{b}, t.Buffers...)
+
+	automatic := 0
+	for i, buf := range t.Buffers {
+		if buf.automatic {
+			automatic++
+			if automatic > tmuxBufferLimit {
+				t.Buffers = append(t.Buffers[:i], t.Buffers[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// setBuffer creates or replaces a named buffer and makes it the most recent.
+func (t *TmuxState) setBuffer(name, data string) {
+	t.deleteBuffer(name)
+	t.Buffers = append([]*TmuxBuffer{{Name: name, Data: data}}, t.Buffers...)
+}
+
+func (t *TmuxState) deleteBuffer(name string) {
+	for i, b := range t.Buffers {
+		if b.Name == name {
+			t.Buffers = append(t.Buffers[:i], t.Buffers[i+1:]...)
+			return
+		}
+	}
+}
+
+// findBuffer resolves -b, with tmux's errors for a missing buffer.
+func (t *TmuxState) findBuffer(name string) (*TmuxBuffer, error) {
+	if b, ok := t.Buffer(name); ok {
+		return b, nil
+	}
+	if name == "" {
+		return nil, errors.New("no buffers")
+	}
+	return nil, fmt.Errorf("no buffer %s", name)
+}
+
+// bufferSample renders buffer contents for list-buffers, escaping control characters.
+func bufferSample(data string) string {
+	sample := strings.NewReplacer("\n", "\\n", "\t", "\\t", "\"", "\\\"").Replace(data)
+	if len(sample) > 200 {
+		sample = sample[:200] + "..."
+	}
+	return sample
+}
+
+func (r *MissionRunner) tmuxSetBuffer(f tmuxFlags) MissionResult {
+	t := &r.Tmux
+	data, ok := f.arg(0)
+	if !ok {
+		return tmuxError(errors.New("usage: set-buffer [-a] [-b buffer-name] data"))
+	}
+
+	name := f.value('b')
+	if f.has('a') {
+		if b, ok := t.Buffer(name); ok {
+			b.Data += data
+			return MissionResult{Success: true}
+		}
+	}
+	if name == "" {
+		t.addBuffer(data)
+	} else {
+		t.setBuffer(name, data)
+	}
+	return MissionResult{Success: true}
+}
+
+func (r *MissionRunner) tmuxPasteBuffer(f tmuxFlags) MissionResult {
+	t := &r.Tmux
+	b, err := t.findBuffer(f.value('b'))
+	if err != nil {
+		// tmux pastes nothing when there are no buffers.
+		if f.hasValue('b') {
+			return tmuxError(err)
+		}
+		return MissionResult{Success: true}
+	}
+	if f.has('d') {
+		t.deleteBuffer(b.Name)
+	}
+	return MissionResult{Success: true, Paste: b.Data}
+}
+
+func (r *MissionRunner) tmuxListBuffers(_ tmuxFlags) MissionResult {
+	lines := make([]string, 0, len(r.Tmux.Buffers))
+	for _, b := range r.Tmux.Buffers {
+		lines = append(lines, fmt.Sprintf("%s: %d bytes: \"%s\"", b.Name, len(b.Data), bufferSample(b.Data)))
+	}
+	return tmuxOK("%s", strings.Join(lines, "\n"))
+}
+
+func (r *MissionRunner) tmuxShowBuffer(f tmuxFlags) MissionResult {
+	b, err := r.Tmux.findBuffer(f.value('b'))
+	if err != nil {
+		return tmuxError(err)
+	}
+	return tmuxOK("%s", strings.TrimSuffix(b.Data, "\n"))
+}
+
+func (r *MissionRunner) tmuxDeleteBuffer(f tmuxFlags) MissionResult {
+	b, err := r.Tmux.findBuffer(f.value('b'))
+	if err != nil {
+		return tmuxError(err)
+	}
+	r.Tmux.deleteBuffer(b.Name)
+	return MissionResult{Success: true}
+}
+
+func (r *MissionRunner) tmuxSaveBuffer(f tmuxFlags) MissionResult {
+	path, ok := f.arg(0)
+	if !ok {
+		return tmuxError(errors.New("usage: save-buffer [-a] [-b buffer-name] path"))
+	}
+	b, err := r.Tmux.findBuffer(f.value('b'))
+	if err != nil {
+		return tmuxError(err)
+	}
+
+	data := b.Data
+	if f.has('a') {
+		if existing, err := r.FS.ReadFile(path); err == nil {
+			data = existing + data
+		}
+	}
+	if err := r.FS.WriteFile(path, data); err != nil {
+		return tmuxError(err)
+	}
+	return MissionResult{Success: true}
+}
@@ -0,0 +1,52 @@
+// ABOUTME: Tests for tmux paste buffers in the simulator
+// ABOUTME: Covers set, list, show, paste, delete and save of buffers
+
+package sandbox
+
+import (
+	"testing"
+)
+
+func TestBuffers_SetListShow(t *testing.T) {
+	runner := NewMissionRunner(&Mission{})
+	runAll(t, runner, "tmux", "tmux set-buffer first", "tmux set-buffer second", "tmux set-buffer -b notes todo")
+
+	want := "notes: 4 bytes: \"todo\"\nbuffer1: 6 bytes: \"second\"\nbuffer0: 5 bytes: \"first\""
+	if got := runner.Execute("tmux list-buffers").Output; got != want {
+		t.Errorf("list-buffers =\n%s\nwant\n%s", got, want)
+	}
+	if got := runner.Execute("tmux show-buffer -b buffer0").Output; got != "first" {
+		t.Errorf("show-buffer -b buffer0 = %q", got)
+	}
+	if got := runner.Execute("tmux show-buffer -b nope").Error; got != "no buffer nope" {
+		t.Errorf("expected missing buffer error, got %q", got)
+	}
+}
+
+func TestBuffers_PasteAndDelete(t *testing.T) {
+	runner := NewMissionRunner(&Mission{})
+	runAll(t, runner, "tmux")
+
+	if got := runner.Execute("tmux show-buffer").Error; got != "no buffers" {
+		t.Errorf("expected no buffers, got %q", got)
+	}
+
+	runAll(t, runner, "tmux set-buffer hello")
+	if result := runner.ExecuteKey("]"); result.Paste != "hello" {
+		t.Errorf("C-b ] should paste the buffer, got %q", result.Paste)
+	}
+
+	runAll(t, runner, "tmux paste-buffer -d")
+	if len(runner.Tmux.Buffers) != 0 {
+		t.Error("paste-buffer -d should delete the buffer")
+	}
+}
+
+func TestBuffers_Save(t *testing.T) {
+	runner := NewMissionRunner(&Mission{})
+	runAll(t, runner, "tmux", "tmux set-buffer saved", "tmux save-buffer /home/learner/out.txt")
+
+	if data, err := runner.FS.ReadFile("/home/learner/out.txt"); err != nil || data != "saved" {
+		t.Errorf("save-buffer wrote %q, %v", data, err)
+	}
+}
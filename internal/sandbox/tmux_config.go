@@ -0,0 +1,629 @@
+// ABOUTME: Configuration for the simulated tmux: ~/.tmux.conf, options and key bindings
+// ABOUTME: Parses tmux config syntax and implements set-option, bind-key, unbind-key and source-file
+
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// tmuxSourceDepth is how deeply source-file may nest before tmux gives up.
+const tmuxSourceDepth = 50
+
+// tmuxConfigFiles are the config files tmux reads at server start, in order.
+// Missing files are skipped quietly.
+var tmuxConfigFiles = []string{"/etc/tmux.conf", "~/.tmux.conf", "~/.config/tmux/tmux.conf"}
+
+// optionKind is the type of a tmux option's value.
+type optionKind int
+
+const (
+	optionString optionKind = iota
+	optionFlag
+	optionNumber
+	optionKey
+	optionChoice
+)
+
+// tmuxOption describes an option set-option accepts.
+type tmuxOption struct {
+	Kind     optionKind
+	Default  string
+	Choices  []string // For optionChoice
+	Min, Max int      // For optionNumber
+}
+
+// tmuxOptions are the options the simulator knows, with tmux's defaults. Only prefix,
+// prefix2, mode-keys and base-index change behaviour; the rest are stored so that
+// configs written for real tmux load cleanly.
+var tmuxOptions = map[string]tmuxOption{
+	"aggressive-resize":           {Kind: optionFlag, Default: "off"},
+	"allow-rename":                {Kind: optionFlag, Default: "off"},
+	"automatic-rename":            {Kind: optionFlag, Default: "on"},
+	"base-index":                  {Kind: optionNumber, Default: "0", Max: 999},
+	"bell-action":                 {Kind: optionChoice, Default: "any", Choices: []string{"none", "any", "current", "other"}},
+	"default-command":             {Kind: optionString},
+	"default-shell":               {Kind: optionString, Default: "/bin/bash"},
+	"default-terminal":            {Kind: optionString, Default: "screen"},
+	"display-time":                {Kind: optionNumber, Default: "750", Max: 1 << 30},
+	"escape-time":                 {Kind: optionNumber, Default: "500", Max: 1 << 30},
+	"focus-events":                {Kind: optionFlag, Default: "off"},
+	"history-limit":               {Kind: optionNumber, Default: "2000", Max: 1 << 30},
+	"main-pane-height":            {Kind: optionString, Default: "24"},
+	"main-pane-width":             {Kind: optionString, Default: "80"},
+	"mode-keys":                   {Kind: optionChoice, Default: "emacs", Choices: []string{"emacs", "vi"}},
+	"monitor-activity":            {Kind: optionFlag, Default: "off"},
+	"mouse":                       {Kind: optionFlag, Default: "off"},
+	"pane-active-border-style":    {Kind: optionString, Default: "fg=green"},
+	"pane-base-index":             {Kind: optionNumber, Default: "0", Max: 999},
+	"pane-border-style":           {Kind: optionString, Default: "default"},
+	"prefix":                      {Kind: optionKey, Default: DefaultTmuxPrefix},
+	"prefix2":                     {Kind: optionKey, Default: "None"},
+	"renumber-windows":            {Kind: optionFlag, Default: "off"},
+	"repeat-time":                 {Kind: optionNumber, Default: "500", Max: 1 << 30},
+	"set-clipboard":               {Kind: optionChoice, Default: "external", Choices: []string{"off", "external", "on"}},
+	"set-titles":                  {Kind: optionFlag, Default: "off"},
+	"status":                      {Kind: optionChoice, Default: "on", Choices: []string{"off", "on", "2", "3", "4", "5"}},
+	"status-bg":                   {Kind: optionString, Default: "default"},
+	"status-fg":                   {Kind: optionString, Default: "default"},
+	"status-interval":             {Kind: optionNumber, Default: "15", Max: 1 << 30},
+	"status-keys":                 {Kind: optionChoice, Default: "emacs", Choices: []string{"emacs", "vi"}},
+	"status-left":                 {Kind: optionString, Default: "[#S] "},
+	"status-position":             {Kind: optionChoice, Default: "bottom", Choices: []string{"top", "bottom"}},
+	"status-right":                {Kind: optionString, Default: "\"#{=21:pane_title}\" %H:%M %d-%b-%y"},
+	"status-style":                {Kind: optionString, Default: "bg=green,fg=black"},
+	"synchronize-panes":           {Kind: optionFlag, Default: "off"},
+	"terminal-overrides":          {Kind: optionString},
+	"visual-activity":             {Kind: optionChoice, Default: "off", Choices: []string{"on", "off", "both"}},
+	"window-status-current-style": {Kind: optionString, Default: "default"},
+	"window-status-style":         {Kind: optionString, Default: "default"},
+}
+
+// Option returns the value of a global option, or tmux's default if it is unset.
+func (t *TmuxState) Option(name string) string {
+	if v, ok := t.Options[name]; ok {
+		return v
+	}
+	return tmuxOptions[name].Default
+}
+
+// serverUp reports whether the server is running or starting up (reading its config).
+func (t *TmuxState) serverUp() bool {
+	return t.Running() || t.starting
+}
+
+// baseIndex returns the base-index option as a number.
+func (t *TmuxState) baseIndex() int {
+	n, _ := strconv.Atoi(t.Option("base-index"))
+	return n
+}
+
+// parseOptionValue checks a value against an option's type and returns it normalised.
+// A missing value toggles a flag option, like tmux.
+func parseOptionValue(opt tmuxOption, current, value string, hasValue bool) (string, error) {
+	if !hasValue {
+		if opt.Kind == optionFlag {
+			if current == "on" {
+				return "off", nil
+			}
+			return "on", nil
+		}
+		return "", errors.New("empty value")
+	}
+
+	switch opt.Kind {
+	case optionFlag:
+		switch strings.ToLower(value) {
+		case "on", "yes", "1":
+			return "on", nil
+		case "off", "no", "0":
+			return "off", nil
+		}
+		return "", fmt.Errorf("bad value: %s", value)
+	case optionNumber:
+		n, err := strconv.Atoi(value)
+		switch {
+		case err != nil:
+			return "", fmt.Errorf("value is invalid: %s", value)
+		case n < opt.Min:
+			return "", fmt.Errorf("value is too small: %s", value)
+		case n > opt.Max:
+			return "", fmt.Errorf("value is too large: %s", value)
+		}
+		return strconv.Itoa(n), nil
+	case optionKey:
+		key, ok := normalizeTmuxKey(value)
+		if !ok {
+			return "", fmt.Errorf("bad key: %s", value)
+		}
+		return key, nil
+	case optionChoice:
+		for _, choice := range opt.Choices {
+			if value == choice {
+				return value, nil
+			}
+		}
+		return "", fmt.Errorf("unknown value: %s", value)
+	}
+	return value, nil
+}
+
+// tmuxNamedKeys are the key names tmux accepts, mapped to their canonical spelling.
+var tmuxNamedKeys = map[string]string{
+	"up": "Up", "down": "Down", "left": "Left", "right": "Right",
+	"home": "Home", "end": "End", "ppage": "PPage", "pageup": "PPage", "pgup": "PPage",
+	"npage": "NPage", "pagedown": "NPage", "pgdn": "NPage", "ic": "IC", "insert": "IC",
+	"dc": "DC", "delete": "DC", "enter": "Enter", "escape": "Escape", "tab": "Tab",
+	"btab": "BTab", "bspace": "BSpace", "space": "Space", "any": "Any", "none": "None",
+}
+
+// normalizeTmuxKey validates a key in tmux notation ("C-a", "^a", "M-Left", "F5", "|")
+// and returns its canonical spelling.
+func normalizeTmuxKey(key string) (string, bool) {
+	if len(key) == 2 && key[0] == '^' {
+		key = "C-" + strings.ToLower(key[1:])
+	}
+
+	var mods string
+	for len(key) > 2 && key[1] == '-' {
+		switch key[0] {
+		case 'C', 'c':
+			mods += "C-"
+		case 'M', 'm':
+			mods += "M-"
+		case 'S', 's':
+			mods += "S-"
+		default:
+			return "", false
+		}
+		key = key[2:]
+	}
+
+	switch {
+	case len(key) == 1 && key[0] > ' ' && key[0] < 0x7f:
+		return mods + key, true
+	case tmuxNamedKeys[strings.ToLower(key)] != "":
+		return mods + tmuxNamedKeys[strings.ToLower(key)], true
+	case len(key) >= 2 && (key[0] == 'F' || key[0] == 'f'):
+		if n, err := strconv.Atoi(key[1:]); err == nil && n >= 1 && n <= 12 {
+			return mods + "F" + key[1:], true
+		}
+	}
+	return "", false
+}
+
+// tmuxToken is a word of tmux config syntax; Sep marks an unescaped ";" between commands.
+type tmuxToken struct {
+	Text string
+	Sep  bool
+}
+
+// tokenArgs flattens tokens into command arguments, with ";" between commands.
+func tokenArgs(tokens []tmuxToken) []string {
+	args := make([]string, 0, len(tokens))
+	for _, tok := range tokens {
+		if tok.Sep {
+			args = append(args, ";")
+		} else {
+			args = append(args, tok.Text)
+		}
+	}
+	return args
+}
+
+// configCommand is one command read from a config file.
+type configCommand struct {
+	Line int
+	Args []string
+}
+
+// parseTmuxConfig splits a config file into commands using tmux's quoting rules:
+// '#' comments, single and double quotes, backslash escapes, "\;" to pass a literal
+// ";" (for bind-key command sequences) and a trailing backslash to continue a line.
+// Errors are tmux's "path:line: message" diagnostics.
+func parseTmuxConfig(file, data string) ([]configCommand, []error) {
+	var commands []configCommand
+	var errs []error
+
+	lines := strings.Split(data, "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNo := i + 1
+		line := lines[i]
+		for strings.HasSuffix(line, "\\") && i+1 < len(lines) {
+			i++
+			line = line[:len(line)-1] + lines[i]
+		}
+
+		tokens, err := tokenizeTmux(line)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s:%d: %w", file, lineNo, err))
+			continue
+		}
+		var args []string
+		for _, tok := range append(tokens, tmuxToken{Sep: true}) {
+			if !tok.Sep {
+				args = append(args, tok.Text)
+				continue
+			}
+			if len(args) > 0 {
+				commands = append(commands, configCommand{Line: lineNo, Args: args})
+			}
+			args = nil
+		}
+	}
+	return commands, errs
+}
+
+// tokenizeTmux splits one line of tmux command syntax into words.
+//
+//nolint:gocyclo // Quote-aware tokenizer handles each syntax character in one switch
+func tokenizeTmux(line string) ([]tmuxToken, error) {
+	var tokens []tmuxToken
+	var word strings.Builder
+	inWord := false
+	var quote byte
+
+	flush := func() {
+		if inWord {
+			tokens = append(tokens, tmuxToken{Text: word.String()})
+			word.Reset()
+			inWord = false
+		}
+	}
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			} else {
+				word.WriteByte(c)
+			}
+		case quote == '"':
+			switch {
+			case c == '"':
+				quote = 0
+			case c == '\\' && i+1 < len(line):
+				i++
+				word.WriteByte(line[i])
+			default:
+				word.WriteByte(c)
+			}
+		case c == '\'' || c == '"':
+			quote, inWord = c, true
+		case c == '\\' && i+1 < len(line):
+			i++
+			word.WriteByte(line[i])
+			inWord = true
+		case c == ' ' || c == '\t' || c == '\r':
+			flush()
+		case c == '#' && !inWord:
+			flush()
+			return tokens, nil
+		case c == ';' && (i+1 == len(line) || line[i+1] == ' ' || line[i+1] == '\t'):
+			flush()
+			tokens = append(tokens, tmuxToken{Sep: true})
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("syntax error")
+	}
+	flush()
+	return tokens, nil
+}
+
+// loadTmuxConfig reads the config files tmux loads at server start and returns
+// their diagnostics.
+func (r *MissionRunner) loadTmuxConfig() []string {
+	var errs []string
+	for _, file := range tmuxConfigFiles {
+		data, err := r.FS.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		errs = append(errs, r.sourceTmuxConfig(r.expandHome(file), data, false)...)
+	}
+	return errs
+}
+
+// sourceTmuxConfig runs every command in a config file, continuing past errors like
+// tmux does. With parseOnly the commands are only checked for syntax (source-file -n).
+func (r *MissionRunner) sourceTmuxConfig(file, data string, parseOnly bool) []string {
+	commands, parseErrs := parseTmuxConfig(file, data)
+	errs := make([]string, 0, len(parseErrs))
+	for _, err := range parseErrs {
+		errs = append(errs, err.Error())
+	}
+	for _, cmd := range commands {
+		if parseOnly {
+			if _, err := lookupTmuxCommand(cmd.Args[0]); err != nil {
+				errs = append(errs, fmt.Sprintf("%s:%d: %s", file, cmd.Line, err))
+			}
+			continue
+		}
+		if result := r.executeTmux(cmd.Args); result.Error != "" {
+			errs = append(errs, fmt.Sprintf("%s:%d: %s", file, cmd.Line, result.Error))
+		}
+	}
+	return errs
+}
+
+// expandHome replaces a leading ~ with the learner's home directory.
+func (r *MissionRunner) expandHome(p string) string {
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		return r.FS.Home + p[1:]
+	}
+	if !strings.HasPrefix(p, "/") {
+		return path.Join(r.FS.Pwd(), p)
+	}
+	return p
+}
+
+func (r *MissionRunner) tmuxSourceFile(f tmuxFlags) MissionResult {
+	if len(f.args) == 0 {
+		return tmuxError(errors.New("usage: source-file [-nqv] path ..."))
+	}
+	if !r.Tmux.serverUp() {
+		return tmuxError(errNoServer)
+	}
+	if r.sourceDepth >= tmuxSourceDepth {
+		return tmuxError(errors.New("too many nested files"))
+	}
+	r.sourceDepth++
+	defer func() { r.sourceDepth-- }()
+
+	var errs []string
+	for _, file := range f.args {
+		data, err := r.FS.ReadFile(file)
+		if err != nil {
+			if !f.has('q') {
+				errs = append(errs, fmt.Sprintf("%s: No such file or directory", r.expandHome(file)))
+			}
+			continue
+		}
+		errs = append(errs, r.sourceTmuxConfig(r.expandHome(file), data, f.has('n'))...)
+	}
+	if len(errs) > 0 {
+		return MissionResult{Error: strings.Join(errs, "\n")}
+	}
+	return MissionResult{Success: true}
+}
+
+func (r *MissionRunner) tmuxSetOption(f tmuxFlags) MissionResult {
+	t := &r.Tmux
+	if !t.serverUp() {
+		return tmuxError(errNoServer)
+	}
+	name, ok := f.arg(0)
+	if !ok {
+		return tmuxError(errors.New("usage: set-option [-agoqsuw] [-t target-pane] option [value]"))
+	}
+	opt, known := tmuxOptions[name]
+	switch {
+	case strings.HasPrefix(name, "@"):
+		opt = tmuxOption{Kind: optionString}
+	case !known:
+		if f.has('q') {
+			return MissionResult{Success: true}
+		}
+		return tmuxError(fmt.Errorf("invalid option: %s", name))
+	}
+
+	if f.has('u') {
+		delete(t.Options, name)
+		return MissionResult{Success: true}
+	}
+	if _, set := t.Options[name]; set && f.has('o') {
+		return tmuxError(fmt.Errorf("already set: %s", name))
+	}
+
+	value, hasValue := f.arg(1)
+	if f.has('a') && opt.Kind == optionString {
+		value = t.Option(name) + value
+	}
+	value, err := parseOptionValue(opt, t.Option(name), value, hasValue)
+	if err != nil {
+		return tmuxError(err)
+	}
+	if t.Options == nil {
+		t.Options = map[string]string{}
+	}
+	t.Options[name] = value
+	return MissionResult{Success: true}
+}
+
+func (r *MissionRunner) tmuxShowOptions(f tmuxFlags) MissionResult {
+	t := &r.Tmux
+	if !t.serverUp() {
+		return tmuxError(errNoServer)
+	}
+
+	names := f.args
+	if len(names) == 0 {
+		for name := range tmuxOptions {
+			names = append(names, name)
+		}
+		for name := range t.Options {
+			if strings.HasPrefix(name, "@") {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+	}
+
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		_, known := tmuxOptions[name]
+		_, set := t.Options[name]
+		if !known && !set {
+			if f.has('q') {
+				continue
+			}
+			return tmuxError(fmt.Errorf("invalid option: %s", name))
+		}
+		value := t.Option(name)
+		if f.has('v') {
+			lines = append(lines, value)
+			continue
+		}
+		if value == "" || strings.ContainsAny(value, " #\"") {
+			value = strconv.Quote(value)
+		}
+		lines = append(lines, name+" "+value)
+	}
+	return tmuxOK("%s", strings.Join(lines, "\n"))
+}
+
+func (r *MissionRunner) tmuxBindKey(f tmuxFlags) MissionResult {
+	t := &r.Tmux
+	if !t.serverUp() {
+		return tmuxError(errNoServer)
+	}
+	raw, ok := f.arg(0)
+	if !ok || len(f.args) < 2 {
+		return tmuxError(errors.New("usage: bind-key [-nr] [-T key-table] [-N note] key [command [argument ...]]"))
+	}
+	key, ok := normalizeTmuxKey(raw)
+	if !ok {
+		return tmuxError(fmt.Errorf("unknown key: %s", raw))
+	}
+
+	args := f.args[1:]
+	if len(args) == 1 && strings.ContainsAny(args[0], " \t") {
+		// A single quoted argument is itself a command: bind x "kill-pane -a".
+		tokens, err := tokenizeTmux(args[0])
+		if err != nil {
+			return tmuxError(err)
+		}
+		args = tokenArgs(tokens)
+	}
+	for _, command := range splitTmuxCommands(args) {
+		if !isBindableCommand(command[0]) {
+			return tmuxError(fmt.Errorf("unknown command: %s", command[0]))
+		}
+	}
+
+	table := bindingTable(f)
+	if t.keyTable(table) == nil {
+		t.Bindings[table] = map[string][]string{}
+	}
+	t.Bindings[table][key] = append([]string(nil), args...)
+	return MissionResult{Success: true}
+}
+
+func (r *MissionRunner) tmuxUnbindKey(f tmuxFlags) MissionResult {
+	t := &r.Tmux
+	if !t.serverUp() {
+		return tmuxError(errNoServer)
+	}
+	table := bindingTable(f)
+	if f.has('a') {
+		if t.keyTable(table) != nil {
+			t.Bindings[table] = map[string][]string{}
+		}
+		return MissionResult{Success: true}
+	}
+
+	raw, ok := f.arg(0)
+	if !ok {
+		return tmuxError(errors.New("usage: unbind-key [-anq] [-T key-table] key"))
+	}
+	key, ok := normalizeTmuxKey(raw)
+	if !ok {
+		if f.has('q') {
+			return MissionResult{Success: true}
+		}
+		return tmuxError(fmt.Errorf("unknown key: %s", raw))
+	}
+	delete(t.keyTable(table), key)
+	return MissionResult{Success: true}
+}
+
+func (r *MissionRunner) tmuxListKeys(f tmuxFlags) MissionResult {
+	t := &r.Tmux
+	if !t.serverUp() {
+		return tmuxError(errNoServer)
+	}
+
+	tables := []string{f.value('T')}
+	if tables[0] == "" {
+		t.keyTable("prefix")
+		tables = tables[:0]
+		for name := range t.Bindings {
+			tables = append(tables, name)
+		}
+		sort.Strings(tables)
+	} else if t.keyTable(tables[0]) == nil {
+		return tmuxError(fmt.Errorf("table %s doesn't exist", tables[0]))
+	}
+
+	var lines []string
+	for _, table := range tables {
+		bindings := t.keyTable(table)
+		keys := make([]string, 0, len(bindings))
+		for key := range bindings {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			lines = append(lines, fmt.Sprintf("bind-key -T %-12s %-8s %s",
+				table, key, strings.Join(bindings[key], " ")))
+		}
+	}
+	return tmuxOK("%s", strings.Join(lines, "\n"))
+}
+
+func (r *MissionRunner) tmuxDisplayMessage(f tmuxFlags) MissionResult {
+	return tmuxOK("%s", r.expandTmuxFormat(strings.Join(f.args, " ")))
+}
+
+func (r *MissionRunner) tmuxSendPrefix(_ tmuxFlags) MissionResult {
+	// The prefix key goes to the program in the pane; the simulated shell ignores it.
+	return MissionResult{Success: true}
+}
+
+// bindingTable returns the key table named by bind-key or unbind-key flags.
+func bindingTable(f tmuxFlags) string {
+	switch {
+	case f.hasValue('T'):
+		return f.value('T')
+	case f.has('n'):
+		return "root"
+	}
+	return "prefix"
+}
+
+// isBindableCommand reports whether a key binding may run the named command.
+// The prompt commands only make sense from a key binding.
+func isBindableCommand(name string) bool {
+	if name == "command-prompt" || name == "confirm-before" {
+		return true
+	}
+	_, err := lookupTmuxCommand(name)
+	return err == nil
+}
+
+// splitTmuxCommands splits a binding's arguments into the commands separated by ";".
+func splitTmuxCommands(args []string) [][]string {
+	var commands [][]string
+	start := 0
+	for i := 0; i <= len(args); i++ {
+		if i < len(args) && args[i] != ";" {
+			continue
+		}
+		if i > start {
+			commands = append(commands, args[start:i])
+		}
+		start = i + 1
+	}
+	return commands
+}
@@ -0,0 +1,231 @@
+// ABOUTME: Tests for the simulated tmux configuration
+// ABOUTME: Verifies ~/.tmux.conf loading, tmux config syntax, options and key bindings
+
+package sandbox
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/2389-research/turtle/internal/content"
+)
+
+// configRunner returns a runner whose ~/.tmux.conf contains conf.
+func configRunner(conf string) *MissionRunner {
+	return NewMissionRunner(&Mission{Setup: func(fs *Filesystem) {
+		_ = fs.WriteFile("/home/learner/.tmux.conf", conf)
+	}})
+}
+
+func TestTmuxConfig_RemapPrefix(t *testing.T) {
+	runner := configRunner(`# Ctrl-a is easier to reach
+set -g prefix C-a
+unbind C-b
+bind C-a send-prefix
+bind | split-window -h
+`)
+	runAll(t, runner, "tmux")
+
+	if runner.TmuxPrefix() != "C-a" {
+		t.Errorf("expected prefix C-a, got %s", runner.TmuxPrefix())
+	}
+	if !runner.IsTmuxPrefix("C-a") || runner.IsTmuxPrefix("C-b") {
+		t.Error("only C-a should act as the prefix")
+	}
+	if result := runner.ExecuteKey("|"); result.Error != "" {
+		t.Fatalf("C-a | failed: %s", result.Error)
+	}
+	if n := len(runner.Tmux.CurrentWindow().Panes); n != 2 {
+		t.Errorf("expected the | binding to split the window, got %d panes", n)
+	}
+	last := runner.TmuxLog[len(runner.TmuxLog)-1]
+	if last != (content.TmuxOperation{Command: "split-window", Prefix: "C-a", Key: "|"}) {
+		t.Errorf("expected split-window via C-a |, got %+v", last)
+	}
+}
+
+func TestTmuxConfig_Errors(t *testing.T) {
+	runner := configRunner("set -g prefx C-a\nbogus\nset -g mouse maybe\nset -g base-index 1\nbind Hyper-x kill-pane\n")
+	result := runner.Execute("tmux")
+	if result.Error != "" {
+		t.Fatalf("config errors should not stop tmux starting: %s", result.Error)
+	}
+
+	for _, want := range []string{
+		"/home/learner/.tmux.conf:1: invalid option: prefx",
+		"/home/learner/.tmux.conf:2: unknown command: bogus",
+		"/home/learner/.tmux.conf:3: bad value: maybe",
+		"/home/learner/.tmux.conf:5: unknown key: Hyper-x",
+	} {
+		if !strings.Contains(result.Output, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, result.Output)
+		}
+	}
+	if w := runner.Tmux.CurrentWindow(); w.Index != 1 {
+		t.Errorf("lines after an error should still apply: expected window 1, got %d", w.Index)
+	}
+}
+
+func TestTmuxConfig_ReloadOnServerStart(t *testing.T) {
+	runner := configRunner("set -g prefix C-a\n")
+	runAll(t, runner, "tmux", "tmux set -g prefix C-x", "tmux kill-server")
+	if runner.TmuxPrefix() != DefaultTmuxPrefix {
+		t.Errorf("kill-server should drop options, got prefix %s", runner.TmuxPrefix())
+	}
+	runAll(t, runner, "tmux")
+	if runner.TmuxPrefix() != "C-a" {
+		t.Errorf("a new server should read the config again, got prefix %s", runner.TmuxPrefix())
+	}
+}
+
+func TestTmuxConfig_SourceFile(t *testing.T) {
+	runner := configRunner("")
+	runAll(t, runner, "tmux", "echo set -g mode-keys vi > ~/.tmux.conf", "tmux source-file ~/.tmux.conf")
+	if runner.Tmux.Option("mode-keys") != "vi" {
+		t.Errorf("source-file should apply the config, got mode-keys %s", runner.Tmux.Option("mode-keys"))
+	}
+
+	result := runner.Execute("tmux source-file ~/missing.conf")
+	if result.Error != "/home/learner/missing.conf: No such file or directory" {
+		t.Errorf("unexpected error for a missing file: %q", result.Error)
+	}
+	if result := runner.Execute("tmux source -q ~/missing.conf"); result.Error != "" {
+		t.Errorf("-q should ignore a missing file, got %q", result.Error)
+	}
+
+	runAll(t, runner, "echo source-file ~/.tmux.conf > ~/.tmux.conf")
+	if result := runner.Execute("tmux source-file ~/.tmux.conf"); !strings.Contains(result.Error, "too many nested files") {
+		t.Errorf("a config sourcing itself should stop, got %q", result.Error)
+	}
+}
+
+func TestTmuxConfig_CommandSequence(t *testing.T) {
+	runner := configRunner(`bind r source-file ~/.tmux.conf \; display "Reloaded #S"` + "\n")
+	runAll(t, runner, "tmux new -s work")
+
+	result := runner.ExecuteKey("r")
+	if result.Error != "" || result.Output != "Reloaded work" {
+		t.Errorf("expected both commands to run, got %+v", result)
+	}
+}
+
+func TestTmuxConfig_RootBinding(t *testing.T) {
+	runner := configRunner("bind -n M-Right select-pane -R\n")
+	runAll(t, runner, "tmux", "tmux split-window -h", "tmux select-pane -L")
+
+	if _, ok := runner.RootKey("M-Left"); ok {
+		t.Error("unbound root keys should fall through to the shell")
+	}
+	if _, ok := runner.RootKey("M-Right"); !ok {
+		t.Fatal("M-Right should be bound in the root table")
+	}
+	if w := runner.Tmux.CurrentWindow(); w.PaneIndex(w.Active) != 1 {
+		t.Error("M-Right should move to the right pane without the prefix")
+	}
+}
+
+func TestTmuxConfig_Options(t *testing.T) {
+	runner := NewMissionRunner(&Mission{})
+	if result := runner.Execute("tmux set -g mouse on"); result.Error != errNoServer.Error() {
+		t.Errorf("set-option needs a server, got %q", result.Error)
+	}
+	runAll(t, runner, "tmux", "tmux set -g mouse", "tmux set -g status-left [#S]")
+
+	if got := runner.Execute("tmux show -g mouse").Output; got != "mouse on" {
+		t.Errorf("a flag with no value should toggle, got %q", got)
+	}
+	runAll(t, runner, "tmux set -gu mouse")
+	if got := runner.Execute("tmux show -gv mouse").Output; got != "off" {
+		t.Errorf("-u should restore the default, got %q", got)
+	}
+	if got := runner.Execute("tmux set -g base-index x").Error; got != "value is invalid: x" {
+		t.Errorf("unexpected error for a bad number: %q", got)
+	}
+	if got := runner.Execute("tmux set -g mode-keys vim").Error; got != "unknown value: vim" {
+		t.Errorf("unexpected error for a bad choice: %q", got)
+	}
+	if got := runner.Execute("tmux set -g @theme dark").Error; got != "" {
+		t.Errorf("user options should be accepted, got %q", got)
+	}
+}
+
+func TestTmuxConfig_Unbind(t *testing.T) {
+	runner := NewMissionRunner(&Mission{})
+	runAll(t, runner, "tmux", "tmux unbind %", "tmux bind-key -T prefix v split-window -h")
+
+	if result := runner.ExecuteKey("%"); result.Error != "C-b % is not bound" {
+		t.Errorf("expected %% to be unbound, got %+v", result)
+	}
+	if !strings.Contains(runner.Execute("tmux list-keys -T prefix").Output, "v        split-window -h") {
+		t.Error("list-keys should show the new binding")
+	}
+	if got := runner.Execute("tmux bind q frobnicate").Error; got != "unknown command: frobnicate" {
+		t.Errorf("unexpected error for a bad binding: %q", got)
+	}
+	runAll(t, runner, "tmux unbind -a")
+	if len(runner.Tmux.keyTable("prefix")) != 0 {
+		t.Error("unbind -a should empty the prefix table")
+	}
+}
+
+func TestParseTmuxConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		conf string
+		want [][]string
+	}{
+		{"comment", "# nothing here\n  # indented\n", nil},
+		{"trailing comment", "set -g mouse on # scroll with the wheel", [][]string{{"set", "-g", "mouse", "on"}}},
+		{"quotes", `set -g status-left '[#S] ' ; set -g status-right "it's \"late\""`,
+			[][]string{{"set", "-g", "status-left", "[#S] "}, {"set", "-g", "status-right", `it's "late"`}}},
+		{"escaped separator", `bind r source-file x \; display ok`,
+			[][]string{{"bind", "r", "source-file", "x", ";", "display", "ok"}}},
+		{"continuation", "bind x \\\n  kill-pane", [][]string{{"bind", "x", "kill-pane"}}},
+		{"hash inside word", "bind # list-buffers", [][]string{{"bind"}}},
+		{"escaped hash", `bind \# list-buffers`, [][]string{{"bind", "#", "list-buffers"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commands, errs := parseTmuxConfig("test.conf", tt.conf)
+			if len(errs) > 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+			var got [][]string
+			for _, c := range commands {
+				got = append(got, c.Args)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, errs := parseTmuxConfig("test.conf", "set -g status-left 'oops"); len(errs) != 1 || errs[0].Error() != "test.conf:1: syntax error" {
+		t.Errorf("expected a syntax error for an open quote, got %v", errs)
+	}
+}
+
+func TestNormalizeTmuxKey(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"C-a", "C-a", true},
+		{"^a", "C-a", true},
+		{"m-left", "M-Left", true},
+		{"C-M-PageUp", "C-M-PPage", true},
+		{"|", "|", true},
+		{"F12", "F12", true},
+		{"F13", "", false},
+		{"Hyper-x", "", false},
+		{"xyz", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := normalizeTmuxKey(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("normalizeTmuxKey(%q) = %q, %v; want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
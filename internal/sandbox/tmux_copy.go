@@ -0,0 +1,394 @@
+// ABOUTME: Copy mode for the simulated tmux
+// ABOUTME: Cursor movement, search and selection over a pane's scrollback, yanking into paste buffers
+
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// errNotInMode is tmux's error for copy-mode commands outside copy mode.
+var errNotInMode = errors.New("not in a mode")
+
+// copyMode is the copy-mode state of a pane.
+type copyMode struct {
+	CX, CY     int // Cursor column and line in the pane's scrollback
+	Top        int // First scrollback line shown
+	Selecting  bool
+	SX, SY     int // Selection start
+	LineSelect bool
+	Search     string
+	Backward   bool // Direction of the last search
+}
+
+// CopyModeView is what the current pane shows while in copy mode.
+type CopyModeView struct {
+	Lines    []string // Visible lines
+	CursorX  int
+	CursorY  int // Relative to the first visible line
+	Selected func(x, y int) bool
+	Position string // tmux's "[offset/history]" indicator
+}
+
+// CopyMode returns the current pane's copy-mode view, or nil if it is not in copy mode.
+func (r *MissionRunner) CopyMode() *CopyModeView {
+	if !r.InTmuxSession() {
+		return nil
+	}
+	w, p := r.Tmux.CurrentWindow(), r.Tmux.CurrentPane()
+	if p.copy == nil {
+		return nil
+	}
+	cm := p.copy
+	_, height := w.PaneSize(p)
+	lines := p.scrollback()
+	visible := lines[cm.Top:min(cm.Top+height, len(lines))]
+	history := max(len(lines)-height, 0)
+
+	top := cm.Top
+	return &CopyModeView{
+		Lines:   visible,
+		CursorX: cm.CX,
+		CursorY: cm.CY - cm.Top,
+		Selected: func(x, y int) bool {
+			return cm.selected(x, y+top, lines)
+		},
+		Position: fmt.Sprintf("[%d/%d]", history-min(cm.Top, history), history),
+	}
+}
+
+// recordOutput appends a command and its output to a pane's scrollback.
+func (p *TmuxPane) recordOutput(input string, result MissionResult) {
+	p.Lines = append(p.Lines, "$ "+input)
+	for _, text := range []string{result.Output, result.Error} {
+		if text != "" {
+			p.Lines = append(p.Lines, strings.Split(strings.TrimRight(text, "\n"), "\n")...)
+		}
+	}
+}
+
+// scrollback returns the pane's lines, ending with the current prompt line.
+func (p *TmuxPane) scrollback() []string {
+	return append(append([]string(nil), p.Lines...), "$ ")
+}
+
+// enterCopyMode puts a pane into copy mode with the cursor on the prompt line.
+func (p *TmuxPane) enterCopyMode(height int) {
+	if p.copy != nil {
+		return
+	}
+	last := len(p.scrollback()) - 1
+	p.copy = &copyMode{CY: last, Top: max(last-height+1, 0)}
+}
+
+func (r *MissionRunner) tmuxCopyMode(f tmuxFlags) MissionResult {
+	_, w, p, err := r.Tmux.resolvePane(f.target())
+	if err != nil {
+		return tmuxError(err)
+	}
+	_, height := w.PaneSize(p)
+	p.enterCopyMode(height)
+	if f.has('u') {
+		p.copyCommand("page-up", "", height)
+	}
+	return MissionResult{Success: true}
+}
+
+func (r *MissionRunner) tmuxSendKeys(f tmuxFlags) MissionResult {
+	t := &r.Tmux
+	_, w, p, err := t.resolvePane(f.target())
+	if err != nil {
+		return tmuxError(err)
+	}
+
+	if !f.has('X') {
+		// Keys sent to the shell arrive as typed input.
+		var b strings.Builder
+		for _, key := range f.args {
+			switch key {
+			case "Space":
+				b.WriteString(" ")
+			case "Enter", "C-m":
+				b.WriteString("\n")
+			default:
+				b.WriteString(key)
+			}
+		}
+		return MissionResult{Success: true, Paste: b.String()}
+	}
+
+	if p.copy == nil {
+		return tmuxError(errNotInMode)
+	}
+	name, ok := f.arg(0)
+	if !ok {
+		return tmuxError(errors.New("usage: send-keys -X command"))
+	}
+	arg, _ := f.arg(1)
+	_, height := w.PaneSize(p)
+	yanked, err := p.copyCommand(name, arg, height)
+	if err != nil {
+		return tmuxError(err)
+	}
+	if yanked != "" {
+		t.addBuffer(yanked)
+		return tmuxOK("[copied %d bytes to %s]", len(yanked), t.Buffers[0].Name)
+	}
+	return MissionResult{Success: true}
+}
+
+// copyCommand runs a copy-mode command (the names used by send-keys -X) and returns
+// any text copied by a copy-selection command.
+//
+//nolint:funlen,gocyclo // Copy-mode command dispatcher requires many branches
+func (p *TmuxPane) copyCommand(name, arg string, height int) (string, error) {
+	cm := p.copy
+	lines := p.scrollback()
+	last := len(lines) - 1
+
+	switch name {
+	case "cursor-up":
+		cm.CY = max(cm.CY-1, 0)
+	case "cursor-down":
+		cm.CY = min(cm.CY+1, last)
+	case "cursor-left":
+		cm.CX = max(cm.CX-1, 0)
+	case "cursor-right":
+		cm.CX = min(cm.CX+1, lineEnd(lines[cm.CY]))
+	case "start-of-line":
+		cm.CX = 0
+	case "end-of-line":
+		cm.CX = lineEnd(lines[cm.CY])
+	case "history-top":
+		cm.CX, cm.CY = 0, 0
+	case "history-bottom":
+		cm.CX, cm.CY = 0, last
+	case "page-up":
+		cm.CY = max(cm.CY-height, 0)
+	case "page-down":
+		cm.CY = min(cm.CY+height, last)
+	case "halfpage-up":
+		cm.CY = max(cm.CY-height/2, 0)
+	case "halfpage-down":
+		cm.CY = min(cm.CY+height/2, last)
+	case "next-word":
+		cm.CX, cm.CY = nextWord(lines, cm.CX, cm.CY)
+	case "next-word-end":
+		cm.CX, cm.CY = nextWordEnd(lines, cm.CX, cm.CY)
+	case "previous-word":
+		cm.CX, cm.CY = previousWord(lines, cm.CX, cm.CY)
+	case "begin-selection":
+		cm.Selecting, cm.LineSelect = true, false
+		cm.SX, cm.SY = cm.CX, cm.CY
+	case "select-line":
+		cm.Selecting, cm.LineSelect = true, true
+		cm.SX, cm.SY = 0, cm.CY
+	case "clear-selection":
+		cm.Selecting = false
+	case "search-forward", "search-backward":
+		if arg == "" {
+			return "", nil
+		}
+		cm.Search, cm.Backward = arg, name == "search-backward"
+		cm.search(lines, cm.Backward)
+	case "search-again":
+		cm.search(lines, cm.Backward)
+	case "search-reverse":
+		cm.search(lines, !cm.Backward)
+	case "copy-selection-and-cancel", "copy-pipe-and-cancel":
+		text := cm.selectionText(lines)
+		p.copy = nil
+		return text, nil
+	case "copy-selection", "copy-pipe":
+		text := cm.selectionText(lines)
+		cm.Selecting = false
+		return text, nil
+	case "cancel":
+		p.copy = nil
+		return "", nil
+	default:
+		return "", fmt.Errorf("unknown command: %s", name)
+	}
+
+	cm.CX = min(cm.CX, lineEnd(lines[cm.CY]))
+	cm.scrollTo(height)
+	return "", nil
+}
+
+// scrollTo moves the viewport so the cursor line is visible.
+func (cm *copyMode) scrollTo(height int) {
+	if cm.CY < cm.Top {
+		cm.Top = cm.CY
+	}
+	if cm.CY >= cm.Top+height {
+		cm.Top = cm.CY - height + 1
+	}
+}
+
+// search moves the cursor to the next match of the search term, wrapping around.
+func (cm *copyMode) search(lines []string, backward bool) {
+	if cm.Search == "" {
+		return
+	}
+	n := len(lines)
+	for i := 0; i <= n; i++ {
+		y := cm.CY + i
+		if backward {
+			y = cm.CY - i
+		}
+		y = ((y % n) + n) % n
+		line := lines[y]
+
+		var x int
+		switch {
+		case backward && i == 0:
+			x = strings.LastIndex(line[:cm.CX], cm.Search)
+		case backward:
+			x = strings.LastIndex(line, cm.Search)
+		case i == 0:
+			x = strings.Index(line[min(cm.CX+1, len(line)):], cm.Search)
+			if x >= 0 {
+				x += cm.CX + 1
+			}
+		default:
+			x = strings.Index(line, cm.Search)
+		}
+		if x >= 0 {
+			cm.CX, cm.CY = x, y
+			return
+		}
+	}
+}
+
+// selected reports whether the cell at column x of scrollback line y is selected.
+func (cm *copyMode) selected(x, y int, lines []string) bool {
+	if !cm.Selecting {
+		return false
+	}
+	sx, sy, ex, ey := cm.selectionBounds(lines)
+	switch {
+	case y < sy || y > ey:
+		return false
+	case sy == ey:
+		return x >= sx && x <= ex
+	case y == sy:
+		return x >= sx
+	case y == ey:
+		return x <= ex
+	}
+	return true
+}
+
+// selectionBounds returns the ordered start and (inclusive) end of the selection.
+func (cm *copyMode) selectionBounds(lines []string) (int, int, int, int) {
+	sx, sy, ex, ey := cm.SX, cm.SY, cm.CX, cm.CY
+	if ey < sy || (ey == sy && ex < sx) {
+		sx, sy, ex, ey = ex, ey, sx, sy
+	}
+	if cm.LineSelect {
+		sx, ex = 0, max(len(lines[ey])-1, 0)
+	}
+	return sx, sy, ex, ey
+}
+
+// selectionText returns the selected text, or "" if nothing is selected.
+func (cm *copyMode) selectionText(lines []string) string {
+	if !cm.Selecting {
+		return ""
+	}
+	sx, sy, ex, ey := cm.selectionBounds(lines)
+	var parts []string
+	for y := sy; y <= ey; y++ {
+		line := lines[y]
+		start, end := 0, len(line)
+		if y == sy {
+			start = min(sx, len(line))
+		}
+		if y == ey {
+			end = min(ex+1, len(line))
+		}
+		parts = append(parts, line[start:max(start, end)])
+	}
+	text := strings.Join(parts, "\n")
+	if cm.LineSelect {
+		text += "\n"
+	}
+	return text
+}
+
+// lineEnd is the last cursor column on a line.
+func lineEnd(line string) int {
+	return max(len(line)-1, 0)
+}
+
+func isWordChar(c byte) bool {
+	return !unicode.IsSpace(rune(c))
+}
+
+// nextWord moves to the start of the next word, continuing onto following lines.
+func nextWord(lines []string, x, y int) (int, int) {
+	line := lines[y]
+	for x < len(line) && isWordChar(line[x]) {
+		x++
+	}
+	for {
+		for x < len(line) && !isWordChar(line[x]) {
+			x++
+		}
+		if x < len(line) || y == len(lines)-1 {
+			return min(x, lineEnd(line)), y
+		}
+		y, x = y+1, 0
+		line = lines[y]
+	}
+}
+
+// nextWordEnd moves to the end of the current or next word.
+func nextWordEnd(lines []string, x, y int) (int, int) {
+	line := lines[y]
+	x++
+	for {
+		for x < len(line) && !isWordChar(line[x]) {
+			x++
+		}
+		if x < len(line) {
+			break
+		}
+		if y == len(lines)-1 {
+			return lineEnd(line), y
+		}
+		y, x = y+1, 0
+		line = lines[y]
+	}
+	for x+1 < len(line) && isWordChar(line[x+1]) {
+		x++
+	}
+	return x, y
+}
+
+// previousWord moves to the start of the current or previous word.
+func previousWord(lines []string, x, y int) (int, int) {
+	line := lines[y]
+	x--
+	for {
+		for x >= 0 && (x >= len(line) || !isWordChar(line[x])) {
+			x--
+		}
+		if x >= 0 {
+			break
+		}
+		if y == 0 {
+			return 0, 0
+		}
+		y--
+		line = lines[y]
+		x = len(line) - 1
+	}
+	for x > 0 && isWordChar(line[x-1]) {
+		x--
+	}
+	return x, y
+}
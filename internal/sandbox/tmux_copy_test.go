@@ -0,0 +1,114 @@
+// ABOUTME: Tests for tmux copy mode in the simulator
+// ABOUTME: Covers movement, search, selection, yanking into buffers and the copy-mode key tables
+
+package sandbox
+
+import (
+	"testing"
+
+	"github.com/2389-research/turtle/internal/content"
+)
+
+// logRunner starts tmux in a mission with a small log file already printed in the pane.
+func logRunner(t *testing.T, goal func(content.GoalEvaluator) bool) *MissionRunner {
+	t.Helper()
+	runner := NewMissionRunner(&Mission{
+		Setup: func(fs *Filesystem) {
+			_ = fs.WriteFile("/home/learner/app.log", "INFO: started\nERROR: disk full\nINFO: done\n")
+		},
+		Goal: goal,
+	})
+	runAll(t, runner, "tmux", "cat /home/learner/app.log")
+	return runner
+}
+
+func TestCopyMode_EnterAndCancel(t *testing.T) {
+	runner := logRunner(t, nil)
+	if runner.CopyMode() != nil {
+		t.Fatal("pane should not start in copy mode")
+	}
+
+	runner.ExecuteKey("[")
+	view := runner.CopyMode()
+	if view == nil {
+		t.Fatal("C-b [ should enter copy mode")
+	}
+	if view.Lines[view.CursorY] != "$ " {
+		t.Errorf("cursor should start on the prompt line, got %q", view.Lines[view.CursorY])
+	}
+
+	runner.CopyModeKey("q")
+	if runner.CopyMode() != nil {
+		t.Error("q should leave copy mode")
+	}
+}
+
+func TestCopyMode_SearchSelectCopy(t *testing.T) {
+	goal, err := content.ParseGoal(map[string]any{"tmux_buffer": map[string]any{"equals": "ERROR: disk full"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner := logRunner(t, goal.Evaluate)
+	runner.ExecuteKey("[")
+
+	if result := runner.CopyModeKey("C-r"); result.Prompt != "(search up)" {
+		t.Fatalf("C-r should prompt for a search, got %q", result.Prompt)
+	}
+	runner.SubmitPrompt("ERROR")
+
+	for _, key := range []string{"C-a", "C-Space", "C-e"} {
+		runner.CopyModeKey(key)
+	}
+	result := runner.CopyModeKey("M-w")
+	if !result.Completed {
+		t.Errorf("copying the error line should complete the goal, got %+v", result)
+	}
+	if runner.CopyMode() != nil {
+		t.Error("M-w should leave copy mode")
+	}
+	if b, _ := runner.Tmux.Buffer(""); b == nil || b.Name != "buffer0" {
+		t.Errorf("expected an automatic buffer0, got %+v", b)
+	}
+}
+
+func TestCopyMode_ViKeys(t *testing.T) {
+	runner := logRunner(t, nil)
+	runner.Tmux.Options = map[string]string{"mode-keys": "vi"}
+	runAll(t, runner, "tmux copy-mode")
+
+	// Lines: "$ cat ...", three log lines, "$ ". Move up to "INFO: done" and copy it.
+	for _, key := range []string{"k", "V", "Enter"} {
+		runner.CopyModeKey(key)
+	}
+	b, ok := runner.Tmux.Buffer("")
+	if !ok || b.Data != "INFO: done\n" {
+		t.Errorf("expected the line to be copied, got %+v", b)
+	}
+}
+
+func TestCopyMode_Words(t *testing.T) {
+	lines := []string{"one two", "  three"}
+	x, y := nextWord(lines, 0, 0)
+	if x != 4 || y != 0 {
+		t.Errorf("next word from 0,0 = %d,%d", x, y)
+	}
+	x, y = nextWord(lines, 4, 0)
+	if x != 2 || y != 1 {
+		t.Errorf("next word should continue on the next line, got %d,%d", x, y)
+	}
+	x, y = previousWord(lines, 2, 1)
+	if x != 4 || y != 0 {
+		t.Errorf("previous word should go back a line, got %d,%d", x, y)
+	}
+	x, y = nextWordEnd(lines, 0, 0)
+	if x != 2 || y != 0 {
+		t.Errorf("next word end from 0,0 = %d,%d", x, y)
+	}
+}
+
+func TestSendKeysX_RequiresCopyMode(t *testing.T) {
+	runner := logRunner(t, nil)
+	if result := runner.Execute("tmux send-keys -X cursor-up"); result.Error != "not in a mode" {
+		t.Errorf("expected not in a mode, got %q", result.Error)
+	}
+}
@@ -0,0 +1,377 @@
+// ABOUTME: Key tables for the simulated tmux (prefix and copy mode)
+// ABOUTME: Maps keys pressed after the prefix (Ctrl-b) or in copy mode to tmux commands
+
+package sandbox
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/2389-research/turtle/internal/content"
+)
+
+// DefaultTmuxPrefix is the prefix key of a stock tmux install, in tmux key notation.
+const DefaultTmuxPrefix = "C-b"
+
+// DefaultTmuxBindings returns the stock key tables the simulator understands, keyed by
+// table name ("root", "prefix", "copy-mode", "copy-mode-vi"). Keys use tmux notation ("%",
+// "Up", "C-o"); values are tmux command arguments.
+func DefaultTmuxBindings() map[string]map[string][]string {
+	return map[string]map[string][]string{
+		"root":         {},
+		"prefix":       defaultPrefixTable(),
+		"copy-mode":    defaultCopyModeTable(),
+		"copy-mode-vi": defaultCopyModeViTable(),
+	}
+}
+
+func defaultPrefixTable() map[string][]string {
+	return map[string][]string{
+		"C-b":     {"send-prefix"},
+		"%":       {"split-window", "-h"},
+		"\"":      {"split-window", "-v"},
+		"o":       {"select-pane", "-t", ":.+"},
+		"Up":      {"select-pane", "-U"},
+		"Down":    {"select-pane", "-D"},
+		"Left":    {"select-pane", "-L"},
+		"Right":   {"select-pane", "-R"},
+		";":       {"last-pane"},
+		"c":       {"new-window"},
+		"n":       {"next-window"},
+		"p":       {"previous-window"},
+		"l":       {"last-window"},
+		"0":       {"select-window", "-t", ":=0"},
+		"1":       {"select-window", "-t", ":=1"},
+		"2":       {"select-window", "-t", ":=2"},
+		"3":       {"select-window", "-t", ":=3"},
+		"4":       {"select-window", "-t", ":=4"},
+		"5":       {"select-window", "-t", ":=5"},
+		"6":       {"select-window", "-t", ":=6"},
+		"7":       {"select-window", "-t", ":=7"},
+		"8":       {"select-window", "-t", ":=8"},
+		"9":       {"select-window", "-t", ":=9"},
+		"(":       {"switch-client", "-p"},
+		")":       {"switch-client", "-n"},
+		"L":       {"switch-client", "-l"},
+		"d":       {"detach-client"},
+		":":       {"command-prompt"},
+		"x":       {"confirm-before", "-p", "kill-pane #P? (y/n)", "kill-pane"},
+		"&":       {"confirm-before", "-p", "kill-window #W? (y/n)", "kill-window"},
+		",":       {"command-prompt", "-I", "#W", "rename-window", "--", "%%"},
+		"$":       {"command-prompt", "-I", "#S", "rename-session", "--", "%%"},
+		"z":       {"resize-pane", "-Z"},
+		"{":       {"swap-pane", "-U"},
+		"}":       {"swap-pane", "-D"},
+		"!":       {"break-pane"},
+		"Space":   {"next-layout"},
+		"M-1":     {"select-layout", "even-horizontal"},
+		"M-2":     {"select-layout", "even-vertical"},
+		"M-3":     {"select-layout", "main-horizontal"},
+		"M-4":     {"select-layout", "main-vertical"},
+		"M-5":     {"select-layout", "tiled"},
+		"C-Up":    {"resize-pane", "-U"},
+		"C-Down":  {"resize-pane", "-D"},
+		"C-Left":  {"resize-pane", "-L"},
+		"C-Right": {"resize-pane", "-R"},
+		"M-Up":    {"resize-pane", "-U", "5"},
+		"M-Down":  {"resize-pane", "-D", "5"},
+		"M-Left":  {"resize-pane", "-L", "5"},
+		"M-Right": {"resize-pane", "-R", "5"},
+		"[":       {"copy-mode"},
+		"PPage":   {"copy-mode", "-u"},
+		"]":       {"paste-buffer"},
+		"#":       {"list-buffers"},
+		"-":       {"delete-buffer"},
+	}
+}
+
+// defaultCopyModeTable is tmux's emacs-style copy-mode table (mode-keys emacs).
+func defaultCopyModeTable() map[string][]string {
+	return map[string][]string{
+		"Up":      copyCommand("cursor-up"),
+		"C-p":     copyCommand("cursor-up"),
+		"Down":    copyCommand("cursor-down"),
+		"C-n":     copyCommand("cursor-down"),
+		"Left":    copyCommand("cursor-left"),
+		"C-b":     copyCommand("cursor-left"),
+		"Right":   copyCommand("cursor-right"),
+		"C-f":     copyCommand("cursor-right"),
+		"C-a":     copyCommand("start-of-line"),
+		"Home":    copyCommand("start-of-line"),
+		"C-e":     copyCommand("end-of-line"),
+		"End":     copyCommand("end-of-line"),
+		"M-<":     copyCommand("history-top"),
+		"M->":     copyCommand("history-bottom"),
+		"M-f":     copyCommand("next-word-end"),
+		"M-b":     copyCommand("previous-word"),
+		"PPage":   copyCommand("page-up"),
+		"M-v":     copyCommand("page-up"),
+		"NPage":   copyCommand("page-down"),
+		"C-v":     copyCommand("page-down"),
+		"C-Space": copyCommand("begin-selection"),
+		"C-g":     copyCommand("clear-selection"),
+		"M-w":     copyCommand("copy-selection-and-cancel"),
+		"C-w":     copyCommand("copy-selection-and-cancel"),
+		"C-s":     {"command-prompt", "-p", "(search down)", "send-keys", "-X", "search-forward", "%%"},
+		"C-r":     {"command-prompt", "-p", "(search up)", "send-keys", "-X", "search-backward", "%%"},
+		"n":       copyCommand("search-again"),
+		"N":       copyCommand("search-reverse"),
+		"q":       copyCommand("cancel"),
+		"Escape":  copyCommand("cancel"),
+	}
+}
+
+// defaultCopyModeViTable is tmux's vi-style copy-mode table (mode-keys vi).
+func defaultCopyModeViTable() map[string][]string {
+	return map[string][]string{
+		"k":      copyCommand("cursor-up"),
+		"Up":     copyCommand("cursor-up"),
+		"j":      copyCommand("cursor-down"),
+		"Down":   copyCommand("cursor-down"),
+		"h":      copyCommand("cursor-left"),
+		"Left":   copyCommand("cursor-left"),
+		"l":      copyCommand("cursor-right"),
+		"Right":  copyCommand("cursor-right"),
+		"0":      copyCommand("start-of-line"),
+		"$":      copyCommand("end-of-line"),
+		"g":      copyCommand("history-top"),
+		"G":      copyCommand("history-bottom"),
+		"w":      copyCommand("next-word"),
+		"b":      copyCommand("previous-word"),
+		"e":      copyCommand("next-word-end"),
+		"C-b":    copyCommand("page-up"),
+		"PPage":  copyCommand("page-up"),
+		"C-f":    copyCommand("page-down"),
+		"NPage":  copyCommand("page-down"),
+		"C-u":    copyCommand("halfpage-up"),
+		"C-d":    copyCommand("halfpage-down"),
+		"Space":  copyCommand("begin-selection"),
+		"V":      copyCommand("select-line"),
+		"Escape": copyCommand("clear-selection"),
+		"Enter":  copyCommand("copy-pipe-and-cancel"),
+		"C-j":    copyCommand("copy-pipe-and-cancel"),
+		"/":      {"command-prompt", "-p", "(search down)", "send-keys", "-X", "search-forward", "%%"},
+		"?":      {"command-prompt", "-p", "(search up)", "send-keys", "-X", "search-backward", "%%"},
+		"n":      copyCommand("search-again"),
+		"N":      copyCommand("search-reverse"),
+		"q":      copyCommand("cancel"),
+	}
+}
+
+// copyCommand is the binding for a copy-mode command (send-keys -X name).
+func copyCommand(name string) []string {
+	return []string{"send-keys", "-X", name}
+}
+
+// keyTable returns a key table by name, defaulting to stock tmux.
+func (t *TmuxState) keyTable(name string) map[string][]string {
+	if t.Bindings == nil {
+		t.Bindings = DefaultTmuxBindings()
+	}
+	return t.Bindings[name]
+}
+
+// copyModeTable returns the name of the copy-mode key table selected by mode-keys.
+func (t *TmuxState) copyModeTable() string {
+	if t.Option("mode-keys") == "vi" {
+		return "copy-mode-vi"
+	}
+	return "copy-mode"
+}
+
+// TmuxPrefix returns the current prefix key in tmux notation.
+func (r *MissionRunner) TmuxPrefix() string {
+	return r.Tmux.Option("prefix")
+}
+
+// IsTmuxPrefix returns true if key is the prefix or the secondary prefix (prefix2).
+func (r *MissionRunner) IsTmuxPrefix(key string) bool {
+	prefix2 := r.Tmux.Option("prefix2")
+	return key == r.TmuxPrefix() || (prefix2 != "None" && key == prefix2)
+}
+
+// tmuxPrompt is a pending command-prompt or confirm-before opened by a binding.
+type tmuxPrompt struct {
+	Template []string // Command to run; "%%" is replaced by the input (nil = run input)
+	Confirm  bool     // Run the template only if the answer is "y"
+	Prefix   string   // Prefix pressed before Key (empty for other key tables)
+	Key      string   // Binding that opened the prompt
+}
+
+// ExecuteKey runs the binding for a key pressed after the tmux prefix.
+// Keys use tmux notation, e.g. "%", "Up" or "C-o".
+func (r *MissionRunner) ExecuteKey(key string) MissionResult {
+	if !r.InTmuxSession() {
+		return MissionResult{Error: "no current client"}
+	}
+
+	args, ok := r.Tmux.keyTable("prefix")[key]
+	if !ok || len(args) == 0 {
+		return MissionResult{Error: fmt.Sprintf("%s %s is not bound", r.TmuxPrefix(), key)}
+	}
+
+	r.Attempts++
+	return r.runBinding(args, r.TmuxPrefix(), key)
+}
+
+// RootKey runs the root-table binding (bind-key -n) for a key pressed without the
+// prefix. It returns false if the key is unbound, so the key is typed as usual.
+func (r *MissionRunner) RootKey(key string) (MissionResult, bool) {
+	if !r.InTmuxSession() {
+		return MissionResult{}, false
+	}
+	args, ok := r.Tmux.keyTable("root")[key]
+	if !ok || len(args) == 0 {
+		return MissionResult{}, false
+	}
+
+	r.Attempts++
+	return r.runBinding(args, "", key), true
+}
+
+// CopyModeKey runs the copy-mode binding for a key pressed while the current pane is
+// in copy mode. Unbound keys are ignored, as in tmux.
+func (r *MissionRunner) CopyModeKey(key string) MissionResult {
+	if r.CopyMode() == nil {
+		return MissionResult{Error: "not in a mode"}
+	}
+
+	args, ok := r.Tmux.keyTable(r.Tmux.copyModeTable())[key]
+	if !ok || len(args) == 0 {
+		return MissionResult{Success: true}
+	}
+	return r.runBinding(args, "", key)
+}
+
+// runBinding runs a key binding's commands (separated by ";"), stopping at the first
+// error or prompt.
+func (r *MissionRunner) runBinding(args []string, prefix, key string) MissionResult {
+	var result MissionResult
+	var output []string
+	completed := false
+	for _, command := range splitTmuxCommands(args) {
+		result = r.runBindingCommand(command, prefix, key)
+		if result.Output != "" {
+			output = append(output, result.Output)
+		}
+		completed = completed || result.Completed
+		if result.Error != "" || result.Prompt != "" {
+			break
+		}
+	}
+	result.Output = strings.Join(output, "\n")
+	result.Completed = completed
+	return result
+}
+
+// runBindingCommand runs one command from a key binding, opening a prompt for prompt commands.
+func (r *MissionRunner) runBindingCommand(args []string, prefix, key string) MissionResult {
+	switch args[0] {
+	case "command-prompt":
+		f, err := parseTmuxFlags(args[1:], "I:p:")
+		if err != nil {
+			return MissionResult{Error: err.Error()}
+		}
+		label := f.value('p')
+		if label == "" && len(f.args) > 0 {
+			label = "(" + f.args[0] + ")"
+		}
+		return r.openPrompt(&tmuxPrompt{Template: f.args, Prefix: prefix, Key: key}, label, r.expandTmuxFormat(f.value('I')))
+	case "confirm-before":
+		f, err := parseTmuxFlags(args[1:], "p:")
+		if err != nil || len(f.args) == 0 {
+			return MissionResult{Error: "usage: confirm-before [-p prompt] command"}
+		}
+		label := f.value('p')
+		if label == "" {
+			label = f.args[0] + "? (y/n)"
+		}
+		return r.openPrompt(&tmuxPrompt{Template: f.args, Confirm: true, Prefix: prefix, Key: key}, r.expandTmuxFormat(label), "")
+	}
+
+	return r.runTmux(args, prefix, key)
+}
+
+// openPrompt shows a tmux prompt; the TUI collects the answer and calls SubmitPrompt.
+func (r *MissionRunner) openPrompt(p *tmuxPrompt, label, initial string) MissionResult {
+	if label == "" {
+		label = ":"
+	}
+	r.PromptPending = true
+	r.prompt = p
+	return MissionResult{Success: true, Prompt: label, PromptInput: initial}
+}
+
+// PromptConfirm returns true if the pending prompt is a y/n confirmation.
+func (r *MissionRunner) PromptConfirm() bool {
+	return r.PromptPending && r.prompt != nil && r.prompt.Confirm
+}
+
+// SubmitPrompt runs the input typed at a tmux prompt (prefix + :, or a binding's prompt).
+func (r *MissionRunner) SubmitPrompt(input string) MissionResult {
+	p := r.prompt
+	r.CancelPrompt()
+
+	if p == nil || len(p.Template) == 0 {
+		tokens, err := tokenizeTmux(input)
+		if err != nil {
+			return MissionResult{Error: err.Error()}
+		}
+		if len(tokens) == 0 {
+			return MissionResult{Success: true}
+		}
+		return r.runBinding(tokenArgs(tokens), "", "")
+	}
+	if p.Confirm {
+		if input != "y" {
+			return MissionResult{Success: true}
+		}
+		return r.runTmux(p.Template, p.Prefix, p.Key)
+	}
+
+	args := make([]string, len(p.Template))
+	for i, arg := range p.Template {
+		args[i] = strings.ReplaceAll(arg, "%%", input)
+	}
+	return r.runTmux(args, p.Prefix, p.Key)
+}
+
+// CancelPrompt abandons a pending command prompt.
+func (r *MissionRunner) CancelPrompt() {
+	r.PromptPending = false
+	r.prompt = nil
+}
+
+// expandTmuxFormat replaces the #S, #I, #W and #P format aliases with current values.
+func (r *MissionRunner) expandTmuxFormat(format string) string {
+	s, w, p := r.Tmux.CurrentSession(), r.Tmux.CurrentWindow(), r.Tmux.CurrentPane()
+	if s == nil {
+		return format
+	}
+	return strings.NewReplacer(
+		"#S", s.Name,
+		"#I", strconv.Itoa(w.Index),
+		"#W", w.Name,
+		"#P", strconv.Itoa(w.PaneIndex(p)),
+	).Replace(format)
+}
+
+// runTmux executes tmux arguments outside the shell, records the operation, and checks the goal.
+func (r *MissionRunner) runTmux(args []string, prefix, key string) MissionResult {
+	result := r.executeTmux(args)
+	if result.Error == "" {
+		r.recordTmux(args, prefix, key)
+	}
+	r.checkGoal("tmux "+strings.Join(args, " "), &result)
+	return result
+}
+
+// recordTmux appends a successful tmux operation to the runner's log.
+func (r *MissionRunner) recordTmux(args []string, prefix, key string) {
+	command := "new-session"
+	if len(args) > 0 {
+		command = canonicalTmuxCommand(args[0])
+	}
+	r.TmuxLog = append(r.TmuxLog, content.TmuxOperation{Command: command, Prefix: prefix, Key: key})
+}
@@ -0,0 +1,141 @@
+// ABOUTME: Tests for tmux prefix-key bindings
+// ABOUTME: Verifies keybindings drive the same simulated operations as typed commands
+
+package sandbox
+
+import (
+	"testing"
+
+	"github.com/2389-research/turtle/internal/content"
+)
+
+func TestExecuteKey_RequiresSession(t *testing.T) {
+	runner := NewMissionRunner(&Mission{})
+
+	result := runner.ExecuteKey("%")
+	if result.Error == "" {
+		t.Error("prefix keys should fail outside a tmux session")
+	}
+}
+
+func TestExecuteKey_Split(t *testing.T) {
+	runner := NewMissionRunner(&Mission{})
+	runner.Execute("tmux")
+
+	result := runner.ExecuteKey("%")
+	if result.Error != "" {
+		t.Fatalf("C-b %% failed: %s", result.Error)
+	}
+	if n := len(runner.Tmux.CurrentWindow().Panes); n != 2 {
+		t.Errorf("Expected 2 panes after C-b %%, got %d", n)
+	}
+
+	if len(runner.TmuxLog) != 2 {
+		t.Fatalf("Expected 2 logged operations, got %d", len(runner.TmuxLog))
+	}
+	if runner.TmuxLog[0] != (content.TmuxOperation{Command: "new-session"}) {
+		t.Errorf("Expected typed new-session, got %+v", runner.TmuxLog[0])
+	}
+	if runner.TmuxLog[1] != (content.TmuxOperation{Command: "split-window", Prefix: "C-b", Key: "%"}) {
+		t.Errorf("Expected split-window via %%, got %+v", runner.TmuxLog[1])
+	}
+}
+
+func TestExecuteKey_Detach(t *testing.T) {
+	runner := NewMissionRunner(&Mission{})
+	runner.Execute("tmux new -s work")
+
+	runner.ExecuteKey("d")
+	if runner.InTmuxSession() {
+		t.Error("C-b d should detach the client")
+	}
+}
+
+func TestExecuteKey_Unbound(t *testing.T) {
+	runner := NewMissionRunner(&Mission{})
+	runner.Execute("tmux")
+
+	result := runner.ExecuteKey("F12")
+	if result.Error == "" {
+		t.Error("unbound key should report an error")
+	}
+}
+
+func TestExecuteKey_CommandPrompt(t *testing.T) {
+	runner := NewMissionRunner(&Mission{})
+	runner.Execute("tmux")
+
+	result := runner.ExecuteKey(":")
+	if result.Prompt == "" || !runner.PromptPending {
+		t.Fatal("C-b : should open the command prompt")
+	}
+
+	runner.SubmitPrompt("new-window")
+	if runner.PromptPending {
+		t.Error("prompt should close after submit")
+	}
+	if n := len(runner.Tmux.CurrentSession().Windows); n != 2 {
+		t.Errorf("Expected 2 windows, got %d", n)
+	}
+	last := runner.TmuxLog[len(runner.TmuxLog)-1]
+	if last.Key != "" {
+		t.Errorf("command prompt input should count as a typed command, got key %q", last.Key)
+	}
+}
+
+func TestExecuteKey_CompletesGoal(t *testing.T) {
+	goal, err := content.ParseGoal(map[string]any{
+		"tmux_used": map[string]any{"command": "split-window", "via": "keybinding"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner := NewMissionRunner(&Mission{Goal: goal.Evaluate})
+	runner.Execute("tmux")
+
+	if result := runner.Execute("tmux split-window"); result.Completed {
+		t.Error("typed split-window should not satisfy a keybinding goal")
+	}
+	if result := runner.ExecuteKey("\""); !result.Completed {
+		t.Error("C-b \" should satisfy the keybinding goal")
+	}
+}
+
+func TestExecuteKey_ConfirmBeforeKill(t *testing.T) {
+	runner := NewMissionRunner(&Mission{})
+	runner.Execute("tmux")
+	runner.Execute("tmux split-window")
+
+	result := runner.ExecuteKey("x")
+	if result.Prompt != "kill-pane 1? (y/n)" || !runner.PromptConfirm() {
+		t.Fatalf("C-b x should ask for confirmation, got prompt %q", result.Prompt)
+	}
+	runner.SubmitPrompt("n")
+	if n := len(runner.Tmux.CurrentWindow().Panes); n != 2 {
+		t.Fatalf("answering n should keep the pane, got %d panes", n)
+	}
+
+	runner.ExecuteKey("x")
+	runner.SubmitPrompt("y")
+	if n := len(runner.Tmux.CurrentWindow().Panes); n != 1 {
+		t.Errorf("answering y should kill the pane, got %d panes", n)
+	}
+	last := runner.TmuxLog[len(runner.TmuxLog)-1]
+	if last != (content.TmuxOperation{Command: "kill-pane", Prefix: "C-b", Key: "x"}) {
+		t.Errorf("expected kill-pane via x, got %+v", last)
+	}
+}
+
+func TestExecuteKey_RenameWindowPrompt(t *testing.T) {
+	runner := NewMissionRunner(&Mission{})
+	runner.Execute("tmux")
+
+	result := runner.ExecuteKey(",")
+	if result.Prompt != "(rename-window)" || result.PromptInput != "bash" {
+		t.Fatalf("C-b , should prompt with the window name, got %q %q", result.Prompt, result.PromptInput)
+	}
+	runner.SubmitPrompt("my editor")
+	if name := runner.Tmux.CurrentWindow().Name; name != "my editor" {
+		t.Errorf("expected window renamed to %q, got %q", "my editor", name)
+	}
+}
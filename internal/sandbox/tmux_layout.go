@@ -0,0 +1,522 @@
+// ABOUTME: Pane layout tree for the simulated tmux
+// ABOUTME: Splits cells like tmux does and renders the layout string shown by list-windows
+
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Default size of a simulated tmux window (tmux's default-size).
+const (
+	TmuxWindowWidth  = 80
+	TmuxWindowHeight = 24
+)
+
+// errNoSpace mirrors tmux's error when a pane is too small to split.
+var errNoSpace = errors.New("no space for new pane")
+
+// layoutType is the kind of node in a window's layout tree.
+type layoutType int
+
+const (
+	layoutPane      layoutType = iota // Leaf holding a single pane
+	layoutLeftRight                   // Children side by side
+	layoutTopBottom                   // Children stacked
+)
+
+// layoutCell is a node in a window's layout tree, matching tmux's layout_cell.
+type layoutCell struct {
+	Type     layoutType
+	Parent   *layoutCell
+	Children []*layoutCell
+	SX, SY   int // Size in cells
+	XOff     int // Offset from the window's left edge
+	YOff     int // Offset from the window's top edge
+	Pane     *TmuxPane
+}
+
+// newLayout creates a single-pane layout filling the window.
+func newLayout(pane *TmuxPane, sx, sy int) *layoutCell {
+	cell := &layoutCell{Type: layoutPane, SX: sx, SY: sy, Pane: pane}
+	pane.cell = cell
+	return cell
+}
+
+// splitCell splits a leaf cell in two, giving the new half to pane.
+// Horizontal splits place the panes side by side (tmux split-window -h).
+// Size is the new pane's size, or 0 for an even split. If before is set the
+// new pane goes left of / above the old one.
+func splitCell(cell *layoutCell, horizontal bool, size int, before bool, pane *TmuxPane) (*layoutCell, error) {
+	splitType := layoutTopBottom
+	total := cell.SY
+	if horizontal {
+		splitType = layoutLeftRight
+		total = cell.SX
+	}
+	if total < 3 {
+		return nil, errNoSpace
+	}
+
+	newSize := (total+1)/2 - 1
+	if size > 0 {
+		newSize = min(max(size, 1), total-2)
+	}
+	oldSize := total - 1 - newSize
+
+	// Wrap the cell in a container unless its parent already splits this way.
+	parent := cell.Parent
+	if parent == nil || parent.Type != splitType {
+		container := &layoutCell{
+			Type:   splitType,
+			Parent: parent,
+			SX:     cell.SX,
+			SY:     cell.SY,
+			XOff:   cell.XOff,
+			YOff:   cell.YOff,
+		}
+		if parent != nil {
+			replaceChild(parent, cell, container)
+		}
+		container.Children = []*layoutCell{cell}
+		cell.Parent = container
+		parent = container
+	}
+
+	newCell := &layoutCell{Type: layoutPane, Parent: parent, SX: cell.SX, SY: cell.SY, Pane: pane}
+	pane.cell = newCell
+	if horizontal {
+		cell.SX, newCell.SX = oldSize, newSize
+	} else {
+		cell.SY, newCell.SY = oldSize, newSize
+	}
+
+	idx := childIndex(parent, cell)
+	if !before {
+		idx++
+	}
+	parent.Children = append(parent.Children[:idx], append([]*layoutCell{newCell}, parent.Children[idx:]...)...)
+
+	fixOffsets(layoutRoot(parent))
+	return newCell, nil
+}
+
+// layoutRoot returns the top of the tree containing cell.
+func layoutRoot(cell *layoutCell) *layoutCell {
+	for cell.Parent != nil {
+		cell = cell.Parent
+	}
+	return cell
+}
+
+func childIndex(parent, child *layoutCell) int {
+	for i, c := range parent.Children {
+		if c == child {
+			return i
+		}
+	}
+	return -1
+}
+
+func replaceChild(parent, old, replacement *layoutCell) {
+	if i := childIndex(parent, old); i >= 0 {
+		parent.Children[i] = replacement
+	}
+}
+
+// fixOffsets recomputes child offsets from sizes, leaving a one-cell border between siblings.
+func fixOffsets(cell *layoutCell) {
+	switch cell.Type {
+	case layoutLeftRight:
+		x := cell.XOff
+		for _, c := range cell.Children {
+			c.XOff, c.YOff = x, cell.YOff
+			x += c.SX + 1
+			fixOffsets(c)
+		}
+	case layoutTopBottom:
+		y := cell.YOff
+		for _, c := range cell.Children {
+			c.XOff, c.YOff = cell.XOff, y
+			y += c.SY + 1
+			fixOffsets(c)
+		}
+	}
+}
+
+// layoutLeaves returns the panes of a layout in tree order (tmux's pane index order).
+func layoutLeaves(cell *layoutCell) []*TmuxPane {
+	if cell.Type == layoutPane {
+		return []*TmuxPane{cell.Pane}
+	}
+	var panes []*TmuxPane
+	for _, c := range cell.Children {
+		panes = append(panes, layoutLeaves(c)...)
+	}
+	return panes
+}
+
+// layoutString renders a layout like tmux's #{window_layout}, including the checksum.
+func layoutString(root *layoutCell) string {
+	var b strings.Builder
+	writeLayout(&b, root)
+	body := b.String()
+	return fmt.Sprintf("%04x,%s", layoutChecksum(body), body)
+}
+
+func writeLayout(b *strings.Builder, cell *layoutCell) {
+	fmt.Fprintf(b, "%dx%d,%d,%d", cell.SX, cell.SY, cell.XOff, cell.YOff)
+	switch cell.Type {
+	case layoutPane:
+		fmt.Fprintf(b, ",%d", cell.Pane.ID)
+	case layoutLeftRight, layoutTopBottom:
+		open, closing := "{", "}"
+		if cell.Type == layoutTopBottom {
+			open, closing = "[", "]"
+		}
+		b.WriteString(open)
+		for i, c := range cell.Children {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			writeLayout(b, c)
+		}
+		b.WriteString(closing)
+	}
+}
+
+// layoutChecksum is tmux's 16-bit rotating checksum over the layout body.
+func layoutChecksum(layout string) uint16 {
+	var csum uint16
+	for i := 0; i < len(layout); i++ {
+		csum = (csum >> 1) + ((csum & 1) << 15)
+		csum += uint16(layout[i])
+	}
+	return csum
+}
+
+// paneInDirection finds the pane adjacent to from on the given side, wrapping
+// around the window edge like tmux's select-pane -L/-R/-U/-D.
+func paneInDirection(panes []*TmuxPane, from *TmuxPane, dir string) *TmuxPane {
+	c := from.cell
+	var best *TmuxPane
+	bestOverlap := 0
+
+	for _, p := range panes {
+		if p == from {
+			continue
+		}
+		o := p.cell
+		var adjacent bool
+		var overlap int
+		switch dir {
+		case "L", "R":
+			overlap = spanOverlap(c.YOff, c.SY, o.YOff, o.SY)
+			if dir == "R" {
+				adjacent = o.XOff == c.XOff+c.SX+1
+			} else {
+				adjacent = o.XOff+o.SX+1 == c.XOff
+			}
+		case "U", "D":
+			overlap = spanOverlap(c.XOff, c.SX, o.XOff, o.SX)
+			if dir == "D" {
+				adjacent = o.YOff == c.YOff+c.SY+1
+			} else {
+				adjacent = o.YOff+o.SY+1 == c.YOff
+			}
+		}
+		if adjacent && overlap > bestOverlap {
+			best, bestOverlap = p, overlap
+		}
+	}
+	if best != nil {
+		return best
+	}
+
+	// Wrap to the far edge of the window.
+	return farthestPane(panes, from, dir)
+}
+
+// farthestPane returns the pane on the opposite window edge that overlaps from.
+func farthestPane(panes []*TmuxPane, from *TmuxPane, dir string) *TmuxPane {
+	c := from.cell
+	var best *TmuxPane
+	bestEdge := -1
+	for _, p := range panes {
+		if p == from {
+			continue
+		}
+		o := p.cell
+		var edge, overlap int
+		switch dir {
+		case "R":
+			edge, overlap = TmuxWindowWidth-o.XOff, spanOverlap(c.YOff, c.SY, o.YOff, o.SY)
+		case "L":
+			edge, overlap = o.XOff+o.SX, spanOverlap(c.YOff, c.SY, o.YOff, o.SY)
+		case "D":
+			edge, overlap = TmuxWindowHeight-o.YOff, spanOverlap(c.XOff, c.SX, o.XOff, o.SX)
+		case "U":
+			edge, overlap = o.YOff+o.SY, spanOverlap(c.XOff, c.SX, o.XOff, o.SX)
+		}
+		if overlap > 0 && edge > bestEdge {
+			best, bestEdge = p, edge
+		}
+	}
+	return best
+}
+
+// spanOverlap returns how many cells two 1-D spans share.
+func spanOverlap(aOff, aLen, bOff, bLen int) int {
+	start := max(aOff, bOff)
+	end := min(aOff+aLen, bOff+bLen)
+	return max(end-start, 0)
+}
+
+// removeCell removes a leaf from the layout, giving its space to a neighbour like
+// tmux's layout_destroy_cell. It returns the new root (nil if the layout is empty).
+func removeCell(cell *layoutCell) *layoutCell {
+	parent := cell.Parent
+	if parent == nil {
+		return nil
+	}
+
+	// The previous sibling absorbs the space, or the next one if this was first.
+	idx := childIndex(parent, cell)
+	neighbour := idx - 1
+	if idx == 0 {
+		neighbour = 1
+	}
+	if parent.Type == layoutLeftRight {
+		resizeCell(parent.Children[neighbour], true, cell.SX+1)
+	} else {
+		resizeCell(parent.Children[neighbour], false, cell.SY+1)
+	}
+	parent.Children = append(parent.Children[:idx], parent.Children[idx+1:]...)
+
+	// Collapse a container left with a single child.
+	if len(parent.Children) == 1 {
+		only := parent.Children[0]
+		only.Parent = parent.Parent
+		if parent.Parent != nil {
+			replaceChild(parent.Parent, parent, only)
+		}
+		parent = only
+	}
+
+	root := layoutRoot(parent)
+	fixOffsets(root)
+	return root
+}
+
+// cellSize returns a cell's size along one axis.
+func cellSize(cell *layoutCell, horizontal bool) int {
+	if horizontal {
+		return cell.SX
+	}
+	return cell.SY
+}
+
+// minCellSize returns the smallest a cell can be along one axis while keeping every pane visible.
+func minCellSize(cell *layoutCell, horizontal bool) int {
+	switch {
+	case cell.Type == layoutPane:
+		return 1
+	case (cell.Type == layoutLeftRight) == horizontal:
+		total := len(cell.Children) - 1
+		for _, c := range cell.Children {
+			total += minCellSize(c, horizontal)
+		}
+		return total
+	default:
+		most := 1
+		for _, c := range cell.Children {
+			most = max(most, minCellSize(c, horizontal))
+		}
+		return most
+	}
+}
+
+// resizeCell grows or shrinks a cell along one axis, spreading the change over its
+// children. Callers must not shrink a cell below minCellSize.
+func resizeCell(cell *layoutCell, horizontal bool, delta int) {
+	if horizontal {
+		cell.SX += delta
+	} else {
+		cell.SY += delta
+	}
+
+	switch {
+	case cell.Type == layoutPane:
+	case (cell.Type == layoutLeftRight) != horizontal:
+		for _, c := range cell.Children {
+			resizeCell(c, horizontal, delta)
+		}
+	case delta > 0:
+		resizeCell(cell.Children[len(cell.Children)-1], horizontal, delta)
+	default:
+		for i := len(cell.Children) - 1; i >= 0 && delta < 0; i-- {
+			c := cell.Children[i]
+			take := max(delta, minCellSize(c, horizontal)-cellSize(c, horizontal))
+			resizeCell(c, horizontal, take)
+			delta -= take
+		}
+	}
+}
+
+// resizePaneCell moves the border after a pane (or before it, for the last pane)
+// by delta cells, like tmux's resize-pane -L/-R/-U/-D.
+func resizePaneCell(cell *layoutCell, horizontal bool, delta int) {
+	splitType := layoutTopBottom
+	if horizontal {
+		splitType = layoutLeftRight
+	}
+
+	// Find the nearest ancestor that splits along this axis.
+	for cell.Parent != nil && cell.Parent.Type != splitType {
+		cell = cell.Parent
+	}
+	parent := cell.Parent
+	if parent == nil {
+		return
+	}
+	idx := childIndex(parent, cell)
+	if idx == len(parent.Children)-1 {
+		idx--
+	}
+	before, after := parent.Children[idx], parent.Children[idx+1]
+
+	if delta > 0 {
+		delta = min(delta, cellSize(after, horizontal)-minCellSize(after, horizontal))
+	} else {
+		delta = max(delta, minCellSize(before, horizontal)-cellSize(before, horizontal))
+	}
+	resizeCell(before, horizontal, delta)
+	resizeCell(after, horizontal, -delta)
+	fixOffsets(layoutRoot(parent))
+}
+
+// Main pane sizes for the main-horizontal and main-vertical layouts (tmux defaults).
+const (
+	tmuxMainPaneHeight = 24
+	tmuxMainPaneWidth  = 80
+)
+
+// tmuxLayoutPresets lists the preset layouts in select-layout -n order.
+var tmuxLayoutPresets = []string{"even-horizontal", "even-vertical", "main-horizontal", "main-vertical", "tiled"}
+
+// presetLayout arranges panes into one of tmux's preset layouts.
+func presetLayout(name string, panes []*TmuxPane, sx, sy int) (*layoutCell, bool) {
+	if len(panes) == 1 {
+		return newLayout(panes[0], sx, sy), true
+	}
+
+	switch name {
+	case "even-horizontal":
+		return evenLayout(panes, layoutLeftRight, sx, sy), true
+	case "even-vertical":
+		return evenLayout(panes, layoutTopBottom, sx, sy), true
+	case "main-horizontal":
+		return mainLayout(panes, layoutTopBottom, sx, sy, tmuxMainPaneHeight), true
+	case "main-vertical":
+		return mainLayout(panes, layoutLeftRight, sx, sy, tmuxMainPaneWidth), true
+	case "tiled":
+		return tiledLayout(panes, sx, sy), true
+	}
+	return nil, false
+}
+
+// evenLayout places panes in a single row or column with equal sizes; the last
+// cell takes any remainder, like tmux's layout_spread_cell.
+func evenLayout(panes []*TmuxPane, splitType layoutType, sx, sy int) *layoutCell {
+	if len(panes) == 1 {
+		return newLayout(panes[0], sx, sy)
+	}
+	root := &layoutCell{Type: splitType, SX: sx, SY: sy}
+	total := sy
+	if splitType == layoutLeftRight {
+		total = sx
+	}
+	sizes := spreadSizes(total, len(panes))
+	for i, p := range panes {
+		cell := &layoutCell{Type: layoutPane, Parent: root, SX: sx, SY: sy, Pane: p}
+		if splitType == layoutLeftRight {
+			cell.SX = sizes[i]
+		} else {
+			cell.SY = sizes[i]
+		}
+		p.cell = cell
+		root.Children = append(root.Children, cell)
+	}
+	fixOffsets(root)
+	return root
+}
+
+// spreadSizes divides total cells between n children separated by one-cell borders.
+func spreadSizes(total, n int) []int {
+	each := (total - (n - 1)) / n
+	sizes := make([]int, n)
+	for i := range sizes {
+		sizes[i] = each
+	}
+	sizes[n-1] = total - (each+1)*(n-1)
+	return sizes
+}
+
+// mainLayout puts the first pane in a large main cell and spreads the rest beside it.
+func mainLayout(panes []*TmuxPane, splitType layoutType, sx, sy, mainSize int) *layoutCell {
+	total := sy
+	if splitType == layoutLeftRight {
+		total = sx
+	}
+	mainSize = min(mainSize, total-2)
+	otherSize := total - mainSize - 1
+
+	root := &layoutCell{Type: splitType, SX: sx, SY: sy}
+	main := &layoutCell{Type: layoutPane, Parent: root, SX: sx, SY: sy, Pane: panes[0]}
+	panes[0].cell = main
+
+	var others *layoutCell
+	if splitType == layoutLeftRight {
+		main.SX = mainSize
+		others = evenLayout(panes[1:], layoutTopBottom, otherSize, sy)
+	} else {
+		main.SY = mainSize
+		others = evenLayout(panes[1:], layoutLeftRight, sx, otherSize)
+	}
+	others.Parent = root
+	root.Children = []*layoutCell{main, others}
+	fixOffsets(root)
+	return root
+}
+
+// tiledLayout arranges panes in a grid; the last cell in each row and the last row
+// absorb any remainder, like tmux's layout_set_tiled.
+func tiledLayout(panes []*TmuxPane, sx, sy int) *layoutCell {
+	rows, columns := 1, 1
+	for rows*columns < len(panes) {
+		rows++
+		if rows*columns < len(panes) {
+			columns++
+		}
+	}
+
+	root := &layoutCell{Type: layoutTopBottom, SX: sx, SY: sy}
+	heights := spreadSizes(sy, rows)
+	width := spreadSizes(sx, columns)[0]
+	for row := range rows {
+		rowPanes := panes[row*columns : min((row+1)*columns, len(panes))]
+		cell := evenLayout(rowPanes, layoutLeftRight, sx, heights[row])
+		for i, c := range cell.Children {
+			c.SX = width
+			if i == len(cell.Children)-1 {
+				c.SX = sx - (width+1)*(len(cell.Children)-1)
+			}
+		}
+		cell.Parent = root
+		root.Children = append(root.Children, cell)
+	}
+	fixOffsets(root)
+	return root
+}
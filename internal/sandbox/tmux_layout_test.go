@@ -0,0 +1,121 @@
+// ABOUTME: Tests for the simulated tmux layout tree
+// ABOUTME: Verifies split sizing, layout strings, and directional pane selection
+
+package sandbox
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLayoutString_Splits(t *testing.T) {
+	tests := []struct {
+		name  string
+		split []bool // true = left/right
+		want  string
+	}{
+		{"single", nil, "80x24,0,0,0"},
+		{"left/right", []bool{true}, "80x24,0,0{40x24,0,0,0,39x24,41,0,1}"},
+		{"top/bottom", []bool{false}, "80x24,0,0[80x12,0,0,0,80x11,0,13,1]"},
+		{"nested", []bool{true, false}, "80x24,0,0{40x24,0,0,0,39x24,41,0[39x12,41,0,1,39x11,41,13,2]}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first := &TmuxPane{ID: 0}
+			root := newLayout(first, TmuxWindowWidth, TmuxWindowHeight)
+			last := first
+			for i, horizontal := range tt.split {
+				pane := &TmuxPane{ID: i + 1}
+				if _, err := splitCell(last.cell, horizontal, 0, false, pane); err != nil {
+					t.Fatal(err)
+				}
+				root = layoutRoot(pane.cell)
+				last = pane
+			}
+
+			got := layoutString(root)
+			_, body, _ := strings.Cut(got, ",")
+			if body != tt.want {
+				t.Errorf("layout = %q, want %q", body, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitCell_NoSpace(t *testing.T) {
+	pane := &TmuxPane{}
+	newLayout(pane, 2, 2)
+	if _, err := splitCell(pane.cell, true, 0, false, &TmuxPane{ID: 1}); !errors.Is(err, errNoSpace) {
+		t.Errorf("expected errNoSpace, got %v", err)
+	}
+}
+
+func TestPaneInDirection(t *testing.T) {
+	left := &TmuxPane{ID: 0}
+	root := newLayout(left, TmuxWindowWidth, TmuxWindowHeight)
+	right := &TmuxPane{ID: 1}
+	if _, err := splitCell(left.cell, true, 0, false, right); err != nil {
+		t.Fatal(err)
+	}
+	panes := layoutLeaves(layoutRoot(root))
+
+	if got := paneInDirection(panes, left, "R"); got != right {
+		t.Error("right of the left pane should be the right pane")
+	}
+	if got := paneInDirection(panes, right, "R"); got != left {
+		t.Error("moving right from the rightmost pane should wrap")
+	}
+	if got := paneInDirection(panes, left, "U"); got != nil {
+		t.Error("no pane above a full-height pane")
+	}
+}
+
+func TestPresetLayouts(t *testing.T) {
+	tests := []struct {
+		name  string
+		panes int
+		want  string
+	}{
+		{"even-horizontal", 2, "80x24,0,0{39x24,0,0,0,40x24,40,0,1}"},
+		{"even-vertical", 3, "80x24,0,0[80x7,0,0,0,80x7,0,8,1,80x8,0,16,2]"},
+		{"main-vertical", 3, "80x24,0,0{78x24,0,0,0,1x24,79,0[1x11,79,0,1,1x12,79,12,2]}"},
+		{"tiled", 3, "80x24,0,0[80x11,0,0{39x11,0,0,0,40x11,40,0,1},80x12,0,12,2]"},
+		{"tiled", 4, "80x24,0,0[80x11,0,0{39x11,0,0,0,40x11,40,0,1},80x12,0,12{39x12,0,12,2,40x12,40,12,3}]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			panes := make([]*TmuxPane, tt.panes)
+			for i := range panes {
+				panes[i] = &TmuxPane{ID: i}
+			}
+			root, ok := presetLayout(tt.name, panes, TmuxWindowWidth, TmuxWindowHeight)
+			if !ok {
+				t.Fatal("unknown preset")
+			}
+			_, body, _ := strings.Cut(layoutString(root), ",")
+			if body != tt.want {
+				t.Errorf("layout = %q, want %q", body, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemoveCell_CollapsesContainer(t *testing.T) {
+	first := &TmuxPane{ID: 0}
+	newLayout(first, TmuxWindowWidth, TmuxWindowHeight)
+	second := &TmuxPane{ID: 1}
+	if _, err := splitCell(first.cell, true, 0, false, second); err != nil {
+		t.Fatal(err)
+	}
+
+	root := removeCell(second.cell)
+	if root != first.cell || root.Parent != nil {
+		t.Fatal("removing one of two panes should leave the other as the root")
+	}
+	if root.SX != TmuxWindowWidth {
+		t.Errorf("remaining pane should take the freed space, got width %d", root.SX)
+	}
+}
@@ -0,0 +1,331 @@
+// ABOUTME: Pane and window management commands for the simulated tmux
+// ABOUTME: Implements kill, resize, zoom, rename, swap, break, join and select-layout
+
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// removePane destroys a pane, closing its window (and session) if it was the last one.
+func (t *TmuxState) removePane(s *TmuxSession, w *TmuxWindow, p *TmuxPane) {
+	idx := w.PaneIndex(p)
+	root := removeCell(p.cell)
+	if root == nil {
+		t.removeWindow(s, w)
+		return
+	}
+	w.layout = root
+	w.Panes = layoutLeaves(root)
+	w.Zoomed = false
+
+	if w.LastPane == p {
+		w.LastPane = nil
+	}
+	if w.Active == p {
+		// Like tmux, fall back to the last pane, then the previous one.
+		w.Active = w.LastPane
+		w.LastPane = nil
+		if w.Active == nil {
+			w.Active = w.Panes[max(idx-1, 0)]
+		}
+	}
+}
+
+// removeWindow destroys a window, closing its session if it was the last one.
+func (t *TmuxState) removeWindow(s *TmuxSession, w *TmuxWindow) {
+	pos := s.windowPos(w)
+	s.Windows = append(s.Windows[:pos], s.Windows[pos+1:]...)
+	if len(s.Windows) == 0 {
+		t.removeSession(s)
+		return
+	}
+
+	if s.Last == w {
+		s.Last = nil
+	}
+	if s.Current == w {
+		// Like tmux, fall back to the last window, then the previous one.
+		s.Current = s.Last
+		s.Last = nil
+		if s.Current == nil {
+			s.Current = s.Windows[max(pos-1, 0)]
+		}
+	}
+}
+
+// movePaneOut detaches a pane from its window's layout without destroying it.
+func (t *TmuxState) movePaneOut(s *TmuxSession, w *TmuxWindow, p *TmuxPane) {
+	t.removePane(s, w, p)
+	p.cell = nil
+}
+
+func (r *MissionRunner) tmuxKillPane(f tmuxFlags) MissionResult {
+	t := &r.Tmux
+	s, w, p, err := t.resolvePane(f.target())
+	if err != nil {
+		return tmuxError(err)
+	}
+
+	if f.has('a') {
+		for _, other := range append([]*TmuxPane(nil), w.Panes...) {
+			if other != p {
+				t.removePane(s, w, other)
+			}
+		}
+		return tmuxOK("[killed all panes except %d]", w.PaneIndex(p))
+	}
+
+	idx := w.PaneIndex(p)
+	t.removePane(s, w, p)
+	return tmuxOK("[killed pane %d]", idx)
+}
+
+func (r *MissionRunner) tmuxKillWindow(f tmuxFlags) MissionResult {
+	t := &r.Tmux
+	s, w, err := t.resolveWindow(f.target())
+	if err != nil {
+		return tmuxError(err)
+	}
+
+	if f.has('a') {
+		for _, other := range append([]*TmuxWindow(nil), s.Windows...) {
+			if other != w {
+				t.removeWindow(s, other)
+			}
+		}
+		return tmuxOK("[killed all windows except %d]", w.Index)
+	}
+
+	t.removeWindow(s, w)
+	return tmuxOK("[killed window %d]", w.Index)
+}
+
+func (r *MissionRunner) tmuxRenameWindow(f tmuxFlags) MissionResult {
+	name, ok := f.arg(0)
+	if !ok {
+		return tmuxError(errors.New("usage: rename-window [-t target-window] new-name"))
+	}
+	_, w, err := r.Tmux.resolveWindow(f.target())
+	if err != nil {
+		return tmuxError(err)
+	}
+	w.Name = name
+	return tmuxOK("[renamed window %d to %s]", w.Index, name)
+}
+
+func (r *MissionRunner) tmuxResizePane(f tmuxFlags) MissionResult {
+	_, w, p, err := r.Tmux.resolvePane(f.target())
+	if err != nil {
+		return tmuxError(err)
+	}
+
+	if f.has('Z') {
+		if len(w.Panes) > 1 {
+			w.selectPane(p)
+			w.Zoomed = !w.Zoomed
+		}
+		if w.Zoomed {
+			return tmuxOK("[zoomed pane %d]", w.PaneIndex(p))
+		}
+		return tmuxOK("[unzoomed pane %d]", w.PaneIndex(p))
+	}
+
+	adjust := 1
+	if arg, ok := f.arg(0); ok {
+		if adjust, err = strconv.Atoi(arg); err != nil || adjust < 1 {
+			return tmuxError(fmt.Errorf("adjustment invalid: %s", arg))
+		}
+	}
+
+	w.Zoomed = false
+	switch {
+	case f.has('L'):
+		resizePaneCell(p.cell, true, -adjust)
+	case f.has('R'):
+		resizePaneCell(p.cell, true, adjust)
+	case f.has('U'):
+		resizePaneCell(p.cell, false, -adjust)
+	case f.has('D'):
+		resizePaneCell(p.cell, false, adjust)
+	}
+	return tmuxOK("[resized pane %d to %dx%d]", w.PaneIndex(p), p.Width(), p.Height())
+}
+
+func (r *MissionRunner) tmuxSwapPane(f tmuxFlags) MissionResult {
+	t := &r.Tmux
+	_, dstW, dst, err := t.resolvePane(f.target())
+	if err != nil {
+		return tmuxError(err)
+	}
+
+	// Like tmux, -D/-U swap the target with its neighbour; otherwise -s (or the
+	// current pane) is swapped with the target.
+	var srcW *TmuxWindow
+	var src *TmuxPane
+	switch {
+	case f.has('D'), f.has('U'):
+		spec := "+"
+		if f.has('U') {
+			spec = "-"
+		}
+		srcW, src = dstW, dstW.Panes[offsetIndex(dstW.PaneIndex(dst), spec, len(dstW.Panes))]
+	case f.hasValue('s'):
+		if _, srcW, src, err = t.resolvePane(f.value('s')); err != nil {
+			return tmuxError(err)
+		}
+	default:
+		srcW, src = t.CurrentWindow(), t.CurrentPane()
+	}
+	if src == dst {
+		return MissionResult{Success: true}
+	}
+
+	src.cell, dst.cell = dst.cell, src.cell
+	src.cell.Pane, dst.cell.Pane = src, dst
+	srcW.Panes = layoutLeaves(srcW.layout)
+	dstW.Panes = layoutLeaves(dstW.layout)
+	srcW.Zoomed, dstW.Zoomed = false, false
+
+	switch {
+	case srcW != dstW:
+		replaceActive(srcW, src, dst)
+		replaceActive(dstW, dst, src)
+		if !f.has('d') {
+			srcW.selectPane(dst)
+			dstW.selectPane(src)
+		}
+	case !f.has('d'):
+		srcW.selectPane(dst)
+	case srcW.Active == src:
+		srcW.Active = dst
+	case srcW.Active == dst:
+		srcW.Active = src
+	}
+	return tmuxOK("[swapped panes %d and %d]", srcW.PaneIndex(dst), dstW.PaneIndex(src))
+}
+
+// replaceActive updates a window's pane pointers after old was moved out for replacement.
+func replaceActive(w *TmuxWindow, old, replacement *TmuxPane) {
+	if w.Active == old {
+		w.Active = replacement
+	}
+	if w.LastPane == old {
+		w.LastPane = nil
+	}
+}
+
+func (r *MissionRunner) tmuxBreakPane(f tmuxFlags) MissionResult {
+	t := &r.Tmux
+	srcS, srcW, p, err := t.resolvePane(f.value('s'))
+	if err != nil {
+		return tmuxError(err)
+	}
+	if len(srcW.Panes) == 1 {
+		return tmuxError(errors.New("can't break with only one pane"))
+	}
+	dstS, index, err := t.resolveNewWindow(f.target())
+	if err != nil {
+		return tmuxError(err)
+	}
+
+	t.movePaneOut(srcS, srcW, p)
+	name := f.value('n')
+	if name == "" {
+		name = "bash"
+	}
+	w := &TmuxWindow{ID: t.nextWindowID, Index: index, Name: name, Panes: []*TmuxPane{p}, Active: p}
+	t.nextWindowID++
+	w.layout = newLayout(p, TmuxWindowWidth, TmuxWindowHeight)
+	dstS.addWindow(w)
+	if !f.has('d') {
+		dstS.selectWindow(w)
+	}
+	return tmuxOK("[broke pane into window %d]", w.Index)
+}
+
+func (r *MissionRunner) tmuxJoinPane(f tmuxFlags) MissionResult {
+	t := &r.Tmux
+	srcS, srcW, src, err := t.resolvePane(f.value('s'))
+	if err != nil {
+		return tmuxError(err)
+	}
+	_, dstW, dst, err := t.resolvePane(f.target())
+	if err != nil {
+		return tmuxError(err)
+	}
+	if src == dst {
+		return tmuxError(errors.New("source and target panes must be different"))
+	}
+
+	size := 0
+	if f.hasValue('l') {
+		if size, err = strconv.Atoi(f.value('l')); err != nil || size < 1 {
+			return tmuxError(fmt.Errorf("size is invalid: %s", f.value('l')))
+		}
+	}
+	// Split the target first, so a failed split leaves the source pane where it
+	// was, then take the source's old cell out of its window.
+	oldCell := src.cell
+	newCell, err := splitCell(dst.cell, f.has('h'), size, f.has('b'), src)
+	if err != nil {
+		return tmuxError(err)
+	}
+	src.cell = oldCell
+	t.removePane(srcS, srcW, src)
+	src.cell = newCell
+	dstW.layout = layoutRoot(src.cell)
+	dstW.Panes = layoutLeaves(dstW.layout)
+	dstW.Zoomed = false
+	if !f.has('d') {
+		dstW.selectPane(src)
+	}
+	return tmuxOK("[joined pane, now %d panes]", len(dstW.Panes))
+}
+
+func (r *MissionRunner) tmuxSelectLayout(f tmuxFlags) MissionResult {
+	_, w, _, err := r.Tmux.resolvePane(f.target())
+	if err != nil {
+		return tmuxError(err)
+	}
+
+	name, _ := f.arg(0)
+	switch {
+	case f.has('n'), f.has('p'):
+		pos := -1
+		for i, preset := range tmuxLayoutPresets {
+			if preset == w.Preset {
+				pos = i
+			}
+		}
+		if f.has('n') {
+			name = tmuxLayoutPresets[(pos+1)%len(tmuxLayoutPresets)]
+		} else {
+			name = tmuxLayoutPresets[offsetIndex(max(pos, 0), "-", len(tmuxLayoutPresets))]
+		}
+	case name == "":
+		if w.Preset == "" {
+			return MissionResult{Success: true}
+		}
+		name = w.Preset
+	}
+
+	root, ok := presetLayout(name, w.Panes, TmuxWindowWidth, TmuxWindowHeight)
+	if !ok {
+		return tmuxError(fmt.Errorf("invalid layout: %s", name))
+	}
+	w.layout = root
+	w.Preset = name
+	w.Zoomed = false
+	return tmuxOK("[layout %s]", name)
+}
+
+func (r *MissionRunner) tmuxNextLayout(f tmuxFlags) MissionResult {
+	return r.tmuxSelectLayout(tmuxFlags{set: map[byte]bool{'n': true}, values: f.values})
+}
+
+func (r *MissionRunner) tmuxPreviousLayout(f tmuxFlags) MissionResult {
+	return r.tmuxSelectLayout(tmuxFlags{set: map[byte]bool{'p': true}, values: f.values})
+}
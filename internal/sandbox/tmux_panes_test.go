@@ -0,0 +1,178 @@
+// ABOUTME: Tests for tmux pane and window management commands
+// ABOUTME: Covers kill, resize, zoom, rename, swap, break, join and select-layout
+
+package sandbox
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTmux_KillPane(t *testing.T) {
+	runner := NewMissionRunner(&Mission{})
+	runAll(t, runner, "tmux new -s work", "tmux split-window -h", "tmux kill-pane")
+
+	w := runner.Tmux.CurrentWindow()
+	if len(w.Panes) != 1 {
+		t.Fatalf("expected 1 pane, got %d", len(w.Panes))
+	}
+	if w.Panes[0].Width() != TmuxWindowWidth {
+		t.Errorf("remaining pane should fill the window, got width %d", w.Panes[0].Width())
+	}
+
+	runAll(t, runner, "tmux kill-pane")
+	if runner.Tmux.Running() {
+		t.Error("killing the last pane should end the session and the server")
+	}
+}
+
+func TestTmux_KillWindow(t *testing.T) {
+	runner := NewMissionRunner(&Mission{})
+	runAll(t, runner, "tmux new -s work", "tmux new-window", "tmux new-window", "tmux kill-window -t 1")
+
+	s := runner.Tmux.Session("work")
+	if len(s.Windows) != 2 || s.Window(1) != nil {
+		t.Fatalf("expected windows 0 and 2, got %d windows", len(s.Windows))
+	}
+	runAll(t, runner, "tmux kill-window")
+	if s.Current.Index != 0 {
+		t.Errorf("expected to fall back to window 0, got %d", s.Current.Index)
+	}
+}
+
+func TestTmux_RenameWindow(t *testing.T) {
+	runner := NewMissionRunner(&Mission{})
+	runAll(t, runner, "tmux new -s work", "tmux rename-window editor", "tmux select-window -t editor")
+
+	if got := runner.TmuxStatusLine(); got != "[work] 0:editor*" {
+		t.Errorf("status line = %q", got)
+	}
+	if result := runner.Execute("tmux rename-window"); !strings.HasPrefix(result.Error, "usage: rename-window") {
+		t.Errorf("expected usage error, got %q", result.Error)
+	}
+}
+
+func TestTmux_ResizePane(t *testing.T) {
+	runner := NewMissionRunner(&Mission{})
+	runAll(t, runner, "tmux new -s work", "tmux split-window -h", "tmux resize-pane -L 10")
+
+	w := runner.Tmux.CurrentWindow()
+	if left, right := w.Panes[0].Width(), w.Panes[1].Width(); left != 30 || right != 49 {
+		t.Errorf("expected 30/49 after resize -L 10, got %d/%d", left, right)
+	}
+
+	runAll(t, runner, "tmux resize-pane -R 100")
+	if left := w.Panes[0].Width(); left != 78 {
+		t.Errorf("resize should stop at the minimum pane size, got left width %d", left)
+	}
+
+	if result := runner.Execute("tmux resize-pane -L abc"); result.Error == "" {
+		t.Error("expected an error for an invalid adjustment")
+	}
+}
+
+func TestTmux_Zoom(t *testing.T) {
+	runner := NewMissionRunner(&Mission{})
+	runAll(t, runner, "tmux new -s work", "tmux split-window", "tmux resize-pane -Z")
+
+	w := runner.Tmux.CurrentWindow()
+	if !w.Zoomed {
+		t.Fatal("resize-pane -Z should zoom the window")
+	}
+	if sx, sy := w.PaneSize(w.Active); sx != TmuxWindowWidth || sy != TmuxWindowHeight {
+		t.Errorf("zoomed pane should fill the window, got %dx%d", sx, sy)
+	}
+	if got := runner.TmuxStatusLine(); got != "[work] 0:bash*Z" {
+		t.Errorf("status line should flag zoom, got %q", got)
+	}
+
+	runAll(t, runner, "tmux select-pane -t 0")
+	if w.Zoomed {
+		t.Error("moving to another pane should unzoom")
+	}
+}
+
+func TestTmux_SwapPane(t *testing.T) {
+	runner := NewMissionRunner(&Mission{})
+	runAll(t, runner, "tmux new -s work", "tmux split-window -h", "tmux select-pane -t 0")
+
+	w := runner.Tmux.CurrentWindow()
+	first := w.Panes[0]
+	runAll(t, runner, "tmux swap-pane -D")
+
+	if w.Panes[1] != first {
+		t.Error("swap-pane -D should move the pane to the next position")
+	}
+	if w.Active != first {
+		t.Error("the swapped pane should stay active")
+	}
+}
+
+func TestTmux_BreakAndJoinPane(t *testing.T) {
+	runner := NewMissionRunner(&Mission{})
+	runAll(t, runner, "tmux new -s work", "tmux split-window -h")
+
+	s := runner.Tmux.Session("work")
+	moved := runner.Tmux.CurrentPane()
+	runAll(t, runner, "tmux break-pane -n logs")
+	if len(s.Windows) != 2 || s.Current.Name != "logs" || s.Current.Active != moved {
+		t.Fatal("break-pane should move the pane into a new current window")
+	}
+	if len(s.Window(0).Panes) != 1 {
+		t.Errorf("window 0 should have 1 pane left, got %d", len(s.Window(0).Panes))
+	}
+
+	runAll(t, runner, "tmux select-window -t 0", "tmux join-pane -h -s :1")
+	if len(s.Windows) != 1 {
+		t.Errorf("joining the only pane of window 1 should close it, got %d windows", len(s.Windows))
+	}
+	if len(s.Current.Panes) != 2 || s.Current.Active != moved {
+		t.Error("join-pane should bring the pane back into window 0")
+	}
+
+	if result := runner.Execute("tmux join-pane -s 1"); result.Error != "source and target panes must be different" {
+		t.Errorf("unexpected error %q", result.Error)
+	}
+	runAll(t, runner, "tmux kill-pane")
+	if result := runner.Execute("tmux break-pane"); result.Error != "can't break with only one pane" {
+		t.Errorf("unexpected error %q", result.Error)
+	}
+}
+
+func TestTmux_JoinPaneWithoutRoom(t *testing.T) {
+	runner := NewMissionRunner(&Mission{})
+	runAll(t, runner, "tmux new -s work", "tmux new-window", "tmux select-window -t 0", "tmux split-window -l 1")
+
+	s := runner.Tmux.Session("work")
+	moving := s.Window(1).Active
+	if result := runner.Execute("tmux join-pane -s :1"); result.Error != errNoSpace.Error() {
+		t.Fatalf("joining into a one-line pane should fail for lack of space, got %q", result.Error)
+	}
+	if len(s.Windows) != 2 || s.Window(1).Active != moving || len(s.Window(1).Panes) != 1 {
+		t.Error("a failed join-pane should leave the source pane in its window")
+	}
+	if len(s.Window(0).Panes) != 2 {
+		t.Errorf("a failed join-pane should leave the target window alone, got %d panes", len(s.Window(0).Panes))
+	}
+}
+
+func TestTmux_SelectLayout(t *testing.T) {
+	runner := NewMissionRunner(&Mission{})
+	runAll(t, runner, "tmux new -s work", "tmux split-window", "tmux split-window", "tmux select-layout even-horizontal")
+
+	w := runner.Tmux.CurrentWindow()
+	for i, want := range []int{26, 26, 26} {
+		if got := w.Panes[i].Width(); got != want {
+			t.Errorf("pane %d width = %d, want %d", i, got, want)
+		}
+	}
+
+	runAll(t, runner, "tmux next-layout")
+	if w.Preset != "even-vertical" {
+		t.Errorf("next-layout should move to even-vertical, got %q", w.Preset)
+	}
+
+	if result := runner.Execute("tmux select-layout bogus"); result.Error != "invalid layout: bogus" {
+		t.Errorf("unexpected error %q", result.Error)
+	}
+}
@@ -0,0 +1,222 @@
+// ABOUTME: Tests for the simulated tmux server
+// ABOUTME: Covers multiple sessions, target syntax, and list output formats
+
+package sandbox
+
+import (
+	"strings"
+	"testing"
+)
+
+// runAll executes commands in order and fails the test on the first error.
+func runAll(t *testing.T, runner *MissionRunner, commands ...string) {
+	t.Helper()
+	for _, cmd := range commands {
+		if result := runner.Execute(cmd); result.Error != "" {
+			t.Fatalf("%s: %s", cmd, result.Error)
+		}
+	}
+}
+
+func TestTmux_MultipleSessions(t *testing.T) {
+	runner := NewMissionRunner(&Mission{})
+	runAll(t, runner, "tmux new -d -s work", "tmux new -d -s play", "tmux attach -t work")
+
+	result := runner.Execute("tmux ls")
+	lines := strings.Split(result.Output, "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 sessions, got %q", result.Output)
+	}
+	if !strings.HasPrefix(lines[0], "play: 1 windows (created ") || strings.HasSuffix(lines[0], "(attached)") {
+		t.Errorf("unexpected play line: %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "work: 1 windows") || !strings.HasSuffix(lines[1], "(attached)") {
+		t.Errorf("unexpected work line: %q", lines[1])
+	}
+}
+
+func TestTmux_SessionErrors(t *testing.T) {
+	runner := NewMissionRunner(&Mission{})
+
+	tests := []struct {
+		cmd  string
+		want string
+	}{
+		{"tmux ls", "no server running on /tmp/tmux-1000/default"},
+		{"tmux attach", "no sessions"},
+		{"tmux frobnicate", "unknown command: frobnicate"},
+		{"tmux new -s work", ""},
+		{"tmux new -s other", "sessions should be nested with care, unset $TMUX to force"},
+		{"tmux new -d -s work", "duplicate session: work"},
+		{"tmux has-session -t nope", "can't find session: nope"},
+		{"tmux split-window -Q", "usage: split-window [-bdhv] [-c start-directory] [-l size] [-t target-pane]"},
+		{"tmux select-window -t 7", "can't find window: 7"},
+	}
+	for _, tt := range tests {
+		if got := runner.Execute(tt.cmd).Error; got != tt.want {
+			t.Errorf("%s: error = %q, want %q", tt.cmd, got, tt.want)
+		}
+	}
+}
+
+func TestTmux_AmbiguousCommand(t *testing.T) {
+	runner := NewMissionRunner(&Mission{})
+	result := runner.Execute("tmux list")
+	if !strings.HasPrefix(result.Error, "ambiguous command: list, could be: ") {
+		t.Errorf("expected ambiguous command error, got %q", result.Error)
+	}
+	if runner.Execute("tmux lsw").Error != errNoServer.Error() {
+		t.Error("alias lsw should resolve to list-windows")
+	}
+}
+
+func TestTmux_KillSessionTarget(t *testing.T) {
+	runner := NewMissionRunner(&Mission{})
+	runAll(t, runner, "tmux new -d -s work", "tmux new -d -s play", "tmux kill-session -t pl")
+
+	if runner.Tmux.Session("play") != nil {
+		t.Error("kill-session -t pl should match play by prefix")
+	}
+	if runner.Tmux.Session("work") == nil {
+		t.Error("work should still be running")
+	}
+
+	runAll(t, runner, "tmux kill-session -t work")
+	if runner.Tmux.Running() {
+		t.Error("server should exit when the last session is killed")
+	}
+}
+
+func TestTmux_SwitchAndRename(t *testing.T) {
+	runner := NewMissionRunner(&Mission{})
+	runAll(t, runner, "tmux new -d -s a", "tmux new -s b", "tmux switch-client -t a")
+
+	if runner.Tmux.Client.Name != "a" {
+		t.Fatalf("expected attached to a, got %s", runner.Tmux.Client.Name)
+	}
+	runAll(t, runner, "tmux switch-client -l")
+	if runner.Tmux.Client.Name != "b" {
+		t.Errorf("switch-client -l should return to b, got %s", runner.Tmux.Client.Name)
+	}
+
+	runAll(t, runner, "tmux rename-session -t b build", "tmux has-session -t build")
+	if runner.Tmux.Session("b") != nil {
+		t.Error("old name should be gone after rename")
+	}
+}
+
+func TestTmux_TargetSyntax(t *testing.T) {
+	runner := NewMissionRunner(&Mission{})
+	runAll(t, runner,
+		"tmux new -d -s work",
+		"tmux new-window -t work:3 -n logs",
+		"tmux split-window -h -t work:logs",
+		"tmux select-pane -t work:3.0",
+	)
+
+	s := runner.Tmux.Session("work")
+	w := s.Window(3)
+	if w == nil || w.Name != "logs" {
+		t.Fatalf("expected window 3 named logs, got %+v", s.Windows)
+	}
+	if len(w.Panes) != 2 {
+		t.Errorf("expected 2 panes in work:3, got %d", len(w.Panes))
+	}
+	if w.PaneIndex(w.Active) != 0 {
+		t.Errorf("select-pane -t work:3.0 should activate pane 0, got %d", w.PaneIndex(w.Active))
+	}
+	if len(s.Window(0).Panes) != 1 {
+		t.Error("targeted split should not touch window 0")
+	}
+
+	if result := runner.Execute("tmux new-window -t work:3"); result.Error != "index 3 in use" {
+		t.Errorf("expected index in use, got %q", result.Error)
+	}
+}
+
+func TestTmux_ListWindowsAndPanes(t *testing.T) {
+	runner := NewMissionRunner(&Mission{})
+	runAll(t, runner, "tmux new -s work", "tmux split-window -h", "tmux new-window")
+
+	windows := runner.Execute("tmux list-windows").Output
+	want := []string{
+		"0: bash- (2 panes) [80x24] [layout ",
+		"1: bash* (1 panes) [80x24] [layout ",
+	}
+	lines := strings.Split(windows, "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 windows, got %q", windows)
+	}
+	for i, prefix := range want {
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Errorf("line %d = %q, want prefix %q", i, lines[i], prefix)
+		}
+	}
+	if !strings.HasSuffix(lines[1], " (active)") {
+		t.Errorf("current window should be marked active: %q", lines[1])
+	}
+
+	panes := runner.Execute("tmux list-panes -t :0").Output
+	wantPanes := "0: [40x24] [history 0/2000, 0 bytes] %0\n1: [39x24] [history 0/2000, 0 bytes] %1 (active)"
+	if panes != wantPanes {
+		t.Errorf("list-panes =\n%s\nwant\n%s", panes, wantPanes)
+	}
+
+	all := runner.Execute("tmux list-panes -a").Output
+	if !strings.HasPrefix(all, "work:0.0: [40x24]") {
+		t.Errorf("list-panes -a should prefix session:window., got %q", all)
+	}
+}
+
+func TestTmux_StatusLine(t *testing.T) {
+	runner := NewMissionRunner(&Mission{})
+	runAll(t, runner, "tmux new -s work", "tmux neww", "tmux neww", "tmux select-window -t 0")
+
+	if got, want := runner.TmuxStatusLine(), "[work] 0:bash* 1:bash 2:bash-"; got != want {
+		t.Errorf("status line = %q, want %q", got, want)
+	}
+}
+
+func TestTmuxMissionGoals(t *testing.T) {
+	solutions := map[string][]string{
+		"4.1-start-tmux":       {"tmux"},
+		"4.2-named-session":    {"tmux new -s work"},
+		"4.3-detach":           {"tmux detach"},
+		"4.4-list-sessions":    {"tmux ls"},
+		"4.5-attach":           {"tmux attach"},
+		"4.6-split-horizontal": {"tmux split-window -h"},
+		"4.7-split-vertical":   {"tmux split-window -v"},
+		"4.8-select-pane":      {"tmux select-pane -R"},
+		"4.9-new-window":       {"tmux new-window"},
+		"4.10-select-window":   {"tmux select-window -n"},
+		"4.11-kill-session":    {"tmux kill-session"},
+		"5.3-tmux-dev-setup":   {"tmux new -s dev", "tmux split-window"},
+		"5.6-multi-window":     {"tmux new -s project", "tmux new-window", "tmux new-window"},
+	}
+
+	found := 0
+	for _, missions := range GetAllMissions() {
+		for _, m := range missions {
+			steps, ok := solutions[m.ID]
+			if !ok {
+				continue
+			}
+			found++
+			t.Run(m.ID, func(t *testing.T) {
+				runner := NewMissionRunner(m)
+				for i, cmd := range steps {
+					result := runner.Execute(cmd)
+					if result.Error != "" {
+						t.Fatalf("%s: %s", cmd, result.Error)
+					}
+					if result.Completed != (i == len(steps)-1) {
+						t.Fatalf("after %q: completed = %v", cmd, result.Completed)
+					}
+				}
+			})
+		}
+	}
+	if found != len(solutions) {
+		t.Errorf("expected %d tmux missions, found %d", len(solutions), found)
+	}
+}
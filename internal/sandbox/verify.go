@@ -0,0 +1,135 @@
+// ABOUTME: Checks that missions can be solved by playing their reference commands, and that
+// ABOUTME: challenge answers run in the sandbox; catches goals that hold too early and stale answers
+
+package sandbox
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/2389-research/turtle/internal/content"
+)
+
+// verifyVariants is how many fresh instances of a templated mission Verify plays,
+// besides the one it is given.
+const verifyVariants = 4
+
+// Play enters one step of a mission's reference solution as a learner would: a
+// shell command, or tmux keys such as "C-b %" (the prefix, then a key). A step sent
+// to a prompt, or to copy mode, may carry text after its key: "C-r timeout" searches
+// for "timeout".
+func (r *MissionRunner) Play(step string) MissionResult {
+	if r.PromptPending {
+		return r.SubmitPrompt(step)
+	}
+	key, rest, _ := strings.Cut(step, " ")
+	switch {
+	case r.CopyMode() != nil:
+		return r.answer(r.CopyModeKey(key), rest)
+	case r.InTmuxSession() && r.IsTmuxPrefix(key) && rest != "":
+		key, rest, _ = strings.Cut(rest, " ")
+		return r.answer(r.ExecuteKey(key), rest)
+	case r.InTmuxSession() && rest == "":
+		if result, ok := r.RootKey(key); ok {
+			return result
+		}
+	}
+	return r.Execute(step)
+}
+
+// answer types text into the prompt a key opened, if it opened one.
+func (r *MissionRunner) answer(result MissionResult, text string) MissionResult {
+	if result.Prompt == "" || text == "" {
+		return result
+	}
+	return r.SubmitPrompt(text)
+}
+
+// Verify checks that a mission can be solved. Its goal (or first stage's) must not
+// hold right after setup, and playing its Commands in order must complete it. Commands
+// may list alternatives, so play stops once the mission is complete. A templated
+// mission is checked in several variants.
+func Verify(m *Mission) error {
+	if err := verifyInstance(m); err != nil {
+		return err
+	}
+	if m.Variant == nil {
+		return nil
+	}
+	for range verifyVariants {
+		if err := verifyInstance(m.Variant()); err != nil {
+			return fmt.Errorf("variant: %w", err)
+		}
+	}
+	return nil
+}
+
+func verifyInstance(m *Mission) error {
+	r := NewMissionRunner(m)
+	if r.SetupErr != nil {
+		return r.SetupErr
+	}
+	if len(m.Commands) == 0 {
+		return fmt.Errorf("no reference commands to play")
+	}
+	goal := m.Goal
+	if stage := r.CurrentStage(); stage != nil {
+		goal = stage.Goal
+	}
+	if goal != nil && goal(r.goalContext()) {
+		return fmt.Errorf("goal already holds after setup, before the learner does anything")
+	}
+
+	var transcript []string
+	for _, step := range m.Commands {
+		result := r.Play(step)
+		line := "  " + step
+		if result.Error != "" {
+			line += " (error: " + result.Error + ")"
+		}
+		transcript = append(transcript, line)
+		if r.Completed {
+			return nil
+		}
+	}
+	where := ""
+	if len(m.Stages) > 0 {
+		where = fmt.Sprintf(" (stopped at stage %d of %d)", r.Stage+1, len(m.Stages))
+	}
+	return fmt.Errorf("reference commands don't complete the mission%s:\n%s", where, strings.Join(transcript, "\n"))
+}
+
+// VerifyChallenge checks a challenge's answer against the sandbox, set up with the
+// challenge's context. The expected command of a command, translate or fix_error
+// challenge, and each accepted alternative, must run without error; the correct
+// option of a predict_output challenge must be what its command prints. Other types
+// have nothing to run.
+func VerifyChallenge(c content.YAMLChallenge) error {
+	r := NewMissionRunner(&Mission{SetupActions: c.Context})
+	if r.SetupErr != nil {
+		return fmt.Errorf("context: %w", r.SetupErr)
+	}
+	switch c.Type {
+	case "command", "translate", "fix_error":
+		if result := r.Play(c.Expected); result.Error != "" {
+			return fmt.Errorf("expected answer %q fails: %s", c.Expected, result.Error)
+		}
+		for _, answer := range c.Accepted {
+			if result := NewMissionRunner(r.Mission).Play(answer); result.Error != "" {
+				return fmt.Errorf("accepted answer %q fails: %s", answer, result.Error)
+			}
+		}
+	case "predict_output":
+		result := r.Play(c.Command)
+		if result.Error != "" {
+			return fmt.Errorf("command %q fails: %s", c.Command, result.Error)
+		}
+		if c.Correct < 0 || c.Correct >= len(c.Options) {
+			return fmt.Errorf("correct is %d, but there are %d options", c.Correct, len(c.Options))
+		}
+		if got, want := strings.TrimSpace(result.Output), strings.TrimSpace(c.Options[c.Correct]); got != want {
+			return fmt.Errorf("%s prints %q, but the correct option is %q", c.Command, got, want)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+// ABOUTME: Tests that every mission can be solved with its reference commands, and every
+// ABOUTME: challenge answer runs; also checks Verify catches missions already solved or unsolvable
+
+package sandbox
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/2389-research/turtle/internal/content"
+)
+
+func TestYAMLMissions_Solvable(t *testing.T) {
+	for _, missions := range GetAllMissions() {
+		for _, m := range missions {
+			if err := Verify(m); err != nil {
+				t.Errorf("mission %s: %v", m.ID, err)
+			}
+		}
+	}
+}
+
+func TestVerify_Errors(t *testing.T) {
+	always := func(content.GoalEvaluator) bool { return true }
+	inTmp := func(g content.GoalEvaluator) bool { return g.Pwd() == "/tmp" }
+	tests := []struct {
+		name    string
+		mission *Mission
+		want    string
+	}{
+		{"already solved", &Mission{Goal: always, Commands: []string{"pwd"}}, "goal already holds after setup"},
+		{"no commands", &Mission{Goal: inTmp}, "no reference commands"},
+		{"wrong solution", &Mission{Goal: inTmp, Commands: []string{"cd /nowhere", "ls"}}, "cd /nowhere (error: "},
+		{"bad setup", &Mission{Goal: inTmp, SetupActions: []content.SetupAction{{Rm: "~/missing"}}}, "setup 1 (rm)"},
+		{"stuck stage", &Mission{Commands: []string{"cd /tmp"}, Stages: []*Stage{{Goal: inTmp}, {Goal: inTmp}}}, "stopped at stage 2 of 2"},
+	}
+	for _, tt := range tests {
+		if err := Verify(tt.mission); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestYAMLChallenges_Verify(t *testing.T) {
+	raw, err := content.GetRawChallenges()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for skillID, challenges := range raw {
+		for i, c := range challenges {
+			if err := VerifyChallenge(c); err != nil {
+				t.Errorf("%s challenge %d: %v", skillID, i+1, err)
+			}
+		}
+	}
+}
+
+func TestVerifyChallenge_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		challenge content.YAMLChallenge
+		want      string
+	}{
+		{"missing file", content.YAMLChallenge{Type: "command", Expected: "cat notes.txt"}, `expected answer "cat notes.txt" fails: no such file`},
+		{"unknown command", content.YAMLChallenge{Type: "fix_error", Expected: "sl"}, "sl: command not found"},
+		{"bad alternative", content.YAMLChallenge{Type: "command", Expected: "cd", Accepted: []string{"cd ~", "cd ~/nowhere"}}, `accepted answer "cd ~/nowhere" fails`},
+		{"bad context", content.YAMLChallenge{Type: "translate", Expected: "ls", Context: []content.SetupAction{{Rm: "~/missing"}}}, "context: setup 1 (rm)"},
+		{"wrong prediction", content.YAMLChallenge{Type: "predict_output", Command: "pwd", Options: []string{"/home/user"}}, `pwd prints "/home/learner", but the correct option is "/home/user"`},
+		{"no correct option", content.YAMLChallenge{Type: "predict_output", Command: "pwd", Correct: 1, Options: []string{"/home/learner"}}, "correct is 1, but there are 1 options"},
+	}
+	for _, tt := range tests {
+		if err := VerifyChallenge(tt.challenge); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got %v, want %q", tt.name, err, tt.want)
+		}
+	}
+
+	withContext := content.YAMLChallenge{Type: "command", Expected: "cat notes.txt", Context: []content.SetupAction{{Touch: "notes.txt"}}}
+	if err := VerifyChallenge(withContext); err != nil {
+		t.Errorf("context should set up the answer's files: %v", err)
+	}
+}
+
+func TestPlay_Keys(t *testing.T) {
+	r := NewMissionRunner(&Mission{})
+	for _, step := range []string{"tmux", "C-b %", "C-b :", "rename-window code", "C-b [", "C-r bash"} {
+		if res := r.Play(step); res.Error != "" {
+			t.Fatalf("%s: %s", step, res.Error)
+		}
+	}
+	snap := r.Tmux.Snapshot()
+	if w := snap.Sessions[0].Windows[0]; w.Panes != 2 || w.Name != "code" {
+		t.Errorf("keys should split the window and rename it from the prompt, got %+v", w)
+	}
+	if r.CopyMode() == nil {
+		t.Error("C-b [ should enter copy mode")
+	}
+}
@@ -0,0 +1,143 @@
+// ABOUTME: Plans a day's flashcard session from the cards that are due and new material
+// ABOUTME: Orders reviews by forgetting risk, interleaves new cards, applies daily limits, buries siblings
+
+package skills
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"time"
+)
+
+// SessionLimits caps how many cards a day's flashcard sessions bring.
+type SessionLimits struct {
+	NewPerDay     int // cards never practiced before
+	ReviewsPerDay int // cards coming back for review
+}
+
+// DefaultSessionLimits are the limits for a learner who hasn't set their own.
+var DefaultSessionLimits = SessionLimits{NewPerDay: 10, ReviewsPerDay: 100}
+
+// Candidate is a card a session may include. Cards with the same Siblings key
+// teach the same thing, such as two challenges answered by the same command, so
+// at most one of them is shown a day: the others are buried until tomorrow.
+type Candidate struct {
+	CardID   string
+	SkillID  string
+	Siblings string
+}
+
+// PlanSession picks and orders the cards for a flashcard session at the time on
+// the progress's clock. Due reviews come first by priority: the more likely a
+// card is forgotten and the further past due it is, the sooner it comes. New
+// cards from unlocked skills, earliest in the curriculum first, are spread evenly
+// between them. Candidates practiced earlier today count toward the daily limits
+// and bury their siblings; other cards, such as missions, don't.
+// The same progress, candidates and day give the same session.
+func PlanSession(graph *SkillGraph, progress *UserProgress, candidates []Candidate) []Candidate {
+	now := progress.now()
+	siblings := make(map[string]string, len(candidates))
+	for _, c := range candidates {
+		siblings[c.CardID] = c.Siblings
+	}
+
+	// Today's earlier sessions of these cards use up the limits and bury siblings
+	newLeft, reviewsLeft := progress.Limits.NewPerDay, progress.Limits.ReviewsPerDay
+	buried := make(map[string]bool)
+	for _, r := range progress.Reviews {
+		key, ok := siblings[r.CardID]
+		if !ok || !sameDay(r.Time, now) {
+			continue
+		}
+		if r.ElapsedDays == 0 {
+			newLeft--
+		} else {
+			reviewsLeft--
+		}
+		buried[key] = true
+	}
+
+	type review struct {
+		Candidate
+		priority float64
+	}
+	var reviews []review
+	var fresh []Candidate
+	for _, c := range candidates {
+		card := progress.GetCard(c.CardID)
+		if card == nil || card.LastReviewed.IsZero() {
+			if skill, ok := graph.Skills[c.SkillID]; !ok || graph.isUnlocked(skill, progress) {
+				fresh = append(fresh, c)
+			}
+			continue
+		}
+		due := card.NextReviewDate()
+		if due.After(now) {
+			continue
+		}
+		overdue := now.Sub(due).Hours() / 24 / math.Max(float64(card.Interval), 1)
+		risk := 1 - progress.Scheduler.Strength(card, now)
+		reviews = append(reviews, review{c, risk * (1 + overdue)})
+	}
+	slices.SortStableFunc(reviews, func(a, b review) int {
+		return cmp.Or(cmp.Compare(b.priority, a.priority), cmp.Compare(a.CardID, b.CardID))
+	})
+
+	depth := make(map[string]int)
+	slices.SortStableFunc(fresh, func(a, b Candidate) int {
+		return cmp.Or(cmp.Compare(graph.depth(a.SkillID, depth), graph.depth(b.SkillID, depth)), cmp.Compare(a.SkillID, b.SkillID))
+	})
+
+	var due, learn []Candidate
+	for _, r := range reviews {
+		if len(due) < reviewsLeft && !buried[r.Siblings] {
+			due = append(due, r.Candidate)
+			buried[r.Siblings] = true
+		}
+	}
+	for _, c := range fresh {
+		if len(learn) < newLeft && !buried[c.Siblings] {
+			learn = append(learn, c)
+			buried[c.Siblings] = true
+		}
+	}
+	return interleave(due, learn)
+}
+
+// interleave spreads new cards evenly through the reviews, a review first.
+func interleave(reviews, fresh []Candidate) []Candidate {
+	session := make([]Candidate, 0, len(reviews)+len(fresh))
+	next := 0
+	for i, c := range fresh {
+		until := ((i+1)*len(reviews) + len(fresh)) / (len(fresh) + 1)
+		session = append(session, reviews[next:until]...)
+		session = append(session, c)
+		next = until
+	}
+	return append(session, reviews[next:]...)
+}
+
+// depth is how far into the curriculum a skill sits: 0 without prerequisites,
+// otherwise one more than its deepest prerequisite. Results are memoised in seen.
+func (g *SkillGraph) depth(skillID string, seen map[string]int) int {
+	if d, ok := seen[skillID]; ok {
+		return d
+	}
+	seen[skillID] = 0 // guards against cycles
+	d := 0
+	if skill, ok := g.Skills[skillID]; ok {
+		for _, prereq := range skill.Prerequisites {
+			d = max(d, g.depth(prereq, seen)+1)
+		}
+	}
+	seen[skillID] = d
+	return d
+}
+
+// sameDay reports whether two times fall on the same calendar day where now is.
+func sameDay(t, now time.Time) bool {
+	y1, m1, d1 := t.In(now.Location()).Date()
+	y2, m2, d2 := now.Date()
+	return y1 == y2 && m1 == m2 && d1 == d2
+}
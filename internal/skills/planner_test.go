@@ -0,0 +1,126 @@
+// ABOUTME: Tests for planning a day's flashcard session
+// ABOUTME: Covers review order, interleaving new cards, daily limits, buried siblings and locked skills
+
+package skills
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/2389-research/turtle/internal/srs"
+)
+
+var today = time.Date(2026, 5, 4, 18, 0, 0, 0, time.UTC)
+
+// reviewed gives progress a card last reviewed daysAgo, due again after interval days.
+func reviewed(p *UserProgress, cardID, skillID string, interval, daysAgo int) {
+	card := srs.NewCard(cardID, skillID)
+	card.Repetitions = 2
+	card.Interval = interval
+	card.LastReviewed = today.AddDate(0, 0, -daysAgo)
+	p.Cards[cardID] = card
+}
+
+func cardIDs(session []Candidate) []string {
+	var ids []string
+	for _, c := range session {
+		ids = append(ids, c.CardID)
+	}
+	return ids
+}
+
+func TestPlanSession(t *testing.T) {
+	graph := NewSkillGraph()
+	graph.AddSkill(&Skill{ID: "pwd"})
+	graph.AddSkill(&Skill{ID: "echo"})
+	graph.AddSkill(&Skill{ID: "ls", Prerequisites: []string{"pwd"}})
+	graph.AddSkill(&Skill{ID: "cd", Prerequisites: []string{"ls"}})
+
+	candidates := []Candidate{
+		{"ls-list", "ls", "ls"},
+		{"pwd-print", "pwd", "pwd"},
+		{"pwd-where", "pwd", "pwd"}, // sibling of pwd-print
+		{"pwd-acronym", "pwd", "pwd-acronym"},
+		{"echo-hello", "echo", "echo"},
+		{"cd-home", "cd", "cd"},
+		{"cd-up", "cd", "cd-up"},
+	}
+
+	tests := []struct {
+		name    string
+		prepare func(p *UserProgress)
+		want    []string
+	}{
+		{
+			name:    "new learner",
+			prepare: func(p *UserProgress) {},
+			// Skills without prerequisites, by ID; ls and cd are locked; pwd-where is buried
+			want: []string{"echo-hello", "pwd-print", "pwd-acronym"},
+		},
+		{
+			name: "reviews by priority with new cards spread between",
+			prepare: func(p *UserProgress) {
+				p.SetStrength("pwd", 1)                 // unlocks ls
+				reviewed(p, "cd-up", "cd", 6, 7)        // a day overdue on a six-day interval
+				reviewed(p, "cd-home", "cd", 1, 3)      // two days overdue on a one-day interval
+				reviewed(p, "echo-hello", "echo", 1, 1) // due today
+			},
+			// Reviews of cd still come though it's locked again; new pwd cards before ls
+			want: []string{"cd-home", "pwd-print", "cd-up", "pwd-acronym", "echo-hello", "ls-list"},
+		},
+		{
+			name: "daily limits count earlier sessions",
+			prepare: func(p *UserProgress) {
+				p.Limits = SessionLimits{NewPerDay: 2, ReviewsPerDay: 1}
+				reviewed(p, "cd-up", "cd", 1, 2)
+				reviewed(p, "cd-home", "cd", 1, 3)
+				p.Reviews = append(p.Reviews,
+					srs.ReviewLog{Time: today.Add(-2 * time.Hour), CardID: "echo-hello"},
+					srs.ReviewLog{Time: today.AddDate(0, 0, -1), CardID: "pwd-acronym"}, // yesterday's doesn't count
+				)
+			},
+			want: []string{"cd-home", "pwd-print"},
+		},
+		{
+			name: "other cards practiced today don't count",
+			prepare: func(p *UserProgress) {
+				p.Limits = SessionLimits{NewPerDay: 1, ReviewsPerDay: 1}
+				reviewed(p, "cd-home", "cd", 1, 3)
+				p.Reviews = append(p.Reviews,
+					srs.ReviewLog{Time: today.Add(-2 * time.Hour), CardID: "1.1"}, // a mission, say
+					srs.ReviewLog{Time: today.Add(-time.Hour), CardID: "1.2", ElapsedDays: 2},
+				)
+			},
+			want: []string{"cd-home", "echo-hello"},
+		},
+		{
+			name: "siblings practiced today are buried",
+			prepare: func(p *UserProgress) {
+				p.Reviews = append(p.Reviews, srs.ReviewLog{Time: today.Add(-time.Hour), CardID: "pwd-where"})
+			},
+			want: []string{"echo-hello", "pwd-acronym"},
+		},
+		{
+			name: "nothing left today",
+			prepare: func(p *UserProgress) {
+				p.Limits = SessionLimits{}
+			},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			progress := NewUserProgress()
+			progress.Clock = srs.NewFixedClock(today)
+			tt.prepare(progress)
+			got := cardIDs(PlanSession(graph, progress, candidates))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			if again := cardIDs(PlanSession(graph, progress, candidates)); !slices.Equal(again, got) {
+				t.Errorf("expected the same session when planned again, got %v then %v", got, again)
+			}
+		})
+	}
+}
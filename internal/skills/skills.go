@@ -4,6 +4,8 @@
 package skills
 
 import (
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/2389-research/turtle/internal/srs"
@@ -123,16 +125,21 @@ func (g *SkillGraph) getCategoryAvgStrength(cat Category, progress *UserProgress
 	return total / float64(len(skills))
 }
 
-// GetDueSkills returns skills that need review based on SRS scheduling.
+// GetDueSkills returns skills that need review based on SRS scheduling: those
+// with at least one card due, sorted by ID.
 func (g *SkillGraph) GetDueSkills(progress *UserProgress) []string {
 	var due []string
 
+	now := progress.now()
 	for skillID := range g.Skills {
-		card := progress.GetCard(skillID)
-		if card != nil && card.IsDue() {
-			due = append(due, skillID)
+		for _, card := range progress.SkillCards(skillID) {
+			if card.IsDue(now) {
+				due = append(due, skillID)
+				break
+			}
 		}
 	}
+	slices.Sort(due)
 
 	return due
 }
@@ -144,10 +151,11 @@ type UserProgress struct {
 	CurrentStreak int
 	BestStreak    int
 	LastActive    time.Time
-	Cards         map[string]*srs.Card // SRS cards for each skill
-
-	// testNow is used for testing time-dependent behavior (nil in production)
-	testNow *time.Time
+	Cards         map[string]*srs.Card // SRS cards by challenge or mission ID
+	Scheduler     srs.Scheduler        // Schedules reviews of the cards
+	Reviews       []srs.ReviewLog      // Every review, oldest first
+	Limits        SessionLimits        // Daily caps on new cards and reviews
+	Clock         srs.Clock            // Tells the time for scheduling, decay and streaks
 }
 
 // NewUserProgress creates a fresh user progress tracker.
@@ -159,76 +167,110 @@ func NewUserProgress() *UserProgress {
 		BestStreak:    0,
 		LastActive:    time.Time{},
 		Cards:         make(map[string]*srs.Card),
-		testNow:       nil,
+		Scheduler:     srs.SM2{},
+		Limits:        DefaultSessionLimits,
+		Clock:         srs.SystemClock{},
 	}
 }
 
-// now returns the current time (or simulated time for testing).
+// now returns the time on the progress's clock.
 func (p *UserProgress) now() time.Time {
-	if p.testNow != nil {
-		return *p.testNow
-	}
-	return time.Now()
+	return p.Clock.Now()
 }
 
-// GetStrength returns the current strength for a skill.
+// GetStrength returns the current strength for a skill: the mean strength of the
+// cards that practice it.
 func (p *UserProgress) GetStrength(skillID string) float64 {
-	card := p.Cards[skillID]
-	if card == nil {
+	cards := p.SkillCards(skillID)
+	if len(cards) == 0 {
 		return 0
 	}
-	return card.Strength()
+	total := 0.0
+	for _, card := range cards {
+		total += p.Scheduler.Strength(card, p.now())
+	}
+	return total / float64(len(cards))
 }
 
-// SetStrength manually sets a skill's strength (for testing/simulation).
+// SetStrength manually sets a skill's strength (for testing/simulation), using a
+// card with the skill's own ID.
 func (p *UserProgress) SetStrength(skillID string, strength float64) {
-	card := p.getOrCreateCard(skillID)
+	card := p.getOrCreateCard(skillID, skillID)
 	// Approximate the strength by setting repetitions
 	// This is a simplified approach for direct strength setting
 	if strength > 0 {
 		card.Repetitions = int(strength * 5) // Rough mapping
-		card.LastReviewed = time.Now()
+		card.LastReviewed = p.now()
 	}
 }
 
-// GetCard returns the SRS card for a skill, or nil if not practiced.
-func (p *UserProgress) GetCard(skillID string) *srs.Card {
-	return p.Cards[skillID]
+// GetCard returns the SRS card for a challenge or mission, or nil if not practiced.
+func (p *UserProgress) GetCard(cardID string) *srs.Card {
+	return p.Cards[cardID]
+}
+
+// SkillCards returns the cards that practice a skill, ordered by ID.
+func (p *UserProgress) SkillCards(skillID string) []*srs.Card {
+	var cards []*srs.Card
+	for _, card := range p.Cards {
+		if card.SkillID == skillID {
+			cards = append(cards, card)
+		}
+	}
+	slices.SortFunc(cards, func(a, b *srs.Card) int { return strings.Compare(a.ID, b.ID) })
+	return cards
 }
 
-// getOrCreateCard ensures a card exists for a skill.
-func (p *UserProgress) getOrCreateCard(skillID string) *srs.Card {
-	if p.Cards[skillID] == nil {
-		p.Cards[skillID] = srs.NewCard(skillID)
+// getOrCreateCard ensures a card exists for a challenge or mission.
+func (p *UserProgress) getOrCreateCard(cardID, skillID string) *srs.Card {
+	if p.Cards[cardID] == nil {
+		p.Cards[cardID] = srs.NewCard(cardID, skillID)
 	}
-	return p.Cards[skillID]
+	return p.Cards[cardID]
 }
 
-// Practice records a practice session for a skill.
-func (p *UserProgress) Practice(skillID string, grade int) {
-	card := p.getOrCreateCard(skillID)
-	card.Review(grade)
+// Practice records a practice session on one challenge of a skill,
+// answered in responseTime, and adds it to the review log.
+func (p *UserProgress) Practice(skillID, cardID string, grade int, responseTime time.Duration) {
+	card := p.getOrCreateCard(cardID, skillID)
+	now := p.now()
+	elapsed := 0.0
+	if !card.LastReviewed.IsZero() {
+		elapsed = now.Sub(card.LastReviewed).Hours() / 24
+	}
+	p.Scheduler.Review(card, grade, now)
+	p.Reviews = append(p.Reviews, srs.ReviewLog{
+		Time:         now,
+		CardID:       cardID,
+		Grade:        grade,
+		ResponseTime: responseTime,
+		ElapsedDays:  elapsed,
+		Interval:     card.Interval,
+	})
+}
+
+// CompleteMission schedules a mission's card after the learner completes it, as a
+// perfect practice. Completing a mission isn't recall under test, so it stays out
+// of the review log FSRS is fitted to.
+func (p *UserProgress) CompleteMission(skillID, missionID string) {
+	p.Scheduler.Review(p.getOrCreateCard(missionID, skillID), 5, p.now())
 }
 
 // IsCracking returns true if a skill's strength has dropped below the crack threshold.
 func (p *UserProgress) IsCracking(skillID string) bool {
-	strength := p.GetStrength(skillID)
-	card := p.Cards[skillID]
-
 	// Must have been practiced at least once to be "cracking"
-	if card == nil || card.Repetitions == 0 {
+	practiced := false
+	for _, card := range p.SkillCards(skillID) {
+		if card.Repetitions > 0 {
+			practiced = true
+			break
+		}
+	}
+	if !practiced {
 		return false
 	}
 
-	return strength < CrackThreshold
-}
-
-// SimulateDecay simulates the passage of time for testing decay mechanics.
-func (p *UserProgress) SimulateDecay(skillID string, days int) {
-	card := p.Cards[skillID]
-	if card != nil {
-		card.LastReviewed = card.LastReviewed.AddDate(0, 0, -days)
-	}
+	return p.GetStrength(skillID) < CrackThreshold
 }
 
 // AddXP awards experience points and handles level ups.
@@ -273,10 +315,3 @@ func (p *UserProgress) RecordActivity() {
 
 	p.LastActive = now
 }
-
-// SimulateNextDay advances the virtual clock by one day (for testing streaks).
-func (p *UserProgress) SimulateNextDay() {
-	current := p.now()
-	next := current.AddDate(0, 0, 1)
-	p.testNow = &next
-}
@@ -4,9 +4,21 @@
 package skills
 
 import (
+	"slices"
 	"testing"
+	"time"
+
+	"github.com/2389-research/turtle/internal/srs"
 )
 
+// progressAt returns fresh progress whose clock stands still until advanced.
+func progressAt() (*UserProgress, *srs.FixedClock) {
+	clock := srs.NewFixedClock(time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC))
+	progress := NewUserProgress()
+	progress.Clock = clock
+	return progress, clock
+}
+
 func TestNewSkillGraph(t *testing.T) {
 	graph := NewSkillGraph()
 
@@ -109,13 +121,13 @@ func TestSkillUnlocking(t *testing.T) {
 }
 
 func TestCrackingSkills(t *testing.T) {
-	progress := NewUserProgress()
+	progress, clock := progressAt()
 
 	// Learn a skill to high strength
 	progress.SetStrength("pwd", 0.9)
 
-	// Simulate time passing - strength should decay
-	progress.SimulateDecay("pwd", 30) // 30 days
+	// Let time pass - strength should decay
+	clock.AdvanceDays(30)
 
 	strength := progress.GetStrength("pwd")
 	if strength >= 0.9 {
@@ -207,7 +219,7 @@ func TestXPAndLevels(t *testing.T) {
 }
 
 func TestStreaks(t *testing.T) {
-	progress := NewUserProgress()
+	progress, clock := progressAt()
 
 	if progress.CurrentStreak != 0 {
 		t.Errorf("expected starting streak 0, got %d", progress.CurrentStreak)
@@ -219,16 +231,15 @@ func TestStreaks(t *testing.T) {
 		t.Errorf("expected streak 1 after activity, got %d", progress.CurrentStreak)
 	}
 
-	// Simulate next day activity
-	progress.SimulateNextDay()
+	// Next day activity
+	clock.AdvanceDays(1)
 	progress.RecordActivity()
 	if progress.CurrentStreak != 2 {
 		t.Errorf("expected streak 2, got %d", progress.CurrentStreak)
 	}
 
-	// Simulate missing a day
-	progress.SimulateNextDay()
-	progress.SimulateNextDay() // Skip a day
+	// Missing a day
+	clock.AdvanceDays(2)
 	progress.RecordActivity()
 	if progress.CurrentStreak != 1 {
 		t.Errorf("expected streak reset to 1, got %d", progress.CurrentStreak)
@@ -240,26 +251,82 @@ func TestDueSkills(t *testing.T) {
 	graph.AddSkill(&Skill{ID: "pwd", Category: CategoryNavigation})
 	graph.AddSkill(&Skill{ID: "ls", Category: CategoryNavigation})
 
-	progress := NewUserProgress()
+	progress, clock := progressAt()
 
-	// Practice skills
-	progress.Practice("pwd", 5) // Perfect
-	progress.Practice("ls", 5)
+	// Practice ls once, then pwd twice a week later, for a six-day interval
+	progress.Practice("ls", "ls-list", 5, time.Second) // Perfect
+	clock.AdvanceDays(8)
+	progress.Practice("pwd", "pwd-print", 5, time.Second)
+	progress.Practice("pwd", "pwd-print", 5, time.Second)
 
-	// Nothing due right after practice
+	// ls is already due again; pwd was just practiced
 	due := graph.GetDueSkills(progress)
-	if len(due) != 0 {
-		t.Errorf("expected no skills due right after practice, got %v", due)
+	if !slices.Equal(due, []string{"ls"}) {
+		t.Errorf("expected only ls due, got %v", due)
 	}
 
-	// Simulate time passing
-	progress.SimulateDecay("pwd", 2) // 2 days
-	progress.SimulateDecay("ls", 10) // 10 days
-
+	// Once pwd's interval passes, both are due
+	clock.AdvanceDays(6)
 	due = graph.GetDueSkills(progress)
-	// ls should be due (longer since review)
-	if !contains(due, "ls") {
-		t.Errorf("expected ls to be due, got %v", due)
+	if !slices.Equal(due, []string{"ls", "pwd"}) {
+		t.Errorf("expected ls and pwd due, got %v", due)
+	}
+}
+
+func TestStrengthFromCards(t *testing.T) {
+	progress, clock := progressAt()
+	progress.Practice("pwd", "pwd-print", 5, time.Second)
+	progress.Practice("pwd", "pwd-where-am-i", 5, time.Second)
+	progress.Practice("ls", "ls-list", 5, time.Second)
+
+	if got := len(progress.SkillCards("pwd")); got != 2 {
+		t.Fatalf("expected 2 pwd cards, got %d", got)
+	}
+	now := clock.Now()
+	want := (progress.Scheduler.Strength(progress.GetCard("pwd-print"), now) + progress.Scheduler.Strength(progress.GetCard("pwd-where-am-i"), now)) / 2
+	if got := progress.GetStrength("pwd"); got != want {
+		t.Errorf("expected pwd strength %.3f (mean of its cards), got %.3f", want, got)
+	}
+
+	// Failing one challenge weakens the skill without resetting the other card
+	progress.Practice("pwd", "pwd-where-am-i", 0, time.Second)
+	if progress.GetCard("pwd-print").Repetitions != 1 {
+		t.Errorf("expected pwd-print to keep its repetition, got %d", progress.GetCard("pwd-print").Repetitions)
+	}
+	if progress.GetStrength("pwd") >= progress.GetStrength("ls") {
+		t.Errorf("expected pwd (one card failed) weaker than ls, got %.3f >= %.3f",
+			progress.GetStrength("pwd"), progress.GetStrength("ls"))
+	}
+}
+
+func TestPracticeLogsReviews(t *testing.T) {
+	progress, clock := progressAt()
+	progress.Practice("pwd", "pwd-print", 5, 800*time.Millisecond)
+	clock.AdvanceDays(3)
+	progress.Practice("pwd", "pwd-print", 2, 4*time.Second)
+
+	if len(progress.Reviews) != 2 {
+		t.Fatalf("expected 2 logged reviews, got %d", len(progress.Reviews))
+	}
+	first, second := progress.Reviews[0], progress.Reviews[1]
+	if first.CardID != "pwd-print" || first.Grade != 5 || first.ResponseTime != 800*time.Millisecond ||
+		first.ElapsedDays != 0 || first.Interval != 1 {
+		t.Errorf("unexpected first review %+v", first)
+	}
+	if second.Grade != 2 || second.ElapsedDays != 3 || second.Interval != 1 {
+		t.Errorf("unexpected second review %+v", second)
+	}
+}
+
+func TestCompleteMissionSkipsReviewLog(t *testing.T) {
+	progress, _ := progressAt()
+	progress.CompleteMission("pwd", "1.1")
+
+	if card := progress.GetCard("1.1"); card.Repetitions != 1 || card.SkillID != "pwd" {
+		t.Errorf("expected the mission's card scheduled once for pwd, got %+v", card)
+	}
+	if len(progress.Reviews) != 0 {
+		t.Errorf("expected no logged reviews, got %d", len(progress.Reviews))
 	}
 }
 
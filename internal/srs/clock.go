@@ -0,0 +1,61 @@
+// ABOUTME: Clocks that scheduling reads the time from, so it can be simulated
+// ABOUTME: The system clock, a clock shifted to another moment, and a fixed clock for tests
+
+package srs
+
+import "time"
+
+// Clock tells the time. Everything that schedules or decays cards reads the time
+// through a Clock, so tests and the --now debug flag can move it consistently.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the real time.
+type SystemClock struct{}
+
+// Now returns the current time.
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// OffsetClock runs at the real pace but shifted by Offset, so response times are
+// still measured while due dates and decay are computed for another moment.
+type OffsetClock struct {
+	Offset time.Duration
+}
+
+// ClockAt returns a clock that reads t now and runs on from there.
+func ClockAt(t time.Time) OffsetClock {
+	return OffsetClock{Offset: time.Until(t)}
+}
+
+// Now returns the current time shifted by the offset.
+func (c OffsetClock) Now() time.Time {
+	return time.Now().Add(c.Offset)
+}
+
+// FixedClock stands still at T until Advance moves it, for tests.
+type FixedClock struct {
+	T time.Time
+}
+
+// NewFixedClock returns a clock stopped at t.
+func NewFixedClock(t time.Time) *FixedClock {
+	return &FixedClock{T: t}
+}
+
+// Now returns the clock's time.
+func (c *FixedClock) Now() time.Time {
+	return c.T
+}
+
+// Advance moves the clock forward by d.
+func (c *FixedClock) Advance(d time.Duration) {
+	c.T = c.T.Add(d)
+}
+
+// AdvanceDays moves the clock forward by whole days.
+func (c *FixedClock) AdvanceDays(days int) {
+	c.T = c.T.AddDate(0, 0, days)
+}
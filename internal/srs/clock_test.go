@@ -0,0 +1,26 @@
+// ABOUTME: Tests for the clocks scheduling reads the time from
+// ABOUTME: Checks a fixed clock advances and a shifted clock starts where it's set
+
+package srs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClocks(t *testing.T) {
+	clock := NewFixedClock(start)
+	clock.AdvanceDays(2)
+	clock.Advance(time.Hour)
+	if want := start.Add(49 * time.Hour); !clock.Now().Equal(want) {
+		t.Errorf("expected fixed clock at %v, got %v", want, clock.Now())
+	}
+
+	shifted := ClockAt(start)
+	if d := shifted.Now().Sub(start); d < 0 || d > time.Second {
+		t.Errorf("expected a clock at %v, got %v", start, shifted.Now())
+	}
+	if (SystemClock{}).Now().IsZero() {
+		t.Error("expected the system clock to tell the time")
+	}
+}
@@ -0,0 +1,159 @@
+// ABOUTME: FSRS (Free Spaced Repetition Scheduler) with stability and difficulty per card
+// ABOUTME: Schedules each review for when recall is predicted to fall to a target retention
+
+package srs
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+const (
+	// DefaultTargetRetention is the recall probability FSRS aims for at each review.
+	DefaultTargetRetention = 0.9
+
+	// fsrsDecay and fsrsFactor shape the forgetting curve; fsrsFactor makes recall
+	// exactly 90% once a card's stability in days has passed.
+	fsrsDecay  = -0.5
+	fsrsFactor = 19.0 / 81.0
+
+	minDifficulty = 1.0
+	maxDifficulty = 10.0
+	maxInterval   = 36500 // days
+)
+
+// DefaultFSRSWeights are the FSRS-4.5 parameters fitted to a large body of
+// review histories. Weights 0-3 are the stability after a first review rated
+// again, hard, good or easy; 4-7 shape difficulty; 8-10 and 15-16 govern stability
+// after a success, and 11-14 after a lapse.
+var DefaultFSRSWeights = []float64{
+	0.4872, 1.4003, 3.7145, 13.8206,
+	5.1618, 1.2298, 0.8975, 0.031,
+	1.6474, 0.1367, 1.0461,
+	2.1072, 0.0793, 0.3246, 1.587,
+	0.2272, 2.8755,
+}
+
+// FSRS schedules reviews by modelling each card's memory: its stability (how
+// slowly it is forgotten) and difficulty (how much each review strengthens it).
+// A card is due when its predicted recall falls to TargetRetention.
+type FSRS struct {
+	Weights         []float64
+	TargetRetention float64
+}
+
+// NewFSRS creates an FSRS scheduler with the default weights.
+func NewFSRS(targetRetention float64) *FSRS {
+	return &FSRS{Weights: slices.Clone(DefaultFSRSWeights), TargetRetention: targetRetention}
+}
+
+// Name returns "fsrs".
+func (f *FSRS) Name() string {
+	return SchedulerFSRS
+}
+
+// Review processes a review session with the given quality grade (0-5), updating
+// the card's stability and difficulty and scheduling its next review. Cards that
+// were scheduled by SM-2 start from a memory state estimated from their history.
+func (f *FSRS) Review(c *Card, grade int, now time.Time) {
+	w := f.Weights
+	r := rating(grade)
+	if c.LastReviewed.IsZero() {
+		c.Stability = w[r-1]
+		c.Difficulty = f.initialDifficulty(r)
+	} else {
+		stability, difficulty := memoryState(c)
+		recall := Retrievability(daysBetween(c.LastReviewed, now), stability)
+		if r == 1 {
+			// Forgotten: stability restarts low, but never rises on a lapse
+			lapse := w[11] * math.Pow(difficulty, -w[12]) * (math.Pow(stability+1, w[13]) - 1) * math.Exp(w[14]*(1-recall))
+			c.Stability = math.Min(lapse, stability)
+		} else {
+			bonus := 1.0
+			if r == 2 {
+				bonus = w[15]
+			} else if r == 4 {
+				bonus = w[16]
+			}
+			growth := math.Exp(w[8]) * (11 - difficulty) * math.Pow(stability, -w[9]) * (math.Exp(w[10]*(1-recall)) - 1) * bonus
+			c.Stability = stability * (1 + growth)
+		}
+		// Difficulty moves with the rating, reverting slowly toward a "good" first review
+		next := difficulty - w[6]*float64(r-3)
+		c.Difficulty = clampDifficulty(w[7]*f.initialDifficulty(3) + (1-w[7])*next)
+	}
+
+	if r == 1 {
+		c.Repetitions = 0
+	} else {
+		c.Repetitions++
+	}
+	c.Interval = f.interval(c.Stability)
+	c.LastReviewed = now
+}
+
+// Strength returns the predicted probability of recalling the card now.
+func (f *FSRS) Strength(c *Card, now time.Time) float64 {
+	if c.LastReviewed.IsZero() {
+		return 0 // Never practiced
+	}
+	stability, _ := memoryState(c)
+	return Retrievability(daysBetween(c.LastReviewed, now), stability)
+}
+
+// Retrievability is the probability of recalling a card with the given stability,
+// elapsedDays after its last review.
+func Retrievability(elapsedDays, stability float64) float64 {
+	if stability <= 0 {
+		return 0
+	}
+	return math.Pow(1+fsrsFactor*elapsedDays/stability, fsrsDecay)
+}
+
+// interval returns the whole days until recall falls to the target retention.
+func (f *FSRS) interval(stability float64) int {
+	days := stability / fsrsFactor * (math.Pow(f.TargetRetention, 1/fsrsDecay) - 1)
+	return min(max(int(math.Round(days)), 1), maxInterval)
+}
+
+// initialDifficulty is the difficulty of a card after a first review rated r.
+func (f *FSRS) initialDifficulty(r int) float64 {
+	return clampDifficulty(f.Weights[4] - float64(r-3)*f.Weights[5])
+}
+
+// memoryState returns a card's FSRS stability and difficulty. A card reviewed only
+// under SM-2 has none yet, so they are estimated from its SM-2 state: SM-2 aims to
+// review at about 90% recall, which is when one stability has passed, and ease
+// factors from 2.5 down to the 1.3 floor map onto difficulties from 5 to 10.
+func memoryState(c *Card) (stability, difficulty float64) {
+	if c.Stability > 0 {
+		return c.Stability, c.Difficulty
+	}
+	stability = math.Max(float64(c.Interval), 1)
+	difficulty = clampDifficulty(5 + (DefaultEaseFactor-c.EaseFactor)/(DefaultEaseFactor-MinEaseFactor)*5)
+	return stability, difficulty
+}
+
+// rating maps an SM-2 grade onto FSRS's four ratings: 1 again, 2 hard, 3 good, 4 easy.
+func rating(grade int) int {
+	switch {
+	case grade < 3:
+		return 1
+	case grade == 3:
+		return 2
+	case grade == 4:
+		return 3
+	default:
+		return 4
+	}
+}
+
+func clampDifficulty(d float64) float64 {
+	return math.Min(math.Max(d, minDifficulty), maxDifficulty)
+}
+
+// daysBetween returns the days from one time to a later one, or 0 if it is earlier.
+func daysBetween(from, to time.Time) float64 {
+	return math.Max(to.Sub(from).Hours()/24, 0)
+}
@@ -0,0 +1,145 @@
+// ABOUTME: Tests for the FSRS scheduler and choosing a scheduler by name
+// ABOUTME: Checks stability, difficulty and intervals after reviews, and cards carried over from SM-2
+
+package srs
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+
+func TestFSRS_FirstReview(t *testing.T) {
+	tests := []struct {
+		grade     int
+		stability float64
+		interval  int
+	}{
+		{1, 0.4872, 1},
+		{3, 1.4003, 1},
+		{4, 3.7145, 4},
+		{5, 13.8206, 14},
+	}
+	for _, tt := range tests {
+		card := NewCard("c", "s")
+		NewFSRS(DefaultTargetRetention).Review(card, tt.grade, start)
+		if card.Stability != tt.stability {
+			t.Errorf("grade %d: expected stability %.4f, got %.4f", tt.grade, tt.stability, card.Stability)
+		}
+		if card.Interval != tt.interval {
+			t.Errorf("grade %d: expected interval %d, got %d", tt.grade, tt.interval, card.Interval)
+		}
+		if card.Difficulty < minDifficulty || card.Difficulty > maxDifficulty {
+			t.Errorf("grade %d: difficulty %.2f out of range", tt.grade, card.Difficulty)
+		}
+	}
+}
+
+func TestFSRS_SuccessAndLapse(t *testing.T) {
+	f := NewFSRS(DefaultTargetRetention)
+	card := NewCard("c", "s")
+	f.Review(card, 4, start)
+
+	// Remembering on the due date makes the memory more stable
+	now := start.AddDate(0, 0, card.Interval)
+	before := card.Stability
+	f.Review(card, 4, now)
+	if card.Stability <= before {
+		t.Errorf("expected stability to grow after a success, got %.2f -> %.2f", before, card.Stability)
+	}
+	if card.Repetitions != 2 {
+		t.Errorf("expected 2 repetitions, got %d", card.Repetitions)
+	}
+
+	// Forgetting makes it less stable, harder, and due again soon
+	now = now.AddDate(0, 0, card.Interval)
+	before, difficulty, interval := card.Stability, card.Difficulty, card.Interval
+	f.Review(card, 1, now)
+	if card.Stability >= before {
+		t.Errorf("expected stability to drop after a lapse, got %.2f -> %.2f", before, card.Stability)
+	}
+	if card.Difficulty <= difficulty {
+		t.Errorf("expected difficulty to rise after a lapse, got %.2f -> %.2f", difficulty, card.Difficulty)
+	}
+	if card.Interval >= interval || card.Repetitions != 0 {
+		t.Errorf("expected a shorter interval and reset repetitions, got %d days, %d reps", card.Interval, card.Repetitions)
+	}
+}
+
+func TestFSRS_TargetRetention(t *testing.T) {
+	strict, relaxed := NewCard("c", "s"), NewCard("c", "s")
+	NewFSRS(0.95).Review(strict, 5, start)
+	NewFSRS(0.8).Review(relaxed, 5, start)
+	if strict.Interval >= relaxed.Interval {
+		t.Errorf("expected a higher target retention to review sooner, got %d >= %d days", strict.Interval, relaxed.Interval)
+	}
+}
+
+func TestFSRS_Strength(t *testing.T) {
+	f := NewFSRS(DefaultTargetRetention)
+	card := NewCard("c", "s")
+	if got := f.Strength(card, start); got != 0 {
+		t.Errorf("expected an unpracticed card to have strength 0, got %.2f", got)
+	}
+	f.Review(card, 5, start)
+	if got := f.Strength(card, start); got != 1 {
+		t.Errorf("expected strength 1 right after review, got %.2f", got)
+	}
+	later := start.Add(time.Duration(card.Stability * 24 * float64(time.Hour)))
+	if got := f.Strength(card, later); math.Abs(got-0.9) > 0.001 {
+		t.Errorf("expected strength 0.9 once a stability has passed, got %.3f", got)
+	}
+}
+
+func TestFSRS_CardFromSM2(t *testing.T) {
+	card := NewCard("c", "s")
+	sm2 := SM2{}
+	sm2.Review(card, 5, start)
+	sm2.Review(card, 5, start.AddDate(0, 0, 1))
+	reviewed := start.AddDate(0, 0, 1)
+
+	f := NewFSRS(DefaultTargetRetention)
+	due := reviewed.AddDate(0, 0, card.Interval)
+	if got := f.Strength(card, due); math.Abs(got-0.9) > 0.001 {
+		t.Errorf("expected an SM-2 card to be at 90%% recall on its due date, got %.3f", got)
+	}
+	if card.Stability != 0 {
+		t.Error("expected Strength to leave the card unchanged")
+	}
+
+	f.Review(card, 4, due)
+	if card.Stability <= float64(6) || card.Difficulty == 0 {
+		t.Errorf("expected FSRS state built on the SM-2 history, got stability %.2f difficulty %.2f", card.Stability, card.Difficulty)
+	}
+}
+
+func TestNewScheduler(t *testing.T) {
+	tests := []struct {
+		name      string
+		retention float64
+		want      string
+		err       bool
+	}{
+		{"", 0, SchedulerSM2, false},
+		{"sm2", 0.8, SchedulerSM2, false},
+		{"fsrs", 0, SchedulerFSRS, false},
+		{"fsrs", 0.85, SchedulerFSRS, false},
+		{"fsrs", 1, "", true},
+		{"leitner", 0, "", true},
+	}
+	for _, tt := range tests {
+		s, err := NewScheduler(tt.name, tt.retention)
+		if (err != nil) != tt.err {
+			t.Errorf("NewScheduler(%q, %g): unexpected error %v", tt.name, tt.retention, err)
+			continue
+		}
+		if err == nil && s.Name() != tt.want {
+			t.Errorf("NewScheduler(%q, %g) = %s, want %s", tt.name, tt.retention, s.Name(), tt.want)
+		}
+	}
+	if s, _ := NewScheduler("fsrs", 0); s.(*FSRS).TargetRetention != DefaultTargetRetention {
+		t.Errorf("expected the default target retention, got %g", s.(*FSRS).TargetRetention)
+	}
+}
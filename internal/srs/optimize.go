@@ -0,0 +1,150 @@
+// ABOUTME: Fits FSRS weights to a learner's review log, offline
+// ABOUTME: Replays each card's history and searches for weights that best predict what was recalled
+
+package srs
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+)
+
+// MinReviewsToFit is the fewest predictable reviews OptimizeFSRS will fit weights to.
+const MinReviewsToFit = 20
+
+// Fit is the outcome of fitting FSRS weights to a review log. Only reviews of a
+// card with an earlier logged review are predictable, and only cards whose first
+// review was logged can be replayed.
+type Fit struct {
+	Reviews int // predictable reviews the weights were fitted to
+	Cards   int // cards they belong to
+
+	Actual        float64 // share of those reviews recalled (graded 3 or more)
+	Before, After float64 // mean predicted recall with the starting and the fitted weights
+
+	LossBefore, LossAfter float64 // log loss of the predictions: lower is better
+
+	Weights []float64
+}
+
+// OptimizeFSRS fits FSRS weights, starting from start, to the reviews in log, by
+// a pattern search that minimises the log loss of predicted recall. Weights are
+// pulled gently toward their starting values, so a short history can't drive
+// them to extremes.
+func OptimizeFSRS(log []ReviewLog, start []float64) (Fit, error) {
+	if len(start) != len(DefaultFSRSWeights) {
+		return Fit{}, fmt.Errorf("fsrs has %d weights, want %d", len(start), len(DefaultFSRSWeights))
+	}
+	cards := replayable(log)
+	before := evaluate(start, cards)
+	if before.n < MinReviewsToFit {
+		return Fit{}, fmt.Errorf("not enough reviews to fit yet: have %d, need %d", before.n, MinReviewsToFit)
+	}
+
+	objective := func(w []float64) float64 {
+		penalty := 0.0
+		for i := range w {
+			d := (w[i] - start[i]) / start[i]
+			penalty += d * d
+		}
+		return evaluate(w, cards).loss + penalty/float64(before.n)
+	}
+
+	w := slices.Clone(start)
+	best := objective(w)
+	for step, rounds := 0.5, 0; step > 0.005 && rounds < 500; rounds++ {
+		improved := false
+		for i := range w {
+			for _, dir := range []float64{1, -1} {
+				trial := slices.Clone(w)
+				trial[i] = clampWeight(i, w[i]*(1+dir*step))
+				if v := objective(trial); v < best {
+					w, best, improved = trial, v, true
+					break
+				}
+			}
+		}
+		if !improved {
+			step /= 2
+		}
+	}
+
+	after := evaluate(w, cards)
+	return Fit{
+		Reviews:    before.n,
+		Cards:      len(cards),
+		Actual:     before.recalled / float64(before.n),
+		Before:     before.predicted / float64(before.n),
+		After:      after.predicted / float64(after.n),
+		LossBefore: before.loss,
+		LossAfter:  after.loss,
+		Weights:    w,
+	}, nil
+}
+
+// replayable groups a log by card, keeping the cards whose first review is logged
+// and which were reviewed again, with each card's reviews in the order they happened.
+func replayable(log []ReviewLog) [][]ReviewLog {
+	byCard := make(map[string][]ReviewLog)
+	for _, r := range log {
+		byCard[r.CardID] = append(byCard[r.CardID], r)
+	}
+	var cards [][]ReviewLog
+	for _, id := range slices.Sorted(maps.Keys(byCard)) {
+		reviews := byCard[id]
+		slices.SortStableFunc(reviews, func(a, b ReviewLog) int { return a.Time.Compare(b.Time) })
+		if len(reviews) > 1 && reviews[0].ElapsedDays == 0 {
+			cards = append(cards, reviews)
+		}
+	}
+	return cards
+}
+
+// evaluation totals FSRS's predictions over the predictable reviews.
+type evaluation struct {
+	n                   int
+	recalled, predicted float64
+	loss                float64 // mean log loss
+}
+
+// evaluate replays each card's reviews under FSRS with weights w, predicting
+// before each review after the first whether the card would be recalled.
+func evaluate(w []float64, cards [][]ReviewLog) evaluation {
+	f := &FSRS{Weights: w, TargetRetention: DefaultTargetRetention}
+	var e evaluation
+	for _, reviews := range cards {
+		card := NewCard(reviews[0].CardID, "")
+		for i, r := range reviews {
+			if i > 0 {
+				p := math.Min(math.Max(f.Strength(card, r.Time), 1e-4), 1-1e-4)
+				e.n++
+				e.predicted += p
+				if r.Grade >= 3 {
+					e.recalled++
+					e.loss -= math.Log(p)
+				} else {
+					e.loss -= math.Log(1 - p)
+				}
+			}
+			f.Review(card, r.Grade, r.Time)
+		}
+	}
+	if e.n > 0 {
+		e.loss /= float64(e.n)
+	}
+	return e
+}
+
+// clampWeight keeps a weight within the range FSRS's formulas make sense for:
+// positive, an initial difficulty within 1-10, and a mean reversion fraction.
+func clampWeight(i int, w float64) float64 {
+	switch i {
+	case 4:
+		return math.Min(math.Max(w, minDifficulty), maxDifficulty)
+	case 7:
+		return math.Min(math.Max(w, 0.001), 1)
+	default:
+		return math.Min(math.Max(w, 0.001), 100)
+	}
+}
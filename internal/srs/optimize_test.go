@@ -0,0 +1,89 @@
+// ABOUTME: Tests for fitting FSRS weights to a review log
+// ABOUTME: Simulates a learner who forgets faster than the defaults predict and checks the fit learns it
+
+package srs
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+// simulateLearner reviews cards whenever the default FSRS schedule says they are
+// due, recalling each with the probability FSRS gives under the learner's true
+// weights, and returns the log.
+func simulateLearner(truth []float64, cards, reviews int) []ReviewLog {
+	rng := rand.New(rand.NewPCG(1, 2))
+	scheduler := NewFSRS(DefaultTargetRetention)
+	learner := &FSRS{Weights: truth, TargetRetention: DefaultTargetRetention}
+	var log []ReviewLog
+	for c := range cards {
+		id := fmt.Sprintf("card-%d", c)
+		card, memory := NewCard(id, "s"), NewCard(id, "s")
+		now := start
+		for i := range reviews {
+			grade, elapsed := 4, 0.0
+			if i > 0 {
+				elapsed = float64(card.Interval)
+				now = now.AddDate(0, 0, card.Interval)
+				if rng.Float64() > learner.Strength(memory, now) {
+					grade = 1
+				}
+			}
+			scheduler.Review(card, grade, now)
+			learner.Review(memory, grade, now)
+			log = append(log, ReviewLog{Time: now, CardID: id, Grade: grade, ElapsedDays: elapsed, Interval: card.Interval})
+		}
+	}
+	return log
+}
+
+func TestOptimizeFSRS(t *testing.T) {
+	truth := append([]float64(nil), DefaultFSRSWeights...)
+	for i := range 4 {
+		truth[i] *= 0.25 // first reviews are forgotten four times as fast
+	}
+	truth[8] -= 0.5 // and memories grow more slowly
+
+	fit, err := OptimizeFSRS(simulateLearner(truth, 40, 6), DefaultFSRSWeights)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fit.Reviews != 200 || fit.Cards != 40 {
+		t.Errorf("expected 200 reviews of 40 cards, got %d of %d", fit.Reviews, fit.Cards)
+	}
+	if fit.LossAfter >= fit.LossBefore {
+		t.Errorf("expected the fit to lower the log loss, got %.3f -> %.3f", fit.LossBefore, fit.LossAfter)
+	}
+	if math.Abs(fit.After-fit.Actual) >= math.Abs(fit.Before-fit.Actual) {
+		t.Errorf("expected predicted retention closer to the actual %.3f, got %.3f -> %.3f", fit.Actual, fit.Before, fit.After)
+	}
+	if fit.Weights[2] >= DefaultFSRSWeights[2] {
+		t.Errorf("expected a lower first-review stability, got %.3f", fit.Weights[2])
+	}
+}
+
+func TestOptimizeFSRS_Errors(t *testing.T) {
+	log := simulateLearner(DefaultFSRSWeights, 3, 4)
+	if _, err := OptimizeFSRS(log, DefaultFSRSWeights); err == nil || !strings.Contains(err.Error(), "have 9, need 20") {
+		t.Errorf("expected too few reviews, got %v", err)
+	}
+
+	// Cards whose first review isn't logged can't be replayed
+	log = simulateLearner(DefaultFSRSWeights, 10, 4)
+	var partial []ReviewLog
+	for _, r := range log {
+		if r.ElapsedDays > 0 {
+			partial = append(partial, r)
+		}
+	}
+	if _, err := OptimizeFSRS(partial, DefaultFSRSWeights); err == nil || !strings.Contains(err.Error(), "have 0") {
+		t.Errorf("expected no replayable reviews, got %v", err)
+	}
+
+	if _, err := OptimizeFSRS(log, DefaultFSRSWeights[:5]); err == nil {
+		t.Error("expected an error for the wrong number of weights")
+	}
+}
@@ -0,0 +1,45 @@
+// ABOUTME: The Scheduler interface that decides when cards come back for review
+// ABOUTME: Picks SM-2 or FSRS by the name saved with the learner's progress
+
+package srs
+
+import (
+	"fmt"
+	"time"
+)
+
+// Scheduler names, as saved with the learner's progress.
+const (
+	SchedulerSM2  = "sm2"
+	SchedulerFSRS = "fsrs"
+)
+
+// Scheduler is a spaced repetition algorithm. Review records a graded review
+// (0-5, see SM2.Review) and sets the card's Interval to the days until it is
+// due again; Strength estimates how well the card is remembered at a moment,
+// from 0.0 to 1.0.
+type Scheduler interface {
+	Name() string
+	Review(c *Card, grade int, now time.Time)
+	Strength(c *Card, now time.Time) float64
+}
+
+// NewScheduler returns the scheduler with the given name: SM-2 for "sm2" or "",
+// FSRS for "fsrs". targetRetention is the recall probability FSRS schedules
+// reviews for; 0 means DefaultTargetRetention. SM-2 ignores it.
+func NewScheduler(name string, targetRetention float64) (Scheduler, error) {
+	switch name {
+	case "", SchedulerSM2:
+		return SM2{}, nil
+	case SchedulerFSRS:
+		if targetRetention == 0 {
+			targetRetention = DefaultTargetRetention
+		}
+		if targetRetention <= 0 || targetRetention >= 1 {
+			return nil, fmt.Errorf("target retention %g must be between 0 and 1", targetRetention)
+		}
+		return NewFSRS(targetRetention), nil
+	default:
+		return nil, fmt.Errorf("unknown scheduler %q (want %s or %s)", name, SchedulerSM2, SchedulerFSRS)
+	}
+}
@@ -1,4 +1,4 @@
-// ABOUTME: SM-2 spaced repetition algorithm implementation
+// ABOUTME: Review cards and the SM-2 spaced repetition algorithm, the default scheduler
 // ABOUTME: Core learning engine that schedules reviews based on forgetting curves
 
 package srs
@@ -15,18 +15,36 @@ const (
 	MinEaseFactor = 1.3
 )
 
-// Card represents a single learnable skill tracked by the SRS system.
+// Card represents a single learnable item (a challenge or a mission) tracked by the
+// SRS system. A skill's strength is aggregated from its cards.
 type Card struct {
+	ID           string // challenge or mission ID
 	SkillID      string
 	EaseFactor   float64
 	Interval     int // days until next review
 	Repetitions  int
 	LastReviewed time.Time
+
+	// FSRS memory state, zero until the card is first reviewed under FSRS
+	Stability  float64 // days until recall probability falls to 90%
+	Difficulty float64 // 1 (easy) to 10 (hard)
+}
+
+// ReviewLog records one review of a card, for fitting scheduler parameters to
+// the learner's history.
+type ReviewLog struct {
+	Time         time.Time
+	CardID       string
+	Grade        int           // 0-5, as passed to Review
+	ResponseTime time.Duration // how long the learner took to answer
+	ElapsedDays  float64       // days since the card's previous review; 0 for its first
+	Interval     int           // days until the next review, as scheduled
 }
 
-// NewCard creates a new card for tracking a skill.
-func NewCard(skillID string) *Card {
+// NewCard creates a new card for tracking an item that practices a skill.
+func NewCard(id, skillID string) *Card {
 	return &Card{
+		ID:           id,
 		SkillID:      skillID,
 		EaseFactor:   DefaultEaseFactor,
 		Interval:     0,
@@ -35,7 +53,55 @@ func NewCard(skillID string) *Card {
 	}
 }
 
-// Review processes a review session with the given quality grade (0-5)
+// NextReviewDate returns when this card should next be reviewed. A new card
+// returns the zero time: it is due immediately.
+func (c *Card) NextReviewDate() time.Time {
+	if c.LastReviewed.IsZero() {
+		return time.Time{}
+	}
+	return c.LastReviewed.AddDate(0, 0, c.Interval)
+}
+
+// IsDue returns true if the card is due for review at now.
+func (c *Card) IsDue(now time.Time) bool {
+	return !now.Before(c.NextReviewDate())
+}
+
+// CalculateGrade determines the quality grade based on correctness and response time
+// This translates user performance into SM-2 grade (0-5).
+func CalculateGrade(correct bool, responseTimeMs int64) int {
+	if correct {
+		// Fast correct (< 1s) = perfect
+		if responseTimeMs < 1000 {
+			return 5
+		}
+		// Medium (1-3s) = good
+		if responseTimeMs < 3000 {
+			return 4
+		}
+		// Slow but correct = pass
+		return 3
+	}
+
+	// Incorrect responses
+	// Hesitated before wrong answer = some recall
+	if responseTimeMs >= 1000 {
+		return 2
+	}
+	// Quick wrong = guessing
+	return 1
+}
+
+// SM2 schedules reviews with the SM-2 algorithm: an ease factor per card that
+// multiplies the interval after each successful review.
+type SM2 struct{}
+
+// Name returns "sm2".
+func (SM2) Name() string {
+	return SchedulerSM2
+}
+
+// Review processes a review session with the given quality grade (0-5).
 // Grade meanings:
 //
 //	5 - perfect response
@@ -44,7 +110,7 @@ func NewCard(skillID string) *Card {
 //	2 - incorrect, but easy recall
 //	1 - incorrect, remembered
 //	0 - complete blackout
-func (c *Card) Review(grade int) {
+func (SM2) Review(c *Card, grade int, now time.Time) {
 	// Clamp grade to valid range
 	if grade < 0 {
 		grade = 0
@@ -79,28 +145,12 @@ func (c *Card) Review(grade int) {
 		c.Repetitions++
 	}
 
-	c.LastReviewed = time.Now()
-}
-
-// NextReviewDate returns when this card should next be reviewed.
-func (c *Card) NextReviewDate() time.Time {
-	if c.LastReviewed.IsZero() {
-		return time.Now() // New card is due immediately
-	}
-	return c.LastReviewed.AddDate(0, 0, c.Interval)
+	c.LastReviewed = now
 }
 
-// IsDue returns true if the card is due for review.
-func (c *Card) IsDue() bool {
-	if c.LastReviewed.IsZero() {
-		return true // New cards are always due
-	}
-	return time.Now().After(c.NextReviewDate()) || time.Now().Equal(c.NextReviewDate())
-}
-
-// Strength returns a 0.0-1.0 value representing mastery of this skill
+// Strength returns a 0.0-1.0 value representing mastery of a card.
 // Takes into account repetitions, ease factor, and time since last review.
-func (c *Card) Strength() float64 {
+func (SM2) Strength(c *Card, now time.Time) float64 {
 	if c.Repetitions == 0 && c.LastReviewed.IsZero() {
 		return 0 // Never practiced
 	}
@@ -111,7 +161,7 @@ func (c *Card) Strength() float64 {
 
 	// Decay based on time since last review
 	if !c.LastReviewed.IsZero() {
-		daysSince := time.Since(c.LastReviewed).Hours() / 24
+		daysSince := now.Sub(c.LastReviewed).Hours() / 24
 		// Forgetting curve: strength decays exponentially
 		// Decay rate is slower for higher ease factors (well-learned items)
 		decayRate := 0.1 / c.EaseFactor
@@ -129,28 +179,3 @@ func (c *Card) Strength() float64 {
 
 	return baseStrength
 }
-
-// CalculateGrade determines the quality grade based on correctness and response time
-// This translates user performance into SM-2 grade (0-5).
-func CalculateGrade(correct bool, responseTimeMs int64) int {
-	if correct {
-		// Fast correct (< 1s) = perfect
-		if responseTimeMs < 1000 {
-			return 5
-		}
-		// Medium (1-3s) = good
-		if responseTimeMs < 3000 {
-			return 4
-		}
-		// Slow but correct = pass
-		return 3
-	}
-
-	// Incorrect responses
-	// Hesitated before wrong answer = some recall
-	if responseTimeMs >= 1000 {
-		return 2
-	}
-	// Quick wrong = guessing
-	return 1
-}
@@ -5,11 +5,10 @@ package srs
 
 import (
 	"testing"
-	"time"
 )
 
 func TestNewCard(t *testing.T) {
-	card := NewCard("test-skill")
+	card := NewCard("test-card", "test-skill")
 
 	if card.SkillID != "test-skill" {
 		t.Errorf("expected skill ID 'test-skill', got '%s'", card.SkillID)
@@ -26,10 +25,10 @@ func TestNewCard(t *testing.T) {
 }
 
 func TestReview_PerfectScore(t *testing.T) {
-	card := NewCard("test-skill")
+	card := NewCard("test-card", "test-skill")
 
 	// First review with perfect score (5)
-	card.Review(5)
+	SM2{}.Review(card, 5, start)
 
 	if card.Interval != 1 {
 		t.Errorf("expected interval 1 after first review, got %d", card.Interval)
@@ -43,7 +42,7 @@ func TestReview_PerfectScore(t *testing.T) {
 	}
 
 	// Second review with perfect score
-	card.Review(5)
+	SM2{}.Review(card, 5, start)
 
 	if card.Interval != 6 {
 		t.Errorf("expected interval 6 after second review, got %d", card.Interval)
@@ -54,7 +53,7 @@ func TestReview_PerfectScore(t *testing.T) {
 
 	// Third review - interval should multiply by ease factor
 	prevInterval := card.Interval
-	card.Review(5)
+	SM2{}.Review(card, 5, start)
 
 	expectedInterval := int(float64(prevInterval) * card.EaseFactor)
 	if card.Interval < expectedInterval-1 || card.Interval > expectedInterval+1 {
@@ -63,15 +62,15 @@ func TestReview_PerfectScore(t *testing.T) {
 }
 
 func TestReview_FailingScore(t *testing.T) {
-	card := NewCard("test-skill")
+	card := NewCard("test-card", "test-skill")
 
 	// Build up some progress
-	card.Review(5)
-	card.Review(5)
-	card.Review(5)
+	SM2{}.Review(card, 5, start)
+	SM2{}.Review(card, 5, start)
+	SM2{}.Review(card, 5, start)
 
 	// Now fail (score < 3)
-	card.Review(2)
+	SM2{}.Review(card, 2, start)
 
 	if card.Interval != 1 {
 		t.Errorf("expected interval reset to 1 after fail, got %d", card.Interval)
@@ -82,11 +81,11 @@ func TestReview_FailingScore(t *testing.T) {
 }
 
 func TestReview_EaseFactorBounds(t *testing.T) {
-	card := NewCard("test-skill")
+	card := NewCard("test-card", "test-skill")
 
 	// Repeatedly fail to drive down ease factor
 	for i := 0; i < 10; i++ {
-		card.Review(0) // Complete blackout
+		SM2{}.Review(card, 0, start) // Complete blackout
 	}
 
 	if card.EaseFactor < MinEaseFactor {
@@ -95,66 +94,62 @@ func TestReview_EaseFactorBounds(t *testing.T) {
 }
 
 func TestNextReviewDate(t *testing.T) {
-	card := NewCard("test-skill")
-	now := time.Now()
+	card := NewCard("test-card", "test-skill")
+	if !card.NextReviewDate().IsZero() {
+		t.Errorf("expected a new card to be due from the start of time, got %v", card.NextReviewDate())
+	}
 
-	card.Review(5)
+	SM2{}.Review(card, 5, start)
 	nextReview := card.NextReviewDate()
 
-	expected := now.AddDate(0, 0, card.Interval)
-	diff := nextReview.Sub(expected)
-
-	// Allow 1 second tolerance
-	if diff > time.Second || diff < -time.Second {
-		t.Errorf("next review date off by %v", diff)
+	expected := start.AddDate(0, 0, card.Interval)
+	if !nextReview.Equal(expected) {
+		t.Errorf("expected next review %v, got %v", expected, nextReview)
 	}
 }
 
 func TestIsDue(t *testing.T) {
-	card := NewCard("test-skill")
+	card := NewCard("test-card", "test-skill")
 
 	// New card should be due immediately
-	if !card.IsDue() {
+	if !card.IsDue(start) {
 		t.Error("new card should be due")
 	}
 
 	// Review it
-	card.Review(5)
+	SM2{}.Review(card, 5, start)
 
 	// Should not be due right after review
-	if card.IsDue() {
+	if card.IsDue(start) {
 		t.Error("card should not be due right after review")
 	}
 
-	// Simulate time passing by manipulating LastReviewed
-	card.LastReviewed = time.Now().AddDate(0, 0, -card.Interval-1)
-
-	if !card.IsDue() {
+	// Due once the interval has passed
+	if !card.IsDue(start.AddDate(0, 0, card.Interval)) {
 		t.Error("card should be due after interval passed")
 	}
 }
 
 func TestStrength(t *testing.T) {
-	card := NewCard("test-skill")
+	card := NewCard("test-card", "test-skill")
 
 	// New card has 0 strength
-	if card.Strength() != 0 {
-		t.Errorf("expected new card strength 0, got %.2f", card.Strength())
+	if (SM2{}).Strength(card, start) != 0 {
+		t.Errorf("expected new card strength 0, got %.2f", SM2{}.Strength(card, start))
 	}
 
 	// Review improves strength
-	card.Review(5)
-	card.Review(5)
-	card.Review(5)
+	SM2{}.Review(card, 5, start)
+	SM2{}.Review(card, 5, start)
+	SM2{}.Review(card, 5, start)
 
-	strength := card.Strength()
+	strength := SM2{}.Strength(card, start)
 	if strength <= 0 || strength > 1.0 {
 		t.Errorf("expected strength between 0 and 1, got %.2f", strength)
 	}
 
 	// Strength should decay over time
-	card.LastReviewed = time.Now().AddDate(0, 0, -30)
-	decayedStrength := card.Strength()
+	decayedStrength := SM2{}.Strength(card, start.AddDate(0, 0, 30))
 
 	if decayedStrength >= strength {
 		t.Errorf("expected strength to decay over time, was %.2f now %.2f", strength, decayedStrength)
@@ -5,12 +5,14 @@ package tui
 
 import (
 	"fmt"
+	"log"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
 	"github.com/2389-research/turtle/internal/progress"
 	"github.com/2389-research/turtle/internal/skills"
+	"github.com/2389-research/turtle/internal/srs"
 )
 
 // View represents the current screen.
@@ -38,16 +40,34 @@ type Model struct {
 	quitting           bool
 }
 
-// NewModel creates the initial application state.
-func NewModel() Model {
+// NewModel creates the initial application state, telling the time by clock.
+// Unless save is set, the progress file is only read: practice isn't saved, and
+// an unreadable file is left where it is.
+func NewModel(clock srs.Clock, save bool) Model {
 	graph := buildDefaultSkillGraph()
-	savePath := progress.GetDefaultPath()
+	loadPath := progress.GetDefaultPath()
+	savePath := ""
+	if save {
+		savePath = loadPath
+	}
 
 	// Load existing progress or start fresh
-	userProgress, err := progress.Load(savePath)
+	userProgress, err := progress.Load(loadPath, clock)
 	if err != nil {
-		// If load fails, start fresh
+		// If load fails, start fresh, but keep the old file: saving would overwrite it
 		userProgress = skills.NewUserProgress()
+		userProgress.Clock = clock
+		if savePath != "" {
+			backup, berr := progress.Backup(savePath, clock)
+			if berr == nil {
+				log.Printf("Error loading progress: %v, starting fresh; the old file is at %s", err, backup)
+			} else {
+				savePath = ""
+			}
+		}
+		if savePath == "" {
+			log.Printf("Error loading progress: %v, starting fresh without saving", err)
+		}
 	}
 
 	return Model{
@@ -28,6 +28,7 @@ func convertChallenges(raw map[string][]content.YAMLChallenge) map[string][]Chal
 		challenges := make([]Challenge, 0, len(yamlChallenges))
 		for _, yc := range yamlChallenges {
 			c := Challenge{
+				ID:            yc.ID,
 				SkillID:       skillID,
 				Prompt:        yc.Prompt,
 				Expected:      yc.Expected,
@@ -36,6 +37,10 @@ func convertChallenges(raw map[string][]content.YAMLChallenge) map[string][]Chal
 				Options:       yc.Options,
 				BrokenCommand: yc.Broken,
 				CommandOutput: yc.Command,
+
+				Accepted:         yc.Accepted,
+				Context:          yc.Context,
+				AcceptSameEffect: yc.AcceptSameEffect,
 			}
 
 			// Map type string to ChallengeType
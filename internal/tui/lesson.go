@@ -5,13 +5,17 @@ package tui
 
 import (
 	"fmt"
+	"maps"
 	"math/rand"
+	"slices"
 	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/2389-research/turtle/internal/content"
+	"github.com/2389-research/turtle/internal/sandbox"
 	"github.com/2389-research/turtle/internal/skills"
 	"github.com/2389-research/turtle/internal/srs"
 )
@@ -43,6 +47,7 @@ const (
 
 // Challenge represents a single practice exercise.
 type Challenge struct {
+	ID            string // Stable challenge ID; progress is kept per challenge
 	Type          ChallengeType
 	SkillID       string
 	Prompt        string
@@ -52,6 +57,19 @@ type Challenge struct {
 	Explanation   string   // Shown after answering
 	BrokenCommand string   // For fix-the-error: the broken command to fix
 	CommandOutput string   // For predict-output: what the command actually outputs
+
+	Accepted         []string              // Other typed answers that are also right
+	Context          []content.SetupAction // Sandbox setup for judging answers by their effect
+	AcceptSameEffect bool                  // Accept any answer with the same effect as Expected
+}
+
+// CardID returns the ID of the SRS card that tracks this challenge. Built-in
+// fallback challenges have no ID and share a card under their skill's ID.
+func (c Challenge) CardID() string {
+	if c.ID == "" {
+		return c.SkillID
+	}
+	return c.ID
 }
 
 // LessonModel handles a practice session.
@@ -86,7 +104,7 @@ func NewLessonModel(progress *skills.UserProgress, graph *skills.SkillGraph, sel
 		CurrentIndex: 0,
 		Input:        "",
 		ShowFeedback: false,
-		StartTime:    time.Now(),
+		StartTime:    progress.Clock.Now(),
 		Hearts:       3,
 		Combo:        0,
 	}
@@ -105,7 +123,7 @@ func NewSpeedRoundModel(progress *skills.UserProgress, graph *skills.SkillGraph,
 		CurrentIndex:  0,
 		Input:         "",
 		ShowFeedback:  false,
-		StartTime:     time.Now(),
+		StartTime:     progress.Clock.Now(),
 		Hearts:        1, // One mistake ends speed round
 		Combo:         0,
 		IsSpeedRound:  true,
@@ -153,38 +171,57 @@ func generateSpeedChallenges(progress *skills.UserProgress, graph *skills.SkillG
 	return allChallenges
 }
 
-// generateChallenges creates a set of challenges for this session.
-// If selectedSkills is nil or empty, all unlocked skills are used.
+// generateChallenges plans the challenges for this session with the session
+// planner: due reviews and new challenges within today's limits. If
+// selectedSkills is nil or empty, challenges from every skill are considered;
+// otherwise only the selected skills', and if none of those are due they are
+// drilled anyway.
 func generateChallenges(progress *skills.UserProgress, graph *skills.SkillGraph, selectedSkills []string) []Challenge {
-	var challenges []Challenge
+	allChallenges := getAllChallenges()
 
 	// Determine which skills to use
-	var skillsToUse []string
-	if len(selectedSkills) > 0 {
-		skillsToUse = selectedSkills
-	} else {
-		skillsToUse = graph.GetUnlockedSkills(progress)
+	skillsToUse := selectedSkills
+	if len(skillsToUse) == 0 {
+		skillsToUse = slices.Sorted(maps.Keys(allChallenges))
 	}
 
+	byCard := make(map[string]Challenge)
+	var candidates []skills.Candidate
 	for _, skillID := range skillsToUse {
-		// Add challenges for this skill
-		skillChallenges := getChallengesForSkill(skillID)
-		challenges = append(challenges, skillChallenges...)
-
-		// Limit total challenges
-		if len(challenges) >= 5 {
-			break
+		for _, c := range allChallenges[skillID] {
+			if _, ok := byCard[c.CardID()]; ok {
+				continue
+			}
+			byCard[c.CardID()] = c
+			candidates = append(candidates, skills.Candidate{CardID: c.CardID(), SkillID: skillID, Siblings: siblingKey(c)})
 		}
 	}
 
-	// Ensure at least some challenges
-	if len(challenges) == 0 {
-		challenges = getDefaultChallenges()
+	var challenges []Challenge
+	for _, c := range skills.PlanSession(graph, progress, candidates) {
+		challenges = append(challenges, byCard[c.CardID])
+	}
+
+	if len(challenges) == 0 && len(selectedSkills) > 0 {
+		for _, skillID := range selectedSkills {
+			challenges = append(challenges, getChallengesForSkill(skillID)...)
+		}
 	}
 
 	return challenges
 }
 
+// siblingKey groups challenges that teach the same thing: typed challenges of a
+// skill with the same expected command. Other challenges stand alone.
+func siblingKey(c Challenge) string {
+	switch c.Type {
+	case ChallengeMultipleChoice, ChallengePredictOutput:
+		return c.CardID()
+	default:
+		return c.SkillID + " " + strings.Join(strings.Fields(c.Expected), " ")
+	}
+}
+
 // getChallengesForSkill returns challenges for a specific skill from the challenge database.
 func getChallengesForSkill(skillID string) []Challenge {
 	allChallenges := getAllChallenges()
@@ -194,11 +231,6 @@ func getChallengesForSkill(skillID string) []Challenge {
 	return nil
 }
 
-// getDefaultChallenges returns starter challenges if none generated.
-func getDefaultChallenges() []Challenge {
-	return getChallengesForSkill("pwd")
-}
-
 // Init implements tea.Model for LessonModel.
 func (m *LessonModel) Init() tea.Cmd {
 	if m.IsSpeedRound {
@@ -245,7 +277,7 @@ func (m *LessonModel) handleKeypress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			if m.CurrentIndex >= len(m.Challenges) {
 				m.Done = true
 			} else {
-				m.StartTime = time.Now() // Reset timer for next question
+				m.StartTime = m.Progress.Clock.Now() // Reset timer for next question
 			}
 		case "q", "esc":
 			m.Done = true
@@ -253,6 +285,12 @@ func (m *LessonModel) handleKeypress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	// An empty session only shows that there's nothing to do; any key leaves it
+	if len(m.Challenges) == 0 {
+		m.Done = true
+		return m, nil
+	}
+
 	// Get current challenge type
 	var challengeType ChallengeType
 	if m.CurrentIndex < len(m.Challenges) {
@@ -340,7 +378,7 @@ func (m *LessonModel) checkAnswer() {
 	}
 
 	challenge := m.Challenges[m.CurrentIndex]
-	elapsed := time.Since(m.StartTime).Milliseconds()
+	elapsed := m.Progress.Clock.Now().Sub(m.StartTime)
 
 	// Check answer based on challenge type
 	switch challenge.Type {
@@ -350,24 +388,16 @@ func (m *LessonModel) checkAnswer() {
 		_, _ = fmt.Sscanf(challenge.Expected, "%d", &expectedIdx)
 		m.WasCorrect = m.SelectedOption == expectedIdx
 
-	case ChallengeFixError:
-		// For fix-the-error, user types the corrected command
-		userAnswer := strings.TrimSpace(strings.ToLower(m.Input))
-		expected := strings.TrimSpace(strings.ToLower(challenge.Expected))
-		m.WasCorrect = userAnswer == expected
-
 	default:
-		// Type command, translate - direct text comparison
-		userAnswer := strings.TrimSpace(strings.ToLower(m.Input))
-		expected := strings.TrimSpace(strings.ToLower(challenge.Expected))
-		m.WasCorrect = userAnswer == expected
+		// Type command, translate, fix error - the typed command
+		m.WasCorrect = isCorrectCommand(challenge, m.Input)
 	}
 
 	// Calculate grade for SRS
-	grade := srs.CalculateGrade(m.WasCorrect, elapsed)
+	grade := srs.CalculateGrade(m.WasCorrect, elapsed.Milliseconds())
 
 	// Update skill progress
-	m.Progress.Practice(challenge.SkillID, grade)
+	m.Progress.Practice(challenge.SkillID, challenge.CardID(), grade, elapsed)
 
 	// Calculate XP with bonuses
 	baseXP := 10
@@ -398,6 +428,21 @@ func (m *LessonModel) checkAnswer() {
 	m.ShowFeedback = true
 }
 
+// isCorrectCommand reports whether a typed answer is the expected command or one of
+// the accepted ones, allowing for flag order, quoting and redundant paths. If the
+// challenge allows it, an answer with the same effect in the sandbox is also right.
+func isCorrectCommand(challenge Challenge, input string) bool {
+	if strings.TrimSpace(input) == "" {
+		return false
+	}
+	for _, answer := range append([]string{challenge.Expected}, challenge.Accepted...) {
+		if sandbox.SameCommand(input, answer) {
+			return true
+		}
+	}
+	return challenge.AcceptSameEffect && sandbox.SameEffect(input, challenge.Expected, challenge.Context)
+}
+
 // View renders the lesson screen.
 //
 //nolint:funlen // View function has many UI components
@@ -669,6 +714,17 @@ func (m *LessonModel) renderComplete() string {
 		)
 	}
 
+	// Nothing planned: every due card is reviewed and today's new cards are done
+	if len(m.Challenges) == 0 {
+		return lipgloss.JoinVertical(
+			lipgloss.Left,
+			SuccessStyle.Render("✅ All caught up!"),
+			"",
+			MutedStyle.Render("🐢 No cards are due, and today's new cards are done. Come back tomorrow."),
+			MutedStyle.Render("\nPress any key to continue..."),
+		)
+	}
+
 	// Regular lesson results
 	if m.Hearts <= 0 {
 		title = DangerStyle.Render("💔 Out of hearts!")
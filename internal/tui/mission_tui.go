@@ -10,8 +10,11 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/2389-research/turtle/internal/content"
+	"github.com/2389-research/turtle/internal/realshell"
 	"github.com/2389-research/turtle/internal/sandbox"
 	"github.com/2389-research/turtle/internal/skills"
+	"github.com/2389-research/turtle/internal/srs"
 )
 
 // MissionScreen represents the current screen state.
@@ -38,6 +41,14 @@ type MissionTUI struct {
 	Input          string
 	History        []historyEntry
 	ShowHint       bool
+	PrefixActive   bool   // Tmux prefix key pressed, waiting for the bound key
+	PromptInput    string // Text typed at the tmux command prompt
+	PromptLabel    string // Label shown before the prompt input
+	PromptKey      string // Keys that opened the prompt, as shown in history
+	RealTmux       bool   // Offer real tmux for tmux missions (--real-tmux)
+	RealShell      bool   // Run shell missions in real bash (--real-shell)
+	Shell          *realshell.Shell
+	Progress       *content.GoalTrace // Which goal conditions hold, after the learner's first command
 
 	// Menu state
 	MenuIndex  int
@@ -56,10 +67,12 @@ type MissionTUI struct {
 }
 
 type historyEntry struct {
-	Command string
-	Output  string
-	Error   string
-	Success bool
+	Command  string
+	Output   string
+	Error    string
+	Success  bool
+	KeyPress bool   // Command is a tmux key sequence rather than typed input
+	Note     string // Shown after the output, e.g. when a mission stage is done
 }
 
 // mainMenuItem defines a menu entry.
@@ -79,15 +92,16 @@ var mainMenuItems = []mainMenuItem{
 	{"Quit", "Exit Turtle", ArrowLeft},
 }
 
-// NewMissionTUI creates a new mission-based learning interface.
-func NewMissionTUI() *MissionTUI {
+// NewMissionTUI creates a new mission-based learning interface whose progress
+// tells the time by clock, and is saved if save is set.
+func NewMissionTUI(clock srs.Clock, save bool) *MissionTUI {
 	return &MissionTUI{
 		Screen:         ScreenMenu,
 		Missions:       sandbox.GetAllMissions(),
 		MenuIndex:      0,
 		Width:          80,
 		Height:         24,
-		FlashcardModel: NewModel(),
+		FlashcardModel: NewModel(clock, save),
 	}
 }
 
@@ -106,6 +120,9 @@ func (m *MissionTUI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.FlashcardModel.Width = msg.Width
 		m.FlashcardModel.Height = msg.Height
 
+	case realTmuxDoneMsg:
+		m.finishRealTmux(msg)
+
 	case tea.KeyMsg:
 		switch m.Screen {
 		case ScreenMenu:
@@ -191,6 +208,29 @@ func (m *MissionTUI) updateLevelSelect(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 }
 
 func (m *MissionTUI) updateMission(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.Runner != nil && m.Runner.PromptPending {
+		return m.updateTmuxPrompt(msg)
+	}
+	if m.PrefixActive {
+		m.PrefixActive = false
+		m.executeTmuxKey(tmuxKeyName(msg))
+		return m, nil
+	}
+	if m.Runner != nil && m.Runner.InTmuxSession() && m.Runner.IsTmuxPrefix(tmuxKeyName(msg)) {
+		m.PrefixActive = true
+		return m, nil
+	}
+	if m.Runner != nil {
+		if result, ok := m.Runner.RootKey(tmuxKeyName(msg)); ok {
+			m.keyResult(tmuxKeyName(msg), result)
+			return m, nil
+		}
+	}
+	if m.Runner != nil && m.Runner.CopyMode() != nil {
+		m.copyModeKey(tmuxKeyName(msg))
+		return m, nil
+	}
+
 	switch msg.String() {
 	case "enter":
 		if m.Input != "" {
@@ -204,13 +244,26 @@ func (m *MissionTUI) updateMission(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.Input = ""
 	case "ctrl+h", "?":
 		m.ShowHint = !m.ShowHint
+	case "ctrl+t":
+		if m.canUseRealTmux() {
+			return m, m.startRealTmux()
+		}
 	case "ctrl+r":
 		if m.Runner != nil {
 			m.Runner.Reset()
+			if m.Shell != nil {
+				if err := m.Shell.Reset(); err != nil {
+					m.closeRealShell()
+				}
+			}
 			m.History = nil
+			m.noteSetupError()
 			m.Input = ""
+			m.PrefixActive = false
+			m.Progress = nil
 		}
 	case "esc":
+		m.closeRealShell()
 		m.Screen = ScreenLevelSelect
 	default:
 		// Regular character input
@@ -226,6 +279,82 @@ func (m *MissionTUI) updateMission(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// updateTmuxPrompt handles input at a tmux prompt (prefix + :, or a binding's prompt).
+func (m *MissionTUI) updateTmuxPrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// Confirmations take a single key, like tmux's confirm-before.
+	if m.Runner.PromptConfirm() {
+		result := m.Runner.SubmitPrompt(msg.String())
+		if msg.String() == "y" {
+			m.recordResult(historyEntry{Command: m.PromptKey, KeyPress: true}, result)
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "enter":
+		input := strings.TrimSpace(m.PromptInput)
+		m.PromptInput = ""
+		result := m.Runner.SubmitPrompt(input)
+		switch {
+		case m.PromptLabel != ":":
+			m.recordResult(historyEntry{Command: m.PromptKey + " " + input, KeyPress: true}, result)
+		case input != "":
+			m.recordResult(historyEntry{Command: ":" + input, KeyPress: true}, result)
+		}
+	case "esc", "ctrl+c":
+		m.PromptInput = ""
+		m.Runner.CancelPrompt()
+	case "backspace":
+		if len(m.PromptInput) > 0 {
+			m.PromptInput = m.PromptInput[:len(m.PromptInput)-1]
+		}
+	default:
+		if len(msg.String()) == 1 {
+			m.PromptInput += msg.String()
+		}
+	}
+	return m, nil
+}
+
+// tmuxKeyNames maps Bubble Tea key names to tmux key notation.
+var tmuxKeyNames = map[string]string{
+	" ":         "Space",
+	"ctrl+@":    "C-Space",
+	"up":        "Up",
+	"down":      "Down",
+	"left":      "Left",
+	"right":     "Right",
+	"enter":     "Enter",
+	"esc":       "Escape",
+	"tab":       "Tab",
+	"backspace": "BSpace",
+	"pgup":      "PPage",
+	"pgdown":    "NPage",
+	"home":      "Home",
+	"end":       "End",
+}
+
+// tmuxKeyName converts a key press to tmux notation (e.g. "ctrl+b" -> "C-b").
+func tmuxKeyName(msg tea.KeyMsg) string {
+	return tmuxKeyString(msg.String())
+}
+
+func tmuxKeyString(key string) string {
+	if name, ok := tmuxKeyNames[key]; ok {
+		return name
+	}
+	if rest, ok := strings.CutPrefix(key, "ctrl+"); ok {
+		return "C-" + tmuxKeyString(rest)
+	}
+	if rest, ok := strings.CutPrefix(key, "alt+"); ok {
+		return "M-" + tmuxKeyString(rest)
+	}
+	if rest, ok := strings.CutPrefix(key, "shift+"); ok {
+		return "S-" + tmuxKeyString(rest)
+	}
+	return key
+}
+
 func (m *MissionTUI) updateComplete(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "enter", " ":
@@ -286,10 +415,17 @@ func (m *MissionTUI) startCurrentMission() {
 	}
 
 	mission := missions[m.CurrentMission]
+	if mission.Variant != nil {
+		// Fresh file names and numbers each time, so replays can't be memorised.
+		mission = mission.Variant()
+	}
 	m.Runner = sandbox.NewMissionRunner(mission)
 	m.History = nil
+	m.noteSetupError()
 	m.Input = ""
 	m.ShowHint = false
+	m.Progress = nil
+	m.startRealShell()
 	m.Screen = ScreenMission
 }
 
@@ -304,22 +440,108 @@ func (m *MissionTUI) executeCommand() {
 	}
 
 	cmd := strings.TrimSpace(m.Input)
-	result := m.Runner.Execute(cmd)
-	m.CommandsUsed++
+	var result sandbox.MissionResult
+	if m.Shell != nil {
+		m.Runner.Attempts++
+		result = m.Shell.Execute(cmd)
+	} else {
+		result = m.Runner.Execute(cmd)
+	}
+	m.Input = ""
+	m.recordResult(historyEntry{Command: cmd}, result)
+}
 
-	entry := historyEntry{
-		Command: cmd,
-		Output:  result.Output,
-		Error:   result.Error,
-		Success: result.Success,
+// executeTmuxKey runs the tmux binding for a key pressed after the prefix.
+func (m *MissionTUI) executeTmuxKey(key string) {
+	if m.Runner == nil {
+		return
 	}
+
+	result := m.Runner.ExecuteKey(key)
+	m.keyResult(m.Runner.TmuxPrefix()+" "+key, result)
+}
+
+// keyResult shows the outcome of a tmux key binding, opening its prompt if it has one.
+func (m *MissionTUI) keyResult(keys string, result sandbox.MissionResult) {
+	if result.Prompt != "" {
+		m.PromptLabel = result.Prompt
+		m.PromptInput = result.PromptInput
+		m.PromptKey = keys
+		return
+	}
+	m.recordResult(historyEntry{Command: keys, KeyPress: true}, result)
+}
+
+// copyModeKey handles a key pressed while the current tmux pane is in copy mode.
+func (m *MissionTUI) copyModeKey(key string) {
+	result := m.Runner.CopyModeKey(key)
+	switch {
+	case result.Prompt != "":
+		m.PromptLabel = result.Prompt
+		m.PromptInput = result.PromptInput
+		m.PromptKey = key
+	case result.Output != "" || result.Error != "" || result.Completed:
+		m.recordResult(historyEntry{Command: key, KeyPress: true}, result)
+	}
+}
+
+// noteSetupError shows in the history that the mission's setup went wrong, so the
+// learner knows the starting state may be incomplete.
+func (m *MissionTUI) noteSetupError() {
+	if err := m.Runner.SetupErr; err != nil {
+		m.History = append(m.History, historyEntry{Command: "setup", KeyPress: true, Error: err.Error()})
+	}
+}
+
+// recordResult appends a command's outcome to the terminal history and handles completion.
+func (m *MissionTUI) recordResult(entry historyEntry, result sandbox.MissionResult) {
+	m.CommandsUsed++
+
+	entry.Output = result.Output
+	entry.Error = result.Error
+	entry.Success = result.Success
 	m.History = append(m.History, entry)
-	m.Input = ""
+
+	// Pasted text arrives at the shell prompt as if typed.
+	if result.Paste != "" {
+		m.Input += strings.ReplaceAll(strings.TrimSuffix(result.Paste, "\n"), "\n", " ")
+	}
+
+	if result.StageDone {
+		m.stageDone()
+	}
+	m.updateProgress()
 
 	if result.Completed {
 		m.MissionsCompleted++
-		m.FlashcardModel.Progress.Practice(m.Runner.Mission.SkillID, 5) // Perfect score for completion
+		mission := m.Runner.Mission
+		m.FlashcardModel.Progress.CompleteMission(mission.SkillID, mission.ID)
 		m.Screen = ScreenComplete
+		m.closeRealShell()
+	}
+}
+
+// stageDone notes the finished stage in the history; the briefing moves on to the next.
+func (m *MissionTUI) stageDone() {
+	stages := m.Runner.Mission.Stages
+	done := stages[m.Runner.Stage-1]
+	note := fmt.Sprintf("Stage %d of %d done!", m.Runner.Stage, len(stages))
+	if done.Explanation != "" {
+		note += " " + strings.TrimSpace(done.Explanation)
+	}
+	m.History[len(m.History)-1].Note = note
+	m.ShowHint = false
+}
+
+// updateProgress re-evaluates the goal for the progress checklist.
+func (m *MissionTUI) updateProgress() {
+	if m.Shell != nil && m.Runner.Mission.Trace != nil {
+		trace := m.Runner.Mission.Trace(m.Shell)
+		m.Progress = &trace
+		return
+	}
+	if trace, ok := m.Runner.GoalTrace(); ok {
+		m.Progress = &trace
 	}
 }
 
@@ -493,27 +715,121 @@ func (m *MissionTUI) viewMission() string {
 			Arrow, m.CurrentMission+1, len(m.Missions[m.CurrentLevel]))),
 	)
 
-	// Mission title and briefing.
+	// Mission title and briefing, followed by the current stage's objective.
 	title := TitleStyle.Render(mission.Title)
-	briefing := GlowBoxStyle.Width(60).Render(mission.Briefing)
+	briefingText := mission.Briefing
+	stageHint := mission.Hint
+	if stage := m.Runner.CurrentStage(); stage != nil {
+		title += " " + BadgeStyle.Render(fmt.Sprintf("stage %d/%d", m.Runner.Stage+1, len(mission.Stages)))
+		briefingText = strings.TrimSpace(mission.Briefing) + "\n\n" + AccentStyle.Render(Arrow+" ") + stage.Briefing
+		if stage.Hint != "" {
+			stageHint = stage.Hint
+		}
+	}
+	briefing := GlowBoxStyle.Width(60).Render(briefingText)
 
-	// Hint.
+	// Hint, tailored to the first unmet condition when the goal has one.
 	var hint string
 	if m.ShowHint {
-		hint = AccentStyle.Render("💡 " + mission.Hint)
+		text := stageHint
+		if m.Progress != nil && m.Progress.NextHint() != "" {
+			text = m.Progress.NextHint()
+		}
+		hint = AccentStyle.Render("💡 " + text)
 	} else {
 		hint = MutedStyle.Render("Press ? for hint")
 	}
+	if m.Progress != nil {
+		hint = lipgloss.JoinVertical(lipgloss.Left, renderChecklist(m.Progress), "", hint)
+	}
 
 	// Terminal output and input.
 	terminalView := m.renderTerminalView()
+	if view := m.Runner.CopyMode(); view != nil {
+		terminalView = renderCopyMode(view)
+	}
 	location := MutedStyle.Render("📍 " + m.Runner.GetCurrentLocation())
+	if m.Shell != nil {
+		location = MutedStyle.Render("📍 "+m.Shell.Location()) + " " + BadgeStyle.Render("bash")
+	}
 	inputLine := TerminalStyle.Render(PromptStyle.Render("$ ") + CommandStyle.Render(m.Input+"▋"))
+	if m.Runner.PromptPending {
+		inputLine = TerminalStyle.Render(AccentStyle.Render(m.PromptLabel+" ") + CommandStyle.Render(m.PromptInput+"▋"))
+	}
 
-	footer := FooterStyle.Render("  enter execute  " + Bullet + " ? hint  " + Bullet + " ctrl+r reset  " + Bullet + " esc exit")
+	footerText := "  enter execute  " + Bullet + " ? hint  " + Bullet + " ctrl+r reset  " + Bullet + " esc exit"
+	if m.Runner.InTmuxSession() {
+		footerText += "  " + Bullet + " " + m.Runner.TmuxPrefix() + " prefix"
+	}
+	if m.canUseRealTmux() {
+		footerText += "  " + Bullet + " ctrl+t real tmux"
+	}
+	if m.Runner.CopyMode() != nil {
+		footerText = "  copy mode  " + Bullet + " arrows move  " + Bullet + " q exit"
+	}
+	footer := FooterStyle.Render(footerText)
 
+	if !m.Runner.InTmuxSession() {
+		return lipgloss.JoinVertical(lipgloss.Left,
+			header, "", title, briefing, "", hint, "", location, terminalView, inputLine, "", footer)
+	}
 	return lipgloss.JoinVertical(lipgloss.Left,
-		header, "", title, briefing, "", hint, "", location, terminalView, inputLine, "", footer)
+		header, "", title, briefing, "", hint, "", location, terminalView, inputLine, m.renderTmuxStatus(), "", footer)
+}
+
+// renderChecklist lists the goal's conditions with a mark for each one that holds.
+func renderChecklist(trace *content.GoalTrace) string {
+	items := trace.Checklist()
+	lines := make([]string, 0, len(items))
+	for _, item := range items {
+		indent := strings.Repeat("  ", item.Depth)
+		text := strings.ReplaceAll(item.Description, "/home/learner", "~")
+		if item.Passed {
+			lines = append(lines, indent+SuccessStyle.Render("✓ ")+TextStyle.Render(text))
+		} else {
+			lines = append(lines, indent+DangerStyle.Render("✗ ")+MutedStyle.Render(text))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderTmuxStatus renders a tmux-style status line with a prefix indicator.
+func (m *MissionTUI) renderTmuxStatus() string {
+	status := BadgeStyle.Render("tmux") + " " + MutedStyle.Render(m.Runner.TmuxStatusLine())
+	if m.PrefixActive {
+		status += " " + ComboStyle.Render("["+m.Runner.TmuxPrefix()+"]")
+	}
+	return status
+}
+
+// renderCopyMode draws a pane in tmux copy mode: tmux's position indicator, then the
+// visible scrollback with the cursor and any selection.
+func renderCopyMode(view *sandbox.CopyModeView) string {
+	lines := make([]string, 0, len(view.Lines)+1)
+	lines = append(lines, ComboStyle.UnsetBlink().Render(view.Position))
+	for y, line := range view.Lines {
+		var b strings.Builder
+		width := len(line)
+		if y == view.CursorY {
+			width = max(width, view.CursorX+1)
+		}
+		for x := range width {
+			cell := " "
+			if x < len(line) {
+				cell = string(line[x])
+			}
+			switch {
+			case y == view.CursorY && x == view.CursorX:
+				b.WriteString(CopyCursorStyle.Render(cell))
+			case view.Selected(x, y):
+				b.WriteString(SelectionStyle.Render(cell))
+			default:
+				b.WriteString(OutputStyle.Render(cell))
+			}
+		}
+		lines = append(lines, b.String())
+	}
+	return TerminalStyle.Render(strings.Join(lines, "\n"))
 }
 
 func (m *MissionTUI) renderTerminalView() string {
@@ -523,12 +839,19 @@ func (m *MissionTUI) renderTerminalView() string {
 
 	var content string
 	for _, entry := range m.History {
-		content += PromptStyle.Render("$ ") + CommandStyle.Render(entry.Command) + "\n"
+		if entry.KeyPress {
+			content += AccentStyle.Render("⌨ ") + CommandStyle.Render(entry.Command) + "\n"
+		} else {
+			content += PromptStyle.Render("$ ") + CommandStyle.Render(entry.Command) + "\n"
+		}
 		if entry.Error != "" {
 			content += DangerStyle.Render(entry.Error) + "\n"
 		} else if entry.Output != "" {
 			content += entry.Output + "\n"
 		}
+		if entry.Note != "" {
+			content += SuccessStyle.Render("✓ "+entry.Note) + "\n"
+		}
 	}
 	return TerminalStyle.Render(content)
 }
@@ -549,7 +872,12 @@ func (m *MissionTUI) viewComplete() string {
 	title := SuccessStyle.Render(Star + " Mission Complete! " + Star)
 	missionTitle := TitleStyle.Render(mission.Title)
 
-	explanation := GlowBoxStyle.Width(60).Render(mission.Explanation)
+	explanationText := strings.TrimSpace(mission.Explanation)
+	if last := len(mission.Stages) - 1; last >= 0 && mission.Stages[last].Explanation != "" {
+		// Earlier stages' explanations were shown as the learner finished them.
+		explanationText = strings.TrimSpace(mission.Stages[last].Explanation + "\n" + explanationText)
+	}
+	explanation := GlowBoxStyle.Width(60).Render(explanationText)
 
 	// Example solutions.
 	var solutions string
@@ -0,0 +1,41 @@
+// ABOUTME: Real-shell practice for shell missions (turtle --real-shell)
+// ABOUTME: Runs the mission's commands in bash inside a throwaway directory instead of the simulator
+
+package tui
+
+import (
+	"strings"
+
+	"github.com/2389-research/turtle/internal/realshell"
+)
+
+// startRealShell runs the current mission in a real bash when --real-shell is on.
+// Tmux missions stay with the simulator (or real tmux), as do multi-stage missions,
+// whose later stages set up on top of the simulated filesystem; if bash can't be
+// started the mission falls back to the simulator with a note in the history.
+func (m *MissionTUI) startRealShell() {
+	m.closeRealShell()
+	if !m.RealShell || m.Runner == nil || strings.HasPrefix(m.Runner.Mission.SkillID, "tmux") ||
+		len(m.Runner.Mission.Stages) > 0 {
+		return
+	}
+	shell, err := realshell.Start(m.Runner.Mission)
+	if err != nil {
+		m.History = append(m.History, historyEntry{Command: "real shell", KeyPress: true, Error: err.Error()})
+		return
+	}
+	m.Shell = shell
+}
+
+// closeRealShell stops the mission's bash and removes its directory.
+func (m *MissionTUI) closeRealShell() {
+	if m.Shell != nil {
+		_ = m.Shell.Close()
+		m.Shell = nil
+	}
+}
+
+// Close releases anything the TUI started outside the process, such as a real shell.
+func (m *MissionTUI) Close() {
+	m.closeRealShell()
+}
@@ -0,0 +1,76 @@
+// ABOUTME: Real tmux practice for tmux missions (turtle --real-tmux)
+// ABOUTME: Suspends the TUI, attaches the learner to a private tmux server, then checks the goal
+
+package tui
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/2389-research/turtle/internal/realtmux"
+	"github.com/2389-research/turtle/internal/sandbox"
+)
+
+// realTmuxDoneMsg is sent when the learner leaves the real tmux session.
+type realTmuxDoneMsg struct {
+	server *realtmux.Server
+	err    error
+}
+
+// canUseRealTmux reports whether the current mission can be practised in real tmux.
+func (m *MissionTUI) canUseRealTmux() bool {
+	return m.RealTmux && m.Runner != nil && m.Runner.Mission.Goal != nil &&
+		strings.HasPrefix(m.Runner.Mission.SkillID, "tmux")
+}
+
+// startRealTmux starts a private tmux server set up for the mission and attaches the
+// learner's terminal to it.
+func (m *MissionTUI) startRealTmux() tea.Cmd {
+	server, err := realtmux.New(strconv.Itoa(os.Getpid()))
+	if err == nil {
+		var startDir string
+		if startDir, err = server.ApplySetup(m.Runner.Mission.SetupActions); err != nil {
+			err = fmt.Errorf("this mission stays in the simulator: %w", err)
+		} else {
+			err = server.Start(startDir)
+		}
+		if err != nil {
+			_ = server.Close()
+		}
+	}
+	if err != nil {
+		m.History = append(m.History, historyEntry{Command: "real tmux", KeyPress: true, Error: err.Error()})
+		return nil
+	}
+
+	return tea.ExecProcess(server.AttachCommand(), func(err error) tea.Msg {
+		return realTmuxDoneMsg{server: server, err: err}
+	})
+}
+
+// finishRealTmux checks the mission goal against the real server, then tears it down.
+func (m *MissionTUI) finishRealTmux(msg realTmuxDoneMsg) {
+	defer func() { _ = msg.server.Close() }()
+
+	entry := historyEntry{Command: "real tmux", KeyPress: true}
+	if msg.err != nil {
+		entry.Error = msg.err.Error()
+		m.History = append(m.History, entry)
+		return
+	}
+
+	result := sandbox.MissionResult{Success: true, Completed: m.Runner.Mission.Goal(msg.server)}
+	if !result.Completed {
+		result.Output = "Goal not reached yet - press ctrl+t to try again"
+	}
+	m.recordResult(entry, result)
+	if m.Runner.Mission.Trace != nil {
+		// The checklist reflects the real server, not the simulator.
+		trace := m.Runner.Mission.Trace(msg.server)
+		m.Progress = &trace
+	}
+}
@@ -287,6 +287,16 @@ var (
 			Foreground(ColorAmber).
 			Bold(true).
 			Blink(true)
+
+	// CopyCursorStyle renders the tmux copy-mode cursor as a reversed cell.
+	CopyCursorStyle = lipgloss.NewStyle().
+			Foreground(ColorAmber).
+			Reverse(true)
+
+	// SelectionStyle renders text selected in tmux copy mode.
+	SelectionStyle = lipgloss.NewStyle().
+			Foreground(ColorBgDeep).
+			Background(ColorAmberGlow)
 )
 
 // Skill tree styles.